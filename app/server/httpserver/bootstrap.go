@@ -3,16 +3,18 @@ package httpserver
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
 // BootstrapServer initializes the HTTP server with the given handler and starts monitoring for shutdown signals.
 func (h *HTTPServer) BootstrapServer(ctx context.Context, handler http.Handler) error {
-	h.server = &http.Server{Addr: h.GetPort(), Handler: handler, ConnState: h.onStateChange}
+	h.server = &http.Server{Addr: h.GetPort(), Handler: handler, ConnState: h.onStateChange, ErrorLog: slog.NewLogLogger(log.Handler(h.log), slog.LevelError)}
 	return h.StartSignalMonitor(ctx)
 }
 
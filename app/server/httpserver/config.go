@@ -67,11 +67,12 @@ type Config struct {
 	*baseapp.Config
 	*DocumentationConfig
 	*TLSConfig
-	Host   string      // Host address
-	Port   string      // Port number
-	Log    log.Log     // Logger instance
-	Mask   *MaskConfig // Configuration for masking headers
-	Tracer Tracer      // Tracer instance
+	Host    string      // Host address
+	Port    string      // Port number
+	Log     log.Log     // Logger instance
+	Mask    *MaskConfig // Configuration for masking headers
+	Tracer  Tracer      // Tracer instance
+	GinSlog bool        // Routes the output of gin to Log, replacing the process wide gin.DefaultWriter and gin.DefaultErrorWriter
 }
 
 // GetDefaultConfig returns the default HTTPServerConfig with values from environment variables or default values.
@@ -79,6 +80,7 @@ type Config struct {
 	Environment Variables
 	- HTTP_SERVER__HOST: Sets [Host]
 	- HTTP_SERVER__PORT: Sets [Port]
+	- HTTP_SERVER__GIN_SLOG: Sets [GinSlog], default false
 */
 func GetDefaultConfig() *Config {
 	return &Config{
@@ -89,6 +91,7 @@ func GetDefaultConfig() *Config {
 		Log:                 log.New(log.WithModuleName("HTTPServer")),
 		Host:                utils.GetEnv(env.HTTPServerHost, "0.0.0.0"),
 		Port:                utils.GetEnv(env.HTTPServerPort, "8080"),
+		GinSlog:             utils.GetEnvBool(env.HTTPServerGinSlog, false),
 	}
 }
 
@@ -150,3 +153,10 @@ func WithTracer(t Tracer) Option {
 		c.Tracer = t
 	}
 }
+
+// WithGinSlog sets the GinSlog field of HTTPServerConfig.
+func WithGinSlog(enabled bool) Option {
+	return func(c *Config) {
+		c.GinSlog = enabled
+	}
+}
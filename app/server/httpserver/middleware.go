@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sabariramc/goserverbase/v6/correlation"
 	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
+	"github.com/sabariramc/goserverbase/v6/log/audit"
 )
 
 // SetCorrelationMiddleware returns a middleware that sets the correlation parameters and user identifier in the request context.
@@ -97,3 +98,28 @@ func (h *HTTPServer) PanicHandleMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AuditMiddleware returns a middleware that writes an audit record for the request with the logger's audit log writer, add it to the routes that should be audited.
+// The outcome is success for a response status below 400, resource defaults to the route path.
+func (h *HTTPServer) AuditMiddleware(action, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		ctx := c.Request.Context()
+		outcome := audit.OutcomeSuccess
+		if c.Writer.Status() >= 400 {
+			outcome = audit.OutcomeFailure
+		}
+		res := resource
+		if res == "" {
+			res = c.FullPath()
+		}
+		rec := audit.NewRecord(ctx, action, res, outcome, map[string]any{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"statusCode": c.Writer.Status(),
+		})
+		if err := h.log.Audit(ctx, rec); err != nil {
+			h.log.Error(ctx, "error writing audit record", err)
+		}
+	}
+}
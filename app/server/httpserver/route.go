@@ -2,10 +2,12 @@ package httpserver
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sabariramc/goserverbase/v6/errors"
+	"github.com/sabariramc/goserverbase/v6/log"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -37,6 +39,9 @@ func MethodNotAllowed() http.HandlerFunc {
 
 // SetupRouter configures routes and middleware for the HTTPServer.
 func (h *HTTPServer) SetupRouter(ctx context.Context) {
+	if h.c.GinSlog {
+		SetGinSlogHandler(log.Handler(h.log.NewResourceLogger("Gin")))
+	}
 	h.handler.NoRoute(gin.WrapF(NotFound()))
 	h.handler.NoMethod(gin.WrapF(MethodNotAllowed()))
 	h.handler.GET("/meta/health", gin.WrapF(h.HealthCheck))
@@ -49,6 +54,13 @@ func (h *HTTPServer) SetupRouter(ctx context.Context) {
 	h.handler.Use(h.SetCorrelationMiddleware(), h.RequestTimerMiddleware(), h.LogRequestResponseMiddleware(), h.PanicHandleMiddleware())
 }
 
+// SetGinSlogHandler routes the debug and error output of gin to handler, gin writes them to the process wide gin.DefaultWriter and gin.DefaultErrorWriter.
+// SetupRouter calls it with the logger of the server when GinSlog is set in [Config].
+func SetGinSlogHandler(handler slog.Handler) {
+	gin.DefaultWriter = slog.NewLogLogger(handler, slog.LevelDebug).Writer()
+	gin.DefaultErrorWriter = slog.NewLogLogger(handler, slog.LevelError).Writer()
+}
+
 // SetupDocumentation configures routes for serving OpenAPI documentation.
 // By default documentation is served in <<host>>/meta/docs/index.html
 // The local root folder for the documentation can be configured with config.SwaggerRootFolder, the root folder should contain swagger.yaml
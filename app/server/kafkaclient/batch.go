@@ -0,0 +1,355 @@
+package kafkaclient
+
+import (
+	"context"
+	e "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	ckafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaBatchProcessor defines the function signature for processing a batch of Kafka events.
+// Return a [*BatchError] to report the messages that failed, any other error fails the whole batch.
+type KafkaBatchProcessor func(context.Context, []*kafka.Message) error
+
+// BatchError reports the messages of a batch that failed, messages not in Errors are treated as processed.
+type BatchError struct {
+	Errors map[int]error // Error of every failed message keyed by its index in the batch
+}
+
+// NewBatchError creates an empty BatchError.
+func NewBatchError() *BatchError {
+	return &BatchError{Errors: map[int]error{}}
+}
+
+// Add records the error of the message at index idx of the batch.
+func (b *BatchError) Add(idx int, err error) {
+	b.Errors[idx] = err
+}
+
+// Error returns the indexes and errors of the failed messages.
+func (b *BatchError) Error() string {
+	idxList := make([]int, 0, len(b.Errors))
+	for idx := range b.Errors {
+		idxList = append(idxList, idx)
+	}
+	sort.Ints(idxList)
+	msg := make([]string, 0, len(idxList))
+	for _, idx := range idxList {
+		msg = append(msg, fmt.Sprintf("%v: %v", idx, b.Errors[idx]))
+	}
+	return fmt.Sprintf("batch failed for %v messages: [%v]", len(idxList), strings.Join(msg, ", "))
+}
+
+// BatchTracer is implemented by tracers that can start one span for a batch of messages, linked to the producer span of every message.
+type BatchTracer interface {
+	StartKafkaBatchSpan(ctx context.Context, topic string, msgs []*ckafka.Message) (context.Context, span.Span)
+}
+
+// batchHandler accumulates the messages of a topic and hands them to the handler in batches.
+type batchHandler struct {
+	topic   string
+	handler KafkaBatchProcessor
+	size    int
+	maxWait time.Duration
+	dedup   *dedupConfig
+	ch      chan *ckafka.Message
+}
+
+// WithBatchSize sets the number of messages that triggers a batch, defaults to 100.
+func WithBatchSize(size uint) HandlerOption {
+	return func(c *handlerConfig) {
+		c.batchSize = size
+	}
+}
+
+// WithBatchMaxWait sets the maximum time the first message of a batch waits before the batch is processed, defaults to 1 second.
+func WithBatchMaxWait(maxWait time.Duration) HandlerOption {
+	return func(c *handlerConfig) {
+		c.batchMaxWait = maxWait
+	}
+}
+
+// AddBatchHandler adds a handler for processing Kafka events of the specified topic in batches.
+// A batch is processed when it reaches the batch size or when its first message waited for the max wait, offsets are committed after the batch completes.
+// When a failure policy is set, the failed messages are retried in process as a smaller batch and then republished individually;
+// the topic and each retry topic of the policy are batched separately.
+func (k *KafkaClient) AddBatchHandler(ctx context.Context, topicName string, handler KafkaBatchProcessor, options ...HandlerOption) {
+	if handler == nil {
+		k.log.Emergency(ctx, "missing handler for topic - "+topicName, nil, fmt.Errorf("KafkaClient.AddBatchHandler: handler parameter cannot be nil"))
+	}
+	config := &handlerConfig{batchSize: 100, batchMaxWait: time.Second}
+	for _, opt := range options {
+		opt(config)
+	}
+	if config.batchSize == 0 {
+		config.batchSize = 1
+	}
+	if config.txn != nil {
+		k.log.Emergency(ctx, "transactions are not supported for batch handler - "+topicName, nil, fmt.Errorf("KafkaClient.AddBatchHandler: WithTransaction is not supported"))
+	}
+	for _, topic := range k.registerTopics(ctx, topicName, config) {
+		k.batchHandler[topic] = &batchHandler{
+			topic:   topic,
+			handler: handler,
+			size:    int(config.batchSize),
+			maxWait: config.batchMaxWait,
+			dedup:   config.dedup,
+		}
+	}
+}
+
+// startBatchers starts the goroutine accumulating the messages of every batched topic.
+func (k *KafkaClient) startBatchers(ctx context.Context) {
+	for _, b := range k.batchHandler {
+		b.ch = make(chan *ckafka.Message, b.size)
+		k.batchWG.Add(1)
+		go k.runBatcher(ctx, b)
+	}
+}
+
+// stopBatchers processes the pending batches and waits for the batchers to exit.
+func (k *KafkaClient) stopBatchers() {
+	for _, b := range k.batchHandler {
+		close(b.ch)
+	}
+	k.batchWG.Wait()
+}
+
+// runBatcher accumulates messages and processes them when the batch is full or the max wait is reached.
+func (k *KafkaClient) runBatcher(ctx context.Context, b *batchHandler) {
+	defer k.batchWG.Done()
+	batch := make([]*ckafka.Message, 0, b.size)
+	timer := time.NewTimer(b.maxWait)
+	stopTimer := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+	stopTimer()
+	flush := func() {
+		if len(batch) > 0 {
+			k.processBatch(ctx, b, batch)
+			batch = make([]*ckafka.Message, 0, b.size)
+		}
+	}
+	defer k.log.Notice(ctx, "batcher stopped for "+b.topic, nil)
+	for {
+		select {
+		case msg, ok := <-b.ch:
+			if !ok {
+				stopTimer()
+				flush()
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(b.maxWait)
+			}
+			batch = append(batch, msg)
+			if len(batch) >= b.size {
+				stopTimer()
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// batchFailure holds the failure of a message of a batch.
+type batchFailure struct {
+	stackTrace string
+	err        error
+}
+
+// processBatch runs the batch handler, routes the failed messages to the error path and acknowledges the batch.
+// Messages delayed till their retry time and failed messages that could not be republished are not acknowledged.
+func (k *KafkaClient) processBatch(ctx context.Context, b *batchHandler, batch []*ckafka.Message) {
+	client := k.poller()
+	msgs := make([]*kafka.Message, 0, len(batch))
+	for _, msg := range batch {
+		// Messages superseded by a delay or rebalance of their partition are handed out again, drop them unprocessed
+		if client != nil && client.Stale(msg) {
+			continue
+		}
+		msgs = append(msgs, &kafka.Message{Message: msg})
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	policy := k.policy[b.topic]
+	if policy != nil {
+		msgs = k.dueMessages(ctx, msgs, policy)
+		if len(msgs) == 0 {
+			return
+		}
+	}
+	due := msgs
+	raw := make([]*ckafka.Message, len(due))
+	for i, msg := range due {
+		raw[i] = msg.Message
+	}
+	batchCtx := k.GetBatchContext(b.topic, raw)
+	sp, spanOk := k.GetSpanFromContext(batchCtx)
+	if b.dedup != nil {
+		msgs = k.dedupBatch(batchCtx, b.dedup, msgs)
+	}
+	var failed map[*kafka.Message]*batchFailure
+	if len(msgs) > 0 {
+		failed = k.executeBatch(batchCtx, msgs, b.handler, policy)
+	}
+	pending := map[*kafka.Message]bool{}
+	for _, msg := range msgs {
+		failure, ok := failed[msg]
+		if !ok {
+			if b.dedup != nil {
+				k.record(batchCtx, b.dedup, b.dedup.id(msg))
+			}
+			continue
+		}
+		msgCtx := k.messageContext(msg)
+		k.ProcessError(msgCtx, failure.stackTrace, failure.err)
+		if policy != nil && k.forward(msgCtx, msg, policy, failure.stackTrace, failure.err) != nil {
+			pending[msg] = true
+		}
+	}
+	if spanOk {
+		sp.SetAttribute("messaging.batch.failed_count", len(failed))
+		if len(failed) > 0 {
+			sp.SetError(fmt.Errorf("batch failed for %v of %v messages", len(failed), len(msgs)), "")
+			sp.SetStatus(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		} else {
+			sp.SetStatus(http.StatusOK, http.StatusText(http.StatusOK))
+		}
+		sp.Finish()
+	}
+	for _, msg := range due {
+		if !pending[msg] {
+			k.ack(ctx, msg.Message)
+		}
+	}
+}
+
+// dueMessages returns the messages of the batch that are past their retry time. In each partition the first message
+// before its retry time and the messages following it are left out, the poller delays the partition and hands them out
+// again. When the poller cannot delay the partition, the batch waits till the latest retry time instead.
+func (k *KafkaClient) dueMessages(ctx context.Context, msgs []*kafka.Message, policy *FailurePolicy) []*kafka.Message {
+	client := k.poller()
+	delayed := map[int]bool{}
+	res := make([]*kafka.Message, 0, len(msgs))
+	var wait time.Duration
+	for _, msg := range msgs {
+		if delayed[msg.Partition] {
+			continue
+		}
+		notBefore, d := retryDelay(msg, policy)
+		if d > 0 {
+			if client != nil && client.Delay(msg.Message, notBefore) {
+				k.log.Debug(ctx, fmt.Sprintf("delaying retry message by %v", d), msg.GetMeta())
+				delayed[msg.Partition] = true
+				continue
+			}
+			wait = max(wait, d)
+		}
+		res = append(res, msg)
+	}
+	if wait > 0 {
+		k.log.Debug(ctx, fmt.Sprintf("delaying retry batch by %v", wait), nil)
+		k.wait(wait)
+	}
+	return res
+}
+
+// executeBatch runs the batch handler, retrying the failed messages in process as per the failure policy.
+func (k *KafkaClient) executeBatch(ctx context.Context, msgs []*kafka.Message, handler KafkaBatchProcessor, policy *FailurePolicy) map[*kafka.Message]*batchFailure {
+	attempts := uint(1)
+	var backoff time.Duration
+	if policy != nil {
+		attempts += policy.MaxRetries
+		backoff = policy.Backoff
+	}
+	pending := msgs
+	for i := uint(1); ; i++ {
+		failed := k.runBatchHandler(ctx, pending, handler)
+		if len(failed) == 0 || i >= attempts {
+			return failed
+		}
+		retry := make([]*kafka.Message, 0, len(failed))
+		for _, msg := range pending {
+			if _, ok := failed[msg]; ok {
+				retry = append(retry, msg)
+			}
+		}
+		pending = retry
+		k.log.Warning(ctx, fmt.Sprintf("batch handler failed for %v messages, retry %v of %v", len(pending), i, attempts-1), nil)
+		if !k.wait(backoff) {
+			return failed
+		}
+		backoff = nextBackoff(backoff, policy)
+	}
+}
+
+// runBatchHandler runs the batch handler and returns the failed messages, a panic fails the whole batch.
+func (k *KafkaClient) runBatchHandler(ctx context.Context, msgs []*kafka.Message, handler KafkaBatchProcessor) (failed map[*kafka.Message]*batchFailure) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			stackTrace, err := k.PanicRecovery(ctx, rec)
+			failed = make(map[*kafka.Message]*batchFailure, len(msgs))
+			for _, msg := range msgs {
+				failed[msg] = &batchFailure{stackTrace: stackTrace, err: err}
+			}
+		}
+	}()
+	err := handler(ctx, msgs)
+	if err == nil {
+		return nil
+	}
+	var batchErr *BatchError
+	if e.As(err, &batchErr) {
+		failed = make(map[*kafka.Message]*batchFailure, len(batchErr.Errors))
+		for idx, msgErr := range batchErr.Errors {
+			if idx >= 0 && idx < len(msgs) && msgErr != nil {
+				failed[msgs[idx]] = &batchFailure{err: msgErr}
+			}
+		}
+		return failed
+	}
+	failed = make(map[*kafka.Message]*batchFailure, len(msgs))
+	for _, msg := range msgs {
+		failed[msg] = &batchFailure{err: err}
+	}
+	return failed
+}
+
+// GetBatchContext creates a context for processing a batch of Kafka messages with a new correlation ID.
+// If a tracer was passed during the server initiation, creates one span for the batch, linked to the producer span of every message when the tracer implements [BatchTracer].
+func (k *KafkaClient) GetBatchContext(topic string, msgs []*ckafka.Message) context.Context {
+	corr := correlation.NewCorrelationParam(k.c.Config.ServiceName)
+	batchCtx := correlation.GetContextWithCorrelationParam(context.Background(), corr)
+	if k.tracer == nil {
+		return batchCtx
+	}
+	var sp span.Span
+	if bt, ok := k.tracer.(BatchTracer); ok {
+		batchCtx, sp = bt.StartKafkaBatchSpan(batchCtx, topic, msgs)
+	} else {
+		batchCtx, sp = k.tracer.NewSpanFromContext(batchCtx, "kafka.consume.batch", span.SpanKindConsumer, topic)
+	}
+	sp.SetAttribute("correlationId", corr.CorrelationID)
+	sp.SetAttribute("messaging.kafka.topic", topic)
+	sp.SetAttribute("messaging.batch.message_count", len(msgs))
+	sp.SetAttribute("messaging.kafka.offset.first", strconv.FormatInt(msgs[0].Offset, 10))
+	sp.SetAttribute("messaging.kafka.offset.last", strconv.FormatInt(msgs[len(msgs)-1].Offset, 10))
+	return batchCtx
+}
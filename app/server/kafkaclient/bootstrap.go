@@ -22,6 +22,7 @@ func (k *KafkaClient) StartClient() {
 	k.StartSignalMonitor(ctx)
 	pollCtx, cancelPoll := context.WithCancel(correlation.GetContextWithCorrelationParam(context.Background(), corr))
 	k.shutdownPoll = cancelPoll
+	k.pollCtx = pollCtx
 	k.log.Notice(ctx, "Starting kafka consumer", nil)
 	defer func() {
 		if rec := recover(); rec != nil {
@@ -32,7 +33,15 @@ func (k *KafkaClient) StartClient() {
 		}
 	}()
 	go k.HealthCheckMonitor(pollCtx)
+	k.provisionTopics(ctx)
+	// held till the poll channel is drained, added before the poller is published so that Shutdown waits for it
+	k.requestWG.Add(1)
 	k.Subscribe(ctx)
+	k.log.Notice(ctx, "Kafka client routes", k.Routes())
+	var pool *workerPool
+	if k.c.Concurrency > 1 {
+		pool = k.startWorkers(ctx)
+	}
 	var pollWg sync.WaitGroup
 	defer pollWg.Wait()
 	pollWg.Add(1)
@@ -44,25 +53,43 @@ func (k *KafkaClient) StartClient() {
 		}
 	}()
 	k.log.Notice(ctx, "Kafka consumer started", nil)
-	k.requestWG.Add(1)
+	if len(k.batchHandler) > 0 {
+		k.startBatchers(ctx)
+	}
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case msg, ok := <-k.ch:
 			if !ok {
+				if pool != nil {
+					k.stopWorkers(ctx, pool)
+				}
+				if len(k.batchHandler) > 0 {
+					k.stopBatchers()
+				}
 				k.requestWG.Done()
 				return
 			}
 			topicName := (*msg).Topic
+			if b, ok := k.batchHandler[topicName]; ok {
+				b.ch <- msg
+				continue
+			}
 			handler := k.handler[topicName]
 			if handler == nil {
 				k.log.Emergency(ctx, "missing handler for topic - "+topicName, nil, fmt.Errorf("KafkaClient.StartClient: missing handler for topic: %v", topicName))
 			}
+			if pool != nil {
+				k.dispatch(pool, msg)
+				continue
+			}
 			emMsg := &kafka.Message{Message: msg}
 			msgCtx := k.GetMessageContext(emMsg)
 			k.requestWG.Add(1)
-			k.ProcessEvent(msgCtx, emMsg, handler)
+			if k.ProcessEvent(msgCtx, emMsg, handler) == nil {
+				k.ack(ctx, msg)
+			}
 			k.requestWG.Done()
 		}
 	}
@@ -0,0 +1,574 @@
+package kafkaclient_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/app/server/kafkaclient"
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/errors"
+	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	ckafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+// waitForSubscribe waits till the client has created its poller.
+func waitForSubscribe(t *testing.T, srv *kafkaclient.KafkaClient) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		status, err := srv.StatusCheck(context.Background())
+		assert.NilError(t, err)
+		if status.(*kafkaclient.Status).Consumer != nil {
+			return
+		}
+	}
+	t.Fatal("client did not subscribe")
+}
+
+func TestKafkaClientWithBroker(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 2), kafkatest.WithTopic("orders.dlq", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	consumerConfig.MaxBuffer = 1
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithBatch(true), kafka.WithAsync(false))
+	assert.NilError(t, err)
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithProducer(producer))
+	processed := make(chan string, 10)
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		if string(m.Value) == "bad" {
+			return &errors.CustomError{ErrorCode: "test.bad.order", ErrorMessage: "bad order"}
+		}
+		processed <- string(m.Value)
+		return nil
+	}, kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{DLQTopic: "orders.dlq"}))
+	for i := 0; i < 4; i++ {
+		err = broker.Produce("orders", ckafka.Message{Partition: i % 2, Key: []byte(fmt.Sprintf("key-%v", i)), Value: []byte(fmt.Sprintf("order-%v", i))})
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Partition: 0, Value: []byte("bad")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	received := map[string]bool{}
+	for len(received) < 4 {
+		select {
+		case value := <-processed:
+			received[value] = true
+		case <-time.After(10 * time.Second):
+			t.Fatalf("processed %v of 4 messages", len(received))
+		}
+	}
+	dlq := broker.ExpectMessages(t, "orders.dlq", 1)
+	assert.Equal(t, string(dlq[0].Value), "bad")
+	assert.Equal(t, kafkatest.Header(&dlq[0], kafkaclient.HeaderOriginalTopic), "orders")
+	broker.ExpectCommitted(t, "orders-group", "orders", 0, 3)
+	broker.ExpectCommitted(t, "orders-group", "orders", 1, 2)
+	srv.BaseApp.Shutdown(ctx)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("client did not stop after shutdown")
+	}
+}
+
+func TestKafkaClientProvisionTopics(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithAutoCreateTopics(false))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	srv := kafkaclient.New(
+		kafkaclient.WithKafkaConsumerConfig(consumerConfig),
+		kafkaclient.WithTopicProvisioning(kafka.DriftPolicyFail, kafka.TopicSpec{Partitions: 2}, kafka.TopicSpec{Name: "orders", Partitions: 3}, kafka.TopicSpec{Name: "invoices"}),
+	)
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		return nil
+	}, kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{RetryTopics: kafkaclient.NewRetryTopics("orders", time.Minute), DLQTopic: "orders.dlq"}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	expected := []string{"invoices", "orders", "orders.dlq", "orders.retry.1m"}
+	for deadline := time.Now().Add(10 * time.Second); len(broker.Topics()) < len(expected) && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.DeepEqual(t, broker.Topics(), expected)
+	admin, err := kafka.NewAdmin(kafka.WithAdminCredConfig(broker.CredConfig()))
+	assert.NilError(t, err)
+	topics, err := admin.DescribeTopics(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, topics["orders"].Partitions, 3)
+	assert.Equal(t, topics["orders.dlq"].Partitions, 2)
+	assert.Equal(t, topics["orders.retry.1m"].Partitions, 2)
+	assert.Equal(t, topics["invoices"].Partitions, 1)
+	waitForSubscribe(t, srv)
+	srv.BaseApp.Shutdown(ctx)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("client did not stop after shutdown")
+	}
+}
+
+// healthHook is a health check hook that fails while unhealthy is set.
+type healthHook struct {
+	unhealthy atomic.Bool
+}
+
+func (h *healthHook) Name(ctx context.Context) string {
+	return "TestHealthHook"
+}
+
+func (h *healthHook) HealthCheck(ctx context.Context) error {
+	if h.unhealthy.Load() {
+		return fmt.Errorf("healthHook.HealthCheck: dependency down")
+	}
+	return nil
+}
+
+// waitForCircuit waits till the health check circuit of the client is in the state.
+func waitForCircuit(t *testing.T, srv *kafkaclient.KafkaClient, open bool) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		status, err := srv.StatusCheck(context.Background())
+		assert.NilError(t, err)
+		if status.(*kafkaclient.Status).CircuitOpen == open {
+			return
+		}
+	}
+	t.Fatalf("circuit open is not %v", open)
+}
+
+func TestKafkaClientHealthCircuit(t *testing.T) {
+	t.Setenv(env.KafkaClientHealthCheckInterval, "1")
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithPauseOnUnhealthy(0))
+	hook := &healthHook{}
+	srv.RegisterHealthCheckHook(hook)
+	processed := make(chan string, 10)
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		processed <- string(m.Value)
+		return nil
+	})
+	expect := func(value string) {
+		t.Helper()
+		select {
+		case got := <-processed:
+			assert.Equal(t, got, value)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%v not processed", value)
+		}
+	}
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("order-0")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	expect("order-0")
+
+	hook.unhealthy.Store(true)
+	waitForCircuit(t, srv, true)
+	status, _ := srv.StatusCheck(ctx)
+	assert.Assert(t, status.(*kafkaclient.Status).Consumer.Pause.Paused)
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("order-1")}))
+	select {
+	case value := <-processed:
+		t.Fatalf("%v processed while the circuit is open", value)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	hook.unhealthy.Store(false)
+	waitForCircuit(t, srv, false)
+	expect("order-1")
+	broker.ExpectCommitted(t, "orders-group", "orders", 0, 2)
+	srv.BaseApp.Shutdown(ctx)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("client did not stop after shutdown")
+	}
+}
+
+// stopClient shuts the client down and waits for StartClient to return.
+func stopClient(t *testing.T, srv *kafkaclient.KafkaClient, done <-chan struct{}) {
+	t.Helper()
+	srv.BaseApp.Shutdown(context.Background())
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("client did not stop after shutdown")
+	}
+}
+
+func TestKafkaClientRetryTopic(t *testing.T) {
+	retryTopics := kafkaclient.NewRetryTopics("orders", 2*time.Second)
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1), kafkatest.WithTopic(retryTopics[0].Topic, 1), kafkatest.WithTopic("orders.dlq", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	consumerConfig.MaxBuffer = 1
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithBatch(true), kafka.WithAsync(false))
+	assert.NilError(t, err)
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithProducer(producer))
+	processed := make(chan *kafka.Message, 10)
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		if string(m.Value) == "bad" && m.Topic == "orders" {
+			return fmt.Errorf("bad order")
+		}
+		processed <- m
+		return nil
+	}, kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{RetryTopics: retryTopics, DLQTopic: "orders.dlq"}))
+	expect := func(value string) *kafka.Message {
+		t.Helper()
+		select {
+		case m := <-processed:
+			assert.Equal(t, string(m.Value), value)
+			return m
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%v not processed", value)
+		}
+		return nil
+	}
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("bad")}, ckafka.Message{Value: []byte("order-1")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	expect("order-1")
+	retry := broker.ExpectMessages(t, retryTopics[0].Topic, 1)
+	notBefore, err := strconv.ParseInt(kafkatest.Header(&retry[0], kafkaclient.HeaderRetryNotBefore), 10, 64)
+	assert.NilError(t, err)
+
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("order-2")}))
+	expect("order-2")
+	assert.Assert(t, time.Now().Before(time.UnixMilli(notBefore)), "other messages are processed while the retry waits")
+	broker.ExpectCommitted(t, "orders-group", "orders", 0, 3)
+
+	m := expect("bad")
+	assert.Equal(t, m.Topic, retryTopics[0].Topic)
+	assert.Assert(t, !time.Now().Before(time.UnixMilli(notBefore)), "retry processed before its retry time")
+	broker.ExpectCommitted(t, "orders-group", retryTopics[0].Topic, 0, 1)
+	broker.ExpectNoMessages(t, "orders.dlq")
+	stopClient(t, srv, done)
+}
+
+func TestKafkaClientRetryTopicQueued(t *testing.T) {
+	retryTopics := kafkaclient.NewRetryTopics("orders", time.Second)
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1), kafkatest.WithTopic(retryTopics[0].Topic, 1), kafkatest.WithTopic("orders.dlq", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	consumerConfig.MaxBuffer = 1
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithBatch(true), kafka.WithAsync(false))
+	assert.NilError(t, err)
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithProducer(producer),
+		kafkaclient.WithConcurrency(2), kafkaclient.WithQueueDepth(10))
+	var lock sync.Mutex
+	processed := map[string]int{}
+	var order []string
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		lock.Lock()
+		defer lock.Unlock()
+		processed[string(m.Value)]++
+		order = append(order, string(m.Value))
+		return nil
+	}, kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{RetryTopics: retryTopics, DLQTopic: "orders.dlq"}))
+	notBefore := time.Now().Add(time.Second)
+	header := func(at time.Time) []ckafka.Header {
+		return []ckafka.Header{{Key: kafkaclient.HeaderRetryNotBefore, Value: []byte(strconv.FormatInt(at.UnixMilli(), 10))}}
+	}
+	// The due retry follows a retry that is not due, it is queued to the worker behind it and must wait for the delay
+	assert.NilError(t, broker.Produce(retryTopics[0].Topic,
+		ckafka.Message{Value: []byte("later"), Headers: header(notBefore)},
+		ckafka.Message{Value: []byte("due"), Headers: header(time.Now().Add(-time.Second))},
+	))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	broker.ExpectCommitted(t, "orders-group", retryTopics[0].Topic, 0, 2)
+	lock.Lock()
+	assert.DeepEqual(t, order, []string{"later", "due"})
+	assert.DeepEqual(t, processed, map[string]int{"later": 1, "due": 1})
+	lock.Unlock()
+	stopClient(t, srv, done)
+}
+
+func TestKafkaClientRepublishFailure(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithAutoCreateTopics(false), kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	consumerConfig.MaxBuffer = 1
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithBatch(true), kafka.WithAsync(false), kafka.WithMaxRetries(0))
+	assert.NilError(t, err)
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithProducer(producer))
+	var attempts atomic.Int32
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		attempts.Add(1)
+		return fmt.Errorf("bad order")
+	}, kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{Backoff: 50 * time.Millisecond, MaxBackoff: 100 * time.Millisecond, DLQTopic: "orders.dlq"}))
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("bad")}, ckafka.Message{Value: []byte("order-1")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	for deadline := time.Now().Add(10 * time.Second); attempts.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(time.Second)
+	assert.Equal(t, attempts.Load(), int32(1), "the next message waits till the failed message is republished")
+	stopClient(t, srv, done)
+	assert.Equal(t, broker.CommittedOffset("orders-group", "orders", 0), int64(-1), "a message that is not republished is not committed")
+}
+
+func TestReplayDLQ(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1), kafkatest.WithTopic("orders.dlq", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	failed := func(value string) ckafka.Message {
+		return ckafka.Message{Value: []byte(value), Headers: []ckafka.Header{
+			{Key: kafkaclient.HeaderOriginalTopic, Value: []byte("orders")},
+			{Key: kafkaclient.HeaderErrorCode, Value: []byte("test.bad.order")},
+			{Key: "x-tenant", Value: []byte("acme")},
+		}}
+	}
+	assert.NilError(t, broker.Produce("orders.dlq", failed("order-0"), ckafka.Message{Value: []byte("unknown")}, failed("order-2")))
+	config := kafkaclient.GetDefaultReplayConfig("orders.dlq")
+	config.CredConfig = broker.CredConfig()
+	config.IdleTimeout = 2 * time.Second
+	config.MaxMessages = 1
+	count, err := kafkaclient.ReplayDLQ(ctx, config)
+	assert.NilError(t, err)
+	assert.Equal(t, count, uint(1))
+	assert.Equal(t, broker.CommittedOffset(config.GroupID, "orders.dlq", 0), int64(1), "messages left after MaxMessages are not committed")
+	replayed := broker.Messages("orders")
+	assert.Equal(t, len(replayed), 1)
+	assert.Equal(t, string(replayed[0].Value), "order-0")
+	assert.Equal(t, kafkatest.Header(&replayed[0], "x-tenant"), "acme")
+	assert.Equal(t, kafkatest.Header(&replayed[0], kafkaclient.HeaderErrorCode), "")
+
+	config.MaxMessages = 0
+	count, err = kafkaclient.ReplayDLQ(ctx, config)
+	assert.NilError(t, err)
+	assert.Equal(t, count, uint(1))
+	assert.Equal(t, broker.CommittedOffset(config.GroupID, "orders.dlq", 0), int64(3))
+	replayed = broker.Messages("orders")
+	assert.Equal(t, len(replayed), 2)
+	assert.Equal(t, string(replayed[1].Value), "order-2")
+}
+
+func TestKafkaClientBatchRetryTopic(t *testing.T) {
+	retryTopics := kafkaclient.NewRetryTopics("orders", time.Second)
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1), kafkatest.WithTopic(retryTopics[0].Topic, 1), kafkatest.WithTopic("orders.dlq", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithBatch(true), kafka.WithAsync(false))
+	assert.NilError(t, err)
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithProducer(producer))
+	type batchCall struct {
+		topics map[string]bool
+		values []string
+		at     time.Time
+	}
+	calls := make(chan batchCall, 10)
+	srv.AddBatchHandler(ctx, "orders", func(ctx context.Context, msgs []*kafka.Message) error {
+		call := batchCall{topics: map[string]bool{}, at: time.Now()}
+		batchErr := kafkaclient.NewBatchError()
+		for i, m := range msgs {
+			call.topics[m.Topic] = true
+			call.values = append(call.values, string(m.Value))
+			if string(m.Value) == "bad" && m.Topic == "orders" {
+				batchErr.Add(i, fmt.Errorf("bad order"))
+			}
+		}
+		calls <- call
+		if len(batchErr.Errors) > 0 {
+			return batchErr
+		}
+		return nil
+	}, kafkaclient.WithBatchSize(2), kafkaclient.WithBatchMaxWait(200*time.Millisecond), kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{RetryTopics: retryTopics, DLQTopic: "orders.dlq"}))
+	next := func() batchCall {
+		t.Helper()
+		select {
+		case call := <-calls:
+			assert.Equal(t, len(call.topics), 1, "a batch holds the messages of one topic")
+			return call
+		case <-time.After(10 * time.Second):
+			t.Fatal("no batch processed")
+		}
+		return batchCall{}
+	}
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("bad")}, ckafka.Message{Value: []byte("order-1")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	call := next()
+	assert.Assert(t, call.topics["orders"])
+	assert.DeepEqual(t, call.values, []string{"bad", "order-1"})
+	retry := broker.ExpectMessages(t, retryTopics[0].Topic, 1)
+	notBefore, err := strconv.ParseInt(kafkatest.Header(&retry[0], kafkaclient.HeaderRetryNotBefore), 10, 64)
+	assert.NilError(t, err)
+	broker.ExpectCommitted(t, "orders-group", "orders", 0, 2)
+
+	call = next()
+	assert.Assert(t, call.topics[retryTopics[0].Topic])
+	assert.DeepEqual(t, call.values, []string{"bad"})
+	assert.Assert(t, !call.at.Before(time.UnixMilli(notBefore)), "retry batch processed before its retry time")
+	broker.ExpectCommitted(t, "orders-group", retryTopics[0].Topic, 0, 1)
+	broker.ExpectNoMessages(t, "orders.dlq")
+	stopClient(t, srv, done)
+}
+
+func TestKafkaClientBatchRepublishFailure(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithAutoCreateTopics(false), kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "orders-group"
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithBatch(true), kafka.WithAsync(false), kafka.WithMaxRetries(0))
+	assert.NilError(t, err)
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig), kafkaclient.WithProducer(producer))
+	var batches atomic.Int32
+	srv.AddBatchHandler(ctx, "orders", func(ctx context.Context, msgs []*kafka.Message) error {
+		batches.Add(1)
+		batchErr := kafkaclient.NewBatchError()
+		for i, m := range msgs {
+			if string(m.Value) == "bad" {
+				batchErr.Add(i, fmt.Errorf("bad order"))
+			}
+		}
+		return batchErr
+	}, kafkaclient.WithBatchSize(2), kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{Backoff: 50 * time.Millisecond, DLQTopic: "orders.dlq"}))
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("bad")}, ckafka.Message{Value: []byte("order-1")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	for deadline := time.Now().Add(10 * time.Second); batches.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, batches.Load(), int32(1))
+	time.Sleep(500 * time.Millisecond)
+	stopClient(t, srv, done)
+	assert.Equal(t, broker.CommittedOffset("orders-group", "orders", 0), int64(-1), "a message that is not republished is not committed")
+}
+
+func TestErrorCode(t *testing.T) {
+	custom := &errors.CustomError{ErrorCode: "RETRY_ME", ErrorMessage: "retry"}
+	assert.Equal(t, kafkaclient.ErrorCode(custom), "RETRY_ME")
+	assert.Equal(t, kafkaclient.ErrorCode(fmt.Errorf("wrapped: %w", errors.HTTPError{CustomError: custom, StatusCode: 500})), "RETRY_ME")
+	assert.Equal(t, kafkaclient.ErrorCode(errors.HTTPError{StatusCode: 500}), "unknown")
+	deep := fmt.Errorf("handler: %w %w", &errors.HTTPError{StatusCode: 500}, fmt.Errorf("cause: %w", custom))
+	assert.Equal(t, kafkaclient.ErrorCode(deep), "RETRY_ME", "custom error wrapped after an HTTP error without one")
+	assert.Equal(t, kafkaclient.ErrorCode(fmt.Errorf("plain")), "unknown")
+}
+
+type commitSpan struct{}
+
+func (commitSpan) SetAttribute(key string, value any)           {}
+func (commitSpan) SetStatus(statusCode int, description string) {}
+func (commitSpan) SetError(err error, stackTrace string)        {}
+func (commitSpan) Finish()                                      {}
+func (commitSpan) TraceID() string                              { return "" }
+func (commitSpan) SpanID() string                               { return "" }
+
+// commitTracer records the operations of the spans created by the consumer.
+type commitTracer struct {
+	operations sync.Map
+}
+
+func (c *commitTracer) NewSpanFromContext(ctx context.Context, operationName string, kind string, resourceName string) (context.Context, span.Span) {
+	c.operations.Store(operationName, true)
+	return ctx, commitSpan{}
+}
+
+func (c *commitTracer) GetSpanFromContext(ctx context.Context) (span.Span, bool) {
+	return nil, false
+}
+
+func TestKafkaClientConsumerConfig(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaClientTest"))
+	tracer := &commitTracer{}
+	consumerConfig := kafka.GetDefaultConsumerConfig()
+	consumerConfig.CredConfig = broker.CredConfig()
+	consumerConfig.GroupID = "config-group"
+	consumerConfig.MaxBuffer = 1
+	consumerConfig.Topics = []string{"orders"}
+	consumerConfig.Trace = tracer
+	srv := kafkaclient.New(kafkaclient.WithKafkaConsumerConfig(consumerConfig))
+	processed := make(chan string, 1)
+	srv.AddHandler(ctx, "orders", func(ctx context.Context, m *kafka.Message) error {
+		processed <- string(m.Value)
+		return nil
+	})
+	assert.NilError(t, broker.Produce("orders", ckafka.Message{Value: []byte("order-0")}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.StartClient()
+	}()
+	select {
+	case value := <-processed:
+		assert.Equal(t, value, "order-0")
+	case <-time.After(10 * time.Second):
+		t.Fatal("message not processed")
+	}
+	broker.ExpectCommitted(t, "config-group", "orders", 0, 1)
+	_, ok := tracer.operations.Load("kafka.consumer.commit")
+	assert.Assert(t, ok, "the tracer of the consumer config is used by the poller")
+	srv.BaseApp.Shutdown(ctx)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("client did not stop after shutdown")
+	}
+}
@@ -0,0 +1,45 @@
+// Command dlqreplay publishes the messages of a dead-letter topic back to their source topic.
+//
+// Usage:
+//
+//	dlqreplay -topic <dlq topic> [-group <consumer group>] [-max <count>] [-idle <duration>]
+//
+// Brokers are read from the KAFAK__BROKER environment variable.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sabariramc/goserverbase/v6/app/server/kafkaclient"
+	"github.com/sabariramc/goserverbase/v6/correlation"
+)
+
+func main() {
+	topic := flag.String("topic", "", "dead-letter topic to replay")
+	group := flag.String("group", "", "consumer group used to track the replayed messages")
+	max := flag.Uint("max", 0, "maximum number of messages to replay, all when zero")
+	idle := flag.Duration("idle", 0, "stop when no message is received for this duration")
+	flag.Parse()
+	if *topic == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	config := kafkaclient.GetDefaultReplayConfig(*topic)
+	if *group != "" {
+		config.GroupID = *group
+	}
+	if *idle > 0 {
+		config.IdleTimeout = *idle
+	}
+	config.MaxMessages = *max
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("DLQReplay"))
+	count, err := kafkaclient.ReplayDLQ(ctx, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay failed after %v messages: %v\n", count, err)
+		os.Exit(1)
+	}
+	fmt.Printf("replayed %v messages from %v\n", count, *topic)
+}
@@ -9,29 +9,65 @@ import (
 	"github.com/sabariramc/goserverbase/v6/utils"
 )
 
+// Ordering keys supported by the worker pool.
+const (
+	OrderingKeyPartition = "PARTITION" // Messages of the same topic partition are processed in order
+	OrderingKeyMessage   = "KEY"       // Messages with the same message key are processed in order
+)
+
 // Config holds the configuration for the application.
 type Config struct {
-	*baseapp.Config               // Embeds for base config
-	*kafka.ConsumerConfig         // Embeds for kafka consumer config
-	HealthCheckInterval   uint    // Interval in seconds to do health check of various modules
-	HealthCheckResultPath string  // Local disk file path for writing health check results
-	Log                   log.Log // Logger instance.
-	Tracer                Tracer  // Tracer instance.
+	*baseapp.Config                         // Embeds for base config
+	*kafka.ConsumerConfig                   // Embeds for kafka consumer config
+	HealthCheckInterval   uint              // Interval in seconds to do health check of various modules
+	HealthCheckResultPath string            // Local disk file path for writing health check results
+	Concurrency           uint              // Number of workers processing messages, 1 processes messages inline
+	QueueDepth            uint              // Buffer size of each worker queue
+	OrderingKey           string            // Ordering guarantee of the worker pool, [OrderingKeyPartition] or [OrderingKeyMessage]
+	PauseOnUnhealthy      bool              // Pause fetching while the health check fails instead of exiting
+	UnhealthyTimeout      uint              // Seconds the client stays paused on a failing health check before exiting, 0 waits indefinitely
+	Log                   log.Log           // Logger instance.
+	Tracer                Tracer            // Tracer instance.
+	Producer              *kafka.Producer   // Producer for republishing failed messages, created on demand when nil
+	ProvisionTopics       bool              // Create the missing topics of the handlers, their retry and dead-letter topics and TopicSpecs on start
+	TopicDriftPolicy      string            // Action on existing topics that differ from their spec, [kafka.DriftPolicyWarn] or [kafka.DriftPolicyFail]
+	TopicDefaults         kafka.TopicSpec   // Spec of the provisioned topics without an entry in TopicSpecs, Name is ignored
+	TopicSpecs            []kafka.TopicSpec // Spec of the topics to provision, may include topics that are only produced to
 }
 
 // GetDefaultConfig creates a new Config with values from environment variables or default values.
 /*
 	Environment Variables
-	- KAFKACS__HEALTH_CHECK_INTERVAL: Sets [HealthCheckInterval]
-	- KAFKACS__HEALTH_CHECK_RESULT_PATH: Sets [HealthCheckResultPath]
+	- KAFKA_CLIENT__HEALTH_CHECK_INTERVAL: Sets [HealthCheckInterval]
+	- KAFKA_CLIENT__HEALTH_CHECK_RESULT_PATH: Sets [HealthCheckResultPath]
+	- KAFKA_CLIENT__CONCURRENCY: Sets [Concurrency]
+	- KAFKA_CLIENT__QUEUE_DEPTH: Sets [QueueDepth]
+	- KAFKA_CLIENT__ORDERING_KEY: Sets [OrderingKey]
+	- KAFKA_CLIENT__PAUSE_ON_UNHEALTHY: Sets [PauseOnUnhealthy]
+	- KAFKA_CLIENT__UNHEALTHY_TIMEOUT: Sets [UnhealthyTimeout]
+	- KAFKA_CLIENT__PROVISION_TOPICS: Sets [ProvisionTopics]
+	- KAFKA_CLIENT__TOPIC_DRIFT_POLICY: Sets [TopicDriftPolicy]
+	- KAFKA_CLIENT__TOPIC_PARTITIONS: Sets [TopicDefaults.Partitions]
+	- KAFKA_CLIENT__TOPIC_REPLICATION_FACTOR: Sets [TopicDefaults.ReplicationFactor]
 */
 func GetDefaultConfig() *Config {
 	return &Config{
 		HealthCheckInterval:   uint(utils.GetEnvInt(env.KafkaClientHealthCheckInterval, 30)),
 		HealthCheckResultPath: utils.GetEnv(env.KafkaClientHealthCheckResultPath, "/tmp/healthCheck"),
-		Config:                baseapp.GetDefaultConfig(),
-		Log:                   log.New(log.WithModuleName("KafkaClient")),
-		ConsumerConfig:        kafka.GetDefaultConsumerConfig(),
+		Concurrency:           uint(utils.GetEnvInt(env.KafkaClientConcurrency, 1)),
+		QueueDepth:            uint(utils.GetEnvInt(env.KafkaClientQueueDepth, 10)),
+		OrderingKey:           utils.GetEnv(env.KafkaClientOrderingKey, OrderingKeyPartition),
+		PauseOnUnhealthy:      utils.GetEnvBool(env.KafkaClientPauseOnUnhealthy, false),
+		UnhealthyTimeout:      uint(utils.GetEnvInt(env.KafkaClientUnhealthyTimeout, 0)),
+		ProvisionTopics:       utils.GetEnvBool(env.KafkaClientProvisionTopics, false),
+		TopicDriftPolicy:      utils.GetEnv(env.KafkaClientTopicDriftPolicy, kafka.DriftPolicyWarn),
+		TopicDefaults: kafka.TopicSpec{
+			Partitions:        utils.GetEnvInt(env.KafkaClientTopicPartitions, 0),
+			ReplicationFactor: utils.GetEnvInt(env.KafkaClientTopicReplicationFactor, 0),
+		},
+		Config:         baseapp.GetDefaultConfig(),
+		Log:            log.New(log.WithModuleName("KafkaClient")),
+		ConsumerConfig: kafka.GetDefaultConsumerConfig(),
 	}
 }
 
@@ -72,3 +108,50 @@ func WithTracer(t Tracer) Options {
 		c.Tracer = t
 	}
 }
+
+// WithProducer sets the producer used to republish failed messages to retry and dead-letter topics.
+func WithProducer(producer *kafka.Producer) Options {
+	return func(c *Config) {
+		c.Producer = producer
+	}
+}
+
+// WithConcurrency sets the number of workers processing messages for KafkaClient.
+func WithConcurrency(concurrency uint) Options {
+	return func(c *Config) {
+		c.Concurrency = concurrency
+	}
+}
+
+// WithQueueDepth sets the buffer size of each worker queue for KafkaClient.
+func WithQueueDepth(depth uint) Options {
+	return func(c *Config) {
+		c.QueueDepth = depth
+	}
+}
+
+// WithOrderingKey sets the ordering guarantee of the worker pool for KafkaClient.
+func WithOrderingKey(key string) Options {
+	return func(c *Config) {
+		c.OrderingKey = key
+	}
+}
+
+// WithPauseOnUnhealthy pauses fetching while the health check fails, exiting once it fails for longer than timeout seconds, 0 waits indefinitely.
+func WithPauseOnUnhealthy(timeout uint) Options {
+	return func(c *Config) {
+		c.PauseOnUnhealthy = true
+		c.UnhealthyTimeout = timeout
+	}
+}
+
+// WithTopicProvisioning creates the missing topics of the handlers, their retry and dead-letter topics and the topics of specs when the client starts.
+// Topics without a spec are created with defaults; existing topics that differ from their spec are handled as per policy.
+func WithTopicProvisioning(policy string, defaults kafka.TopicSpec, specs ...kafka.TopicSpec) Options {
+	return func(c *Config) {
+		c.ProvisionTopics = true
+		c.TopicDriftPolicy = policy
+		c.TopicDefaults = defaults
+		c.TopicSpecs = specs
+	}
+}
@@ -28,12 +28,10 @@ func (k *KafkaClient) GetUserIdentifier(headers map[string]string) *correlation.
 // GetMessageContext creates a context for processing a Kafka message with correlation parameters and user identifier.
 // If a tracer was passed during the server initiation, create a new span for every message and updates attribute
 func (k *KafkaClient) GetMessageContext(msg *kafka.Message) context.Context {
-	msgCtx := context.Background()
-	corr := k.GetCorrelationParams(msg.GetHeaders())
-	identity := k.GetUserIdentifier(msg.GetHeaders())
-	msgCtx = correlation.GetContextWithCorrelationParam(msgCtx, k.GetCorrelationParams(msg.GetHeaders()))
-	msgCtx = correlation.GetContextWithUserIdentifier(msgCtx, identity)
+	msgCtx := k.messageContext(msg)
 	if k.tracer != nil {
+		corr := correlation.ExtractCorrelationParam(msgCtx)
+		identity := correlation.ExtractUserIdentifier(msgCtx)
 		var span span.Span
 		msgCtx, span = k.tracer.StartKafkaSpanFromMessage(msgCtx, msg.Message)
 		span.SetAttribute("correlationId", corr.CorrelationID)
@@ -51,3 +49,9 @@ func (k *KafkaClient) GetMessageContext(msg *kafka.Message) context.Context {
 	}
 	return msgCtx
 }
+
+// messageContext creates a context with the correlation parameters and user identifier of the message, without a span.
+func (k *KafkaClient) messageContext(msg *kafka.Message) context.Context {
+	msgCtx := correlation.GetContextWithCorrelationParam(context.Background(), k.GetCorrelationParams(msg.GetHeaders()))
+	return correlation.GetContextWithUserIdentifier(msgCtx, k.GetUserIdentifier(msg.GetHeaders()))
+}
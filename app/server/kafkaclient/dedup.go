@@ -0,0 +1,108 @@
+package kafkaclient
+
+import (
+	"context"
+	e "errors"
+	"fmt"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/inbox"
+)
+
+// dedupConfig holds the deduplication options of a handler.
+type dedupConfig struct {
+	store   inbox.Store
+	txStore inbox.TransactionalStore
+	id      inbox.IDFunc
+}
+
+// WithDedup skips the messages whose ID is recorded in the store, the ID is recorded once the handler succeeds.
+// A message redelivered after the handler succeeded but before the ID was recorded is processed again.
+func WithDedup(store inbox.Store, id inbox.IDFunc) HandlerOption {
+	return func(c *handlerConfig) {
+		c.dedup = &dedupConfig{store: store, id: id}
+	}
+}
+
+// WithTransactionalDedup runs the handler in a transaction of the store that records the message ID, so the writes of the handler
+// and the ID are committed together. The handler must use the context it receives for its writes; not supported for batch handlers, which use the store as in WithDedup.
+func WithTransactionalDedup(store inbox.TransactionalStore, id inbox.IDFunc) HandlerOption {
+	return func(c *handlerConfig) {
+		c.dedup = &dedupConfig{store: store, txStore: store, id: id}
+	}
+}
+
+// dedupHandler wraps the handler to skip duplicate messages.
+func (k *KafkaClient) dedupHandler(config *dedupConfig, handler KafkaEventProcessor) KafkaEventProcessor {
+	return func(ctx context.Context, msg *kafka.Message) error {
+		id := config.id(msg)
+		if id == "" {
+			return handler(ctx, msg)
+		}
+		if config.txStore != nil {
+			err := config.txStore.RunInTransaction(ctx, id, func(txCtx context.Context) error {
+				return handler(txCtx, msg)
+			})
+			if e.Is(err, inbox.ErrDuplicate) {
+				k.skipDuplicate(ctx, msg, id)
+				return nil
+			}
+			return err
+		}
+		seen, err := config.store.Seen(ctx, id)
+		if err != nil {
+			return fmt.Errorf("KafkaClient.dedupHandler: error checking inbox: %w", err)
+		}
+		if seen {
+			k.skipDuplicate(ctx, msg, id)
+			return nil
+		}
+		err = handler(ctx, msg)
+		if err != nil {
+			return err
+		}
+		k.record(ctx, config, id)
+		return nil
+	}
+}
+
+// dedupBatch returns the messages of the batch that are not recorded in the store.
+func (k *KafkaClient) dedupBatch(ctx context.Context, config *dedupConfig, msgs []*kafka.Message) []*kafka.Message {
+	res := make([]*kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		id := config.id(msg)
+		if id != "" {
+			seen, err := config.store.Seen(ctx, id)
+			if err != nil {
+				k.log.Error(ctx, "error checking inbox, processing message", err)
+			} else if seen {
+				k.skipDuplicate(ctx, msg, id)
+				continue
+			}
+		}
+		res = append(res, msg)
+	}
+	return res
+}
+
+// record records the ID of the processed message, a failure is logged as the message is already processed.
+func (k *KafkaClient) record(ctx context.Context, config *dedupConfig, id string) {
+	if id == "" {
+		return
+	}
+	err := config.store.Record(ctx, id)
+	if err != nil {
+		k.log.Error(ctx, "error recording processed message in inbox", err)
+	}
+}
+
+// skipDuplicate logs the duplicate message and marks the span.
+func (k *KafkaClient) skipDuplicate(ctx context.Context, msg *kafka.Message, id string) {
+	meta := msg.GetMeta()
+	meta["messageId"] = id
+	k.log.Debug(ctx, "skipping duplicate message", meta)
+	if sp, ok := k.GetSpanFromContext(ctx); ok {
+		sp.SetAttribute("messaging.duplicate", true)
+		sp.SetAttribute("messaging.message.id", id)
+	}
+}
@@ -0,0 +1,123 @@
+package kafkaclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/log"
+	ckafka "github.com/segmentio/kafka-go"
+)
+
+// ReplayConfig holds the configuration for replaying a dead-letter topic.
+type ReplayConfig struct {
+	*kafka.CredConfig               // Embeds CredConfig for credential and connection details.
+	DLQTopic          string        // Dead-letter topic to replay
+	GroupID           string        // Consumer group used to track the replayed messages
+	MaxMessages       uint          // Maximum number of messages to replay, all when zero
+	IdleTimeout       time.Duration // Replay stops when no message is received for this duration
+	Log               log.Log       // Logger instance.
+}
+
+// GetDefaultReplayConfig returns a ReplayConfig for the dead-letter topic with default values.
+func GetDefaultReplayConfig(dlqTopic string) *ReplayConfig {
+	return &ReplayConfig{
+		CredConfig:  kafka.GetDefaultCredConfig(),
+		DLQTopic:    dlqTopic,
+		GroupID:     "cg-dlq-replay-" + dlqTopic,
+		IdleTimeout: 10 * time.Second,
+		Log:         log.New(log.WithModuleName("DLQReplay")),
+	}
+}
+
+// ReplayDLQ publishes the messages of the dead-letter topic back to their source topic, identified by the
+// [HeaderOriginalTopic] header, with the original key, value and headers. Messages without the header are skipped.
+// The poller runs in [kafka.CommitModeAck] and only the replayed and skipped messages are acknowledged, so the messages
+// left after MaxMessages or a publish error are replayed by the next run. Offsets are committed only after the replayed
+// messages are flushed. Returns the number of replayed messages.
+func ReplayDLQ(ctx context.Context, config *ReplayConfig) (uint, error) {
+	logger := config.Log
+	poller, err := kafka.NewPoller(
+		kafka.WithConsumerCredConfig(config.CredConfig),
+		kafka.WithGroupID(config.GroupID),
+		kafka.WithConsumerTopic([]string{config.DLQTopic}),
+		kafka.WithAutoCommit(false),
+		kafka.WithCommitMode(kafka.CommitModeAck),
+		kafka.WithConsumerLogger(logger),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("kafkaclient.ReplayDLQ: error creating poller: %w", err)
+	}
+	defer poller.Close(ctx)
+	producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(config.CredConfig), kafka.WithBatch(true), kafka.WithAsync(false), kafka.WithPoducerLogger(logger))
+	if err != nil {
+		return 0, fmt.Errorf("kafkaclient.ReplayDLQ: error creating producer: %w", err)
+	}
+	defer producer.Close(ctx)
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan *ckafka.Message)
+	go poller.Poll(pollCtx, ch)
+	idle := time.NewTimer(config.IdleTimeout)
+	defer idle.Stop()
+	var count uint
+	var replayErr error
+outer:
+	for {
+		select {
+		case <-idle.C:
+			cancel()
+		case msg, ok := <-ch:
+			if !ok {
+				break outer
+			}
+			idle.Reset(config.IdleTimeout)
+			if replayErr != nil || (config.MaxMessages > 0 && count >= config.MaxMessages) {
+				continue
+			}
+			kMsg := &kafka.Message{Message: msg}
+			headers := kMsg.GetHeaders()
+			topic := headers[HeaderOriginalTopic]
+			if topic == "" {
+				logger.Warning(ctx, "skipping message without original topic", kMsg.GetMeta())
+				poller.Ack(ctx, msg)
+				continue
+			}
+			replayHeaders := make(map[string]string, len(headers))
+			for key, value := range headers {
+				replayHeaders[key] = value
+			}
+			for _, key := range failureHeaders {
+				delete(replayHeaders, key)
+			}
+			err := producer.Produce(ctx, topic, kMsg.GetKey(), msg.Value, replayHeaders)
+			if err != nil {
+				replayErr = fmt.Errorf("kafkaclient.ReplayDLQ: error publishing to %v: %w", topic, err)
+				cancel()
+				continue
+			}
+			// auto commit is off, the acknowledged offsets are committed after the flush
+			poller.Ack(ctx, msg)
+			count++
+			if config.MaxMessages > 0 && count >= config.MaxMessages {
+				cancel()
+			}
+		}
+	}
+	if replayErr != nil {
+		logger.Error(ctx, "error replaying dead-letter topic", replayErr)
+		return count, replayErr
+	}
+	err = producer.Flush(ctx)
+	if err != nil {
+		logger.Error(ctx, "error flushing replayed messages", err)
+		return 0, fmt.Errorf("kafkaclient.ReplayDLQ: error flushing replayed messages: %w", err)
+	}
+	_, err = poller.Commit(ctx)
+	if err != nil {
+		return count, fmt.Errorf("kafkaclient.ReplayDLQ: error committing replayed messages: %w", err)
+	}
+	logger.Notice(ctx, fmt.Sprintf("replayed %v messages from %v", count, config.DLQTopic), nil)
+	return count, nil
+}
@@ -2,10 +2,12 @@ package kafkaclient_test
 
 import (
 	"context"
+	"time"
 
 	"github.com/sabariramc/goserverbase/v6/app/server/kafkaclient"
 	"github.com/sabariramc/goserverbase/v6/errors"
 	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/inbox"
 )
 
 func Example() {
@@ -18,3 +20,53 @@ func Example() {
 	})
 	srv.StartClient()
 }
+
+func Example_failurePolicy() {
+	srv := kafkaclient.New()
+	srv.AddHandler(context.Background(), "gobase.test.topic1", func(ctx context.Context, m *kafka.Message) error {
+		return &errors.CustomError{ErrorCode: "gobase.test.error", ErrorMessage: "error sample"}
+	}, kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{
+		MaxRetries:  3,
+		Backoff:     100 * time.Millisecond,
+		RetryTopics: kafkaclient.NewRetryTopics("gobase.test.topic1", time.Minute, 10*time.Minute),
+		DLQTopic:    "gobase.test.topic1.dlq",
+	}))
+	srv.StartClient()
+}
+
+func Example_batchHandler() {
+	srv := kafkaclient.New()
+	srv.AddBatchHandler(context.Background(), "gobase.test.topic1", func(ctx context.Context, msgs []*kafka.Message) error {
+		batchErr := kafkaclient.NewBatchError()
+		for i, m := range msgs {
+			if len(m.Value) == 0 {
+				batchErr.Add(i, &errors.CustomError{ErrorCode: "gobase.test.empty", ErrorMessage: "empty message"})
+			}
+		}
+		if len(batchErr.Errors) > 0 {
+			return batchErr
+		}
+		return nil
+	}, kafkaclient.WithBatchSize(500), kafkaclient.WithBatchMaxWait(2*time.Second), kafkaclient.WithFailurePolicy(&kafkaclient.FailurePolicy{
+		DLQTopic: "gobase.test.topic1.dlq",
+	}))
+	srv.StartClient()
+}
+
+func Example_dedup() {
+	srv := kafkaclient.New()
+	srv.AddHandler(context.Background(), "gobase.test.topic1", func(ctx context.Context, m *kafka.Message) error {
+		return nil
+	}, kafkaclient.WithDedup(inbox.NewMemoryStore(10000), inbox.HeaderID("x-message-id")))
+	srv.StartClient()
+}
+
+func Example_transaction() {
+	producer, _ := kafka.NewTxnProducer(kafka.WithTransactionalID("gobase.test.transformer"))
+	srv := kafkaclient.New()
+	srv.AddHandler(context.Background(), "gobase.test.topic1", func(ctx context.Context, m *kafka.Message) error {
+		return producer.Produce(ctx, "gobase.test.topic2", string(m.Key), m.Value, nil)
+	}, kafkaclient.WithTransaction(producer))
+	srv.RegisterHooks(producer)
+	srv.StartClient()
+}
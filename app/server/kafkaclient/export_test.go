@@ -0,0 +1,4 @@
+package kafkaclient
+
+// ErrorCode exports errorCode to the external tests.
+var ErrorCode = errorCode
@@ -11,46 +11,102 @@ import (
 )
 
 // AddHandler adds a handler for processing Kafka events for the specified topic.
-func (k *KafkaClient) AddHandler(ctx context.Context, topicName string, handler KafkaEventProcessor) {
+// When a failure policy is set, the handler is added for the retry topics of the policy as well.
+func (k *KafkaClient) AddHandler(ctx context.Context, topicName string, handler KafkaEventProcessor, options ...HandlerOption) {
 	if handler == nil {
 		k.log.Emergency(ctx, "missing handler for topic - "+topicName, nil, fmt.Errorf("KafkaClient.AddHandler: handler parameter cannot be nil"))
 	}
-	if _, ok := k.handler[topicName]; ok {
-		k.log.Emergency(ctx, "duplicate handler for topic - "+topicName, nil, fmt.Errorf("KafkaClient.AddHandler: handler for topic exist"))
+	config := &handlerConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+	if config.txn != nil {
+		handler = k.txnHandler(config.txn, handler)
+		k.transactional = true
+	}
+	if config.dedup != nil {
+		handler = k.dedupHandler(config.dedup, handler)
+	}
+	for _, topic := range k.registerTopics(ctx, topicName, config) {
+		k.handler[topic] = handler
+	}
+}
+
+// registerTopics validates that the topic and the retry topics of the failure policy have no handler yet,
+// records the failure policy and creates the retry producer when required. Returns the topics to subscribe.
+func (k *KafkaClient) registerTopics(ctx context.Context, topicName string, config *handlerConfig) []string {
+	topicList := []string{topicName}
+	if config.policy != nil {
+		for _, r := range config.policy.RetryTopics {
+			topicList = append(topicList, r.Topic)
+		}
+	}
+	for _, topic := range topicList {
+		_, ok := k.handler[topic]
+		_, batchOk := k.batchHandler[topic]
+		if ok || batchOk {
+			k.log.Emergency(ctx, "duplicate handler for topic - "+topic, nil, fmt.Errorf("KafkaClient.AddHandler: handler for topic exist"))
+		}
+	}
+	if config.policy != nil {
+		for _, topic := range topicList {
+			k.policy[topic] = config.policy
+		}
+	}
+	if config.policy != nil && (len(config.policy.RetryTopics) > 0 || config.policy.DLQTopic != "") {
+		k.ensureProducer(ctx)
+	}
+	return topicList
+}
+
+// ensureProducer creates the producer used to republish failed messages if it does not exist.
+func (k *KafkaClient) ensureProducer(ctx context.Context) {
+	if k.producer == nil {
+		producer, err := kafka.NewProducer(kafka.WithProducerCredConfig(k.c.CredConfig), kafka.WithBatch(true), kafka.WithAsync(false), kafka.WithProducerModuleName("KafkaClientRetryProducer"))
+		if err != nil {
+			k.log.Emergency(ctx, "error creating retry producer", fmt.Errorf("KafkaClient.AddHandler: error creating retry producer: %w", err), nil)
+		}
+		k.producer = producer
+		k.RegisterOnShutdownHook(producer)
 	}
-	k.handler[topicName] = handler
 }
 
 // ProcessEvent processes a Kafka message using the specified handler.
-func (k *KafkaClient) ProcessEvent(ctx context.Context, msg *kafka.Message, handler KafkaEventProcessor) {
+// A failed message is retried and republished as per the failure policy of its topic. Returns an error when the message
+// is not done and must not be acknowledged: a message superseded by a delay or rebalance of its partition, which is dropped
+// unprocessed, a message of a retry topic delayed till its retry time, or a failed message that could not be republished
+// before the client shut down.
+func (k *KafkaClient) ProcessEvent(ctx context.Context, msg *kafka.Message, handler KafkaEventProcessor) error {
 	span, spanOk := k.GetSpanFromContext(ctx)
 	defer func() {
 		if spanOk {
 			span.Finish()
 		}
 	}()
-	defer func() {
-		if rec := recover(); rec != nil {
-			stackTrace, err := k.PanicRecovery(ctx, rec)
-			statusCode, _ := k.ProcessError(ctx, stackTrace, err)
-			if spanOk {
-				span.SetError(err, stackTrace)
-				span.SetStatus(statusCode, http.StatusText(statusCode))
-			}
-		}
-	}()
-	err := handler(ctx, msg)
+	if client := k.poller(); client != nil && client.Stale(msg.Message) {
+		k.log.Debug(ctx, "dropping message superseded by a delay or rebalance of its partition", msg.GetMeta())
+		return errMessageStale
+	}
+	policy := k.policy[msg.Topic]
+	if policy != nil && k.delayRetry(ctx, msg, policy) {
+		return errRetryDelayed
+	}
+	stackTrace, err := k.execute(ctx, msg, handler, policy)
 	if err != nil {
-		statusCode, _ := k.ProcessError(ctx, "", err)
+		statusCode, _ := k.ProcessError(ctx, stackTrace, err)
 		if spanOk {
-			span.SetError(err, "")
+			span.SetError(err, stackTrace)
 			span.SetStatus(statusCode, http.StatusText(statusCode))
 		}
-		return
+		if policy != nil {
+			return k.forward(ctx, msg, policy, stackTrace, err)
+		}
+		return nil
 	}
 	if spanOk {
 		span.SetStatus(http.StatusOK, http.StatusText(http.StatusOK))
 	}
+	return nil
 }
 
 // Commit commits the current offset of the Kafka consumer.
@@ -59,21 +115,49 @@ func (k *KafkaClient) Commit(ctx context.Context) (kafka.OffsetMap, error) {
 }
 
 // Subscribe subscribes to Kafka topics and starts consuming messages.
+//
+// The poller is created with the consumer config of the client and subscribed to the topics of the handlers, every topic
+// of the config must have a handler.
 func (k *KafkaClient) Subscribe(ctx context.Context) {
-	topicList := make([]string, 0, len(k.handler))
+	k.resolvePatterns(ctx)
+	topicList := make([]string, 0, len(k.handler)+len(k.batchHandler))
 	for h := range k.handler {
 		topicList = append(topicList, h)
 	}
+	for h := range k.batchHandler {
+		topicList = append(topicList, h)
+	}
 	ch := make(chan *ckafka.Message)
 	k.ch = ch
-	client, err := kafka.NewPoller(kafka.WithConsumerTracer(k.tracer), kafka.WithConsumerTopic(topicList))
+	commitMode := k.c.CommitMode
+	if k.c.Concurrency > 1 || len(k.batchHandler) > 0 || k.transactional || len(k.policy) > 0 {
+		// messages complete out of order in the worker pool and the batches, and a failed message is not done till it is
+		// republished, offsets are committed only once acknowledged
+		commitMode = kafka.CommitModeAck
+	}
+	for _, topic := range k.c.ConsumerConfig.Topics {
+		_, ok := k.handler[topic]
+		if _, batch := k.batchHandler[topic]; !ok && !batch {
+			k.log.Emergency(ctx, "missing handler for topic - "+topic, nil, fmt.Errorf("KafkaClient.Subscribe: missing handler for configured topic: %v", topic))
+		}
+	}
+	client, err := kafka.NewPoller(func(c *kafka.ConsumerConfig) {
+		// the poller is created with the whole consumer config, only the commit mode, the topics of the handlers, which
+		// include the topics of the config, and the tracer of the client are set on the copy
+		*c = *k.c.ConsumerConfig
+		c.CommitMode = commitMode
+		c.Topics = topicList
+		if k.tracer != nil {
+			c.Trace = k.tracer
+		}
+	})
 	if err != nil {
 		k.log.Emergency(ctx, "Error occurred during client creation", fmt.Errorf("KafkaClient.Subscribe: error creating kafka consumer: %w", err), map[string]any{
 			"topicList": topicList,
 			"config":    k.c.ConsumerConfig,
 		})
 	}
-	k.client = client
+	k.setPoller(client)
 }
 
 // GetSpanFromContext retrieves the OpenTelemetry span from the given context.
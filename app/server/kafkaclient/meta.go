@@ -2,11 +2,16 @@ package kafkaclient
 
 import (
 	"context"
+	"errors"
 	"os"
 	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
 )
 
 // HealthCheckMonitor starts a health check monitor that periodically runs health checks.
+// A consumer lagging or idle beyond the thresholds of [kafka.ConsumerConfig] does not open the pause circuit
+// as pausing would only increase the lag.
 func (k *KafkaClient) HealthCheckMonitor(ctx context.Context) {
 	timeoutContext, _ := context.WithTimeout(ctx, time.Second*time.Duration(k.c.HealthCheckInterval))
 	defer k.log.Warning(ctx, "Health check monitor stopped", nil)
@@ -16,25 +21,47 @@ func (k *KafkaClient) HealthCheckMonitor(ctx context.Context) {
 			return
 		case <-timeoutContext.Done():
 			err := k.RunHealthCheck(ctx)
-			if err != nil {
+			if err != nil && k.c.PauseOnUnhealthy && !errors.Is(err, kafka.ErrConsumerUnhealthy) && !k.circuitExpired() {
+				k.openCircuit(ctx, err)
+			} else if err != nil {
 				deleteErr := os.Remove(k.c.HealthCheckResultPath)
 				if deleteErr != nil {
 					k.log.Error(ctx, "error deleting health file", deleteErr)
 				}
 				k.log.Emergency(ctx, "health check failed", err, nil)
+			} else {
+				k.closeCircuit(ctx)
 			}
 			timeoutContext, _ = context.WithTimeout(ctx, time.Second*time.Duration(k.c.HealthCheckInterval))
 		}
 	}
 }
 
-// HealthCheck runs a health check on the Kafka consumer server.
+// HealthCheck runs a health check on the Kafka consumer server, it fails when the lag or the time without a message
+// crosses [kafka.ConsumerConfig.MaxLag] or [kafka.ConsumerConfig.MaxIdle].
 func (k *KafkaClient) HealthCheck(ctx context.Context) error {
-	k.client.Stats()
-	return nil
+	client := k.poller()
+	if client == nil {
+		return nil
+	}
+	return client.HealthCheck(ctx)
+}
+
+// Status is the status of the Kafka consumer server reported by StatusCheck.
+type Status struct {
+	Consumer    *kafka.PollerStatus // Partition assignment, offsets, lag, rebalances and reader stats of the consumer, nil before it is subscribed
+	Workers     *WorkerStatus       // Status of the worker pool, nil when messages are processed inline
+	CircuitOpen bool                // Consumer is paused because the health check fails
 }
 
 // StatusCheck runs a status check on the Kafka consumer server.
 func (k *KafkaClient) StatusCheck(ctx context.Context) (any, error) {
-	return k.client.Stats(), nil
+	status := &Status{CircuitOpen: k.circuitStatus()}
+	if client := k.poller(); client != nil {
+		status.Consumer = client.Status()
+	}
+	if pool := k.pool.Load(); pool != nil {
+		status.Workers = k.workerStatus(pool)
+	}
+	return status, nil
 }
@@ -0,0 +1,123 @@
+package kafkaclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+)
+
+// pauseState holds the pauses requested through the API and by the health check circuit.
+type pauseState struct {
+	lock         sync.Mutex      // Guards the pause state and the poller set by Subscribe
+	global       bool            // Paused through the API
+	topics       map[string]bool // Topics paused through the API
+	circuitOpen  bool            // Paused because the health check fails
+	circuitSince time.Time       // Time the circuit opened
+}
+
+// Pause stops consuming the topics, or every topic when none is passed. The consumer stays in the group
+// so no rebalance is triggered; the partitions of a paused topic are not fetched and are resumed from the
+// message following the last one processed. Can be called before the client is started.
+func (k *KafkaClient) Pause(ctx context.Context, topics ...string) {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	if len(topics) == 0 {
+		k.pause.global = true
+	}
+	for _, topic := range topics {
+		k.pause.topics[topic] = true
+	}
+	if k.client != nil {
+		k.client.Pause(topics...)
+	}
+	k.log.Notice(ctx, "Kafka consumer paused", map[string]any{"topics": topics})
+}
+
+// Resume resumes the topics paused with Pause, or the global pause when no topic is passed.
+// The global pause is held while the health check circuit is open.
+func (k *KafkaClient) Resume(ctx context.Context, topics ...string) {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	if len(topics) == 0 {
+		k.pause.global = false
+		if k.pause.circuitOpen {
+			k.log.Warning(ctx, "Kafka consumer stays paused till the health check passes", nil)
+			return
+		}
+	}
+	for _, topic := range topics {
+		delete(k.pause.topics, topic)
+	}
+	if k.client != nil {
+		k.client.Resume(topics...)
+	}
+	k.log.Notice(ctx, "Kafka consumer resumed", map[string]any{"topics": topics})
+}
+
+// setPoller sets the poller created by Subscribe and pauses it as per the pauses requested before it was created.
+func (k *KafkaClient) setPoller(client *kafka.Poller) {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	k.client = client
+	if client == nil {
+		return
+	}
+	if k.pause.global || k.pause.circuitOpen {
+		k.client.Pause()
+	}
+	for topic := range k.pause.topics {
+		k.client.Pause(topic)
+	}
+}
+
+// poller returns the poller set by Subscribe, nil before the client is subscribed.
+func (k *KafkaClient) poller() *kafka.Poller {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	return k.client
+}
+
+// openCircuit pauses every topic because the health check failed.
+func (k *KafkaClient) openCircuit(ctx context.Context, err error) {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	if k.pause.circuitOpen {
+		return
+	}
+	k.pause.circuitOpen = true
+	k.pause.circuitSince = time.Now()
+	if k.client != nil {
+		k.client.Pause()
+	}
+	k.log.Warning(ctx, "health check failed, Kafka consumer paused", err)
+}
+
+// closeCircuit resumes consumption after the health check passes, unless paused through the API.
+func (k *KafkaClient) closeCircuit(ctx context.Context) {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	if !k.pause.circuitOpen {
+		return
+	}
+	k.pause.circuitOpen = false
+	if !k.pause.global && k.client != nil {
+		k.client.Resume()
+	}
+	k.log.Notice(ctx, "health check passed, Kafka consumer resumed", map[string]any{"pausedFor": time.Since(k.pause.circuitSince).String()})
+}
+
+// circuitExpired reports whether the circuit has been open for longer than the unhealthy timeout.
+func (k *KafkaClient) circuitExpired() bool {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	return k.pause.circuitOpen && k.c.UnhealthyTimeout > 0 && time.Since(k.pause.circuitSince) > time.Duration(k.c.UnhealthyTimeout)*time.Second
+}
+
+// circuitStatus reports whether the circuit is open.
+func (k *KafkaClient) circuitStatus() bool {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	return k.pause.circuitOpen
+}
@@ -0,0 +1,58 @@
+package kafkaclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+)
+
+// provisionTopics ensures the topics of the client exist as per their spec when [Config.ProvisionTopics] is set.
+// The client exits when the topics cannot be created or differ from their spec with [kafka.DriftPolicyFail].
+func (k *KafkaClient) provisionTopics(ctx context.Context) {
+	if !k.c.ProvisionTopics {
+		return
+	}
+	admin, err := kafka.NewAdmin(kafka.WithAdminCredConfig(k.c.CredConfig), kafka.WithAdminLogger(k.log))
+	if err == nil {
+		_, err = admin.EnsureTopics(ctx, k.c.TopicDriftPolicy, k.topicSpecs()...)
+	}
+	if err != nil {
+		k.log.Emergency(ctx, "error provisioning topics", fmt.Errorf("KafkaClient.provisionTopics: %w", err), nil)
+	}
+}
+
+// topicSpecs returns the spec of the topics of the handlers, their retry and dead-letter topics and [Config.TopicSpecs],
+// topics without an entry in [Config.TopicSpecs] take [Config.TopicDefaults].
+func (k *KafkaClient) topicSpecs() []kafka.TopicSpec {
+	names := map[string]bool{}
+	for topic := range k.handler {
+		names[topic] = true
+	}
+	for topic := range k.batchHandler {
+		names[topic] = true
+	}
+	for _, policy := range k.policy {
+		if policy.DLQTopic != "" {
+			names[policy.DLQTopic] = true
+		}
+	}
+	for _, router := range k.routers {
+		if router.dlqTopic != "" {
+			names[router.dlqTopic] = true
+		}
+	}
+	specs := make([]kafka.TopicSpec, 0, len(names)+len(k.c.TopicSpecs))
+	for _, spec := range k.c.TopicSpecs {
+		delete(names, spec.Name)
+		specs = append(specs, spec)
+	}
+	for name := range names {
+		spec := k.c.TopicDefaults
+		spec.Name = name
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
@@ -0,0 +1,294 @@
+package kafkaclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/errors"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+)
+
+// Headers set on messages republished to retry and dead-letter topics.
+const (
+	HeaderRetryAttempt      = "x-retry-attempt"      // Number of the retry topic the message was published to
+	HeaderRetryNotBefore    = "x-retry-not-before"   // Unix milliseconds before which the message must not be processed
+	HeaderOriginalTopic     = "x-original-topic"     // Topic the message was first consumed from
+	HeaderOriginalPartition = "x-original-partition" // Partition the message was first consumed from
+	HeaderOriginalOffset    = "x-original-offset"    // Offset the message was first consumed from
+	HeaderOriginalTimestamp = "x-original-timestamp" // Unix milliseconds timestamp of the original message
+	HeaderErrorCode         = "x-error-code"         // Error code of the last failure
+	HeaderErrorMessage      = "x-error-message"      // Error message of the last failure
+	HeaderErrorStackTrace   = "x-error-stack-trace"  // Stack trace of the last failure, set when the handler panicked
+	HeaderFailedAt          = "x-failed-at"          // Unix milliseconds timestamp of the last failure
+)
+
+// failureHeaders are the headers added by the failure policy, stripped when a message is replayed.
+var failureHeaders = []string{HeaderRetryAttempt, HeaderRetryNotBefore, HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset, HeaderOriginalTimestamp, HeaderErrorCode, HeaderErrorMessage, HeaderErrorStackTrace, HeaderFailedAt}
+
+// RetryTopic is a delayed retry topic, messages published to it are processed after the delay.
+type RetryTopic struct {
+	Topic string        // Name of the retry topic
+	Delay time.Duration // Delay before a message of the topic is processed
+}
+
+// FailurePolicy defines how a failed message is handled.
+// The message is retried in process first, then published to each retry topic in order and finally to the dead-letter topic.
+type FailurePolicy struct {
+	MaxRetries  uint          // Number of in-process retries
+	Backoff     time.Duration // Wait before the first in-process retry, doubled on every retry
+	MaxBackoff  time.Duration // Upper bound of the in-process backoff, unbounded when zero
+	RetryTopics []RetryTopic  // Delayed retry topics
+	DLQTopic    string        // Dead-letter topic, the message is dropped after logging when empty
+}
+
+// NewRetryTopics returns a retry topic named <topic>.retry.<delay> for each of the delays, e.g. topic.retry.1m.
+func NewRetryTopics(topic string, delays ...time.Duration) []RetryTopic {
+	res := make([]RetryTopic, len(delays))
+	for i, delay := range delays {
+		res[i] = RetryTopic{Topic: fmt.Sprintf("%v.retry.%v", topic, formatDelay(delay)), Delay: delay}
+	}
+	return res
+}
+
+// formatDelay formats the delay with the largest whole unit.
+func formatDelay(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%vh", int64(d/time.Hour))
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%vm", int64(d/time.Minute))
+	case d%time.Second == 0:
+		return fmt.Sprintf("%vs", int64(d/time.Second))
+	}
+	return fmt.Sprintf("%vms", d.Milliseconds())
+}
+
+// stage returns the index of the retry topic in the policy, -1 for the source topic.
+func (p *FailurePolicy) stage(topic string) int {
+	for i, r := range p.RetryTopics {
+		if r.Topic == topic {
+			return i
+		}
+	}
+	return -1
+}
+
+// HandlerOption configures the handler of a topic.
+type HandlerOption func(*handlerConfig)
+
+// handlerConfig holds the options of a topic handler.
+type handlerConfig struct {
+	policy       *FailurePolicy
+	batchSize    uint
+	batchMaxWait time.Duration
+	dedup        *dedupConfig
+	txn          *kafka.TxnProducer
+}
+
+// WithFailurePolicy sets the failure policy of the topic handler, the retry topics of the policy are subscribed with the same handler.
+func WithFailurePolicy(policy *FailurePolicy) HandlerOption {
+	return func(c *handlerConfig) {
+		c.policy = policy
+	}
+}
+
+// execute runs the handler, retrying in process as per the failure policy.
+func (k *KafkaClient) execute(ctx context.Context, msg *kafka.Message, handler KafkaEventProcessor, policy *FailurePolicy) (string, error) {
+	attempts := uint(1)
+	var backoff time.Duration
+	if policy != nil {
+		attempts += policy.MaxRetries
+		backoff = policy.Backoff
+	}
+	for i := uint(1); ; i++ {
+		stackTrace, err := k.runHandler(ctx, msg, handler)
+		if err == nil || i >= attempts {
+			return stackTrace, err
+		}
+		k.log.Warning(ctx, fmt.Sprintf("handler failed, retry %v of %v", i, attempts-1), err)
+		if !k.wait(backoff) {
+			return stackTrace, err
+		}
+		backoff = nextBackoff(backoff, policy)
+	}
+}
+
+// nextBackoff doubles the backoff, bounded by the max backoff of the policy.
+func nextBackoff(backoff time.Duration, policy *FailurePolicy) time.Duration {
+	backoff *= 2
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	return backoff
+}
+
+// runHandler runs the handler and converts a panic into an error.
+func (k *KafkaClient) runHandler(ctx context.Context, msg *kafka.Message, handler KafkaEventProcessor) (stackTrace string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			stackTrace, err = k.PanicRecovery(ctx, rec)
+		}
+	}()
+	return "", handler(ctx, msg)
+}
+
+// wait blocks for the duration, returns false if the client is shutting down.
+func (k *KafkaClient) wait(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	var done <-chan struct{}
+	if k.pollCtx != nil {
+		done = k.pollCtx.Done()
+	}
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// errRetryDelayed is returned by ProcessEvent for a message consumed from a retry topic before its retry time,
+// the poller hands the message out again once the time has passed.
+var errRetryDelayed = fmt.Errorf("KafkaClient.ProcessEvent: retry message delayed till its retry time")
+
+// errMessageStale is returned by ProcessEvent for a message superseded by a delay of its partition or a rebalance,
+// the message is handed out again by the poller or fetched by the new owner of the partition.
+var errMessageStale = fmt.Errorf("KafkaClient.ProcessEvent: message superseded by a delay or rebalance of its partition")
+
+// retryDelay returns the time left till the retry time of a message consumed from a retry topic, zero for other messages.
+func retryDelay(msg *kafka.Message, policy *FailurePolicy) (time.Time, time.Duration) {
+	stage := policy.stage(msg.Topic)
+	if stage < 0 {
+		return time.Time{}, 0
+	}
+	notBefore := msg.Time.Add(policy.RetryTopics[stage].Delay)
+	if val, ok := msg.GetHeaders()[HeaderRetryNotBefore]; ok {
+		if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+			notBefore = time.UnixMilli(ms)
+		}
+	}
+	return notBefore, time.Until(notBefore)
+}
+
+// delayRetry delays a message consumed from a retry topic till its retry time, returns true if the poller stopped
+// fetching its partition till then and hands the message out again. When the poller cannot delay the partition, i.e. with a
+// reader set in the consumer config, the processing blocks till the retry time instead; on shutdown the message is
+// processed immediately so that it is not lost.
+func (k *KafkaClient) delayRetry(ctx context.Context, msg *kafka.Message, policy *FailurePolicy) bool {
+	notBefore, d := retryDelay(msg, policy)
+	if d <= 0 {
+		return false
+	}
+	k.log.Debug(ctx, fmt.Sprintf("delaying retry message by %v", d), msg.GetMeta())
+	if client := k.poller(); client != nil && client.Delay(msg.Message, notBefore) {
+		return true
+	}
+	k.wait(d)
+	return false
+}
+
+// forward republishes the failed message as per the failure policy, retrying the publish with the backoff of the policy
+// till it succeeds. Returns an error only when the client shuts down first, the message must not be acknowledged then
+// so that it is consumed again.
+func (k *KafkaClient) forward(ctx context.Context, msg *kafka.Message, policy *FailurePolicy, stackTrace string, handlerErr error) error {
+	backoff := policy.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for {
+		err := k.republish(ctx, msg, policy, stackTrace, handlerErr)
+		if err == nil {
+			return nil
+		}
+		if !k.wait(backoff) {
+			return fmt.Errorf("KafkaClient.forward: shutting down before the failed message is republished: %w", err)
+		}
+		backoff = nextBackoff(backoff, policy)
+	}
+}
+
+// republish publishes the failed message to the next retry topic or the dead-letter topic of the policy.
+func (k *KafkaClient) republish(ctx context.Context, msg *kafka.Message, policy *FailurePolicy, stackTrace string, err error) error {
+	next := policy.stage(msg.Topic) + 1
+	headers := make(map[string]string, len(msg.GetHeaders())+len(failureHeaders))
+	for key, value := range msg.GetHeaders() {
+		headers[key] = value
+	}
+	if _, ok := headers[HeaderOriginalTopic]; !ok {
+		headers[HeaderOriginalTopic] = msg.Topic
+		headers[HeaderOriginalPartition] = strconv.Itoa(msg.Partition)
+		headers[HeaderOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+		headers[HeaderOriginalTimestamp] = strconv.FormatInt(msg.Time.UnixMilli(), 10)
+	}
+	now := time.Now()
+	headers[HeaderErrorCode] = errorCode(err)
+	headers[HeaderErrorMessage] = err.Error()
+	headers[HeaderFailedAt] = strconv.FormatInt(now.UnixMilli(), 10)
+	delete(headers, HeaderErrorStackTrace)
+	if stackTrace != "" {
+		headers[HeaderErrorStackTrace] = stackTrace
+	}
+	var topic string
+	if next < len(policy.RetryTopics) {
+		topic = policy.RetryTopics[next].Topic
+		headers[HeaderRetryAttempt] = strconv.Itoa(next + 1)
+		headers[HeaderRetryNotBefore] = strconv.FormatInt(now.Add(policy.RetryTopics[next].Delay).UnixMilli(), 10)
+	} else {
+		topic = policy.DLQTopic
+		delete(headers, HeaderRetryNotBefore)
+	}
+	if topic == "" {
+		k.log.Warning(ctx, "failure policy has no dead-letter topic, message dropped", msg.GetMeta())
+		return nil
+	}
+	k.log.Notice(ctx, "republishing failed message to "+topic, msg.GetMeta())
+	err = k.producer.Produce(ctx, topic, msg.GetKey(), msg.Value, headers)
+	if err == nil {
+		err = k.producer.Flush(ctx)
+	}
+	if err != nil {
+		k.log.Error(ctx, "error republishing failed message to "+topic, err)
+		return fmt.Errorf("KafkaClient.republish: error publishing to %v: %w", topic, err)
+	}
+	return nil
+}
+
+// errorCode returns the error code of the first custom error in the chain of err, an HTTP error without a custom error
+// is skipped. Returns "unknown" when the chain has no custom error.
+func errorCode(err error) string {
+	switch v := err.(type) {
+	case nil:
+		return "unknown"
+	case *errors.HTTPError:
+		if v != nil && v.CustomError != nil {
+			return v.ErrorCode
+		}
+	case errors.HTTPError:
+		if v.CustomError != nil {
+			return v.ErrorCode
+		}
+	case *errors.CustomError:
+		if v != nil {
+			return v.ErrorCode
+		}
+	case errors.CustomError:
+		return v.ErrorCode
+	}
+	switch v := err.(type) {
+	case interface{ Unwrap() error }:
+		return errorCode(v.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, inner := range v.Unwrap() {
+			if code := errorCode(inner); code != "unknown" {
+				return code
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,272 @@
+package kafkaclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// Policies for messages that match no route of a Router.
+const (
+	UnmatchedError = "ERROR" // The message fails with ErrNoRoute and follows the failure policy of the topic
+	UnmatchedSkip  = "SKIP"  // The message is logged and skipped
+	UnmatchedDLQ   = "DLQ"   // The message is published to the unmatched dead-letter topic of the router
+)
+
+// ErrNoRoute is returned by Router.Handle when the message matches no route and the unmatched policy is UnmatchedError.
+var ErrNoRoute = fmt.Errorf("Router.Handle: no route for message")
+
+// Wildcard matches any value in a route.
+const Wildcard = "*"
+
+// route dispatches messages matching either a header value or the entity and event of the [utils.Message].
+type route struct {
+	header, value string
+	entity, event string
+	handler       KafkaEventProcessor
+}
+
+// String describes the match condition of the route.
+func (r *route) String() string {
+	if r.header != "" {
+		return fmt.Sprintf("header %v=%v", r.header, r.value)
+	}
+	return fmt.Sprintf("entity=%v event=%v", r.entity, r.event)
+}
+
+// match reports whether the message matches the route, msg is the decoded [utils.Message], nil if the value is not one.
+func (r *route) match(headers map[string]string, msg *utils.Message) bool {
+	if r.header != "" {
+		value, ok := headers[r.header]
+		return ok && (r.value == Wildcard || r.value == value)
+	}
+	if msg == nil {
+		return false
+	}
+	return (r.entity == Wildcard || r.entity == msg.Entity) && (r.event == Wildcard || r.event == msg.Event)
+}
+
+// Router dispatches the messages of a topic to handlers by header value or by the Entity and Event of [utils.Message].
+// Routes are evaluated in the order they are added and the first match wins.
+type Router struct {
+	routes     []*route
+	fallback   KafkaEventProcessor
+	unmatched  string
+	dlqTopic   string
+	dlqPolicy  *FailurePolicy
+	client     *KafkaClient
+	needsValue bool
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithUnmatchedPolicy sets the policy for messages that match no route, one of UnmatchedError, UnmatchedSkip or UnmatchedDLQ. Defaults to UnmatchedError.
+func WithUnmatchedPolicy(policy string) RouterOption {
+	return func(r *Router) {
+		r.unmatched = policy
+	}
+}
+
+// WithUnmatchedDLQ sends the messages that match no route to the dead-letter topic.
+func WithUnmatchedDLQ(topic string) RouterOption {
+	return func(r *Router) {
+		r.unmatched = UnmatchedDLQ
+		r.dlqTopic = topic
+	}
+}
+
+// NewRouter creates a new Router.
+func NewRouter(options ...RouterOption) *Router {
+	r := &Router{unmatched: UnmatchedError}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// OnHeader routes the messages whose header key has the value, [Wildcard] matches any message carrying the header.
+func (r *Router) OnHeader(key, value string, handler KafkaEventProcessor) *Router {
+	r.routes = append(r.routes, &route{header: key, value: value, handler: handler})
+	return r
+}
+
+// OnEvent routes the messages whose value is a [utils.Message] with the entity and event, [Wildcard] matches any value.
+func (r *Router) OnEvent(entity, event string, handler KafkaEventProcessor) *Router {
+	r.routes = append(r.routes, &route{entity: entity, event: event, handler: handler})
+	r.needsValue = true
+	return r
+}
+
+// Default sets the handler for the messages that match no route, the unmatched policy is not applied when set.
+func (r *Router) Default(handler KafkaEventProcessor) *Router {
+	r.fallback = handler
+	return r
+}
+
+// Handle dispatches the message to the handler of the first matching route.
+// Implements [KafkaEventProcessor]; add the router with AddRouter for the unmatched DLQ policy to take effect.
+func (r *Router) Handle(ctx context.Context, msg *kafka.Message) error {
+	headers := msg.GetHeaders()
+	var event *utils.Message
+	if r.needsValue {
+		decoded, err := kafka.LoadMessage(msg.Message)
+		if err == nil {
+			event = decoded
+		}
+	}
+	for _, rt := range r.routes {
+		if rt.match(headers, event) {
+			return rt.handler(ctx, msg)
+		}
+	}
+	if r.fallback != nil {
+		return r.fallback(ctx, msg)
+	}
+	switch r.unmatched {
+	case UnmatchedSkip:
+		if r.client != nil {
+			r.client.log.Warning(ctx, "skipping message without route", msg.GetMeta())
+		}
+		return nil
+	case UnmatchedDLQ:
+		if r.client != nil && r.dlqPolicy != nil {
+			return r.client.republish(ctx, msg, r.dlqPolicy, "", ErrNoRoute)
+		}
+	}
+	return ErrNoRoute
+}
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Topic   string // Topic or topic pattern of the route
+	Kind    string // Kind of handler, one of handler, batch, router or pattern
+	Match   string // Match condition of router routes
+	Options string // Failure policy and unmatched policy of the route
+}
+
+// AddRouter adds the router as the handler of the topic. Options are the same as for AddHandler.
+func (k *KafkaClient) AddRouter(ctx context.Context, topicName string, router *Router, options ...HandlerOption) {
+	switch router.unmatched {
+	case UnmatchedError, UnmatchedSkip:
+	case UnmatchedDLQ:
+		if router.dlqTopic == "" {
+			k.log.Emergency(ctx, "missing unmatched dead-letter topic for router - "+topicName, nil, fmt.Errorf("KafkaClient.AddRouter: unmatched policy DLQ needs a topic"))
+		}
+		router.dlqPolicy = &FailurePolicy{DLQTopic: router.dlqTopic}
+		k.ensureProducer(ctx)
+	default:
+		k.log.Emergency(ctx, "invalid unmatched policy for router - "+topicName, nil, fmt.Errorf("KafkaClient.AddRouter: invalid unmatched policy `%v`", router.unmatched))
+	}
+	router.client = k
+	config := &handlerConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+	handler := router.Handle
+	if config.txn != nil {
+		handler = k.txnHandler(config.txn, handler)
+		k.transactional = true
+	}
+	if config.dedup != nil {
+		handler = k.dedupHandler(config.dedup, handler)
+	}
+	for _, topic := range k.registerTopics(ctx, topicName, config) {
+		k.handler[topic] = handler
+		k.routers[topic] = router
+	}
+}
+
+// patternHandler handles every topic matching the pattern.
+type patternHandler struct {
+	pattern *regexp.Regexp
+	handler KafkaEventProcessor
+}
+
+// AddPatternHandler adds a handler for every topic whose name matches the regular expression, e.g. `^orders\..+$`.
+// Matching topics are resolved from the cluster when the client subscribes; topics created later are not consumed
+// and topics with their own handler or used as a dead-letter topic are excluded.
+func (k *KafkaClient) AddPatternHandler(ctx context.Context, pattern string, handler KafkaEventProcessor) {
+	if handler == nil {
+		k.log.Emergency(ctx, "missing handler for pattern - "+pattern, nil, fmt.Errorf("KafkaClient.AddPatternHandler: handler parameter cannot be nil"))
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		k.log.Emergency(ctx, "invalid topic pattern - "+pattern, nil, fmt.Errorf("KafkaClient.AddPatternHandler: invalid pattern: %w", err))
+	}
+	k.patterns = append(k.patterns, &patternHandler{pattern: re, handler: handler})
+}
+
+// resolvePatterns adds the handler of every pattern for the matching topics of the cluster.
+func (k *KafkaClient) resolvePatterns(ctx context.Context) {
+	if len(k.patterns) == 0 {
+		return
+	}
+	topics, err := kafka.ListTopics(ctx, k.c.CredConfig)
+	if err != nil {
+		k.log.Emergency(ctx, "error listing topics for pattern handlers", nil, fmt.Errorf("KafkaClient.resolvePatterns: %w", err))
+	}
+	excluded := map[string]bool{}
+	for _, policy := range k.policy {
+		excluded[policy.DLQTopic] = true
+	}
+	for _, router := range k.routers {
+		excluded[router.dlqTopic] = true
+	}
+	for _, topic := range topics {
+		if excluded[topic] {
+			continue
+		}
+		if _, ok := k.handler[topic]; ok {
+			continue
+		}
+		if _, ok := k.batchHandler[topic]; ok {
+			continue
+		}
+		for _, p := range k.patterns {
+			if p.pattern.MatchString(topic) {
+				k.handler[topic] = p.handler
+				k.patternTopics[topic] = p.pattern.String()
+				break
+			}
+		}
+	}
+}
+
+// Routes returns every registered route sorted by topic.
+func (k *KafkaClient) Routes() []RouteInfo {
+	res := make([]RouteInfo, 0, len(k.handler)+len(k.batchHandler))
+	options := func(topic string) string {
+		policy := k.policy[topic]
+		if policy == nil {
+			return ""
+		}
+		return fmt.Sprintf("maxRetries=%v retryTopics=%v dlq=%v", policy.MaxRetries, len(policy.RetryTopics), policy.DLQTopic)
+	}
+	for topic := range k.handler {
+		if router, ok := k.routers[topic]; ok {
+			unmatched := "unmatched=" + router.unmatched
+			if router.fallback != nil {
+				unmatched = "unmatched=default"
+			}
+			for _, rt := range router.routes {
+				res = append(res, RouteInfo{Topic: topic, Kind: "router", Match: rt.String(), Options: unmatched})
+			}
+			continue
+		}
+		if pattern, ok := k.patternTopics[topic]; ok {
+			res = append(res, RouteInfo{Topic: topic, Kind: "pattern", Match: pattern})
+			continue
+		}
+		res = append(res, RouteInfo{Topic: topic, Kind: "handler", Options: options(topic)})
+	}
+	for topic := range k.batchHandler {
+		res = append(res, RouteInfo{Topic: topic, Kind: "batch", Options: options(topic)})
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Topic < res[j].Topic })
+	return res
+}
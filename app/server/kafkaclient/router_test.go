@@ -0,0 +1,50 @@
+package kafkaclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/app/server/kafkaclient"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/utils"
+	ckafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	var matched string
+	handler := func(name string) kafkaclient.KafkaEventProcessor {
+		return func(ctx context.Context, m *kafka.Message) error {
+			matched = name
+			return nil
+		}
+	}
+	router := kafkaclient.NewRouter().
+		OnHeader("x-event", "created", handler("header")).
+		OnEvent("order", kafkaclient.Wildcard, handler("order")).
+		OnEvent(kafkaclient.Wildcard, "deleted", handler("deleted"))
+	event := func(entity, eventName string) *kafka.Message {
+		value, _ := json.Marshal(utils.NewMessage(entity, eventName))
+		return &kafka.Message{Message: &ckafka.Message{Value: value}}
+	}
+
+	msg := event("order", "created")
+	msg.Headers = []ckafka.Header{{Key: "x-event", Value: []byte("created")}}
+	assert.NilError(t, router.Handle(ctx, msg))
+	assert.Equal(t, matched, "header")
+	assert.NilError(t, router.Handle(ctx, event("order", "updated")))
+	assert.Equal(t, matched, "order")
+	assert.NilError(t, router.Handle(ctx, event("user", "deleted")))
+	assert.Equal(t, matched, "deleted")
+	assert.Equal(t, router.Handle(ctx, event("user", "created")), kafkaclient.ErrNoRoute)
+	assert.Equal(t, router.Handle(ctx, &kafka.Message{Message: &ckafka.Message{Value: []byte("not json")}}), kafkaclient.ErrNoRoute)
+
+	router.Default(handler("default"))
+	assert.NilError(t, router.Handle(ctx, event("user", "created")))
+	assert.Equal(t, matched, "default")
+
+	skip := kafkaclient.NewRouter(kafkaclient.WithUnmatchedPolicy(kafkaclient.UnmatchedSkip))
+	assert.NilError(t, skip.Handle(ctx, event("user", "created")))
+}
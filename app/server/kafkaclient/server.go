@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	baseapp "github.com/sabariramc/goserverbase/v6/app"
 	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
@@ -28,13 +29,24 @@ type Tracer interface {
 type KafkaClient struct {
 	*baseapp.BaseApp
 	client                 *kafka.Poller
+	pool                   atomic.Pointer[workerPool] // Worker pool, nil when the messages are processed inline
+	pause                  pauseState
+	policy                 map[string]*FailurePolicy
+	producer               *kafka.Producer
+	pollCtx                context.Context
 	handler                map[string]KafkaEventProcessor
+	batchHandler           map[string]*batchHandler
+	routers                map[string]*Router
+	patterns               []*patternHandler
+	patternTopics          map[string]string
+	batchWG                sync.WaitGroup
 	log                    log.Log
 	ch                     chan *ckafka.Message
 	c                      *Config
 	shutdown, shutdownPoll context.CancelFunc
 	requestWG, shutdownWG  sync.WaitGroup
 	tracer                 Tracer
+	transactional          bool // A handler commits offsets in producer transactions
 }
 
 // New creates a new instance of KafkaClient.
@@ -45,11 +57,17 @@ func New(option ...Options) *KafkaClient {
 	}
 	os.WriteFile(config.HealthCheckResultPath, []byte("Hello"), fs.ModeAppend)
 	h := &KafkaClient{
-		BaseApp: baseapp.NewWithConfig(config.Config),
-		log:     config.Log,
-		c:       config,
-		handler: make(map[string]KafkaEventProcessor),
-		tracer:  config.Tracer,
+		BaseApp:       baseapp.NewWithConfig(config.Config),
+		log:           config.Log,
+		c:             config,
+		handler:       make(map[string]KafkaEventProcessor),
+		batchHandler:  make(map[string]*batchHandler),
+		routers:       make(map[string]*Router),
+		patternTopics: make(map[string]string),
+		policy:        make(map[string]*FailurePolicy),
+		pause:         pauseState{topics: make(map[string]bool)},
+		tracer:        config.Tracer,
+		producer:      config.Producer,
 	}
 	h.RegisterHealthCheckHook(h)
 	h.RegisterOnShutdownHook(h)
@@ -70,6 +88,8 @@ func (k *KafkaClient) Shutdown(ctx context.Context) error {
 	k.shutdownPoll()
 	k.requestWG.Wait()
 	k.shutdown()
-	k.client.Close(ctx)
+	if client := k.poller(); client != nil {
+		client.Close(ctx)
+	}
 	return nil
 }
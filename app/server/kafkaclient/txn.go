@@ -0,0 +1,40 @@
+package kafkaclient
+
+import (
+	"context"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+)
+
+// WithTransaction runs the handler in a transaction of the producer for consume-transform-produce flows: the messages the
+// handler produces with the producer and the context it receives are committed atomically with the offset of the consumed message.
+// The client switches to [kafka.CommitModeAck] so the consumer commit never runs ahead of the transaction. When the producer
+// falls back to plain writes the handler runs without a transaction and the offset is committed by the consumer.
+// Not supported for batch handlers.
+//
+// The transaction commits the offset only up to the messages of the partition that completed, see [kafka.Poller.TxnOffsets],
+// so a message is never skipped; with messages processed out of order, as with concurrency or ordering keys, a restart can
+// reprocess a message whose transaction committed before an earlier message of its partition completed.
+func WithTransaction(producer *kafka.TxnProducer) HandlerOption {
+	return func(c *handlerConfig) {
+		c.txn = producer
+	}
+}
+
+// txnHandler wraps the handler to run it in a transaction that also commits the offset of the message,
+// the offset is committed with the generation and member ID of the poller so a consumer fenced by a rebalance aborts.
+func (k *KafkaClient) txnHandler(producer *kafka.TxnProducer, handler KafkaEventProcessor) KafkaEventProcessor {
+	return func(ctx context.Context, msg *kafka.Message) error {
+		return producer.WithTransaction(ctx, func(txCtx context.Context) error {
+			err := handler(txCtx, msg)
+			if err != nil {
+				return err
+			}
+			client := k.poller()
+			if client == nil {
+				return nil
+			}
+			return producer.SendOffsets(txCtx, client.GroupMetadata(), client.TxnOffsets(msg.Message))
+		})
+	}
+}
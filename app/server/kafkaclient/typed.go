@@ -0,0 +1,20 @@
+package kafkaclient
+
+import (
+	"context"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/serde"
+)
+
+// TypedHandler adapts a handler of decoded values to a KafkaEventProcessor.
+// A message that fails to decode or validate fails like a handler error and follows the failure policy of the topic.
+func TypedHandler[T any](codec *serde.Codec[T], handler func(ctx context.Context, msg *kafka.Message, value T) error) KafkaEventProcessor {
+	return func(ctx context.Context, msg *kafka.Message) error {
+		value, err := kafka.DecodeMessage(ctx, codec, msg)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, msg, value)
+	}
+}
@@ -0,0 +1,110 @@
+package kafkaclient
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	ckafka "github.com/segmentio/kafka-go"
+)
+
+// WorkerStatus is the status of the worker pool reported by StatusCheck.
+type WorkerStatus struct {
+	Workers     int                                      // Number of workers
+	QueueDepth  int                                      // Buffer size of each worker queue
+	Queued      []int                                    // Number of messages waiting in each worker queue
+	InFlight    int                                      // Number of messages dispatched but not yet committable
+	OrderingKey string                                   // Ordering guarantee of the pool
+	Partitions  map[string]map[int]kafka.PartitionStatus // Offset status of every partition seen by the pool
+}
+
+// workerPool fans messages out to a fixed set of workers. Every message with the same ordering key is
+// routed to the same worker so that order is preserved within the key. The poller runs in
+// [kafka.CommitModeAck] so offsets are committed only up to the lowest contiguous completed offset of each partition.
+type workerPool struct {
+	queues []chan *ckafka.Message
+	wg     sync.WaitGroup
+}
+
+// startWorkers creates the worker pool and starts the workers, the pool is published before the poll starts
+// so that StatusCheck never observes a half initialised pool.
+func (k *KafkaClient) startWorkers(ctx context.Context) *workerPool {
+	n := int(k.c.Concurrency)
+	pool := &workerPool{
+		queues: make([]chan *ckafka.Message, n),
+	}
+	for i := range pool.queues {
+		pool.queues[i] = make(chan *ckafka.Message, k.c.QueueDepth)
+		pool.wg.Add(1)
+		go k.worker(ctx, pool, pool.queues[i])
+	}
+	k.pool.Store(pool)
+	k.log.Notice(ctx, "Kafka client worker pool started", map[string]any{"workers": n, "queueDepth": k.c.QueueDepth, "orderingKey": k.c.OrderingKey})
+	return pool
+}
+
+// dispatch queues the message to the worker owning its ordering key.
+func (k *KafkaClient) dispatch(pool *workerPool, msg *ckafka.Message) {
+	pool.queues[k.workerIndex(msg, len(pool.queues))] <- msg
+}
+
+// workerIndex returns the index of the worker owning the ordering key of the message.
+// Messages without a key fall back to partition ordering.
+func (k *KafkaClient) workerIndex(msg *ckafka.Message, workers int) int {
+	h := fnv.New32a()
+	if k.c.OrderingKey == OrderingKeyMessage && len(msg.Key) > 0 {
+		h.Write([]byte(msg.Topic))
+		h.Write(msg.Key)
+	} else {
+		h.Write([]byte(msg.Topic))
+		h.Write([]byte(strconv.Itoa(msg.Partition)))
+	}
+	return int(h.Sum32() % uint32(workers))
+}
+
+// worker processes the messages of its queue sequentially until the queue is closed.
+func (k *KafkaClient) worker(ctx context.Context, pool *workerPool, queue <-chan *ckafka.Message) {
+	defer pool.wg.Done()
+	for msg := range queue {
+		emMsg := &kafka.Message{Message: msg}
+		msgCtx := k.GetMessageContext(emMsg)
+		if k.ProcessEvent(msgCtx, emMsg, k.handler[msg.Topic]) == nil {
+			k.ack(ctx, msg)
+		}
+	}
+}
+
+// ack acknowledges the processed message to the poller.
+func (k *KafkaClient) ack(ctx context.Context, msg *ckafka.Message) {
+	err := k.client.Ack(ctx, msg)
+	if err != nil {
+		k.log.Error(ctx, "error acknowledging message", err)
+	}
+}
+
+// stopWorkers closes the worker queues and waits for the queued messages to be processed.
+func (k *KafkaClient) stopWorkers(ctx context.Context, pool *workerPool) {
+	for _, queue := range pool.queues {
+		close(queue)
+	}
+	pool.wg.Wait()
+	k.log.Notice(ctx, "Kafka client worker pool stopped", nil)
+}
+
+// workerStatus returns the current status of the worker pool.
+func (k *KafkaClient) workerStatus(pool *workerPool) *WorkerStatus {
+	queued := make([]int, len(pool.queues))
+	for i, queue := range pool.queues {
+		queued[i] = len(queue)
+	}
+	return &WorkerStatus{
+		Workers:     len(pool.queues),
+		QueueDepth:  int(k.c.QueueDepth),
+		Queued:      queued,
+		InFlight:    k.client.InFlight(),
+		OrderingKey: k.c.OrderingKey,
+		Partitions:  k.client.OffsetStatus(),
+	}
+}
@@ -31,6 +31,7 @@ func (b *BaseApp) RegisterOnShutdownHook(handler ShutdownHook) {
 //
 // This function iterates through all registered shutdown hooks, executing each one within a specified timeout context.
 // It logs the progress of the shutdown process and ensures all hooks are processed before completing the shutdown.
+// The logger is shut down last when it implements ShutdownHook, so that a buffered log mux is flushed.
 func (b *BaseApp) Shutdown(ctx context.Context) {
 	b.log.Notice(ctx, "Gracefully shutting down server", nil)
 	hooksCount := len(b.shutdownHooks)
@@ -41,6 +42,11 @@ func (b *BaseApp) Shutdown(ctx context.Context) {
 		b.log.Notice(ctx, fmt.Sprintf("completed step %v of %v", i+1, hooksCount), nil)
 	}
 	b.log.Notice(ctx, "server shutdown completed", nil)
+	if hook, ok := b.log.(ShutdownHook); ok {
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+		b.processShutdownHook(shutdownCtx, hook)
+		cancel()
+	}
 	b.shutdownWg.Done()
 }
 
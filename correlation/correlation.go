@@ -15,6 +15,7 @@ type CorrelationParam struct {
 	ScenarioID    *string `header:"x-scenario-id,omitempty" body:"scenarioId,omitempty"`
 	SessionID     *string `header:"x-session-id,omitempty" body:"sessionId,omitempty"`
 	ScenarioName  *string `header:"x-scenario-name,omitempty" body:"scenarioName,omitempty"`
+	LogLevel      *string `header:"x-log-level,omitempty" body:"logLevel,omitempty"` // LogLevel overrides the log level for the request when the logger allows it.
 }
 
 // GetPayload encodes CorrelationParam into a map[string]string with body struct tags.
@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log/audit"
+)
+
+// auditDocument is the document of an audit record, the details are stored as a document instead of JSON.
+type auditDocument struct {
+	Timestamp     time.Time     `bson:"timestamp"`
+	CorrelationID string        `bson:"correlationId"`
+	Actor         audit.Actor   `bson:"actor"`
+	Action        string        `bson:"action"`
+	Resource      string        `bson:"resource"`
+	Outcome       audit.Outcome `bson:"outcome"`
+	Details       any           `bson:"details,omitempty"`
+}
+
+// AuditWriter inserts audit records into a collection, implements [log.AuditLogWriter].
+//
+// Grant the service insert only access to the collection to keep it append-only.
+type AuditWriter struct {
+	coll *Collection
+}
+
+// NewAuditWriter creates a new AuditWriter that inserts into the collection.
+func NewAuditWriter(coll *Collection) *AuditWriter {
+	return &AuditWriter{coll: coll}
+}
+
+// WriteMessage inserts the message as a record, see [audit.FromMessage].
+func (a *AuditWriter) WriteMessage(ctx context.Context, msg interface{}) error {
+	rec := audit.FromMessage(ctx, msg)
+	doc := auditDocument{
+		Timestamp:     rec.Timestamp,
+		CorrelationID: rec.CorrelationID,
+		Actor:         rec.Actor,
+		Action:        rec.Action,
+		Resource:      rec.Resource,
+		Outcome:       rec.Outcome,
+	}
+	if len(rec.Details) > 0 {
+		if err := json.Unmarshal(rec.Details, &doc.Details); err != nil {
+			return fmt.Errorf("AuditWriter.WriteMessage: error decoding details: %w", err)
+		}
+	}
+	if _, err := a.coll.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("AuditWriter.WriteMessage: %w", err)
+	}
+	return nil
+}
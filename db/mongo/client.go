@@ -5,11 +5,13 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/sabariramc/goserverbase/v6/correlation"
 	"github.com/sabariramc/goserverbase/v6/env"
 	"github.com/sabariramc/goserverbase/v6/log"
+	logmessage "github.com/sabariramc/goserverbase/v6/log/message"
 	"github.com/sabariramc/goserverbase/v6/utils"
 
 	"go.mongodb.org/mongo-driver/event"
@@ -49,15 +51,17 @@ func GetDefaultConfig(moduleName string, t Tracer, logger log.Log) *options.Clie
 	if t != nil {
 		connectionOptions.SetMonitor(t.MongoDB())
 	}
+	internalLog := logger.NewResourceLogger(moduleName + ":InternalLog")
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam(moduleName+"-InternalLog"))
 	mongoLogger := &MongoLogger{
-		log: logger.NewResourceLogger(moduleName + ":InternalLog"),
-		ctx: correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam(moduleName+"-InternalLog")),
+		log: internalLog,
+		ctx: ctx,
 	}
 	connectionOptions.SetLoggerOptions(&options.LoggerOptions{
 		ComponentLevels: map[options.LogComponent]options.LogLevel{
 			options.LogComponentAll: options.LogLevelDebug,
 		},
-		Sink:              mongoLogger,
+		Sink:              NewSlogSink(ctx, slog.New(log.Handler(internalLog))),
 		MaxDocumentLength: 1024,
 	})
 	connectionOptions.SetPoolMonitor(&event.PoolMonitor{
@@ -160,3 +164,30 @@ func (m *MongoLogger) Error(err error, message string, keysAndValues ...interfac
 func (m *MongoLogger) PoolEvent(e *event.PoolEvent) {
 	m.log.Debug(m.ctx, "mongo pool event", e)
 }
+
+// SlogSink is a mongo driver LogSink that writes to a slog.Logger, the key-value pairs of the driver are written as attributes.
+//
+//	connectionOptions.SetLoggerOptions(&options.LoggerOptions{Sink: mongo.NewSlogSink(ctx, slog.New(log.Handler(logger)))})
+type SlogSink struct {
+	log *slog.Logger
+	ctx context.Context
+}
+
+// NewSlogSink creates a new SlogSink that logs with ctx.
+func NewSlogSink(ctx context.Context, logger *slog.Logger) *SlogSink {
+	return &SlogSink{log: logger, ctx: ctx}
+}
+
+// Info logs the message at debug level for info messages of the driver and at trace level for debug messages.
+func (s *SlogSink) Info(level int, message string, keysAndValues ...interface{}) {
+	if level == int(options.LogLevelInfo) {
+		s.log.Log(s.ctx, slog.LevelDebug, message, keysAndValues...)
+	} else {
+		s.log.Log(s.ctx, logmessage.TRACE.SlogLevel(), message, keysAndValues...)
+	}
+}
+
+// Error logs the message at error level with the error.
+func (s *SlogSink) Error(err error, message string, keysAndValues ...interface{}) {
+	s.log.Log(s.ctx, slog.LevelError, message, append(keysAndValues, "error", err)...)
+}
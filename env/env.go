@@ -6,10 +6,58 @@ const (
 
 	// LogLevel is the environment variable for the log level setting.
 	LogLevel = "LOG__LEVEL"
+	// LogModuleLevel is the environment variable for the per module log level rules, e.g. MongoClient:*=DEBUG,KafkaReader=DEBUG.
+	LogModuleLevel = "LOG__MODULE_LEVEL"
+	// LogLevelOverride is the environment variable for allowing the log level of a request to be overridden with the correlation param.
+	LogLevelOverride = "LOG__LEVEL_OVERRIDE"
+	// LogSamplingRules is the environment variable for the log sampling rules, e.g. *@DEBUG=10/100,KafkaReader@*=100/0.
+	LogSamplingRules = "LOG__SAMPLING__RULES"
+	// LogSamplingInterval is the environment variable for the interval in milliseconds of the log sampling windows.
+	LogSamplingInterval = "LOG__SAMPLING__INTERVAL"
+	// LogRedactEnabled is the environment variable for enabling the redaction of sensitive values from the logs.
+	LogRedactEnabled = "LOG__REDACT__ENABLED"
+	// LogRedactAction is the environment variable for the replacement of redacted values, MASK, HASH or DROP.
+	LogRedactAction = "LOG__REDACT__ACTION"
+	// LogRedactMask is the environment variable for the mask that replaces redacted values.
+	LogRedactMask = "LOG__REDACT__MASK"
+	// LogRedactFieldNames is the environment variable for the list of field name patterns whose values are redacted.
+	LogRedactFieldNames = "LOG__REDACT__FIELD_NAMES"
+	// LogAuditWriter is the environment variable for the audit log writer of the logger, FILE or empty for none.
+	LogAuditWriter = "LOG__AUDIT__WRITER"
+	// LogAuditFilePath is the environment variable for the path of the file of the audit file writer.
+	LogAuditFilePath = "LOG__AUDIT__FILE__PATH"
+	// LogAuditFileSync is the environment variable for syncing the file of the audit file writer after every record.
+	LogAuditFileSync = "LOG__AUDIT__FILE__SYNC"
 	// LogFileTrace is the environment variable for enabling file trace logging.
 	LogFileTrace = "LOG__FILE_TRACE"
 	// LogWriter is the environment variable for specifying the log writer type.
 	LogWriter = "LOG__WRITER"
+	// LogFilePath is the environment variable for the path of the file of the file log writer.
+	LogFilePath = "LOG__FILE__PATH"
+	// LogFileMaxSize is the environment variable for the size in bytes at which the file log writer rotates the file.
+	LogFileMaxSize = "LOG__FILE__MAX_SIZE"
+	// LogFileRotateInterval is the environment variable for the interval in milliseconds at which the file log writer rotates the file.
+	LogFileRotateInterval = "LOG__FILE__ROTATE_INTERVAL"
+	// LogFileMaxBackups is the environment variable for the number of rotated files retained by the file log writer.
+	LogFileMaxBackups = "LOG__FILE__MAX_BACKUPS"
+	// LogFileMaxAge is the environment variable for the age in milliseconds after which the file log writer deletes rotated files.
+	LogFileMaxAge = "LOG__FILE__MAX_AGE"
+	// LogFileCompress is the environment variable for enabling gzip compression of the files rotated by the file log writer.
+	LogFileCompress = "LOG__FILE__COMPRESS"
+	// LogFileBufferSize is the environment variable for the write buffer size in bytes of the file log writer.
+	LogFileBufferSize = "LOG__FILE__BUFFER_SIZE"
+	// LogFileSyncInterval is the environment variable for the interval in milliseconds at which the file log writer flushes and syncs the file.
+	LogFileSyncInterval = "LOG__FILE__SYNC_INTERVAL"
+	// LogFileReopenOnSIGHUP is the environment variable for enabling reopening of the file on SIGHUP by the file log writer.
+	LogFileReopenOnSIGHUP = "LOG__FILE__REOPEN_ON_SIGHUP"
+	// LogFileFormat is the environment variable for the name of the formatter of the file log writer.
+	LogFileFormat = "LOG__FILE__FORMAT"
+	// LogMuxBufferSize is the environment variable for the buffer size of each writer of the channeled log mux.
+	LogMuxBufferSize = "LOG__MUX__BUFFER_SIZE"
+	// LogMuxBatchSize is the environment variable for the maximum number of messages written to a writer of the channeled log mux at once.
+	LogMuxBatchSize = "LOG__MUX__BATCH_SIZE"
+	// LogMuxDropPolicy is the environment variable for the policy of the channeled log mux when the buffer of a writer is full.
+	LogMuxDropPolicy = "LOG__MUX__DROP_POLICY"
 
 	// NotifierTopic is the environment variable for the notifier topic.
 	NotifierTopic = "NOTIFIER__TOPIC"
@@ -26,6 +74,28 @@ const (
 	KafkaProducerAsync = "KAFKA__PRODUCER__ASYNC"
 	// KafkaProducerBatch is the environment variable for enabling batch processing in Kafka producer.
 	KafkaProducerBatch = "KAFKA__PRODUCER__BATCH"
+	// KafkaProducerMaxRetries is the environment variable for the number of retries of a failed Kafka producer write.
+	KafkaProducerMaxRetries = "KAFKA__PRODUCER__MAX_RETRIES"
+	// KafkaProducerRetryBackoff is the environment variable for the backoff in milliseconds between Kafka producer retries.
+	KafkaProducerRetryBackoff = "KAFKA__PRODUCER__RETRY_BACKOFF"
+	// KafkaProducerSpoolDir is the environment variable for the directory of the Kafka producer spool.
+	KafkaProducerSpoolDir = "KAFKA__PRODUCER__SPOOL__DIR"
+	// KafkaProducerSpoolSegmentSize is the environment variable for the segment size in bytes of the Kafka producer spool.
+	KafkaProducerSpoolSegmentSize = "KAFKA__PRODUCER__SPOOL__SEGMENT_SIZE"
+	// KafkaProducerSpoolMaxSize is the environment variable for the maximum size in bytes of the Kafka producer spool.
+	KafkaProducerSpoolMaxSize = "KAFKA__PRODUCER__SPOOL__MAX_SIZE"
+	// KafkaProducerSpoolOverflow is the environment variable for the overflow policy of the Kafka producer spool.
+	KafkaProducerSpoolOverflow = "KAFKA__PRODUCER__SPOOL__OVERFLOW"
+	// KafkaProducerSpoolReplayInterval is the environment variable for the interval in milliseconds between Kafka producer spool replays.
+	KafkaProducerSpoolReplayInterval = "KAFKA__PRODUCER__SPOOL__REPLAY_INTERVAL"
+	// KafkaProducerIdempotent is the environment variable for enabling idempotent writes of the Kafka transactional producer.
+	KafkaProducerIdempotent = "KAFKA__PRODUCER__IDEMPOTENT"
+	// KafkaProducerTransactionalID is the environment variable for the transactional ID of the Kafka transactional producer.
+	KafkaProducerTransactionalID = "KAFKA__PRODUCER__TRANSACTIONAL_ID"
+	// KafkaProducerTxnTimeout is the environment variable for the transaction timeout in milliseconds of the Kafka transactional producer.
+	KafkaProducerTxnTimeout = "KAFKA__PRODUCER__TXN_TIMEOUT"
+	// KafkaProducerFallback is the environment variable for falling back to plain writes when the broker does not support idempotence or transactions.
+	KafkaProducerFallback = "KAFKA__PRODUCER__FALLBACK"
 	// KafkaConsumerGroupID is the environment variable for the Kafka consumer group ID.
 	KafkaConsumerGroupID = "KAFKA__CONSUMER__GROUP_ID"
 	// KafkaConsumerTopics is the environment variable for the Kafka consumer topics.
@@ -36,14 +106,55 @@ const (
 	KafkaConsumerMaxBuffer = "KAFKA__CONSUMER__MAX_BUFFER"
 	// KafkaConsumerAutoCommitInterval is the environment variable for the auto commit interval for Kafka consumer.
 	KafkaConsumerAutoCommitInterval = "KAFKA__CONSUMER__AUTO_COMMIT_INTERVAL"
+	// KafkaConsumerCommitMode is the environment variable for the commit mode of Kafka consumer.
+	KafkaConsumerCommitMode = "KAFKA__CONSUMER__COMMIT_MODE"
+	// KafkaConsumerReadCommitted is the environment variable for reading only committed messages in Kafka consumer.
+	KafkaConsumerReadCommitted = "KAFKA__CONSUMER__READ_COMMITTED"
+	// KafkaConsumerMaxLag is the environment variable for the partition lag above which the Kafka consumer is unhealthy.
+	KafkaConsumerMaxLag = "KAFKA__CONSUMER__MAX_LAG"
+	// KafkaConsumerMaxIdle is the environment variable for the milliseconds without a message after which the Kafka consumer is unhealthy.
+	KafkaConsumerMaxIdle = "KAFKA__CONSUMER__MAX_IDLE"
+	// KafkaConsumerStatsInterval is the environment variable for the interval to refresh the partition assignment and offsets of Kafka consumer.
+	KafkaConsumerStatsInterval = "KAFKA__CONSUMER__STATS_INTERVAL"
+	// KafkaAdminTimeout is the environment variable for the timeout in milliseconds of the Kafka admin requests.
+	KafkaAdminTimeout = "KAFKA__ADMIN__TIMEOUT"
 
 	// MongoConnectionString is the environment variable for the MongoDB connection string.
 	MongoConnectionString = "MONGO__CONNECTION_STRING"
 
+	// OutboxMode is the environment variable for how the outbox relay detects new events.
+	OutboxMode = "OUTBOX__MODE"
+	// OutboxPollInterval is the environment variable for the outbox relay poll interval in milliseconds.
+	OutboxPollInterval = "OUTBOX__POLL_INTERVAL"
+	// OutboxBatchSize is the environment variable for the number of events the outbox relay publishes at once.
+	OutboxBatchSize = "OUTBOX__BATCH_SIZE"
+	// OutboxRetention is the environment variable for the seconds sent outbox events are kept.
+	OutboxRetention = "OUTBOX__RETENTION"
+	// OutboxLeaseTTL is the environment variable for the milliseconds the outbox relay lease is held without renewal.
+	OutboxLeaseTTL = "OUTBOX__LEASE_TTL"
+
 	// KafkaClientHealthCheckInterval is the environment variable for the Kafka client health check interval.
 	KafkaClientHealthCheckInterval = "KAFKA_CLIENT__HEALTH_CHECK_INTERVAL"
 	// KafkaClientHealthCheckResultPath is the environment variable for the Kafka client health check result path.
 	KafkaClientHealthCheckResultPath = "KAFKA_CLIENT__HEALTH_CHECK_RESULT_PATH"
+	// KafkaClientConcurrency is the environment variable for the number of Kafka client workers.
+	KafkaClientConcurrency = "KAFKA_CLIENT__CONCURRENCY"
+	// KafkaClientQueueDepth is the environment variable for the queue depth of each Kafka client worker.
+	KafkaClientQueueDepth = "KAFKA_CLIENT__QUEUE_DEPTH"
+	// KafkaClientOrderingKey is the environment variable for the Kafka client message ordering key.
+	KafkaClientOrderingKey = "KAFKA_CLIENT__ORDERING_KEY"
+	// KafkaClientPauseOnUnhealthy is the environment variable to pause the Kafka client while the health check fails.
+	KafkaClientPauseOnUnhealthy = "KAFKA_CLIENT__PAUSE_ON_UNHEALTHY"
+	// KafkaClientUnhealthyTimeout is the environment variable for the seconds the Kafka client stays paused before failing the health check.
+	KafkaClientUnhealthyTimeout = "KAFKA_CLIENT__UNHEALTHY_TIMEOUT"
+	// KafkaClientProvisionTopics is the environment variable to create the missing topics of the Kafka client on start.
+	KafkaClientProvisionTopics = "KAFKA_CLIENT__PROVISION_TOPICS"
+	// KafkaClientTopicDriftPolicy is the environment variable for the action on topics that differ from their spec.
+	KafkaClientTopicDriftPolicy = "KAFKA_CLIENT__TOPIC_DRIFT_POLICY"
+	// KafkaClientTopicPartitions is the environment variable for the number of partitions of the topics provisioned by the Kafka client.
+	KafkaClientTopicPartitions = "KAFKA_CLIENT__TOPIC_PARTITIONS"
+	// KafkaClientTopicReplicationFactor is the environment variable for the replication factor of the topics provisioned by the Kafka client.
+	KafkaClientTopicReplicationFactor = "KAFKA_CLIENT__TOPIC_REPLICATION_FACTOR"
 
 	// HTTPServerHost is the environment variable for the HTTP server host.
 	HTTPServerHost = "HTTP_SERVER__HOST"
@@ -59,4 +170,6 @@ const (
 	HTTPServerTLSPublicKey = "HTTP_SERVER__TLS_PUBLIC_KEY"
 	// HTTPServerTLSPrivateKey is the environment variable for the path to the TLS private key.
 	HTTPServerTLSPrivateKey = "HTTP_SERVER__TLS_PRIVATE_KEY"
+	// HTTPServerGinSlog is the environment variable to route the output of gin to the logger of the HTTP server.
+	HTTPServerGinSlog = "HTTP_SERVER__GIN_SLOG"
 )
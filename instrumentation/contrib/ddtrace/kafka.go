@@ -2,9 +2,11 @@ package ddtrace
 
 import (
 	"context"
+	"encoding/binary"
 
 	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
 	"github.com/segmentio/kafka-go"
+	dd "gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	ddtrace "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
@@ -81,3 +83,32 @@ func (t *tracer) StartKafkaSpanFromMessage(ctx context.Context, msg *kafka.Messa
 	sp, ctx := ddtrace.StartSpanFromContext(ctx, "kafka.consume", opts...)
 	return ctx, &ddtraceSpan{Span: sp}
 }
+
+// StartKafkaBatchSpan starts a new Kafka consumer span for a batch of messages.
+// The span is linked to the parent span context found in the headers of every message.
+func (t *tracer) StartKafkaBatchSpan(ctx context.Context, topic string, msgs []*kafka.Message) (context.Context, span.Span) {
+	links := make([]dd.SpanLink, 0, len(msgs))
+	for _, msg := range msgs {
+		spanCtx, err := ddtrace.Extract(NewKafkaCarrier(msg))
+		if err != nil {
+			continue
+		}
+		link := dd.SpanLink{TraceID: spanCtx.TraceID(), SpanID: spanCtx.SpanID()}
+		if w3c, ok := spanCtx.(dd.SpanContextW3C); ok {
+			traceID := w3c.TraceID128Bytes()
+			link.TraceIDHigh = binary.BigEndian.Uint64(traceID[:8])
+		}
+		links = append(links, link)
+	}
+	opts := []ddtrace.StartSpanOption{
+		ddtrace.ResourceName(topic),
+		ddtrace.SpanType(ext.SpanTypeMessageConsumer),
+		ddtrace.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		ddtrace.Tag(ext.MessagingSystem, "kafka"),
+		ddtrace.Tag("messaging.batch.message_count", len(msgs)),
+		ddtrace.WithSpanLinks(links),
+		ddtrace.Measured(),
+	}
+	sp, ctx := ddtrace.StartSpanFromContext(ctx, "kafka.consume.batch", opts...)
+	return ctx, &ddtraceSpan{Span: sp}
+}
@@ -2,8 +2,10 @@ package ddtrace
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
+	dd "gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	ddtrace "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
@@ -59,3 +61,17 @@ func (s *ddtraceSpan) SetError(err error, stackTrace string) {
 func (s *ddtraceSpan) SetStatus(statusCode int, description string) {
 	s.Span.SetTag(ext.HTTPCode, statusCode)
 }
+
+// TraceID returns the hex encoded 128-bit trace ID of the span, the 64-bit trace ID is zero padded when the span context does not carry a 128-bit trace ID.
+func (s *ddtraceSpan) TraceID() string {
+	sc := s.Span.Context()
+	if w3c, ok := sc.(dd.SpanContextW3C); ok {
+		return w3c.TraceID128()
+	}
+	return fmt.Sprintf("%032x", sc.TraceID())
+}
+
+// SpanID returns the hex encoded span ID of the span.
+func (s *ddtraceSpan) SpanID() string {
+	return fmt.Sprintf("%016x", s.Span.Context().SpanID())
+}
@@ -72,3 +72,23 @@ func (t *tracerManager) StartKafkaSpanFromMessage(ctx context.Context, msg *kafk
 	spanCtx, span := tr.Start(msgCtx, "kafka.consume", opts...)
 	return spanCtx, &otelSpan{Span: span}
 }
+
+// StartKafkaBatchSpan starts a consumer span for a batch of Kafka messages, linked to the producer span of every message.
+// It returns the new context with the span and the created span.
+func (t *tracerManager) StartKafkaBatchSpan(ctx context.Context, topic string, msgs []*kafka.Message) (context.Context, span.Span) {
+	links := make([]trace.Link, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := otel.GetTextMapPropagator().Extract(context.Background(), NewMessageCarrier(msg))
+		if sc := trace.SpanContextFromContext(msgCtx); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc, Attributes: []attribute.KeyValue{attribute.Int("messaging.kafka.partition", msg.Partition), attribute.Int64("messaging.kafka.offset", msg.Offset)}})
+		}
+	}
+	tr := otel.Tracer("")
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.String("messaging.system", "kafka"), attribute.String("resource.name", topic), attribute.Int("messaging.batch.message_count", len(msgs))),
+	}
+	spanCtx, span := tr.Start(ctx, "kafka.consume.batch", opts...)
+	return spanCtx, &otelSpan{Span: span}
+}
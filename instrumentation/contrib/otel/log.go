@@ -0,0 +1,264 @@
+package otel
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log/logwriter"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"github.com/sabariramc/goserverbase/v6/utils"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// LogWriterConfig represents the configuration of the [LogWriter].
+type LogWriterConfig struct {
+	Endpoint string            // Endpoint is the host:port of the OTLP gRPC receiver.
+	Insecure bool              // Insecure disables TLS for the connection to the receiver.
+	Headers  map[string]string // Headers are sent with every export.
+	Timeout  time.Duration     // Timeout is the timeout of an export.
+	Env      string            // Env is added to the resource as deployment.environment.
+	Version  string            // Version is added to the resource as service.version.
+}
+
+// GetDefaultLogWriterConfig returns the new LogWriterConfig with values from environment variables or default values,
+// the LOGS variables take precedence over the variables shared with traces and metrics.
+/*
+	Environment Variables
+	- OTEL_EXPORTER_OTLP_LOGS_ENDPOINT, OTEL_EXPORTER_OTLP_ENDPOINT: Sets [Endpoint], default localhost:4317, an http:// endpoint sets [Insecure]
+	- OTEL_EXPORTER_OTLP_LOGS_INSECURE, OTEL_EXPORTER_OTLP_INSECURE: Sets [Insecure]
+	- OTEL_EXPORTER_OTLP_LOGS_HEADERS, OTEL_EXPORTER_OTLP_HEADERS: Sets [Headers], e.g. api-key=secret,tenant=a
+	- OTEL_EXPORTER_OTLP_LOGS_TIMEOUT, OTEL_EXPORTER_OTLP_TIMEOUT: Sets [Timeout] in milliseconds, default 10000
+	- OTEL_ENV: Sets [Env]
+	- OTEL_SERVICE_VERSION: Sets [Version]
+*/
+func GetDefaultLogWriterConfig() LogWriterConfig {
+	endpoint := utils.GetEnv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", utils.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"))
+	plain := strings.HasPrefix(endpoint, "http://")
+	endpoint = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://"), "/")
+	headers := map[string]string{}
+	for _, h := range utils.GetEnvAsSlice("OTEL_EXPORTER_OTLP_LOGS_HEADERS", utils.GetEnvAsSlice("OTEL_EXPORTER_OTLP_HEADERS", nil, ","), ",") {
+		key, value, ok := strings.Cut(h, "=")
+		if ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return LogWriterConfig{
+		Endpoint: endpoint,
+		Insecure: utils.GetEnvBool("OTEL_EXPORTER_OTLP_LOGS_INSECURE", utils.GetEnvBool("OTEL_EXPORTER_OTLP_INSECURE", plain)),
+		Headers:  headers,
+		Timeout:  time.Duration(utils.GetEnvInt("OTEL_EXPORTER_OTLP_LOGS_TIMEOUT", utils.GetEnvInt("OTEL_EXPORTER_OTLP_TIMEOUT", 10000))) * time.Millisecond,
+		Env:      utils.GetEnv("OTEL_ENV", ""),
+		Version:  utils.GetEnv("OTEL_SERVICE_VERSION", ""),
+	}
+}
+
+// LogWriterOption represents an option function for configuring the [LogWriter].
+type LogWriterOption func(*LogWriterConfig)
+
+// WithLogEndpoint sets the host:port of the OTLP gRPC receiver and whether the connection is without TLS.
+func WithLogEndpoint(endpoint string, insecure bool) LogWriterOption {
+	return func(c *LogWriterConfig) {
+		c.Endpoint = endpoint
+		c.Insecure = insecure
+	}
+}
+
+// WithLogHeaders sets the headers sent with every export.
+func WithLogHeaders(headers map[string]string) LogWriterOption {
+	return func(c *LogWriterConfig) {
+		c.Headers = headers
+	}
+}
+
+// WithLogTimeout sets the timeout of an export.
+func WithLogTimeout(timeout time.Duration) LogWriterOption {
+	return func(c *LogWriterConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// LogWriter exports the logs to an OTLP gRPC receiver, implements [logwriter.BatchLogWriter] and [logwriter.CloseLogWriter].
+//
+// The level of the message is mapped with [message.LogLevelCode.SeverityNumber], the service of the message is the resource and
+// the module is the instrumentation scope. The correlation ID, file, log objects and fields are added as attributes and
+// the trace and span IDs of the message link the record to its trace.
+//
+// Every write is an export, use the LogWriter with the [log.ChanneledLogMux] to export the messages in batches off the logging path.
+type LogWriter struct {
+	c      LogWriterConfig
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+}
+
+// NewLogWriter creates a new LogWriter, the connection to the receiver is established on the first export.
+func NewLogWriter(options ...LogWriterOption) (*LogWriter, error) {
+	config := GetDefaultLogWriterConfig()
+	for _, opt := range options {
+		opt(&config)
+	}
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if config.Insecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(config.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("otel.NewLogWriter: error creating client: %w", err)
+	}
+	return &LogWriter{c: config, conn: conn, client: collogspb.NewLogsServiceClient(conn)}, nil
+}
+
+// WriteMessage exports the log message.
+func (w *LogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	return w.export(ctx, []message.MuxLogMessage{{Ctx: ctx, LogMessage: *l}})
+}
+
+// WriteBatch exports the log messages with a single request.
+func (w *LogWriter) WriteBatch(msgs []message.MuxLogMessage) error {
+	return w.export(context.Background(), msgs)
+}
+
+// Sync is a no-op, the messages are exported when they are written.
+func (w *LogWriter) Sync() error {
+	return nil
+}
+
+// Close closes the connection to the receiver.
+func (w *LogWriter) Close() error {
+	if err := w.conn.Close(); err != nil {
+		return fmt.Errorf("LogWriter.Close: %w", err)
+	}
+	return nil
+}
+
+// export sends the messages to the receiver, the export is not cancelled with ctx, it is bound by the timeout of the config.
+func (w *LogWriter) export(ctx context.Context, msgs []message.MuxLogMessage) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.c.Timeout)
+	defer cancel()
+	if len(w.c.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(w.c.Headers))
+	}
+	res, err := w.client.Export(ctx, &collogspb.ExportLogsServiceRequest{ResourceLogs: w.resourceLogs(msgs)})
+	if err != nil {
+		return fmt.Errorf("LogWriter.export: %w", err)
+	}
+	if ps := res.GetPartialSuccess(); ps.GetRejectedLogRecords() > 0 {
+		return fmt.Errorf("LogWriter.export: receiver rejected %v log records: %v", ps.GetRejectedLogRecords(), ps.GetErrorMessage())
+	}
+	return nil
+}
+
+// resourceLogs groups the messages by service and module, preserving the order of the messages of a module.
+func (w *LogWriter) resourceLogs(msgs []message.MuxLogMessage) []*logspb.ResourceLogs {
+	var resources []*logspb.ResourceLogs
+	resourceIndex := map[string]int{}
+	scopeIndex := map[[2]string]int{}
+	observed := uint64(time.Now().UnixNano())
+	for i := range msgs {
+		msg := &msgs[i]
+		ri, ok := resourceIndex[msg.ServiceName]
+		if !ok {
+			ri = len(resources)
+			resourceIndex[msg.ServiceName] = ri
+			resources = append(resources, &logspb.ResourceLogs{Resource: w.resource(msg.ServiceName)})
+		}
+		rl := resources[ri]
+		key := [2]string{msg.ServiceName, msg.ModuleName}
+		si, ok := scopeIndex[key]
+		if !ok {
+			si = len(rl.ScopeLogs)
+			scopeIndex[key] = si
+			rl.ScopeLogs = append(rl.ScopeLogs, &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: msg.ModuleName}})
+		}
+		sl := rl.ScopeLogs[si]
+		sl.LogRecords = append(sl.LogRecords, logRecord(msg, observed))
+	}
+	return resources
+}
+
+// resource returns the resource of the service.
+func (w *LogWriter) resource(serviceName string) *resourcepb.Resource {
+	attrs := []*commonpb.KeyValue{stringAttribute("service.name", serviceName)}
+	if w.c.Version != "" {
+		attrs = append(attrs, stringAttribute("service.version", w.c.Version))
+	}
+	if w.c.Env != "" {
+		attrs = append(attrs, stringAttribute("deployment.environment", w.c.Env))
+	}
+	return &resourcepb.Resource{Attributes: attrs}
+}
+
+// logRecord converts the message to a log record.
+func logRecord(msg *message.MuxLogMessage, observed uint64) *logspb.LogRecord {
+	rec := &logspb.LogRecord{
+		TimeUnixNano:         uint64(msg.Timestamp.UnixNano()),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       logspb.SeverityNumber(msg.LogLevel.Level.SeverityNumber()),
+		SeverityText:         msg.LogLevelName,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: msg.Message}},
+	}
+	if msg.Ctx != nil {
+		if cr := correlation.ExtractCorrelationParam(msg.Ctx); cr.CorrelationID != "" {
+			rec.Attributes = append(rec.Attributes, stringAttribute("CorrelationID", cr.CorrelationID))
+		}
+	}
+	if msg.File != "" {
+		rec.Attributes = append(rec.Attributes, stringAttribute("FilePtr", msg.File))
+	}
+	for _, f := range msg.Fields {
+		rec.Attributes = append(rec.Attributes, &commonpb.KeyValue{Key: f.Key, Value: anyValue(f.Value)})
+	}
+	if len(msg.LogObject) > 0 {
+		rec.Attributes = append(rec.Attributes, stringAttribute("LogObject", logwriter.ParseLogObject(msg.LogObject, false)))
+	}
+	if id, err := hex.DecodeString(msg.TraceID); err == nil && len(id) == 16 {
+		rec.TraceId = id
+		if id, err := hex.DecodeString(msg.SpanID); err == nil && len(id) == 8 {
+			rec.SpanId = id
+		}
+	}
+	return rec
+}
+
+// stringAttribute returns a string attribute.
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+// anyValue converts the value of a field, values that are not a scalar are encoded with [logwriter.ParseObject].
+func anyValue(v any) *commonpb.AnyValue {
+	switch val := v.(type) {
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: val}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(val)}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(val)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: val}}
+	case uint32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(val)}}
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(val)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: val}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: val}}
+	case time.Time:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: val.Format(time.RFC3339Nano)}}
+	case fmt.Stringer:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: val.String()}}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: logwriter.ParseObject(v, false)}}
+}
@@ -0,0 +1,99 @@
+package otel_test
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/instrumentation/contrib/otel"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gotest.tools/assert"
+)
+
+type TestCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+	lock    sync.Mutex
+	req     []*collogspb.ExportLogsServiceRequest
+	headers []metadata.MD
+}
+
+func (c *TestCollector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.req = append(c.req, req)
+	c.headers = append(c.headers, md)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestOTLPLogWriter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	srv := grpc.NewServer()
+	collector := &TestCollector{}
+	collogspb.RegisterLogsServiceServer(srv, collector)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	w, err := otel.NewLogWriter(otel.WithLogEndpoint(lis.Addr().String(), true), otel.WithLogHeaders(map[string]string{"api-key": "secret"}), otel.WithLogTimeout(5*time.Second))
+	assert.NilError(t, err)
+	defer w.Close()
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: "otlp-test"})
+	ts := time.Now()
+	err = w.WriteBatch([]message.MuxLogMessage{
+		{Ctx: ctx, LogMessage: message.LogMessage{
+			LogLevel:    message.GetLogLevel(message.WARNING),
+			Message:     "slow request",
+			Timestamp:   ts,
+			ServiceName: "orders",
+			ModuleName:  "http",
+			Fields:      []message.Field{log.Int("attempt", 2), log.String("topic", "payments")},
+			TraceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:      "00f067aa0ba902b7",
+		}},
+		{Ctx: ctx, LogMessage: message.LogMessage{LogLevel: message.GetLogLevel(message.NOTICE), Message: "consumer", ServiceName: "orders", ModuleName: "kafka", LogObject: []any{map[string]int{"lag": 3}}}},
+		{Ctx: ctx, LogMessage: message.LogMessage{LogLevel: message.GetLogLevel(message.ERROR), Message: "failed", ServiceName: "orders", ModuleName: "http"}},
+	})
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(collector.req), 1)
+	assert.DeepEqual(t, collector.headers[0].Get("api-key"), []string{"secret"})
+	rl := collector.req[0].ResourceLogs
+	assert.Equal(t, len(rl), 1)
+	assert.Equal(t, rl[0].Resource.Attributes[0].Key, "service.name")
+	assert.Equal(t, rl[0].Resource.Attributes[0].Value.GetStringValue(), "orders")
+	assert.Equal(t, len(rl[0].ScopeLogs), 2)
+	http := rl[0].ScopeLogs[0]
+	assert.Equal(t, http.Scope.Name, "http")
+	assert.Equal(t, len(http.LogRecords), 2)
+	rec := http.LogRecords[0]
+	assert.Equal(t, rec.SeverityNumber, logspb.SeverityNumber_SEVERITY_NUMBER_WARN)
+	assert.Equal(t, rec.SeverityText, "WARNING")
+	assert.Equal(t, rec.Body.GetStringValue(), "slow request")
+	assert.Equal(t, rec.TimeUnixNano, uint64(ts.UnixNano()))
+	assert.Equal(t, hex.EncodeToString(rec.TraceId), "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, hex.EncodeToString(rec.SpanId), "00f067aa0ba902b7")
+	attrs := map[string]*commonpb.AnyValue{}
+	for _, kv := range rec.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, attrs["CorrelationID"].GetStringValue(), "otlp-test")
+	assert.Equal(t, attrs["attempt"].GetIntValue(), int64(2))
+	assert.Equal(t, attrs["topic"].GetStringValue(), "payments")
+	assert.Equal(t, http.LogRecords[1].SeverityNumber, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR)
+	assert.Equal(t, len(http.LogRecords[1].TraceId), 0)
+	kafka := rl[0].ScopeLogs[1]
+	assert.Equal(t, kafka.LogRecords[0].SeverityNumber, logspb.SeverityNumber_SEVERITY_NUMBER_INFO2)
+	assert.Equal(t, kafka.LogRecords[0].Attributes[1].Key, "LogObject")
+	assert.Equal(t, kafka.LogRecords[0].Attributes[1].Value.GetStringValue(), `{"lag":3}`)
+}
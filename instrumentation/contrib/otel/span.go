@@ -105,3 +105,21 @@ func (s *otelSpan) SetStatus(statusCode int, description string) {
 		s.Span.SetStatus(codes.Error, description)
 	}
 }
+
+// TraceID returns the hex encoded trace ID of the span, empty when the span context has no trace ID.
+func (s *otelSpan) TraceID() string {
+	sc := s.SpanContext()
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// SpanID returns the hex encoded span ID of the span, empty when the span context has no span ID.
+func (s *otelSpan) SpanID() string {
+	sc := s.SpanContext()
+	if !sc.HasSpanID() {
+		return ""
+	}
+	return sc.SpanID().String()
+}
@@ -20,6 +20,7 @@ type Tracer interface {
 	retryhttp.Tracer
 	kafka.ProduceTracer
 	kafkaclient.Tracer
+	kafkaclient.BatchTracer
 	httpserver.Tracer
 	span.SpanOp
 }
@@ -4,11 +4,14 @@ package span
 import "context"
 
 // Span represents a tracing span with methods to set attributes, status, errors, and to finish the span.
+// TraceID and SpanID return the hex encoded IDs of the span, empty when the span has no IDs, they correlate the logs with the trace.
 type Span interface {
 	SetAttribute(key string, value any)
 	SetStatus(statusCode int, description string)
 	SetError(err error, stackTrace string)
 	Finish()
+	TraceID() string
+	SpanID() string
 }
 
 // SpanOp represents operations that can be performed with spans, including creating new spans and retrieving existing spans from context.
@@ -0,0 +1,443 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// Policies for topics that differ from their spec in [Admin.EnsureTopics].
+const (
+	DriftPolicyWarn = "WARN" // The drift is logged as a warning
+	DriftPolicyFail = "FAIL" // EnsureTopics fails with [ErrTopicDrift]
+)
+
+// ErrTopicDrift is returned by [Admin.EnsureTopics] when an existing topic differs from its spec and the policy is [DriftPolicyFail].
+var ErrTopicDrift = errors.New("kafka: topic differs from spec")
+
+// TopicSpec describes the expected partitions, replication and config of a topic.
+type TopicSpec struct {
+	Name              string            // Name of the topic
+	Partitions        int               // Number of partitions, the broker default when zero
+	ReplicationFactor int               // Replication factor, the broker default when zero
+	Config            map[string]string // Topic level configs, e.g. retention.ms, other configs are not checked
+}
+
+// TopicDescription describes a topic of the cluster.
+type TopicDescription struct {
+	Name              string            // Name of the topic
+	Partitions        int               // Number of partitions
+	ReplicationFactor int               // Number of replicas of the first partition
+	Internal          bool              // Topic is internal to Kafka
+	Config            map[string]string // Configs of the topic as reported by the broker
+}
+
+// TopicDrift is a difference between an existing topic and its spec.
+type TopicDrift struct {
+	Topic    string // Name of the topic
+	Property string // partitions, replicationFactor or the config name
+	Expected string // Value of the spec
+	Actual   string // Value of the topic, empty for a config that is not set
+}
+
+// String describes the drift.
+func (d TopicDrift) String() string {
+	return fmt.Sprintf("%v: %v is %q, expected %q", d.Topic, d.Property, d.Actual, d.Expected)
+}
+
+// GroupMember describes a member of a consumer group.
+type GroupMember struct {
+	MemberID   string           // Member ID assigned by the coordinator
+	ClientID   string           // Client ID of the consumer
+	ClientHost string           // Host of the consumer
+	Assignment map[string][]int // Partitions assigned to the member by topic
+}
+
+// GroupDescription describes a consumer group, its members and committed offsets.
+type GroupDescription struct {
+	GroupID string                   // ID of the group
+	State   string                   // State of the group, e.g. Stable, Empty or Dead
+	Members []GroupMember            // Members of the group
+	Offsets map[string]map[int]int64 // Committed offsets by topic and partition
+	Lag     map[string]map[int]int64 // High-water mark minus the committed offset by topic and partition
+}
+
+// Admin lists, creates, describes and alters the topics and consumer groups of the cluster.
+//
+// Every call uses a new connection so that the metadata reflects the changes of the previous calls.
+type Admin struct {
+	config *AdminConfig
+	log    log.Log
+}
+
+// NewAdmin creates a new Admin with the provided admin options.
+func NewAdmin(options ...AdminOption) (*Admin, error) {
+	config := GetDefaultAdminConfig()
+	for _, opt := range options {
+		opt(config)
+	}
+	if config.CredConfig == nil || len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka.NewAdmin: brokers are not set")
+	}
+	return &Admin{config: config, log: config.Log}, nil
+}
+
+// client creates a client on a new transport, release closes its connections.
+func (a *Admin) client() (client *kafka.Client, release func()) {
+	client = NewClient(a.config.CredConfig)
+	client.Timeout = time.Duration(a.config.Timeout) * time.Millisecond
+	return client, client.Transport.(*kafka.Transport).CloseIdleConnections
+}
+
+// ListTopics returns the sorted names of the topics in the cluster, internal topics are excluded.
+func (a *Admin) ListTopics(ctx context.Context) ([]string, error) {
+	topics, err := a.DescribeTopics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Admin.ListTopics: %w", err)
+	}
+	names := make([]string, 0, len(topics))
+	for name, topic := range topics {
+		if !topic.Internal {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DescribeTopics describes the topics, every topic of the cluster when no name is passed. Topics that do not exist are absent from the result.
+func (a *Admin) DescribeTopics(ctx context.Context, names ...string) (map[string]*TopicDescription, error) {
+	client, release := a.client()
+	defer release()
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: names})
+	if err != nil {
+		return nil, fmt.Errorf("Admin.DescribeTopics: error fetching metadata: %w", err)
+	}
+	res := make(map[string]*TopicDescription, len(meta.Topics))
+	req := &kafka.DescribeConfigsRequest{}
+	for _, topic := range meta.Topics {
+		if errors.Is(topic.Error, kafka.UnknownTopicOrPartition) {
+			continue
+		}
+		if topic.Error != nil {
+			return nil, fmt.Errorf("Admin.DescribeTopics: error describing topic %v: %w", topic.Name, topic.Error)
+		}
+		desc := &TopicDescription{Name: topic.Name, Partitions: len(topic.Partitions), Internal: topic.Internal, Config: map[string]string{}}
+		for _, p := range topic.Partitions {
+			if p.ID == 0 {
+				desc.ReplicationFactor = len(p.Replicas)
+			}
+		}
+		res[topic.Name] = desc
+		req.Resources = append(req.Resources, kafka.DescribeConfigRequestResource{ResourceType: kafka.ResourceTypeTopic, ResourceName: topic.Name})
+	}
+	if len(req.Resources) == 0 {
+		return res, nil
+	}
+	configs, err := client.DescribeConfigs(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Admin.DescribeTopics: error describing configs: %w", err)
+	}
+	for _, resource := range configs.Resources {
+		desc, ok := res[resource.ResourceName]
+		if !ok {
+			continue
+		}
+		if resource.Error != nil {
+			return nil, fmt.Errorf("Admin.DescribeTopics: error describing configs of %v: %w", resource.ResourceName, resource.Error)
+		}
+		for _, entry := range resource.ConfigEntries {
+			desc.Config[entry.ConfigName] = entry.ConfigValue
+		}
+	}
+	return res, nil
+}
+
+// CreateTopics creates the topics as per their spec.
+func (a *Admin) CreateTopics(ctx context.Context, specs ...TopicSpec) error {
+	if len(specs) == 0 {
+		return nil
+	}
+	client, release := a.client()
+	defer release()
+	req := &kafka.CreateTopicsRequest{Topics: make([]kafka.TopicConfig, len(specs))}
+	for i, spec := range specs {
+		req.Topics[i] = kafka.TopicConfig{Topic: spec.Name, NumPartitions: -1, ReplicationFactor: -1}
+		if spec.Partitions > 0 {
+			req.Topics[i].NumPartitions = spec.Partitions
+		}
+		if spec.ReplicationFactor > 0 {
+			req.Topics[i].ReplicationFactor = spec.ReplicationFactor
+		}
+		for name, value := range spec.Config {
+			req.Topics[i].ConfigEntries = append(req.Topics[i].ConfigEntries, kafka.ConfigEntry{ConfigName: name, ConfigValue: value})
+		}
+	}
+	res, err := client.CreateTopics(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Admin.CreateTopics: %w", err)
+	}
+	if err := joinErrors(res.Errors); err != nil {
+		return fmt.Errorf("Admin.CreateTopics: %w", err)
+	}
+	return nil
+}
+
+// DeleteTopics deletes the topics.
+func (a *Admin) DeleteTopics(ctx context.Context, names ...string) error {
+	client, release := a.client()
+	defer release()
+	res, err := client.DeleteTopics(ctx, &kafka.DeleteTopicsRequest{Topics: names})
+	if err != nil {
+		return fmt.Errorf("Admin.DeleteTopics: %w", err)
+	}
+	if err := joinErrors(res.Errors); err != nil {
+		return fmt.Errorf("Admin.DeleteTopics: %w", err)
+	}
+	return nil
+}
+
+// AddPartitions increases the number of partitions of the topic to count.
+func (a *Admin) AddPartitions(ctx context.Context, topic string, count int) error {
+	client, release := a.client()
+	defer release()
+	res, err := client.CreatePartitions(ctx, &kafka.CreatePartitionsRequest{Topics: []kafka.TopicPartitionsConfig{{Name: topic, Count: int32(count)}}})
+	if err != nil {
+		return fmt.Errorf("Admin.AddPartitions: %w", err)
+	}
+	if err := joinErrors(res.Errors); err != nil {
+		return fmt.Errorf("Admin.AddPartitions: %w", err)
+	}
+	return nil
+}
+
+// AlterTopicConfig sets the configs of the topic, an empty value removes the config so that the broker default applies.
+func (a *Admin) AlterTopicConfig(ctx context.Context, topic string, config map[string]string) error {
+	client, release := a.client()
+	defer release()
+	resource := kafka.IncrementalAlterConfigsRequestResource{ResourceType: kafka.ResourceTypeTopic, ResourceName: topic}
+	for name, value := range config {
+		op := kafka.ConfigOperationSet
+		if value == "" {
+			op = kafka.ConfigOperationDelete
+		}
+		resource.Configs = append(resource.Configs, kafka.IncrementalAlterConfigsRequestConfig{Name: name, Value: value, ConfigOperation: op})
+	}
+	res, err := client.IncrementalAlterConfigs(ctx, &kafka.IncrementalAlterConfigsRequest{Resources: []kafka.IncrementalAlterConfigsRequestResource{resource}})
+	if err != nil {
+		return fmt.Errorf("Admin.AlterTopicConfig: %w", err)
+	}
+	for _, r := range res.Resources {
+		if r.Error != nil {
+			return fmt.Errorf("Admin.AlterTopicConfig: %w", r.Error)
+		}
+	}
+	return nil
+}
+
+// ListGroups returns the sorted IDs of the consumer groups of the cluster.
+func (a *Admin) ListGroups(ctx context.Context) ([]string, error) {
+	client, release := a.client()
+	defer release()
+	res, err := client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+	if err == nil {
+		err = res.Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Admin.ListGroups: %w", err)
+	}
+	ids := make([]string, 0, len(res.Groups))
+	for _, g := range res.Groups {
+		ids = append(ids, g.GroupID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// DescribeGroup describes the consumer group, its members, committed offsets and lag.
+func (a *Admin) DescribeGroup(ctx context.Context, groupID string) (*GroupDescription, error) {
+	client, release := a.client()
+	defer release()
+	groups, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupID}})
+	if err != nil {
+		return nil, fmt.Errorf("Admin.DescribeGroup: error describing group: %w", err)
+	}
+	res := &GroupDescription{GroupID: groupID, Offsets: map[string]map[int]int64{}, Lag: map[string]map[int]int64{}}
+	for _, group := range groups.Groups {
+		if group.Error != nil {
+			return nil, fmt.Errorf("Admin.DescribeGroup: error describing group: %w", group.Error)
+		}
+		res.State = group.GroupState
+		for _, m := range group.Members {
+			member := GroupMember{MemberID: m.MemberID, ClientID: m.ClientID, ClientHost: m.ClientHost, Assignment: map[string][]int{}}
+			for _, topic := range m.MemberAssignments.Topics {
+				member.Assignment[topic.Topic] = append(member.Assignment[topic.Topic], topic.Partitions...)
+			}
+			res.Members = append(res.Members, member)
+		}
+	}
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: groupID})
+	if err == nil && committed.Error != nil {
+		err = committed.Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Admin.DescribeGroup: error fetching committed offsets: %w", err)
+	}
+	req := &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{}}
+	for topic, partitions := range committed.Topics {
+		for _, p := range partitions {
+			if p.Error != nil || p.CommittedOffset < 0 {
+				continue
+			}
+			if res.Offsets[topic] == nil {
+				res.Offsets[topic] = map[int]int64{}
+			}
+			res.Offsets[topic][p.Partition] = p.CommittedOffset
+			req.Topics[topic] = append(req.Topics[topic], kafka.LastOffsetOf(p.Partition))
+		}
+	}
+	if len(req.Topics) == 0 {
+		return res, nil
+	}
+	hwm, err := client.ListOffsets(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Admin.DescribeGroup: error listing offsets: %w", err)
+	}
+	for topic, partitions := range hwm.Topics {
+		for _, p := range partitions {
+			offset, ok := res.Offsets[topic][p.Partition]
+			if !ok || p.Error != nil {
+				continue
+			}
+			if res.Lag[topic] == nil {
+				res.Lag[topic] = map[int]int64{}
+			}
+			res.Lag[topic][p.Partition] = max(p.LastOffset-offset, 0)
+		}
+	}
+	return res, nil
+}
+
+// DeleteGroups deletes the consumer groups and their committed offsets, the groups must have no members.
+func (a *Admin) DeleteGroups(ctx context.Context, groupIDs ...string) error {
+	client, release := a.client()
+	defer release()
+	res, err := client.DeleteGroups(ctx, &kafka.DeleteGroupsRequest{GroupIDs: groupIDs})
+	if err != nil {
+		return fmt.Errorf("Admin.DeleteGroups: %w", err)
+	}
+	if err := joinErrors(res.Errors); err != nil {
+		return fmt.Errorf("Admin.DeleteGroups: %w", err)
+	}
+	return nil
+}
+
+// ResetGroupOffsets commits the offsets, the next offset to read by partition, of the topic for the consumer group.
+// The group must have no active members.
+func (a *Admin) ResetGroupOffsets(ctx context.Context, groupID, topic string, offsets map[int]int64) error {
+	client, release := a.client()
+	defer release()
+	req := &kafka.OffsetCommitRequest{GroupID: groupID, GenerationID: -1, Topics: map[string][]kafka.OffsetCommit{}}
+	for partition, offset := range offsets {
+		req.Topics[topic] = append(req.Topics[topic], kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+	res, err := client.OffsetCommit(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Admin.ResetGroupOffsets: %w", err)
+	}
+	for _, partitions := range res.Topics {
+		for _, p := range partitions {
+			if p.Error != nil {
+				return fmt.Errorf("Admin.ResetGroupOffsets: error committing partition %v: %w", p.Partition, p.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// EnsureTopics creates the topics that do not exist as per their spec and checks the existing ones against it.
+// Partitions, replication factor and the configs of the spec are compared; the drift is logged with [DriftPolicyWarn]
+// and fails with [ErrTopicDrift] with [DriftPolicyFail]. Returns the drift found.
+func (a *Admin) EnsureTopics(ctx context.Context, policy string, specs ...TopicSpec) ([]TopicDrift, error) {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	topics, err := a.DescribeTopics(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("Admin.EnsureTopics: %w", err)
+	}
+	var missing []TopicSpec
+	var drift []TopicDrift
+	for _, spec := range specs {
+		topic, ok := topics[spec.Name]
+		if !ok {
+			missing = append(missing, spec)
+			continue
+		}
+		drift = append(drift, topicDrift(&spec, topic)...)
+	}
+	if len(missing) > 0 {
+		err = a.CreateTopics(ctx, missing...)
+		if err != nil {
+			return drift, fmt.Errorf("Admin.EnsureTopics: %w", err)
+		}
+		a.log.Notice(ctx, "topics created", missing)
+	}
+	if len(drift) == 0 {
+		return nil, nil
+	}
+	desc := make([]string, len(drift))
+	for i, d := range drift {
+		desc[i] = d.String()
+	}
+	if policy == DriftPolicyFail {
+		return drift, fmt.Errorf("Admin.EnsureTopics: %w: %v", ErrTopicDrift, desc)
+	}
+	a.log.Warning(ctx, "topics differ from spec", desc)
+	return drift, nil
+}
+
+// topicDrift compares the topic with the spec, zero values of the spec are not compared.
+func topicDrift(spec *TopicSpec, topic *TopicDescription) []TopicDrift {
+	var drift []TopicDrift
+	if spec.Partitions > 0 && spec.Partitions != topic.Partitions {
+		drift = append(drift, TopicDrift{Topic: spec.Name, Property: "partitions", Expected: strconv.Itoa(spec.Partitions), Actual: strconv.Itoa(topic.Partitions)})
+	}
+	if spec.ReplicationFactor > 0 && spec.ReplicationFactor != topic.ReplicationFactor {
+		drift = append(drift, TopicDrift{Topic: spec.Name, Property: "replicationFactor", Expected: strconv.Itoa(spec.ReplicationFactor), Actual: strconv.Itoa(topic.ReplicationFactor)})
+	}
+	names := make([]string, 0, len(spec.Config))
+	for name := range spec.Config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if actual := topic.Config[name]; actual != spec.Config[name] {
+			drift = append(drift, TopicDrift{Topic: spec.Name, Property: name, Expected: spec.Config[name], Actual: actual})
+		}
+	}
+	return drift
+}
+
+// joinErrors joins the errors of a response keyed by topic or group, nil entries are successes.
+func joinErrors(errs map[string]error) error {
+	keys := make([]string, 0, len(errs))
+	for key, err := range errs {
+		if err != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	res := make([]error, len(keys))
+	for i, key := range keys {
+		res[i] = fmt.Errorf("%v: %w", key, errs[key])
+	}
+	return errors.Join(res...)
+}
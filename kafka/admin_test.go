@@ -0,0 +1,88 @@
+package kafka_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	ckafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestAdminTopics(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithAutoCreateTopics(false))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	admin, err := kafka.NewAdmin(kafka.WithAdminCredConfig(broker.CredConfig()))
+	assert.NilError(t, err)
+	specs := []kafka.TopicSpec{
+		{Name: "orders", Partitions: 3, ReplicationFactor: 1, Config: map[string]string{"retention.ms": "86400000"}},
+		{Name: "orders.dlq"},
+	}
+	drift, err := admin.EnsureTopics(ctx, kafka.DriftPolicyFail, specs...)
+	assert.NilError(t, err)
+	assert.Equal(t, len(drift), 0)
+	topics, err := admin.ListTopics(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, topics, []string{"orders", "orders.dlq"})
+	desc, err := admin.DescribeTopics(ctx, "orders", "missing")
+	assert.NilError(t, err)
+	assert.Equal(t, len(desc), 1)
+	assert.Equal(t, desc["orders"].Partitions, 3)
+	assert.Equal(t, desc["orders"].ReplicationFactor, 1)
+	assert.Equal(t, desc["orders"].Config["retention.ms"], "86400000")
+
+	drift, err = admin.EnsureTopics(ctx, kafka.DriftPolicyFail, specs...)
+	assert.NilError(t, err)
+	assert.Equal(t, len(drift), 0)
+	assert.NilError(t, admin.AlterTopicConfig(ctx, "orders", map[string]string{"retention.ms": "3600000"}))
+	specs[0].Partitions = 4
+	drift, err = admin.EnsureTopics(ctx, kafka.DriftPolicyFail, specs...)
+	assert.Assert(t, errors.Is(err, kafka.ErrTopicDrift))
+	assert.DeepEqual(t, drift, []kafka.TopicDrift{
+		{Topic: "orders", Property: "partitions", Expected: "4", Actual: "3"},
+		{Topic: "orders", Property: "retention.ms", Expected: "86400000", Actual: "3600000"},
+	})
+	drift, err = admin.EnsureTopics(ctx, kafka.DriftPolicyWarn, specs...)
+	assert.NilError(t, err)
+	assert.Equal(t, len(drift), 2)
+
+	assert.NilError(t, admin.AddPartitions(ctx, "orders", 4))
+	assert.NilError(t, admin.AlterTopicConfig(ctx, "orders", map[string]string{"retention.ms": "86400000"}))
+	drift, err = admin.EnsureTopics(ctx, kafka.DriftPolicyFail, specs...)
+	assert.NilError(t, err)
+	assert.Equal(t, len(drift), 0)
+	assert.Assert(t, errors.Is(admin.CreateTopics(ctx, specs[1]), ckafka.TopicAlreadyExists))
+	assert.NilError(t, admin.DeleteTopics(ctx, "orders.dlq"))
+	topics, err = admin.ListTopics(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, topics, []string{"orders"})
+}
+
+func TestAdminGroups(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 2))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	for i := 0; i < 5; i++ {
+		assert.NilError(t, broker.Produce("orders", ckafka.Message{Partition: i % 2, Value: []byte("order")}))
+	}
+	admin, err := kafka.NewAdmin(kafka.WithAdminCredConfig(broker.CredConfig()))
+	assert.NilError(t, err)
+	assert.NilError(t, admin.ResetGroupOffsets(ctx, "orders-group", "orders", map[int]int64{0: 1, 1: 2}))
+	assert.Equal(t, broker.CommittedOffset("orders-group", "orders", 0), int64(1))
+	groups, err := admin.ListGroups(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, groups, []string{"orders-group"})
+	group, err := admin.DescribeGroup(ctx, "orders-group")
+	assert.NilError(t, err)
+	assert.Equal(t, group.State, "Empty")
+	assert.DeepEqual(t, group.Offsets, map[string]map[int]int64{"orders": {0: 1, 1: 2}})
+	assert.DeepEqual(t, group.Lag, map[string]map[int]int64{"orders": {0: 2, 1: 0}})
+	assert.NilError(t, admin.DeleteGroups(ctx, "orders-group"))
+	groups, err = admin.ListGroups(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 0)
+}
@@ -0,0 +1,375 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrConsumerUnhealthy is wrapped by the error of [Poller.HealthCheck] when the lag or the time without a message
+// crosses the configured threshold.
+var ErrConsumerUnhealthy = errors.New("kafka: consumer unhealthy")
+
+// PartitionInfo is the consumption state of a partition assigned to the Poller.
+type PartitionInfo struct {
+	Committed     int64     // Last committed offset, the offset of the next message to read; -1 when unknown
+	Fetched       int64     // Offset of the last fetched message, -1 when none is fetched
+	HighWaterMark int64     // High-water mark as of the last fetch or refresh, -1 when unknown
+	Lag           int64     // Number of messages between the next message to read and the high-water mark
+	LastFetch     time.Time // Time the last message was fetched, zero when none is fetched
+	AssignedAt    time.Time // Time the partition was first seen assigned to the Poller
+}
+
+// PollerStatus is the status of the Poller reported by StatusCheck.
+type PollerStatus struct {
+	Partitions        map[string]map[int]PartitionInfo // State of every assigned partition
+	Rebalances        int64                            // Number of consumer group generations joined
+	LastRebalance     time.Time                        // Time the last rebalance was observed
+	AssignmentChanges int64                            // Number of times the set of assigned partitions changed
+	LastMessage       time.Time                        // Time the last message was fetched, zero when none is fetched
+	InFlight          int                              // Messages handed out but not yet committable in CommitModeAck
+	Pause             PauseStatus                      // Paused state of the Poller
+	Reader            kafka.ReaderStats                // Reader stats of the last refresh, counters cover the last interval
+	RefreshError      string                           // Error of the last refresh from the broker, empty on success
+}
+
+// partitionState tracks the partitions assigned to the Poller.
+type partitionState struct {
+	lock              sync.Mutex
+	partitions        map[string]map[int]*PartitionInfo
+	rebalances        int64
+	lastRebalance     time.Time
+	assignmentChanges int64
+	lastMessage       time.Time
+	stats             kafka.ReaderStats
+	refreshErr        error
+}
+
+// newPartitionState creates an empty partitionState.
+func newPartitionState() *partitionState {
+	return &partitionState{partitions: map[string]map[int]*PartitionInfo{}}
+}
+
+// get returns the state of the partition, adding it as assigned when it is not known.
+// Must be called with the lock held.
+func (p *partitionState) get(topic string, partition int, now time.Time) (*PartitionInfo, bool) {
+	pMap, ok := p.partitions[topic]
+	if !ok {
+		pMap = map[int]*PartitionInfo{}
+		p.partitions[topic] = pMap
+	}
+	info, ok := pMap[partition]
+	if !ok {
+		info = &PartitionInfo{Committed: -1, Fetched: -1, HighWaterMark: -1, AssignedAt: now}
+		pMap[partition] = info
+	}
+	return info, !ok
+}
+
+// updateLag computes the lag of the partition from the next offset to read and the high-water mark.
+func (info *PartitionInfo) updateLag() {
+	next := info.Committed
+	if info.Fetched >= 0 {
+		next = info.Fetched + 1
+	}
+	if next < 0 || info.HighWaterMark < 0 {
+		return
+	}
+	info.Lag = max(info.HighWaterMark-next, 0)
+}
+
+// recordFetch stores the offset, high-water mark and fetch time of the partition of the fetched message.
+func (k *Poller) recordFetch(ctx context.Context, msg *kafka.Message) {
+	k.partitions.lock.Lock()
+	now := time.Now()
+	info, added := k.partitions.get(msg.Topic, msg.Partition, now)
+	info.Fetched = msg.Offset
+	info.HighWaterMark = msg.HighWaterMark
+	info.LastFetch = now
+	info.updateLag()
+	k.partitions.lastMessage = now
+	k.partitions.lock.Unlock()
+	if added {
+		k.log.Notice(ctx, "partition assigned", map[string]any{"topic": msg.Topic, "partition": msg.Partition})
+	}
+}
+
+// recordCommit stores the committed offsets.
+func (k *Poller) recordCommit(offsets OffsetMap) {
+	k.partitions.lock.Lock()
+	defer k.partitions.lock.Unlock()
+	now := time.Now()
+	for topic, pMap := range offsets {
+		for partition, offset := range pMap {
+			info, _ := k.partitions.get(topic, partition, now)
+			info.Committed = offset
+			info.updateLag()
+		}
+	}
+}
+
+// PartitionLag returns the lag of every assigned partition, the number of messages between the next message to read and the high-water mark.
+func (k *Poller) PartitionLag() map[string]map[int]int64 {
+	k.partitions.lock.Lock()
+	defer k.partitions.lock.Unlock()
+	res := make(map[string]map[int]int64, len(k.partitions.partitions))
+	for topic, pMap := range k.partitions.partitions {
+		res[topic] = make(map[int]int64, len(pMap))
+		for partition, info := range pMap {
+			res[topic][partition] = info.Lag
+		}
+	}
+	return res
+}
+
+// Status returns the partition assignment, offsets, lag and rebalance counts of the Poller.
+func (k *Poller) Status() *PollerStatus {
+	status := &PollerStatus{
+		InFlight: k.InFlight(),
+		Pause:    k.PauseStatus(),
+	}
+	k.partitions.lock.Lock()
+	defer k.partitions.lock.Unlock()
+	status.Partitions = make(map[string]map[int]PartitionInfo, len(k.partitions.partitions))
+	for topic, pMap := range k.partitions.partitions {
+		status.Partitions[topic] = make(map[int]PartitionInfo, len(pMap))
+		for partition, info := range pMap {
+			status.Partitions[topic][partition] = *info
+		}
+	}
+	status.Rebalances = k.partitions.rebalances
+	status.LastRebalance = k.partitions.lastRebalance
+	status.AssignmentChanges = k.partitions.assignmentChanges
+	status.LastMessage = k.partitions.lastMessage
+	status.Reader = k.partitions.stats
+	if k.partitions.refreshErr != nil {
+		status.RefreshError = k.partitions.refreshErr.Error()
+	}
+	return status
+}
+
+// StatusCheck returns the status of the Poller.
+func (k *Poller) StatusCheck(ctx context.Context) (any, error) {
+	return k.Status(), nil
+}
+
+// HealthCheck fails when the lag of a partition exceeds [ConsumerConfig.MaxLag] or when no message is fetched
+// for [ConsumerConfig.MaxIdle] since the last message or the assignment of the partitions.
+// Topics paused with Pause are not checked for lag and the idle check is skipped while the Poller is paused.
+// The returned error wraps [ErrConsumerUnhealthy].
+func (k *Poller) HealthCheck(ctx context.Context) error {
+	pause := k.PauseStatus()
+	paused := make(map[string]bool, len(pause.Topics))
+	for _, topic := range pause.Topics {
+		paused[topic] = true
+	}
+	k.partitions.lock.Lock()
+	defer k.partitions.lock.Unlock()
+	var assignedAt time.Time
+	for topic, pMap := range k.partitions.partitions {
+		for partition, info := range pMap {
+			if assignedAt.IsZero() || info.AssignedAt.Before(assignedAt) {
+				assignedAt = info.AssignedAt
+			}
+			if k.config.MaxLag > 0 && !pause.Paused && !paused[topic] && info.Lag > k.config.MaxLag {
+				return fmt.Errorf("Poller.HealthCheck: lag %v of partition %v of %v exceeds %v: %w", info.Lag, partition, topic, k.config.MaxLag, ErrConsumerUnhealthy)
+			}
+		}
+	}
+	if k.config.MaxIdle == 0 || pause.Paused || assignedAt.IsZero() {
+		return nil
+	}
+	since := k.partitions.lastMessage
+	if since.IsZero() {
+		since = assignedAt
+	}
+	idle := time.Since(since)
+	if idle > time.Duration(k.config.MaxIdle)*time.Millisecond {
+		return fmt.Errorf("Poller.HealthCheck: no message fetched for %v: %w", idle.Truncate(time.Millisecond), ErrConsumerUnhealthy)
+	}
+	return nil
+}
+
+// refreshLoop refreshes the reader stats and, when the Poller created the reader, the partition assignment,
+// committed offsets and high-water marks from the broker every [ConsumerConfig.StatsInterval].
+func (k *Poller) refreshLoop(ctx context.Context, client *kafka.Client) {
+	defer k.wg.Done()
+	interval := time.Duration(k.config.StatsInterval) * time.Millisecond
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			k.refreshStats(ctx)
+			if client == nil {
+				continue
+			}
+			refreshCtx, cancel := context.WithTimeout(ctx, interval)
+			err := k.refresh(refreshCtx, client)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				k.log.Warning(ctx, "error refreshing partition assignment", err)
+			}
+			k.partitions.lock.Lock()
+			k.partitions.refreshErr = err
+			k.partitions.lock.Unlock()
+		}
+	}
+}
+
+// refreshStats takes the stats of the reader and counts the rebalances since the last refresh.
+// The counters of [kafka.Reader.Stats] are reset on every call.
+func (k *Poller) refreshStats(ctx context.Context) {
+	stats := k.Stats()
+	k.partitions.lock.Lock()
+	k.partitions.stats = stats
+	k.partitions.rebalances += stats.Rebalances
+	total := k.partitions.rebalances
+	if stats.Rebalances > 0 {
+		k.partitions.lastRebalance = time.Now()
+	}
+	k.partitions.lock.Unlock()
+	if stats.Rebalances > 0 {
+		k.log.Notice(ctx, "consumer group rebalanced", map[string]any{"groupId": k.config.GroupID, "rebalances": total})
+	}
+}
+
+// refresh loads the partitions assigned to the member of the Poller, their committed offsets and high-water marks from the broker.
+// When the Poller joins the consumer group itself, the partitions are the ones assigned by the current generation.
+func (k *Poller) refresh(ctx context.Context, client *kafka.Client) error {
+	var assigned map[string][]int
+	if k.group != nil {
+		assigned = k.assignedPartitions()
+	} else {
+		var err error
+		assigned, err = k.describeAssignment(ctx, client)
+		if err != nil {
+			return err
+		}
+		k.assign(ctx, assigned)
+	}
+	if len(assigned) == 0 {
+		return nil
+	}
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: k.config.GroupID, Topics: assigned})
+	if err == nil && committed.Error != nil {
+		err = committed.Error
+	}
+	if err != nil {
+		return fmt.Errorf("Poller.refresh: error fetching committed offsets: %w", err)
+	}
+	req := &kafka.ListOffsetsRequest{Topics: make(map[string][]kafka.OffsetRequest, len(assigned))}
+	if k.config.ReadCommitted {
+		req.IsolationLevel = kafka.ReadCommitted
+	}
+	for topic, partitions := range assigned {
+		for _, partition := range partitions {
+			req.Topics[topic] = append(req.Topics[topic], kafka.LastOffsetOf(partition))
+		}
+	}
+	hwm, err := client.ListOffsets(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Poller.refresh: error listing offsets: %w", err)
+	}
+	k.partitions.lock.Lock()
+	defer k.partitions.lock.Unlock()
+	for topic, pMap := range k.partitions.partitions {
+		for _, p := range committed.Topics[topic] {
+			if info, ok := pMap[p.Partition]; ok && p.Error == nil && p.CommittedOffset >= 0 {
+				info.Committed = p.CommittedOffset
+			}
+		}
+		for _, p := range hwm.Topics[topic] {
+			if info, ok := pMap[p.Partition]; ok && p.Error == nil {
+				info.HighWaterMark = p.LastOffset
+			}
+		}
+		for _, info := range pMap {
+			info.updateLag()
+		}
+	}
+	return nil
+}
+
+// describeAssignment loads the partitions assigned to the member of the Poller by describing the consumer group.
+func (k *Poller) describeAssignment(ctx context.Context, client *kafka.Client) (map[string][]int, error) {
+	groups, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{k.config.GroupID}})
+	if err != nil {
+		return nil, fmt.Errorf("Poller.refresh: error describing group: %w", err)
+	}
+	assigned := map[string][]int{}
+	for _, group := range groups.Groups {
+		if group.Error != nil {
+			return nil, fmt.Errorf("Poller.refresh: error describing group: %w", group.Error)
+		}
+		for _, member := range group.Members {
+			if member.ClientID != k.clientID {
+				continue
+			}
+			for _, topic := range member.MemberAssignments.Topics {
+				assigned[topic.Topic] = append(assigned[topic.Topic], topic.Partitions...)
+			}
+		}
+	}
+	return assigned, nil
+}
+
+// assign replaces the assigned partitions, logging and counting the change when the assignment differs.
+// The tracked messages and the offsets stored for commit of revoked partitions are dropped.
+func (k *Poller) assign(ctx context.Context, assigned map[string][]int) {
+	k.partitions.lock.Lock()
+	now := time.Now()
+	var added, revoked []string
+	revokedPartitions := map[string][]int{}
+	for topic, partitions := range assigned {
+		for _, partition := range partitions {
+			if _, isNew := k.partitions.get(topic, partition, now); isNew {
+				added = append(added, fmt.Sprintf("%v[%v]", topic, partition))
+			}
+		}
+	}
+	for topic, pMap := range k.partitions.partitions {
+		for partition := range pMap {
+			found := false
+			for _, p := range assigned[topic] {
+				if p == partition {
+					found = true
+					break
+				}
+			}
+			if !found {
+				delete(pMap, partition)
+				revoked = append(revoked, fmt.Sprintf("%v[%v]", topic, partition))
+				revokedPartitions[topic] = append(revokedPartitions[topic], partition)
+			}
+		}
+		if len(pMap) == 0 {
+			delete(k.partitions.partitions, topic)
+		}
+	}
+	if len(added) == 0 && len(revoked) == 0 {
+		k.partitions.lock.Unlock()
+		return
+	}
+	k.partitions.assignmentChanges++
+	k.partitions.lock.Unlock()
+	// The new owner of a revoked partition resumes from its committed offset, the offsets of this member are stale
+	for topic, partitions := range revokedPartitions {
+		for _, partition := range partitions {
+			if k.tracker != nil {
+				k.tracker.Reset(topic, partition)
+			}
+			k.dropOffset(topic, partition)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(revoked)
+	k.log.Notice(ctx, "partition assignment changed", map[string]any{"assigned": added, "revoked": revoked})
+}
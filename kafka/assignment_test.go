@@ -0,0 +1,62 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	cKafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestPollerStatusUnassigned(t *testing.T) {
+	ctx := GetCorrelationContext()
+	co, err := kafka.NewPoller(
+		kafka.WithConsumerLogger(KafkaTestLogger),
+		kafka.WithReader(cKafka.NewReader(cKafka.ReaderConfig{Brokers: []string{"127.0.0.1:1"}, GroupID: "gobase.test.status", Topic: "gobase.test.status", MaxAttempts: 1})),
+		kafka.WithMaxIdle(1),
+		kafka.WithMaxLag(1),
+		kafka.WithStatsInterval(10),
+	)
+	assert.NilError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	status, err := co.StatusCheck(ctx)
+	assert.NilError(t, err)
+	pollerStatus := status.(*kafka.PollerStatus)
+	assert.Equal(t, len(pollerStatus.Partitions), 0)
+	assert.Equal(t, pollerStatus.RefreshError, "")
+	assert.Assert(t, pollerStatus.LastMessage.IsZero())
+	assert.NilError(t, co.HealthCheck(ctx), "idle check is skipped without assigned partitions")
+	assert.NilError(t, co.Close(ctx))
+}
+
+func TestKafkaPollerStatus(t *testing.T) {
+	ctx := GetCorrelationContext()
+	co, err := kafka.NewPoller(
+		kafka.WithConsumerTopic([]string{KafkaTestConfig.KafkaTestTopic}),
+		kafka.WithMaxLag(1<<40),
+		kafka.WithMaxIdle(100),
+		kafka.WithStatsInterval(500),
+	)
+	assert.NilError(t, err)
+	defer co.Close(ctx)
+	pr, err := kafka.NewProducer(kafka.WithProducerTopic(KafkaTestConfig.KafkaTestTopic))
+	assert.NilError(t, err)
+	defer pr.Close(ctx)
+	assert.NilError(t, pr.Produce(ctx, KafkaTestConfig.KafkaTestTopic, "key", []byte("value"), nil))
+	assert.NilError(t, pr.Flush(ctx))
+	ch := make(chan *cKafka.Message, 100)
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*45)
+	defer cancel()
+	go co.Poll(tCtx, ch)
+	msg := <-ch
+	status := co.Status()
+	info := status.Partitions[msg.Topic][msg.Partition]
+	assert.Equal(t, info.Fetched, msg.Offset)
+	assert.Equal(t, info.HighWaterMark, msg.HighWaterMark)
+	assert.Assert(t, !status.LastMessage.IsZero())
+	co.Pause()
+	time.Sleep(200 * time.Millisecond)
+	assert.NilError(t, co.HealthCheck(ctx), "idle check is skipped while paused")
+}
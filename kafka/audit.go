@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sabariramc/goserverbase/v6/log/audit"
+)
+
+// AuditWriter writes audit records to a topic with a [Producer], implements [log.AuditLogWriter].
+//
+// The records are keyed by the user ID of the actor so that the records of a user stay in order, and WriteMessage
+// waits until the record is delivered to the broker.
+type AuditWriter struct {
+	producer *Producer
+	topic    string
+}
+
+// NewAuditWriter creates a new AuditWriter that writes to the topic.
+func NewAuditWriter(producer *Producer, topic string) *AuditWriter {
+	return &AuditWriter{producer: producer, topic: topic}
+}
+
+// WriteMessage writes the message as a record, see [audit.FromMessage], and waits for its delivery.
+func (a *AuditWriter) WriteMessage(ctx context.Context, msg interface{}) error {
+	rec := audit.FromMessage(ctx, msg)
+	blob, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("AuditWriter.WriteMessage: error marshalling record: %w", err)
+	}
+	delivery, err := a.producer.ProduceWithDelivery(ctx, a.topic, rec.Actor.UserID, blob, nil)
+	if err != nil {
+		return fmt.Errorf("AuditWriter.WriteMessage: %w", err)
+	}
+	if err := delivery.Wait(ctx); err != nil {
+		return fmt.Errorf("AuditWriter.WriteMessage: %w", err)
+	}
+	return nil
+}
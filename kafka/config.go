@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/kafka/spool"
 	"github.com/sabariramc/goserverbase/v6/log"
 	"github.com/sabariramc/goserverbase/v6/utils"
 	"github.com/segmentio/kafka-go"
@@ -14,6 +15,13 @@ import (
 const (
 	ModuleProducer = "KafkaProducer"
 	ModuleConsumer = "KafkaConsumer"
+	ModuleAdmin    = "KafkaAdmin"
+)
+
+// Commit modes of the consumer.
+const (
+	CommitModePoll = "POLL" // Offset is stored as soon as the message is handed to the channel, fire and forget
+	CommitModeAck  = "ACK"  // Offset is stored once the message and every message before it in the partition is acknowledged
 )
 
 // CredConfig holds the configuration for Kafka credentials and connection details.
@@ -37,17 +45,27 @@ func GetDefaultCredConfig() *CredConfig {
 
 // ProducerConfig holds the configuration for a Kafka producer.
 type ProducerConfig struct {
-	*CredConfig                     // Embeds CredConfig for credential and connection details.
-	RequiredAcks      int           // Number of acknowledgments required from Kafka.
-	MaxBuffer         int           // Maximum buffer size for the producer.
-	AutoFlushInterval uint64        // Interval in milliseconds to auto flush messages.
-	Async             bool          // Flag to indicate if the producer should work asynchronously.
-	Batch             bool          // Flag to indicate if messages should be batched.
-	Topic             string        // Kafka topic to produce messages to.
-	ModuleName        string        // Name of the module for logging.
-	Log               log.Log       // Logger instance.
-	Trace             ProduceTracer // Tracer for producing messages.
-	Writer            *kafka.Writer // Writer for producing messages.
+	*CredConfig                        // Embeds CredConfig for credential and connection details.
+	RequiredAcks      int              // Number of acknowledgments required from Kafka.
+	MaxBuffer         int              // Maximum buffer size for the producer.
+	AutoFlushInterval uint64           // Interval in milliseconds to auto flush messages.
+	Async             bool             // Flag to indicate if the producer should work asynchronously.
+	Batch             bool             // Flag to indicate if messages should be batched.
+	Topic             string           // Kafka topic to produce messages to.
+	ModuleName        string           // Name of the module for logging.
+	Log               log.Log          // Logger instance.
+	Trace             ProduceTracer    // Tracer for producing messages.
+	Writer            *kafka.Writer    // Writer for producing messages.
+	MaxRetries        int              // Number of times a failed message is written again before it is reported as failed.
+	RetryBackoff      uint64           // Interval in milliseconds before a retry, multiplied by the attempt number.
+	OnDelivery        DeliveryCallback // Called with the result of every message.
+	OnFailure         FailureHook      // Called with the messages that failed after the retries, e.g. to spill them to disk or an outbox.
+	Spool             *spool.Spool     // Disk spool keeping the messages that failed till the broker is reachable, closed with the producer.
+	ReplayInterval    uint64           // Interval in milliseconds between replays of the spool.
+	Idempotent        bool             // TxnProducer writes with a producer ID and sequence numbers so the broker drops duplicated retries.
+	TransactionalID   string           // Transactional ID of TxnProducer, enables transactions and implies Idempotent.
+	TxnTimeout        uint64           // Transaction timeout in milliseconds of TxnProducer.
+	Fallback          bool             // TxnProducer falls back to plain writes when the broker does not support idempotence or transactions.
 }
 
 func ValidateProducerConfig(config *ProducerConfig) error {
@@ -65,6 +83,9 @@ func ValidateProducerConfig(config *ProducerConfig) error {
 			config.AutoFlushInterval = 1000
 		}
 	}
+	if config.Spool != nil && config.ReplayInterval <= 0 {
+		config.ReplayInterval = 5000
+	}
 	return nil
 }
 
@@ -76,6 +97,13 @@ func ValidateProducerConfig(config *ProducerConfig) error {
 	- KAFKA__PRODUCER__AUTO_FLUSH_INTERVAL: Sets [AutoFlushInterval]
 	- KAFKA__PRODUCER__ASYNC: Sets [Async]
 	- KAFKA__PRODUCER__BATCH: Sets [Batch]
+	- KAFKA__PRODUCER__MAX_RETRIES: Sets [MaxRetries]
+	- KAFKA__PRODUCER__RETRY_BACKOFF: Sets [RetryBackoff]
+	- KAFKA__PRODUCER__SPOOL__REPLAY_INTERVAL: Sets [ReplayInterval]
+	- KAFKA__PRODUCER__IDEMPOTENT: Sets [Idempotent]
+	- KAFKA__PRODUCER__TRANSACTIONAL_ID: Sets [TransactionalID]
+	- KAFKA__PRODUCER__TXN_TIMEOUT: Sets [TxnTimeout]
+	- KAFKA__PRODUCER__FALLBACK: Sets [Fallback]
 */
 func GetDefaultProducerConfig() *ProducerConfig {
 	config := &ProducerConfig{
@@ -85,6 +113,13 @@ func GetDefaultProducerConfig() *ProducerConfig {
 		AutoFlushInterval: uint64(utils.GetEnvInt(env.KafkaProducerAutoFlushInterval, 1000)),
 		Async:             utils.GetEnvBool(env.KafkaProducerAsync, true),
 		Batch:             utils.GetEnvBool(env.KafkaProducerBatch, false),
+		MaxRetries:        utils.GetEnvInt(env.KafkaProducerMaxRetries, 3),
+		RetryBackoff:      uint64(utils.GetEnvInt(env.KafkaProducerRetryBackoff, 100)),
+		ReplayInterval:    uint64(utils.GetEnvInt(env.KafkaProducerSpoolReplayInterval, 5000)),
+		Idempotent:        utils.GetEnvBool(env.KafkaProducerIdempotent, false),
+		TransactionalID:   utils.GetEnv(env.KafkaProducerTransactionalID, ""),
+		TxnTimeout:        uint64(utils.GetEnvInt(env.KafkaProducerTxnTimeout, 60000)),
+		Fallback:          utils.GetEnvBool(env.KafkaProducerFallback, true),
 		Log:               log.New(log.WithModuleName(ModuleProducer)),
 		ModuleName:        ModuleProducer,
 	}
@@ -171,6 +206,76 @@ func WithWriter(writer *kafka.Writer) ProducerOption {
 	}
 }
 
+// WithMaxRetries sets the number of times a failed message is written again for kafka producer.
+func WithMaxRetries(retries int) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.MaxRetries = retries
+	}
+}
+
+// WithRetryBackoff sets the retry backoff in milliseconds for kafka producer.
+func WithRetryBackoff(backoff uint64) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.RetryBackoff = backoff
+	}
+}
+
+// WithDeliveryCallback sets the callback called with the result of every message for kafka producer.
+func WithDeliveryCallback(callback DeliveryCallback) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.OnDelivery = callback
+	}
+}
+
+// WithFailureHook sets the hook called with the messages that failed after the retries for kafka producer.
+func WithFailureHook(hook FailureHook) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.OnFailure = hook
+	}
+}
+
+// WithSpool sets the disk spool for kafka producer.
+func WithSpool(s *spool.Spool) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.Spool = s
+	}
+}
+
+// WithSpoolReplayInterval sets the interval in milliseconds between replays of the spool for kafka producer.
+func WithSpoolReplayInterval(interval uint64) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.ReplayInterval = interval
+	}
+}
+
+// WithIdempotent sets the idempotent flag for kafka transactional producer.
+func WithIdempotent(idempotent bool) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.Idempotent = idempotent
+	}
+}
+
+// WithTransactionalID sets the transactional ID for kafka transactional producer.
+func WithTransactionalID(id string) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.TransactionalID = id
+	}
+}
+
+// WithTxnTimeout sets the transaction timeout in milliseconds for kafka transactional producer.
+func WithTxnTimeout(timeout uint64) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.TxnTimeout = timeout
+	}
+}
+
+// WithFallback sets whether kafka transactional producer falls back to plain writes when the broker does not support it.
+func WithFallback(fallback bool) ProducerOption {
+	return func(c *ProducerConfig) {
+		c.Fallback = fallback
+	}
+}
+
 // ConsumerConfig represents the configuration for a Kafka consumer.
 type ConsumerConfig struct {
 	*CredConfig                       // Embeds CredConfig for credential and connection details.
@@ -178,6 +283,11 @@ type ConsumerConfig struct {
 	AutoCommit         bool           // Flag to enable auto commit for consumed messages
 	MaxBuffer          uint           // Count of message for batch commit
 	AutoCommitInterval uint64         // Interval in milliseconds to auto commit messages.
+	CommitMode         string         // Commit mode, [CommitModePoll] or [CommitModeAck]
+	ReadCommitted      bool           // Read only committed messages of transactional producers
+	MaxLag             int64          // Lag of a partition above which the health check fails, 0 disables the check
+	MaxIdle            uint64         // Milliseconds without a message after which the health check fails, 0 disables the check
+	StatsInterval      uint64         // Interval in milliseconds to refresh the partition assignment, offsets and reader stats, 0 disables the refresh
 	Log                log.Log        // Logger instance
 	Trace              ConsumerTracer // Tracer for consuming messages
 	Reader             *kafka.Reader  // Reader for consuming messages
@@ -193,6 +303,13 @@ func ValidateConsumerConfig(config *ConsumerConfig) error {
 	if config.AutoCommitInterval <= 0 {
 		config.AutoCommitInterval = 1000
 	}
+	switch config.CommitMode {
+	case "":
+		config.CommitMode = CommitModePoll
+	case CommitModePoll, CommitModeAck:
+	default:
+		return fmt.Errorf("ValidateConsumerConfig: invalid commit mode `%v`", config.CommitMode)
+	}
 	return nil
 }
 
@@ -205,6 +322,11 @@ func ValidateConsumerConfig(config *ConsumerConfig) error {
 	- KAFKA__CONSUMER__AUTO_COMMIT: Sets [AutoCommit]
 	- KAFKA__CONSUMER__MAX_BUFFER: Sets [MaxBuffer]
 	- KAFKA__CONSUMER__AUTO_COMMIT_INTERVAL: Sets [AutoCommitInterval]
+	- KAFKA__CONSUMER__COMMIT_MODE: Sets [CommitMode]
+	- KAFKA__CONSUMER__READ_COMMITTED: Sets [ReadCommitted]
+	- KAFKA__CONSUMER__MAX_LAG: Sets [MaxLag]
+	- KAFKA__CONSUMER__MAX_IDLE: Sets [MaxIdle]
+	- KAFKA__CONSUMER__STATS_INTERVAL: Sets [StatsInterval]
 */
 func GetDefaultConsumerConfig() *ConsumerConfig {
 	// Default configuration
@@ -214,6 +336,11 @@ func GetDefaultConsumerConfig() *ConsumerConfig {
 		AutoCommit:         utils.GetEnvBool(env.KafkaConsumerAutoCommit, true),
 		MaxBuffer:          uint(utils.GetEnvInt(env.KafkaConsumerMaxBuffer, 100)),
 		AutoCommitInterval: uint64(utils.GetEnvInt(env.KafkaConsumerAutoCommitInterval, 1000)),
+		CommitMode:         utils.GetEnv(env.KafkaConsumerCommitMode, CommitModePoll),
+		ReadCommitted:      utils.GetEnvBool(env.KafkaConsumerReadCommitted, false),
+		MaxLag:             int64(utils.GetEnvInt(env.KafkaConsumerMaxLag, 0)),
+		MaxIdle:            uint64(utils.GetEnvInt(env.KafkaConsumerMaxIdle, 0)),
+		StatsInterval:      uint64(utils.GetEnvInt(env.KafkaConsumerStatsInterval, 10000)),
 		Log:                log.New(log.WithModuleName(ModuleConsumer)),
 		Topics:             utils.GetEnvAsSlice(env.KafkaConsumerTopics, []string{}, ","),
 		ModuleName:         ModuleConsumer,
@@ -261,6 +388,41 @@ func WithAutoCommitInterval(intervalInMs uint64) ConsumerOption {
 	}
 }
 
+// WithCommitMode sets the commit mode for the Kafka consumer.
+func WithCommitMode(mode string) ConsumerOption {
+	return func(config *ConsumerConfig) {
+		config.CommitMode = mode
+	}
+}
+
+// WithReadCommitted sets the read committed isolation level for the Kafka consumer.
+func WithReadCommitted(readCommitted bool) ConsumerOption {
+	return func(config *ConsumerConfig) {
+		config.ReadCommitted = readCommitted
+	}
+}
+
+// WithMaxLag sets the lag of a partition above which the health check of the Kafka consumer fails.
+func WithMaxLag(lag int64) ConsumerOption {
+	return func(config *ConsumerConfig) {
+		config.MaxLag = lag
+	}
+}
+
+// WithMaxIdle sets the milliseconds without a message after which the health check of the Kafka consumer fails.
+func WithMaxIdle(idleInMs uint64) ConsumerOption {
+	return func(config *ConsumerConfig) {
+		config.MaxIdle = idleInMs
+	}
+}
+
+// WithStatsInterval sets the interval to refresh the partition assignment, offsets and stats of the Kafka consumer.
+func WithStatsInterval(intervalInMs uint64) ConsumerOption {
+	return func(config *ConsumerConfig) {
+		config.StatsInterval = intervalInMs
+	}
+}
+
 // WithConsumerLogger sets the logger for the Kafka consumer.
 func WithConsumerLogger(logger log.Log) ConsumerOption {
 	return func(config *ConsumerConfig) {
@@ -302,3 +464,49 @@ func WithConsumerServiceName(name string) ConsumerOption {
 		c.ClientId = name
 	}
 }
+
+// AdminConfig holds the configuration for the Kafka admin.
+type AdminConfig struct {
+	*CredConfig         // Embeds CredConfig for credential and connection details.
+	Timeout     uint64  // Timeout in milliseconds of the admin requests
+	Log         log.Log // Logger instance
+	ModuleName  string  // Name of the module for logging.
+}
+
+// GetDefaultAdminConfig creates a new AdminConfig with values from environment variables or default values.
+/*
+	Environment Variables
+	- KAFKA__ADMIN__TIMEOUT: Sets [Timeout]
+*/
+func GetDefaultAdminConfig() *AdminConfig {
+	return &AdminConfig{
+		CredConfig: GetDefaultCredConfig(),
+		Timeout:    uint64(utils.GetEnvInt(env.KafkaAdminTimeout, 30000)),
+		Log:        log.New(log.WithModuleName(ModuleAdmin)),
+		ModuleName: ModuleAdmin,
+	}
+}
+
+// AdminOption defines a function signature for applying options for kafka admin.
+type AdminOption func(*AdminConfig)
+
+// WithAdminCredConfig sets the credential config for kafka admin.
+func WithAdminCredConfig(credConfig *CredConfig) AdminOption {
+	return func(c *AdminConfig) {
+		c.CredConfig = credConfig
+	}
+}
+
+// WithAdminTimeout sets the timeout in milliseconds of the admin requests.
+func WithAdminTimeout(timeout uint64) AdminOption {
+	return func(c *AdminConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// WithAdminLogger sets the logger for kafka admin.
+func WithAdminLogger(logger log.Log) AdminOption {
+	return func(c *AdminConfig) {
+		c.Log = logger
+	}
+}
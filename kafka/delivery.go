@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrSpooled is the delivery error of a message written to the spool of the producer instead of the broker, it is replayed later.
+var ErrSpooled = fmt.Errorf("kafka: message written to spool for replay")
+
+// DeliveryCallback is called with the result of a produced message, err is nil when the message is written to the broker.
+// ctx carries the correlation of the context the message was produced with.
+type DeliveryCallback func(ctx context.Context, msg *kafka.Message, err error)
+
+// FailureHook is called with the messages that failed after the retries, e.g. to spill them to local disk or an outbox.
+// When the hook returns nil the messages are treated as handled and the flush does not fail.
+type FailureHook func(ctx context.Context, msgs []kafka.Message, err error) error
+
+// Delivery is the future of a produced message, completed once the message is written to the broker
+// or fails after the retries.
+type Delivery struct {
+	Message *kafka.Message
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// newDelivery creates a pending Delivery for the message.
+func newDelivery(msg *kafka.Message) *Delivery {
+	return &Delivery{Message: msg, done: make(chan struct{})}
+}
+
+// complete records the result, only the first result is kept.
+func (d *Delivery) complete(err error) {
+	d.once.Do(func() {
+		d.err = err
+		close(d.done)
+	})
+}
+
+// Done returns a channel that is closed when the delivery completes.
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err returns the delivery error, nil while the delivery is pending or when the message is delivered.
+func (d *Delivery) Err() error {
+	select {
+	case <-d.done:
+		return d.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks till the delivery completes or the context is done and returns the delivery error.
+func (d *Delivery) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return d.err
+	case <-ctx.Done():
+		return fmt.Errorf("Delivery.Wait: %w", ctx.Err())
+	}
+}
+
+// DeliveryMetrics holds the delivery counts of a Writer since it was created.
+type DeliveryMetrics struct {
+	Delivered uint64 // Messages written to the broker
+	Failed    uint64 // Messages that failed after the retries
+	Retried   uint64 // Retries of failed messages
+}
+
+// envelope is stored in [kafka.Message.WriterData] to track a message across retries.
+type envelope struct {
+	ctx      context.Context
+	delivery *Delivery
+	attempts int
+}
+
+// getEnvelope returns the envelope of the message, attaching a new one when WriterData is unset.
+// Returns nil when WriterData holds data of the application.
+func getEnvelope(ctx context.Context, msg *kafka.Message) *envelope {
+	if msg.WriterData == nil {
+		msg.WriterData = &envelope{ctx: context.WithoutCancel(ctx)}
+	}
+	env, _ := msg.WriterData.(*envelope)
+	return env
+}
@@ -0,0 +1,211 @@
+package kafka_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/serde"
+	"github.com/sabariramc/goserverbase/v6/kafka/spool"
+	cKafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestProducerDeliveryFailure(t *testing.T) {
+	ctx := GetCorrelationContext()
+	var spilled []cKafka.Message
+	var callbacks atomic.Int64
+	pr, err := kafka.NewProducer(
+		kafka.WithBatch(true),
+		kafka.WithAsync(false),
+		kafka.WithProducerBuffer(10),
+		kafka.WithAutoFlushInterval(60000),
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithWriter(&cKafka.Writer{Addr: cKafka.TCP("127.0.0.1:1"), MaxAttempts: 1}),
+		kafka.WithMaxRetries(2),
+		kafka.WithRetryBackoff(1),
+		kafka.WithDeliveryCallback(func(ctx context.Context, msg *cKafka.Message, err error) {
+			callbacks.Add(1)
+		}),
+		kafka.WithFailureHook(func(ctx context.Context, msgs []cKafka.Message, err error) error {
+			spilled = append(spilled, msgs...)
+			return nil
+		}),
+	)
+	assert.NilError(t, err)
+	delivery, err := pr.ProduceWithDelivery(ctx, "gobase.test.delivery", "key", []byte("value"), nil)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.delivery", "key", []byte("value"), nil))
+	assert.NilError(t, delivery.Err())
+	assert.NilError(t, pr.Flush(ctx))
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.Assert(t, delivery.Wait(waitCtx) != nil)
+	assert.Equal(t, len(spilled), 2)
+	assert.Equal(t, callbacks.Load(), int64(2))
+	assert.Equal(t, pr.DeliveryMetrics(), kafka.DeliveryMetrics{Failed: 2, Retried: 4})
+	assert.NilError(t, pr.Close(ctx))
+}
+
+func TestProducerFlushError(t *testing.T) {
+	ctx := GetCorrelationContext()
+	pr, err := kafka.NewProducer(
+		kafka.WithBatch(true),
+		kafka.WithAsync(false),
+		kafka.WithProducerBuffer(1),
+		kafka.WithAutoFlushInterval(60000),
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithWriter(&cKafka.Writer{Addr: cKafka.TCP("127.0.0.1:1"), MaxAttempts: 1}),
+		kafka.WithMaxRetries(0),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.delivery", "key", []byte("value"), nil))
+	err = pr.Produce(ctx, "gobase.test.delivery", "key", []byte("value"), nil)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, pr.DeliveryMetrics().Failed, uint64(1))
+	pr.Close(ctx)
+}
+
+func TestProducerSpool(t *testing.T) {
+	ctx := GetCorrelationContext()
+	dir := t.TempDir()
+	sp, err := spool.New(spool.WithDir(dir))
+	assert.NilError(t, err)
+	pr, err := kafka.NewProducer(
+		kafka.WithBatch(true),
+		kafka.WithAsync(false),
+		kafka.WithProducerBuffer(10),
+		kafka.WithAutoFlushInterval(60000),
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithWriter(&cKafka.Writer{Addr: cKafka.TCP("127.0.0.1:1"), MaxAttempts: 1}),
+		kafka.WithMaxRetries(0),
+		kafka.WithSpool(sp),
+		kafka.WithSpoolReplayInterval(60000),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.spool", "1", []byte("value"), nil))
+	assert.NilError(t, pr.Flush(ctx))
+	delivery, err := pr.ProduceWithDelivery(ctx, "gobase.test.spool", "2", []byte("value"), nil)
+	assert.NilError(t, err)
+	assert.Equal(t, delivery.Err(), kafka.ErrSpooled)
+	status, err := pr.StatusCheck(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, status.(*kafka.ProducerStatus).Spool.Records, int64(2))
+	closeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	pr.Close(closeCtx)
+
+	sp, err = spool.New(spool.WithDir(dir))
+	assert.NilError(t, err)
+	assert.Equal(t, sp.Pending(), int64(2))
+	sp.Close()
+}
+
+func TestProducerSpoolBuffered(t *testing.T) {
+	ctx := GetCorrelationContext()
+	dir := t.TempDir()
+	sp, err := spool.New(spool.WithDir(dir))
+	assert.NilError(t, err)
+	pr, err := kafka.NewProducer(
+		kafka.WithBatch(true),
+		kafka.WithAsync(false),
+		kafka.WithProducerBuffer(10),
+		kafka.WithAutoFlushInterval(60000),
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithWriter(&cKafka.Writer{Addr: cKafka.TCP("127.0.0.1:1"), MaxAttempts: 1}),
+		kafka.WithMaxRetries(1),
+		kafka.WithRetryBackoff(500),
+		kafka.WithSpool(sp),
+		kafka.WithSpoolReplayInterval(60000),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.spool", "1", []byte("value"), nil))
+	flushed := make(chan error)
+	go func() {
+		flushed <- pr.Flush(ctx)
+	}()
+	for pr.DeliveryMetrics().Retried == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	delivery, err := pr.ProduceWithDelivery(ctx, "gobase.test.spool", "2", []byte("value"), nil)
+	assert.NilError(t, err)
+	assert.NilError(t, <-flushed)
+	assert.Equal(t, delivery.Err(), kafka.ErrSpooled, "the buffered message is moved to the spool with the failed batch")
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.spool", "3", []byte("value"), nil))
+	status, err := pr.StatusCheck(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, status.(*kafka.ProducerStatus).Buffered, 0)
+	closeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	pr.Close(closeCtx)
+
+	sp, err = spool.New(spool.WithDir(dir))
+	assert.NilError(t, err)
+	defer sp.Close()
+	var keys []string
+	_, err = sp.Replay(ctx, 10, func(ctx context.Context, msgs []cKafka.Message) error {
+		for _, msg := range msgs {
+			keys = append(keys, string(msg.Key))
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, keys, []string{"1", "2", "3"})
+}
+
+func TestProducerFlushRetryDoesNotBlockProduce(t *testing.T) {
+	ctx := GetCorrelationContext()
+	pr, err := kafka.NewProducer(
+		kafka.WithBatch(true),
+		kafka.WithAsync(false),
+		kafka.WithProducerBuffer(10),
+		kafka.WithAutoFlushInterval(60000),
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithWriter(&cKafka.Writer{Addr: cKafka.TCP("127.0.0.1:1"), MaxAttempts: 1}),
+		kafka.WithMaxRetries(1),
+		kafka.WithRetryBackoff(1000),
+		kafka.WithFailureHook(func(ctx context.Context, msgs []cKafka.Message, err error) error {
+			return nil
+		}),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.delivery", "key", []byte("value"), nil))
+	flushed := make(chan error)
+	go func() {
+		flushed <- pr.Flush(ctx)
+	}()
+	for pr.DeliveryMetrics().Retried == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	assert.NilError(t, pr.Produce(ctx, "gobase.test.delivery", "key", []byte("value"), nil))
+	assert.Assert(t, time.Since(start) < 500*time.Millisecond, time.Since(start))
+	assert.NilError(t, <-flushed)
+	assert.NilError(t, pr.Flush(ctx))
+	assert.Equal(t, pr.DeliveryMetrics().Failed, uint64(2))
+	pr.Close(ctx)
+}
+
+func TestProduceTypedHeaders(t *testing.T) {
+	ctx := GetCorrelationContext()
+	pr, err := kafka.NewProducer(
+		kafka.WithBatch(true),
+		kafka.WithAsync(false),
+		kafka.WithProducerBuffer(10),
+		kafka.WithAutoFlushInterval(60000),
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithWriter(&cKafka.Writer{Addr: cKafka.TCP("127.0.0.1:1"), MaxAttempts: 1}),
+		kafka.WithMaxRetries(0),
+		kafka.WithFailureHook(func(ctx context.Context, msgs []cKafka.Message, err error) error {
+			return nil
+		}),
+	)
+	assert.NilError(t, err)
+	codec := serde.NewCodec[map[string]string](serde.NewJSONSerializer())
+	headers := map[string]string{"source": "test"}
+	assert.NilError(t, kafka.ProduceTyped(ctx, pr, codec, "gobase.test.typed", "key", map[string]string{"id": "1"}, headers))
+	assert.DeepEqual(t, headers, map[string]string{"source": "test"})
+	pr.Close(ctx)
+}
@@ -0,0 +1,7 @@
+package kafka
+
+// EncodeRecordSet exports encodeRecordSet to the external tests.
+var EncodeRecordSet = encodeRecordSet
+
+// NextSequence exports nextSequence to the external tests.
+var NextSequence = nextSequence
@@ -0,0 +1,447 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// maxJoinAttempts is the number of consecutive failed attempts to join the consumer group after which the poll fails,
+// as with the MaxAttempts default of [kafka.ReaderConfig].
+const maxJoinAttempts = 3
+
+// groupState holds the consumer group generation and the fetch state of every partition assigned to the Poller
+// when the Poller joins the consumer group itself. Each assigned partition is fetched by its own [kafka.Reader],
+// so a paused partition has no reader and none of its messages are fetched.
+type groupState struct {
+	group      *kafka.ConsumerGroup
+	reader     kafka.ReaderConfig // Config of the partition readers, Topic and Partition are set per partition
+	ctx        context.Context    // Context of the group loop and the partition readers, done when the Poller is closed
+	cancel     context.CancelFunc
+	fetched    chan fetchResult // Messages fetched by the partition readers
+	joinErr    chan error       // Error of the last attempt to join the group, sent after maxJoinAttempts failed attempts
+	changed    chan struct{}    // Wakes the poll loop when a partition is paused, resumed or revoked
+	lock       sync.Mutex
+	cond       *sync.Cond // Signalled when the poll loop observes a change
+	gen        *kafka.Generation
+	partitions map[string]map[int]*partitionFetch
+	epoch      uint64         // Incremented on every change of the partitions
+	seen       uint64         // Epoch last observed by the poll loop
+	polling    int            // Number of running poll loops, Poll and FetchMessage
+	wg         sync.WaitGroup // Group loop and partition readers
+}
+
+// partitionFetch is the fetch state of an assigned partition.
+type partitionFetch struct {
+	topic     string
+	partition int
+	next      int64         // Offset of the next message to hand out, kafka.FirstOffset or kafka.LastOffset before the first
+	reader    *kafka.Reader // Reader of the partition, nil while the partition is paused or delayed
+	cancel    context.CancelFunc
+	delayed   time.Time   // Fetching is stopped till this time by Delay
+	timer     *time.Timer // Restarts the reader when the delay ends
+}
+
+// fetchResult is a message or an error of a partition reader.
+type fetchResult struct {
+	fetch  *partitionFetch
+	reader *kafka.Reader
+	msg    *kafka.Message
+	err    error
+}
+
+// newGroupState creates the groupState of the consumer group, the partition readers are created with the reader config.
+func newGroupState(ctx context.Context, group *kafka.ConsumerGroup, reader kafka.ReaderConfig) *groupState {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &groupState{
+		group:      group,
+		reader:     reader,
+		ctx:        ctx,
+		cancel:     cancel,
+		fetched:    make(chan fetchResult),
+		joinErr:    make(chan error),
+		changed:    make(chan struct{}, 1),
+		partitions: map[string]map[int]*partitionFetch{},
+	}
+	g.cond = sync.NewCond(&g.lock)
+	return g
+}
+
+// current reports whether the result is of the running reader of its partition. Must be called with the lock held.
+func (g *groupState) current(res fetchResult) bool {
+	return res.fetch.reader != nil && res.fetch.reader == res.reader
+}
+
+// signal wakes the poll loop.
+func (g *groupState) signal() {
+	select {
+	case g.changed <- struct{}{}:
+	default:
+	}
+}
+
+// observe records that the poll loop observed every change so far.
+func (g *groupState) observe() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.seen = g.epoch
+	g.cond.Broadcast()
+}
+
+// setPolling records that a poll loop started or ended.
+func (g *groupState) setPolling(polling bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if polling {
+		g.polling++
+	} else {
+		g.polling--
+	}
+	g.cond.Broadcast()
+}
+
+// notify wakes the poll loop and waits till it observes the change of the epoch, so that no message fetched
+// before the change is handed out after notify returns.
+func (g *groupState) notify(epoch uint64) {
+	g.signal()
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for g.polling > 0 && g.seen < epoch {
+		g.cond.Wait()
+	}
+}
+
+// runGroup joins the generations of the consumer group till the Poller is closed.
+func (k *Poller) runGroup() {
+	defer k.group.wg.Done()
+	ctx := k.group.ctx
+	attempts := 0
+	for {
+		gen, err := k.group.group.Next(ctx)
+		if err != nil {
+			if errors.Is(err, kafka.ErrGroupClosed) || ctx.Err() != nil {
+				return
+			}
+			k.log.Warning(ctx, "error joining consumer group", err)
+			attempts++
+			if attempts >= maxJoinAttempts {
+				attempts = 0
+				select {
+				case k.group.joinErr <- err:
+				default: // No poll loop is waiting, keep trying so that the group heals
+				}
+			}
+			continue
+		}
+		attempts = 0
+		k.startGeneration(ctx, gen)
+	}
+}
+
+// startGeneration assigns the partitions of the generation and starts their readers. Partitions kept from the
+// previous generation resume from the offset following the last message handed out, the others from their committed offset.
+func (k *Poller) startGeneration(ctx context.Context, gen *kafka.Generation) {
+	assigned := make(map[string][]int, len(gen.Assignments))
+	for topic, partitions := range gen.Assignments {
+		for _, p := range partitions {
+			assigned[topic] = append(assigned[topic], p.ID)
+		}
+	}
+	k.group.lock.Lock()
+	// A member that missed a generation may have lost its partitions to another member in between
+	keep := k.group.gen != nil && gen.ID == k.group.gen.ID+1
+	k.group.lock.Unlock()
+	if !keep {
+		k.assign(ctx, nil)
+	}
+	k.assign(ctx, assigned)
+	k.partitions.lock.Lock()
+	k.partitions.rebalances++
+	k.partitions.lastRebalance = time.Now()
+	rebalances := k.partitions.rebalances
+	k.partitions.lock.Unlock()
+	k.log.Notice(ctx, "consumer group generation joined", map[string]any{"groupId": gen.GroupID, "generationId": gen.ID, "memberId": gen.MemberID, "rebalances": rebalances})
+
+	k.group.lock.Lock()
+	partitions := make(map[string]map[int]*partitionFetch, len(gen.Assignments))
+	for topic, assignments := range gen.Assignments {
+		partitions[topic] = make(map[int]*partitionFetch, len(assignments))
+		for _, a := range assignments {
+			p, ok := k.group.partitions[topic][a.ID]
+			if !ok || !keep {
+				p = &partitionFetch{topic: topic, partition: a.ID, next: a.Offset}
+			}
+			partitions[topic][a.ID] = p
+		}
+	}
+	k.group.gen = gen
+	k.group.partitions = partitions
+	k.syncFetchers(ctx)
+	k.group.epoch++
+	k.group.lock.Unlock()
+	k.group.signal()
+	gen.Start(func(genCtx context.Context) {
+		<-genCtx.Done()
+		k.endGeneration(ctx)
+	})
+}
+
+// endGeneration stops the partition readers and commits the stored offsets before the generation ends.
+func (k *Poller) endGeneration(ctx context.Context) {
+	k.group.lock.Lock()
+	for _, pMap := range k.group.partitions {
+		for _, p := range pMap {
+			k.stopFetcher(p)
+		}
+	}
+	k.group.epoch++
+	k.group.lock.Unlock()
+	k.group.signal()
+	err := k.commit(ctx, true)
+	if err != nil {
+		k.log.Error(ctx, "error committing offsets at the end of the generation", err)
+	}
+}
+
+// syncFetchers starts the readers of the partitions that are neither paused nor delayed and stops the readers of the others.
+// Must be called with the group lock held.
+func (k *Poller) syncFetchers(ctx context.Context) {
+	now := time.Now()
+	for topic, pMap := range k.group.partitions {
+		paused := k.paused(topic)
+		for _, p := range pMap {
+			stopped := paused || p.delayed.After(now)
+			switch {
+			case stopped && p.reader != nil:
+				k.stopFetcher(p)
+			case !stopped && p.reader == nil:
+				k.startFetcher(ctx, p)
+			}
+		}
+	}
+}
+
+// startFetcher starts the reader of the partition from its next offset. Must be called with the group lock held.
+func (k *Poller) startFetcher(ctx context.Context, p *partitionFetch) {
+	config := k.group.reader
+	config.Topic = p.topic
+	config.Partition = p.partition
+	r := kafka.NewReader(config)
+	r.SetOffset(p.next)
+	fetchCtx, cancel := context.WithCancel(ctx)
+	p.reader = r
+	p.cancel = cancel
+	k.group.wg.Add(1)
+	go k.fetch(fetchCtx, p, r)
+}
+
+// stopFetcher stops the reader of the partition. Messages tracked but not yet handed out are forgotten,
+// they are fetched again when the partition is resumed. Must be called with the group lock held.
+func (k *Poller) stopFetcher(p *partitionFetch) {
+	if p.reader == nil {
+		return
+	}
+	p.cancel()
+	p.reader = nil
+	p.cancel = nil
+	if k.tracker != nil {
+		k.tracker.Rewind(p.topic, p.partition, p.next)
+	}
+}
+
+// fetch passes the messages of the partition reader to the poll loop till the context is done, then closes the reader.
+func (k *Poller) fetch(ctx context.Context, p *partitionFetch, r *kafka.Reader) {
+	defer k.group.wg.Done()
+	defer r.Close()
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case k.group.fetched <- fetchResult{fetch: p, reader: r, err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		select {
+		case k.group.fetched <- fetchResult{fetch: p, reader: r, msg: &msg}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pollGroup hands the messages fetched by the partition readers to the channel till the context is done.
+func (k *Poller) pollGroup(ctx context.Context, ch chan<- *kafka.Message) error {
+	k.group.setPolling(true)
+	defer k.group.setPolling(false)
+	for {
+		res, err := k.nextFetched(ctx)
+		if err != nil {
+			return fmt.Errorf("Consumer.Poll: %w", err)
+		}
+		if res == nil {
+			return nil
+		}
+		k.deliverFetched(ctx, ch, *res)
+	}
+}
+
+// nextFetched waits for the next message fetched by the running reader of its partition, nil when the context is done.
+func (k *Poller) nextFetched(ctx context.Context) (*fetchResult, error) {
+	for {
+		k.group.observe()
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-k.group.changed:
+		case err := <-k.group.joinErr:
+			k.log.Error(ctx, "error joining consumer group", err)
+			return nil, fmt.Errorf("error joining consumer group: %w", err)
+		case res := <-k.group.fetched:
+			k.group.lock.Lock()
+			current := k.group.current(res)
+			k.group.lock.Unlock()
+			if !current {
+				continue
+			}
+			if res.err != nil {
+				k.log.Error(ctx, "error fetching message", res.err)
+				return nil, fmt.Errorf("error fetching message of partition %v of %v: %w", res.fetch.partition, res.fetch.topic, res.err)
+			}
+			return &res, nil
+		}
+	}
+}
+
+// deliverFetched hands the message to the channel unless its partition is paused or revoked before it is taken.
+// In [CommitModeAck] the message is tracked before it is handed out, so that an acknowledgement is never missed.
+func (k *Poller) deliverFetched(ctx context.Context, ch chan<- *kafka.Message, res fetchResult) {
+	msg := res.msg
+	k.group.lock.Lock()
+	if !k.group.current(res) {
+		k.group.lock.Unlock()
+		return
+	}
+	if res.fetch.next < 0 {
+		// Resolve the start offset of the partition so that it is resumed from this message if paused before handing it out
+		res.fetch.next = msg.Offset
+	}
+	if k.tracker != nil {
+		k.tracker.Track(msg)
+	}
+	k.group.lock.Unlock()
+	k.recordFetch(ctx, msg)
+	for {
+		select {
+		case ch <- msg:
+			k.group.lock.Lock()
+			current := k.group.current(res)
+			if current {
+				res.fetch.next = msg.Offset + 1
+			}
+			k.group.lock.Unlock()
+			if current && k.tracker == nil {
+				k.StoreOffset(ctx, msg)
+				k.consumerCount.Add(1)
+				k.commit(ctx, false)
+			}
+			return
+		case <-k.group.changed:
+			k.group.observe()
+			k.group.lock.Lock()
+			current := k.group.current(res)
+			k.group.lock.Unlock()
+			if !current {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// commitGeneration commits the offsets with the current generation of the consumer group.
+func (k *Poller) commitGeneration(ctx context.Context, offsets OffsetMap) error {
+	k.group.lock.Lock()
+	gen := k.group.gen
+	k.group.lock.Unlock()
+	if gen == nil {
+		return fmt.Errorf("Poller.commitGeneration: consumer group not joined")
+	}
+	return gen.CommitOffsets(offsets)
+}
+
+// GroupMetadata returns the consumer group generation the Poller is a member of, pass it to [TxnProducer.SendOffsets].
+// The generation ID is -1 and the member ID is empty while no generation is joined or with a reader set in [ConsumerConfig].
+func (k *Poller) GroupMetadata() GroupMetadata {
+	res := GroupMetadata{GroupID: k.config.GroupID, GenerationID: -1}
+	if k.group == nil {
+		return res
+	}
+	k.group.lock.Lock()
+	defer k.group.lock.Unlock()
+	if gen := k.group.gen; gen != nil {
+		res.GenerationID, res.MemberID = int(gen.ID), gen.MemberID
+	}
+	return res
+}
+
+// groupStats returns the stats of the partition readers summed up, the counters cover the interval since the last call.
+func (k *Poller) groupStats() kafka.ReaderStats {
+	k.group.lock.Lock()
+	defer k.group.lock.Unlock()
+	stats := kafka.ReaderStats{ClientID: k.clientID, QueueCapacity: int64(k.group.reader.QueueCapacity)}
+	for _, pMap := range k.group.partitions {
+		for _, p := range pMap {
+			if p.reader == nil {
+				continue
+			}
+			s := p.reader.Stats()
+			stats.Dials += s.Dials
+			stats.Fetches += s.Fetches
+			stats.Messages += s.Messages
+			stats.Bytes += s.Bytes
+			stats.Timeouts += s.Timeouts
+			stats.Errors += s.Errors
+			stats.QueueLength += s.QueueLength
+			stats.Lag += s.Lag
+		}
+	}
+	return stats
+}
+
+// assignedPartitions returns the partitions assigned by the current generation.
+func (k *Poller) assignedPartitions() map[string][]int {
+	k.group.lock.Lock()
+	defer k.group.lock.Unlock()
+	res := make(map[string][]int, len(k.group.partitions))
+	for topic, pMap := range k.group.partitions {
+		for partition := range pMap {
+			res[topic] = append(res[topic], partition)
+		}
+	}
+	return res
+}
+
+// closeGroup stops the delay timers, leaves the consumer group and waits for the partition readers to close.
+func (k *Poller) closeGroup() {
+	k.group.lock.Lock()
+	for _, pMap := range k.group.partitions {
+		for _, p := range pMap {
+			if p.timer != nil {
+				p.timer.Stop()
+			}
+		}
+	}
+	k.group.lock.Unlock()
+	k.group.group.Close()
+	k.group.cancel()
+	k.group.wg.Wait()
+}
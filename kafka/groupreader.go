@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrNotAvailableWithGroup is returned by the [kafka.Reader] methods that set or read the offset of a single partition
+// when the Poller joins the consumer group itself, as by [kafka.Reader] with a GroupID.
+var ErrNotAvailableWithGroup = errors.New("unavailable when GroupID is set")
+
+// The methods below shadow the methods of the embedded [kafka.Reader], which is nil when the Poller joins the consumer group
+// itself, and serve them from the partition readers of the group, as [kafka.Reader] does with a GroupID.
+
+// Config returns the config of the reader. When the Poller joins the consumer group itself it is the config of the partition
+// readers with the GroupID and GroupTopics of the Poller.
+func (k *Poller) Config() kafka.ReaderConfig {
+	if k.group == nil {
+		return k.Reader.Config()
+	}
+	config := k.group.reader
+	config.GroupID = k.config.GroupID
+	config.GroupTopics = k.topics
+	return config
+}
+
+// FetchMessage returns the next message without committing its offset, commit it with CommitMessages, or with Ack in
+// [CommitModeAck] where the message is tracked. Use either Poll or FetchMessage to read the messages of the Poller.
+func (k *Poller) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if k.group == nil {
+		return k.Reader.FetchMessage(ctx)
+	}
+	k.group.setPolling(true)
+	defer k.group.setPolling(false)
+	for {
+		res, err := k.nextFetched(ctx)
+		if err != nil {
+			return kafka.Message{}, fmt.Errorf("Poller.FetchMessage: %w", err)
+		}
+		if res == nil {
+			return kafka.Message{}, fmt.Errorf("Poller.FetchMessage: %w", ctx.Err())
+		}
+		msg := res.msg
+		k.group.lock.Lock()
+		if !k.group.current(*res) {
+			k.group.lock.Unlock()
+			continue
+		}
+		res.fetch.next = msg.Offset + 1
+		if k.tracker != nil {
+			k.tracker.Track(msg)
+		}
+		k.group.lock.Unlock()
+		k.recordFetch(ctx, msg)
+		return *msg, nil
+	}
+}
+
+// ReadMessage returns the next message and commits its offset.
+func (k *Poller) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	if k.group == nil {
+		return k.Reader.ReadMessage(ctx)
+	}
+	msg, err := k.FetchMessage(ctx)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	err = k.CommitMessages(ctx, msg)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return msg, nil
+}
+
+// CommitMessages commits the offsets of the messages, the offset of a partition moves past the last of its messages.
+// When the Poller joins the consumer group itself the offsets are committed with the generation of the group.
+func (k *Poller) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if k.group == nil {
+		return k.Reader.CommitMessages(ctx, msgs...)
+	}
+	offsets := OffsetMap{}
+	for _, msg := range msgs {
+		if offsets[msg.Topic] == nil {
+			offsets[msg.Topic] = map[int]int64{}
+		}
+		if offset, ok := offsets[msg.Topic][msg.Partition]; !ok || msg.Offset+1 > offset {
+			offsets[msg.Topic][msg.Partition] = msg.Offset + 1
+		}
+	}
+	return k.CommitOffsets(ctx, offsets)
+}
+
+// Offset returns the offset of the next message of the reader, -1 when the Poller joins the consumer group itself.
+func (k *Poller) Offset() int64 {
+	if k.group == nil {
+		return k.Reader.Offset()
+	}
+	return -1
+}
+
+// Lag returns the lag of the reader, -1 when the Poller joins the consumer group itself; see PartitionLag for the lag of
+// every assigned partition.
+func (k *Poller) Lag() int64 {
+	if k.group == nil {
+		return k.Reader.Lag()
+	}
+	return -1
+}
+
+// ReadLag reads the lag of the reader from the broker, returns [ErrNotAvailableWithGroup] when the Poller joins the
+// consumer group itself.
+func (k *Poller) ReadLag(ctx context.Context) (int64, error) {
+	if k.group == nil {
+		return k.Reader.ReadLag(ctx)
+	}
+	return 0, fmt.Errorf("Poller.ReadLag: %w", ErrNotAvailableWithGroup)
+}
+
+// SetOffset sets the offset of the next message of the reader, returns [ErrNotAvailableWithGroup] when the Poller joins
+// the consumer group itself.
+func (k *Poller) SetOffset(offset int64) error {
+	if k.group == nil {
+		return k.Reader.SetOffset(offset)
+	}
+	return fmt.Errorf("Poller.SetOffset: %w", ErrNotAvailableWithGroup)
+}
+
+// SetOffsetAt sets the offset of the reader to the first message at or after the time, returns [ErrNotAvailableWithGroup]
+// when the Poller joins the consumer group itself.
+func (k *Poller) SetOffsetAt(ctx context.Context, t time.Time) error {
+	if k.group == nil {
+		return k.Reader.SetOffsetAt(ctx, t)
+	}
+	return fmt.Errorf("Poller.SetOffsetAt: %w", ErrNotAvailableWithGroup)
+}
@@ -0,0 +1,53 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	"gotest.tools/assert"
+)
+
+func TestPollerGroupReader(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	produce(t, broker, "orders", 0, 3)
+	co, err := kafka.NewPoller(
+		kafka.WithConsumerCredConfig(broker.CredConfig()),
+		kafka.WithConsumerLogger(KafkaTestLogger),
+		kafka.WithGroupID("reader-group"),
+		kafka.WithConsumerTopic([]string{"orders"}),
+	)
+	assert.NilError(t, err)
+	defer co.Close(ctx)
+	config := co.Config()
+	assert.Equal(t, config.GroupID, "reader-group")
+	assert.DeepEqual(t, config.GroupTopics, []string{"orders"})
+	assert.Equal(t, co.Offset(), int64(-1))
+	assert.Equal(t, co.Lag(), int64(-1))
+	assert.Assert(t, errors.Is(co.SetOffset(0), kafka.ErrNotAvailableWithGroup))
+	assert.Assert(t, errors.Is(co.SetOffsetAt(ctx, time.Now()), kafka.ErrNotAvailableWithGroup))
+	_, err = co.ReadLag(ctx)
+	assert.Assert(t, errors.Is(err, kafka.ErrNotAvailableWithGroup))
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	first, err := co.FetchMessage(fetchCtx)
+	assert.NilError(t, err)
+	assert.Equal(t, string(first.Value), "orders-0")
+	second, err := co.FetchMessage(fetchCtx)
+	assert.NilError(t, err)
+	assert.Equal(t, string(second.Value), "orders-1")
+	assert.Equal(t, broker.CommittedOffset("reader-group", "orders", 0), int64(-1), "fetched messages are not committed")
+	assert.NilError(t, co.CommitMessages(ctx, second, first))
+	assert.Equal(t, broker.CommittedOffset("reader-group", "orders", 0), int64(2))
+	third, err := co.ReadMessage(fetchCtx)
+	assert.NilError(t, err)
+	assert.Equal(t, string(third.Value), "orders-2")
+	assert.Equal(t, broker.CommittedOffset("reader-group", "orders", 0), int64(3))
+}
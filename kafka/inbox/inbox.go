@@ -0,0 +1,47 @@
+// Package inbox records the IDs of processed messages so that redelivered messages can be skipped.
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+)
+
+// ErrDuplicate is returned by TransactionalStore.RunInTransaction when the ID is already recorded.
+var ErrDuplicate = errors.New("inbox: duplicate message")
+
+// IDFunc derives the ID of a message, an empty ID disables deduplication for the message.
+type IDFunc func(msg *kafka.Message) string
+
+// HeaderID uses the value of the header as the message ID.
+func HeaderID(key string) IDFunc {
+	return func(msg *kafka.Message) string {
+		return msg.GetHeaders()[key]
+	}
+}
+
+// KeyOffsetID uses the topic, partition, offset and key of the message as the message ID.
+// It identifies redeliveries of the same record, not the same event produced twice.
+func KeyOffsetID() IDFunc {
+	return func(msg *kafka.Message) string {
+		return fmt.Sprintf("%v/%v/%v/%v", msg.Topic, msg.Partition, msg.Offset, msg.GetKey())
+	}
+}
+
+// Store records the IDs of processed messages.
+type Store interface {
+	// Seen reports whether the ID is recorded.
+	Seen(ctx context.Context, id string) (bool, error)
+	// Record records the ID, recording an ID twice is not an error.
+	Record(ctx context.Context, id string) error
+}
+
+// TransactionalStore records the ID in the same transaction as the writes of the handler.
+type TransactionalStore interface {
+	Store
+	// RunInTransaction runs fn in a transaction that records the ID, fn must use the context it receives for its writes.
+	// Returns ErrDuplicate without running fn when the ID is already recorded.
+	RunInTransaction(ctx context.Context, id string, fn func(ctx context.Context) error) error
+}
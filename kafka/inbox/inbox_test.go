@@ -0,0 +1,33 @@
+package inbox_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/inbox"
+	ckafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := inbox.NewMemoryStore(2)
+	assert.NilError(t, store.Record(ctx, "a"))
+	assert.NilError(t, store.Record(ctx, "b"))
+	seen, _ := store.Seen(ctx, "a")
+	assert.Assert(t, seen)
+	assert.NilError(t, store.Record(ctx, "c")) // evicts b, the least recently used
+	seen, _ = store.Seen(ctx, "b")
+	assert.Assert(t, !seen)
+	seen, _ = store.Seen(ctx, "a")
+	assert.Assert(t, seen)
+	assert.Equal(t, store.Len(), 2)
+}
+
+func TestIDFunc(t *testing.T) {
+	msg := &kafka.Message{Message: &ckafka.Message{Topic: "t", Partition: 1, Offset: 10, Key: []byte("k"), Headers: []ckafka.Header{{Key: "x-message-id", Value: []byte("m1")}}}}
+	assert.Equal(t, inbox.HeaderID("x-message-id")(msg), "m1")
+	assert.Equal(t, inbox.HeaderID("missing")(msg), "")
+	assert.Equal(t, inbox.KeyOffsetID()(msg), "t/1/10/k")
+}
@@ -0,0 +1,63 @@
+package inbox
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// MemoryStore is an in-memory Store keeping the most recently recorded IDs up to the capacity.
+// IDs are lost on restart and are not shared between instances.
+type MemoryStore struct {
+	lock     sync.Mutex
+	capacity int
+	order    *list.List
+	ids      map[string]*list.Element
+}
+
+// NewMemoryStore creates a new MemoryStore holding up to capacity IDs.
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &MemoryStore{
+		capacity: capacity,
+		order:    list.New(),
+		ids:      make(map[string]*list.Element, capacity),
+	}
+}
+
+// Seen reports whether the ID is recorded and marks it as recently used.
+func (s *MemoryStore) Seen(ctx context.Context, id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	el, ok := s.ids[id]
+	if ok {
+		s.order.MoveToFront(el)
+	}
+	return ok, nil
+}
+
+// Record records the ID, evicting the least recently used ID when the store is full.
+func (s *MemoryStore) Record(ctx context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if el, ok := s.ids[id]; ok {
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.ids[id] = s.order.PushFront(id)
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.ids, oldest.Value.(string))
+	}
+	return nil
+}
+
+// Len returns the number of recorded IDs.
+func (s *MemoryStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.order.Len()
+}
@@ -0,0 +1,87 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// record is an inbox document.
+type record struct {
+	ID          string    `bson:"_id"`
+	ProcessedAt time.Time `bson:"processedAt"`
+}
+
+// MongoStore is a TransactionalStore backed by a Mongo collection, records are removed by a TTL index after the retention period.
+type MongoStore struct {
+	coll      *mongodrv.Collection
+	retention time.Duration
+}
+
+// NewMongoStore creates a new MongoStore on the collection keeping records for the retention period.
+func NewMongoStore(coll *mongo.Collection, retention time.Duration) *MongoStore {
+	return &MongoStore{coll: coll.Collection, retention: retention}
+}
+
+// EnsureIndexes creates the TTL index removing records after the retention period.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.coll.Indexes().CreateOne(ctx, mongodrv.IndexModel{
+		Keys:    bson.D{{Key: "processedAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(s.retention.Seconds())),
+	})
+	if err != nil {
+		return fmt.Errorf("MongoStore.EnsureIndexes: %w", err)
+	}
+	return nil
+}
+
+// Seen reports whether the ID is recorded.
+func (s *MongoStore) Seen(ctx context.Context, id string) (bool, error) {
+	count, err := s.coll.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("MongoStore.Seen: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Record records the ID.
+func (s *MongoStore) Record(ctx context.Context, id string) error {
+	_, err := s.coll.InsertOne(ctx, &record{ID: id, ProcessedAt: time.Now()})
+	if err != nil && !mongodrv.IsDuplicateKeyError(err) {
+		return fmt.Errorf("MongoStore.Record: %w", err)
+	}
+	return nil
+}
+
+// RunInTransaction runs fn in a transaction that inserts the record of the ID, the transaction is retried by the driver on transient errors.
+// Returns ErrDuplicate when the ID is already recorded.
+func (s *MongoStore) RunInTransaction(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	session, err := s.coll.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("MongoStore.RunInTransaction: error starting session: %w", err)
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sessCtx mongodrv.SessionContext) (any, error) {
+		_, err := s.coll.InsertOne(sessCtx, &record{ID: id, ProcessedAt: time.Now()})
+		if mongodrv.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicate
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error recording message: %w", err)
+		}
+		return nil, fn(sessCtx)
+	})
+	if errors.Is(err, ErrDuplicate) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("MongoStore.RunInTransaction: %w", err)
+	}
+	return nil
+}
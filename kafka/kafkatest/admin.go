@@ -0,0 +1,216 @@
+package kafkatest
+
+import (
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/createpartitions"
+	"github.com/segmentio/kafka-go/protocol/createtopics"
+	"github.com/segmentio/kafka-go/protocol/deletegroups"
+	"github.com/segmentio/kafka-go/protocol/deletetopics"
+	"github.com/segmentio/kafka-go/protocol/describeconfigs"
+	"github.com/segmentio/kafka-go/protocol/incrementalalterconfigs"
+	"github.com/segmentio/kafka-go/protocol/listgroups"
+)
+
+// configSourceTopic is the config source reported for topic level configs, DYNAMIC_TOPIC_CONFIG.
+const configSourceTopic = 1
+
+// handleAdmin handles the topic and group administration requests, returns false for other requests.
+func (b *Broker) handleAdmin(req protocol.Message) (any, bool) {
+	switch req := req.(type) {
+	case *createtopics.Request:
+		return b.createTopics(req), true
+	case *deletetopics.Request:
+		return b.deleteTopics(req), true
+	case *createpartitions.Request:
+		return b.createPartitions(req), true
+	case *describeconfigs.Request:
+		return b.describeConfigs(req), true
+	case *incrementalalterconfigs.Request:
+		return b.alterConfigs(req), true
+	case *listgroups.Request:
+		return b.listGroups(), true
+	case *deletegroups.Request:
+		return b.deleteGroups(req), true
+	}
+	return nil, false
+}
+
+// createTopics creates the topics, -1 partitions or replication factor takes the broker default.
+func (b *Broker) createTopics(req *createtopics.Request) *createtopics.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &createtopics.Response{Topics: make([]createtopics.ResponseTopic, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		resTopic := createtopics.ResponseTopic{Name: rt.Name}
+		switch {
+		case b.topics[rt.Name] != nil:
+			resTopic.ErrorCode = int16(kafka.TopicAlreadyExists)
+		case rt.NumPartitions == 0 || rt.NumPartitions < -1:
+			resTopic.ErrorCode = int16(kafka.InvalidPartitionNumber)
+		case rt.ReplicationFactor == 0 || rt.ReplicationFactor < -1:
+			resTopic.ErrorCode = int16(kafka.InvalidReplicationFactor)
+		case !req.ValidateOnly:
+			partitions := int(rt.NumPartitions)
+			if partitions == -1 {
+				partitions = b.config.Partitions
+			}
+			t := b.createTopic(rt.Name, partitions)
+			if rt.ReplicationFactor > 0 {
+				t.replicationFactor = int(rt.ReplicationFactor)
+			}
+			for _, c := range rt.Configs {
+				t.configs[c.Name] = c.Value
+			}
+		}
+		res.Topics = append(res.Topics, resTopic)
+	}
+	return res
+}
+
+// deleteTopics deletes the topics and their messages.
+func (b *Broker) deleteTopics(req *deletetopics.Request) *deletetopics.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &deletetopics.Response{Responses: make([]deletetopics.ResponseTopic, 0, len(req.TopicNames))}
+	for _, name := range req.TopicNames {
+		resTopic := deletetopics.ResponseTopic{Name: name}
+		if b.topics[name] == nil {
+			resTopic.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+		}
+		delete(b.topics, name)
+		res.Responses = append(res.Responses, resTopic)
+	}
+	return res
+}
+
+// createPartitions grows the topics to the requested number of partitions, partitions cannot be removed.
+func (b *Broker) createPartitions(req *createpartitions.Request) *createpartitions.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &createpartitions.Response{Results: make([]createpartitions.ResponseResult, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		result := createpartitions.ResponseResult{Name: rt.Name}
+		t := b.topics[rt.Name]
+		switch {
+		case t == nil:
+			result.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+		case int(rt.Count) <= len(t.partitions):
+			result.ErrorCode = int16(kafka.InvalidPartitionNumber)
+		case !req.ValidateOnly:
+			b.createTopic(rt.Name, int(rt.Count))
+		}
+		res.Results = append(res.Results, result)
+	}
+	return res
+}
+
+// describeConfigs returns the configs set on the topics, other resource types are described without configs.
+func (b *Broker) describeConfigs(req *describeconfigs.Request) *describeconfigs.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &describeconfigs.Response{Resources: make([]describeconfigs.ResponseResource, 0, len(req.Resources))}
+	for _, rr := range req.Resources {
+		resource := describeconfigs.ResponseResource{ResourceType: rr.ResourceType, ResourceName: rr.ResourceName}
+		if rr.ResourceType != int8(kafka.ResourceTypeTopic) {
+			res.Resources = append(res.Resources, resource)
+			continue
+		}
+		t := b.topics[rr.ResourceName]
+		if t == nil {
+			resource.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+			res.Resources = append(res.Resources, resource)
+			continue
+		}
+		names := rr.ConfigNames
+		if names == nil {
+			for name := range t.configs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		for _, name := range names {
+			value, ok := t.configs[name]
+			if !ok {
+				continue
+			}
+			resource.ConfigEntries = append(resource.ConfigEntries, describeconfigs.ResponseConfigEntry{
+				ConfigName:   name,
+				ConfigValue:  value,
+				ConfigSource: configSourceTopic,
+			})
+		}
+		res.Resources = append(res.Resources, resource)
+	}
+	return res
+}
+
+// alterConfigs sets or deletes the configs of the topics.
+func (b *Broker) alterConfigs(req *incrementalalterconfigs.Request) *incrementalalterconfigs.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &incrementalalterconfigs.Response{Responses: make([]incrementalalterconfigs.ResponseAlterResponse, 0, len(req.Resources))}
+	for _, rr := range req.Resources {
+		resource := incrementalalterconfigs.ResponseAlterResponse{ResourceType: rr.ResourceType, ResourceName: rr.ResourceName}
+		t := b.topics[rr.ResourceName]
+		switch {
+		case rr.ResourceType != int8(kafka.ResourceTypeTopic):
+			resource.ErrorCode = int16(kafka.InvalidRequest)
+		case t == nil:
+			resource.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+		default:
+			for _, c := range rr.Configs {
+				if c.ConfigOperation != int8(kafka.ConfigOperationSet) && c.ConfigOperation != int8(kafka.ConfigOperationDelete) {
+					resource.ErrorCode = int16(kafka.InvalidRequest)
+				}
+			}
+			if resource.ErrorCode != 0 || req.ValidateOnly {
+				break
+			}
+			for _, c := range rr.Configs {
+				if c.ConfigOperation == int8(kafka.ConfigOperationDelete) {
+					delete(t.configs, c.Name)
+					continue
+				}
+				t.configs[c.Name] = c.Value
+			}
+		}
+		res.Responses = append(res.Responses, resource)
+	}
+	return res
+}
+
+// listGroups lists the groups coordinated by the broker.
+func (b *Broker) listGroups() *listgroups.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &listgroups.Response{Groups: make([]listgroups.ResponseGroup, 0, len(b.groups))}
+	for id, g := range b.groups {
+		res.Groups = append(res.Groups, listgroups.ResponseGroup{GroupID: id, ProtocolType: g.protocolType})
+	}
+	sort.Slice(res.Groups, func(i, j int) bool { return res.Groups[i].GroupID < res.Groups[j].GroupID })
+	return res
+}
+
+// deleteGroups deletes the groups and their committed offsets, a group with members cannot be deleted.
+func (b *Broker) deleteGroups(req *deletegroups.Request) *deletegroups.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &deletegroups.Response{Responses: make([]deletegroups.ResponseGroup, 0, len(req.GroupIDs))}
+	for _, id := range req.GroupIDs {
+		resGroup := deletegroups.ResponseGroup{GroupID: id}
+		g := b.groups[id]
+		switch {
+		case g == nil:
+			resGroup.ErrorCode = int16(kafka.GroupIdNotFound)
+		case len(g.members) > 0:
+			resGroup.ErrorCode = int16(kafka.NonEmptyGroup)
+		default:
+			delete(b.groups, id)
+		}
+		res.Responses = append(res.Responses, resGroup)
+	}
+	return res
+}
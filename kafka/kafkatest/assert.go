@@ -0,0 +1,67 @@
+package kafkatest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ExpectMessages waits up to [Config.WaitTimeout] for the topic to have at least n messages and returns the messages
+// of the topic, the test fails when fewer messages are produced.
+func (b *Broker) ExpectMessages(t testing.TB, topicName string, n int) []kafka.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.WaitTimeout)
+	defer cancel()
+	msgs, err := b.WaitForMessages(ctx, topicName, n)
+	if err != nil {
+		t.Fatalf("kafkatest: expected %v messages in %v, found %v", n, topicName, len(msgs))
+	}
+	return msgs
+}
+
+// ExpectMessage waits up to [Config.WaitTimeout] for a message of the topic that matches and returns it,
+// the test fails when no message matches.
+func (b *Broker) ExpectMessage(t testing.TB, topicName string, match func(msg *kafka.Message) bool) kafka.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.WaitTimeout)
+	defer cancel()
+	for n := 1; ; n++ {
+		msgs, err := b.WaitForMessages(ctx, topicName, n)
+		if err != nil {
+			t.Fatalf("kafkatest: no matching message in %v among %v messages", topicName, len(msgs))
+		}
+		if match(&msgs[n-1]) {
+			return msgs[n-1]
+		}
+	}
+}
+
+// ExpectNoMessages fails the test when the topic has any message.
+func (b *Broker) ExpectNoMessages(t testing.TB, topicName string) {
+	t.Helper()
+	if msgs := b.Messages(topicName); len(msgs) > 0 {
+		t.Fatalf("kafkatest: expected no messages in %v, found %v", topicName, len(msgs))
+	}
+}
+
+// ExpectCommitted waits up to [Config.WaitTimeout] for the group to commit at least the offset for the partition,
+// the test fails when the offset is not committed.
+func (b *Broker) ExpectCommitted(t testing.TB, groupID, topicName string, partition int, offset int64) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.WaitTimeout)
+	defer cancel()
+	if err := b.WaitForCommit(ctx, groupID, topicName, partition, offset); err != nil {
+		t.Fatalf("kafkatest: %v", err)
+	}
+}
+
+// Header returns the value of the first header of the message with the key, empty when the header is missing.
+func Header(msg *kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
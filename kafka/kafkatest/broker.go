@@ -0,0 +1,414 @@
+// Package kafkatest provides an in-memory Kafka broker for tests.
+//
+// The broker speaks the subset of the Kafka protocol used by [kafka.Writer], [kafka.Reader] and [kafka.Client]:
+// topics and partitions, produce and fetch with keys, headers and timestamps, list offsets, consumer groups with
+// rebalancing, offset commit and fetch, describe groups, the topic and group administration requests, and idempotent and
+// transactional producers. The messages of a transaction are appended when it commits and dropped when it aborts, so every
+// consumer reads as with [kafka.ReadCommitted] isolation. Compression on fetch, SASL and TLS are not supported.
+//
+// Point the brokers of the producer, poller or Kafka client at [Broker.Addr] or use [Broker.CredConfig]:
+//
+//	broker, _ := kafkatest.New(kafkatest.WithTopic("orders", 3))
+//	defer broker.Close()
+//	pr, _ := kafka.NewProducer(kafka.WithProducerCredConfig(broker.CredConfig()))
+//
+// Unknown topics are created on first use, but [kafka.Writer] serves metadata from a cache refreshed every few seconds;
+// create the topics a producer writes to, such as retry and dead-letter topics, with [WithTopic] or [Broker.CreateTopic].
+package kafkatest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	gokafka "github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/apiversions"
+	"github.com/segmentio/kafka-go/protocol/findcoordinator"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+)
+
+// nodeID is the broker ID reported in metadata, the broker is the leader of every partition and the coordinator of every group.
+const nodeID = 1
+
+// supportedVersions lists the API versions advertised to clients. Fetch is pinned to v10 as its response is encoded by the broker.
+var supportedVersions = []apiversions.ApiKeyResponse{
+	{ApiKey: int16(protocol.Produce), MinVersion: 2, MaxVersion: 7},
+	{ApiKey: int16(protocol.Fetch), MinVersion: 10, MaxVersion: 10},
+	{ApiKey: int16(protocol.ListOffsets), MinVersion: 1, MaxVersion: 5},
+	{ApiKey: int16(protocol.Metadata), MinVersion: 0, MaxVersion: 6},
+	{ApiKey: int16(protocol.OffsetCommit), MinVersion: 0, MaxVersion: 7},
+	{ApiKey: int16(protocol.OffsetFetch), MinVersion: 0, MaxVersion: 5},
+	{ApiKey: int16(protocol.FindCoordinator), MinVersion: 0, MaxVersion: 2},
+	{ApiKey: int16(protocol.JoinGroup), MinVersion: 0, MaxVersion: 5},
+	{ApiKey: int16(protocol.Heartbeat), MinVersion: 0, MaxVersion: 3},
+	{ApiKey: int16(protocol.LeaveGroup), MinVersion: 0, MaxVersion: 2},
+	{ApiKey: int16(protocol.SyncGroup), MinVersion: 0, MaxVersion: 3},
+	{ApiKey: int16(protocol.DescribeGroups), MinVersion: 0, MaxVersion: 4},
+	{ApiKey: int16(protocol.ApiVersions), MinVersion: 0, MaxVersion: 2},
+	{ApiKey: int16(protocol.CreateTopics), MinVersion: 0, MaxVersion: 4},
+	{ApiKey: int16(protocol.DeleteTopics), MinVersion: 0, MaxVersion: 3},
+	{ApiKey: int16(protocol.CreatePartitions), MinVersion: 0, MaxVersion: 1},
+	{ApiKey: int16(protocol.DescribeConfigs), MinVersion: 0, MaxVersion: 3},
+	{ApiKey: int16(protocol.IncrementalAlterConfigs), MinVersion: 0, MaxVersion: 0},
+	{ApiKey: int16(protocol.ListGroups), MinVersion: 0, MaxVersion: 2},
+	{ApiKey: int16(protocol.DeleteGroups), MinVersion: 0, MaxVersion: 1},
+	{ApiKey: int16(protocol.InitProducerId), MinVersion: 0, MaxVersion: 4},
+	{ApiKey: int16(protocol.AddPartitionsToTxn), MinVersion: 0, MaxVersion: 3},
+	{ApiKey: int16(protocol.AddOffsetsToTxn), MinVersion: 0, MaxVersion: 3},
+	{ApiKey: int16(protocol.TxnOffsetCommit), MinVersion: 3, MaxVersion: 3},
+	{ApiKey: int16(protocol.EndTxn), MinVersion: 0, MaxVersion: 3},
+}
+
+// ErrClosed is returned by the wait helpers when the broker is closed.
+var ErrClosed = errors.New("kafkatest: broker closed")
+
+// Broker is an in-memory Kafka broker listening on a local TCP port.
+type Broker struct {
+	config   *Config
+	log      log.Log
+	listener net.Listener
+	host     string
+	port     int32
+	lock     sync.Mutex
+	topics   map[string]*topic
+	groups   map[string]*group
+	txns     map[string]*transaction     // Producer sessions by transactional ID
+	seqs     map[producerPartition]int32 // Next sequence number of the idempotent producer sessions
+	pids     int64                       // Last assigned producer ID
+	conns    map[net.Conn]struct{}
+	changed  chan struct{} // Closed and replaced when messages are appended or offsets committed
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// topic holds the messages and the settings of a topic.
+type topic struct {
+	partitions        [][]kafka.Message // Messages of every partition, the index is the offset
+	appended          []kafka.Message   // Messages of every partition in the order they were appended
+	replicationFactor int               // Reported in metadata, replication is not simulated
+	configs           map[string]string // Topic level configs set on create or alter
+}
+
+// New starts a new in-memory broker with the provided options.
+func New(options ...Option) (*Broker, error) {
+	config := GetDefaultConfig()
+	for _, opt := range options {
+		opt(config)
+	}
+	listener, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("kafkatest.New: error listening: %w", err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	b := &Broker{
+		config:   config,
+		log:      config.Log,
+		listener: listener,
+		host:     host,
+		port:     int32(portNum),
+		topics:   map[string]*topic{},
+		groups:   map[string]*group{},
+		txns:     map[string]*transaction{},
+		seqs:     map[producerPartition]int32{},
+		conns:    map[net.Conn]struct{}{},
+		changed:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for name, partitions := range config.Topics {
+		b.CreateTopic(name, partitions)
+	}
+	b.wg.Add(2)
+	go b.accept()
+	go b.expireMembers()
+	return b, nil
+}
+
+// Addr returns the address of the broker.
+func (b *Broker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// CredConfig returns a credential config with the address of the broker.
+func (b *Broker) CredConfig() *gokafka.CredConfig {
+	return &gokafka.CredConfig{Brokers: []string{b.Addr()}}
+}
+
+// Close stops the broker, closing the client connections.
+func (b *Broker) Close() error {
+	b.lock.Lock()
+	select {
+	case <-b.done:
+		b.lock.Unlock()
+		return nil
+	default:
+	}
+	close(b.done)
+	err := b.listener.Close()
+	for conn := range b.conns {
+		conn.Close()
+	}
+	b.lock.Unlock()
+	b.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("Broker.Close: %w", err)
+	}
+	return nil
+}
+
+// CreateTopic creates the topic with the number of partitions, an existing topic is grown to the number of partitions.
+func (b *Broker) CreateTopic(name string, partitions int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.createTopic(name, partitions)
+}
+
+// createTopic creates or grows the topic, must be called with the lock held.
+func (b *Broker) createTopic(name string, partitions int) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{replicationFactor: 1, configs: map[string]string{}}
+		b.topics[name] = t
+	}
+	for len(t.partitions) < max(partitions, 1) {
+		t.partitions = append(t.partitions, nil)
+	}
+	return t
+}
+
+// getTopic returns the topic, creating it when auto creation is enabled. Must be called with the lock held.
+func (b *Broker) getTopic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok && b.config.AutoCreateTopics {
+		t = b.createTopic(name, b.config.Partitions)
+	}
+	return t
+}
+
+// Topics returns the sorted names of the topics.
+func (b *Broker) Topics() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	names := make([]string, 0, len(b.topics))
+	for name := range b.topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// signal wakes the fetches and helpers waiting for a change, must be called with the lock held.
+func (b *Broker) signal() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// wait blocks till the next change, the deadline or the broker is closed. ch must be read with the lock held.
+func (b *Broker) wait(ctx context.Context, ch chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-b.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// accept accepts client connections till the broker is closed.
+func (b *Broker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.lock.Lock()
+		select {
+		case <-b.done:
+			b.lock.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		b.conns[conn] = struct{}{}
+		b.wg.Add(1)
+		b.lock.Unlock()
+		go b.serve(conn)
+	}
+}
+
+// serve reads the requests of the connection and writes their responses in order.
+func (b *Broker) serve(conn net.Conn) {
+	defer b.wg.Done()
+	defer func() {
+		b.lock.Lock()
+		delete(b.conns, conn)
+		b.lock.Unlock()
+		conn.Close()
+	}()
+	ctx, cancel := context.WithCancel(correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaTestBroker")))
+	defer cancel()
+	go func() {
+		select {
+		case <-b.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	for {
+		version, correlationID, clientID, req, err := protocol.ReadRequest(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, net.ErrClosed) {
+				b.log.Error(ctx, "error reading request", err)
+			}
+			return
+		}
+		res, err := b.handle(ctx, &client{id: clientID, host: host}, req)
+		if err != nil {
+			b.log.Error(ctx, "error handling request", err)
+			return
+		}
+		switch res := res.(type) {
+		case nil:
+			continue
+		case rawResponse:
+			err = res.writeTo(w, correlationID)
+		default:
+			err = protocol.WriteResponse(w, version, correlationID, res.(protocol.Message))
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				b.log.Error(ctx, "error writing response", err)
+			}
+			return
+		}
+	}
+}
+
+// client identifies the connection a request is received on.
+type client struct {
+	id   string
+	host string
+}
+
+// handle dispatches the request to its handler and returns the response, nil when the request has no response.
+func (b *Broker) handle(ctx context.Context, c *client, req protocol.Message) (any, error) {
+	switch req := req.(type) {
+	case *apiversions.Request:
+		return &apiversions.Response{ApiKeys: b.apiVersions()}, nil
+	case *metadata.Request:
+		return b.metadata(req), nil
+	case *findcoordinator.Request:
+		return &findcoordinator.Response{NodeID: nodeID, Host: b.host, Port: b.port}, nil
+	}
+	if res, ok := b.handleLog(ctx, req); ok {
+		return res, nil
+	}
+	if res, ok := b.handleGroup(ctx, c, req); ok {
+		return res, nil
+	}
+	if res, ok := b.handleAdmin(req); ok {
+		return res, nil
+	}
+	if b.config.Transactions {
+		if res, ok := b.handleTxn(req); ok {
+			return res, nil
+		}
+	}
+	return nil, fmt.Errorf("Broker.handle: unsupported request %v", req.ApiKey())
+}
+
+// apiVersions returns the API versions advertised to clients, without the producer session and transaction requests
+// when they are disabled.
+func (b *Broker) apiVersions() []apiversions.ApiKeyResponse {
+	if b.config.Transactions {
+		return supportedVersions
+	}
+	versions := make([]apiversions.ApiKeyResponse, 0, len(supportedVersions))
+	for _, version := range supportedVersions {
+		switch protocol.ApiKey(version.ApiKey) {
+		case protocol.InitProducerId, protocol.AddPartitionsToTxn, protocol.AddOffsetsToTxn, protocol.TxnOffsetCommit, protocol.EndTxn:
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// metadata describes the requested topics, or every topic when none is requested.
+func (b *Broker) metadata(req *metadata.Request) *metadata.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	names := req.TopicNames
+	if len(names) == 0 {
+		for name := range b.topics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	res := &metadata.Response{
+		Brokers:      []metadata.ResponseBroker{{NodeID: nodeID, Host: b.host, Port: b.port}},
+		ClusterID:    "kafkatest",
+		ControllerID: nodeID,
+		Topics:       make([]metadata.ResponseTopic, 0, len(names)),
+	}
+	for _, name := range names {
+		t := b.getTopic(name)
+		if t == nil {
+			res.Topics = append(res.Topics, metadata.ResponseTopic{Name: name, ErrorCode: int16(kafka.UnknownTopicOrPartition)})
+			continue
+		}
+		replicas := make([]int32, t.replicationFactor)
+		for i := range replicas {
+			replicas[i] = nodeID
+		}
+		rt := metadata.ResponseTopic{Name: name, Partitions: make([]metadata.ResponsePartition, len(t.partitions))}
+		for i := range t.partitions {
+			rt.Partitions[i] = metadata.ResponsePartition{
+				PartitionIndex: int32(i),
+				LeaderID:       nodeID,
+				ReplicaNodes:   replicas,
+				IsrNodes:       replicas,
+			}
+		}
+		res.Topics = append(res.Topics, rt)
+	}
+	return res
+}
+
+// expireMembers removes the group members whose session timed out.
+func (b *Broker) expireMembers() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case now := <-ticker.C:
+			b.lock.Lock()
+			for _, g := range b.groups {
+				b.expireGroup(g, now)
+			}
+			b.lock.Unlock()
+		}
+	}
+}
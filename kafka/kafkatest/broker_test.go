@@ -0,0 +1,108 @@
+package kafkatest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	gokafka "github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	"github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func newBroker(t *testing.T, options ...kafkatest.Option) *kafkatest.Broker {
+	broker, err := kafkatest.New(options...)
+	assert.NilError(t, err)
+	t.Cleanup(func() { broker.Close() })
+	return broker
+}
+
+func getContext() context.Context {
+	return correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("KafkaTest"))
+}
+
+func TestBrokerProduce(t *testing.T) {
+	broker := newBroker(t, kafkatest.WithTopic("orders", 3))
+	ctx := getContext()
+	pr, err := gokafka.NewProducer(gokafka.WithProducerCredConfig(broker.CredConfig()), gokafka.WithAsync(false), gokafka.WithBatch(true), gokafka.WithProducerTopic("orders"))
+	assert.NilError(t, err)
+	for i := 0; i < 10; i++ {
+		err = pr.Produce(ctx, "orders", fmt.Sprintf("key-%v", i%4), []byte(fmt.Sprintf("order-%v", i)), map[string]string{"source": "test"})
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, pr.Close(ctx))
+	msgs := broker.ExpectMessages(t, "orders", 10)
+	assert.Equal(t, len(msgs), 10)
+	msg := broker.ExpectMessage(t, "orders", func(msg *kafka.Message) bool { return string(msg.Value) == "order-5" })
+	assert.Equal(t, string(msg.Key), "key-1")
+	assert.Equal(t, kafkatest.Header(&msg, "source"), "test")
+	total := 0
+	for partition := 0; partition < 3; partition++ {
+		for i, m := range broker.PartitionMessages("orders", partition) {
+			assert.Equal(t, m.Offset, int64(i))
+			assert.Equal(t, m.Partition, partition)
+		}
+		total += len(broker.PartitionMessages("orders", partition))
+	}
+	assert.Equal(t, total, 10)
+	broker.ExpectNoMessages(t, "payments")
+}
+
+func TestBrokerConsume(t *testing.T) {
+	broker := newBroker(t, kafkatest.WithTopic("orders", 2))
+	ctx := getContext()
+	for i := 0; i < 6; i++ {
+		err := broker.Produce("orders", kafka.Message{Partition: i % 2, Key: []byte(fmt.Sprintf("key-%v", i)), Value: []byte(fmt.Sprintf("order-%v", i))})
+		assert.NilError(t, err)
+	}
+	co, err := gokafka.NewPoller(
+		gokafka.WithConsumerCredConfig(broker.CredConfig()),
+		gokafka.WithGroupID("orders-group"),
+		gokafka.WithConsumerTopic([]string{"orders"}),
+		gokafka.WithAutoCommit(true),
+		gokafka.WithConsumerBuffer(1),
+		gokafka.WithStatsInterval(200),
+	)
+	assert.NilError(t, err)
+	pollCtx, cancel := context.WithCancel(ctx)
+	ch := make(chan *kafka.Message, 10)
+	done := make(chan error, 1)
+	go func() { done <- co.Poll(pollCtx, ch) }()
+	received := map[string]bool{}
+	timeout := time.After(10 * time.Second)
+	for len(received) < 6 {
+		select {
+		case msg := <-ch:
+			received[string(msg.Value)] = true
+		case <-timeout:
+			t.Fatalf("received %v of 6 messages", len(received))
+		}
+	}
+	broker.ExpectCommitted(t, "orders-group", "orders", 0, 3)
+	broker.ExpectCommitted(t, "orders-group", "orders", 1, 3)
+	caughtUp := func() bool {
+		for _, info := range co.Status().Partitions["orders"] {
+			if info.HighWaterMark != 3 || info.Committed != 3 {
+				return false
+			}
+		}
+		return true
+	}
+	for deadline := time.Now().Add(5 * time.Second); !caughtUp() && time.Now().Before(deadline); {
+		time.Sleep(100 * time.Millisecond)
+	}
+	status := co.Status()
+	assert.Equal(t, status.RefreshError, "")
+	assert.Equal(t, len(status.Partitions["orders"]), 2)
+	for partition, info := range status.Partitions["orders"] {
+		assert.Equal(t, info.HighWaterMark, int64(3), partition)
+		assert.Equal(t, info.Committed, int64(3), partition)
+		assert.Equal(t, info.Lag, int64(0), partition)
+	}
+	cancel()
+	assert.NilError(t, <-done)
+	assert.NilError(t, co.Close(ctx))
+}
@@ -0,0 +1,102 @@
+package kafkatest
+
+import (
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log"
+)
+
+// Config holds the configuration of the in-memory broker.
+type Config struct {
+	Addr             string         // Address to listen on, port 0 picks a free local port
+	Partitions       int            // Number of partitions of auto created topics
+	AutoCreateTopics bool           // Create unknown topics on metadata and produce requests
+	Topics           map[string]int // Topics to create on start with their partition count
+	RebalanceTimeout time.Duration  // Maximum time a rebalance waits for the known members to rejoin
+	MaxWait          time.Duration  // Upper bound of the time a fetch waits for new messages
+	WaitTimeout      time.Duration  // Time the Expect helpers wait for messages and offsets
+	Transactions     bool           // Serve the idempotent and transactional producer requests, advertised in the API versions
+	Log              log.Log        // Logger instance
+}
+
+// GetDefaultConfig creates a new Config with default values.
+func GetDefaultConfig() *Config {
+	return &Config{
+		Addr:             "127.0.0.1:0",
+		Partitions:       1,
+		AutoCreateTopics: true,
+		Topics:           map[string]int{},
+		RebalanceTimeout: 5 * time.Second,
+		MaxWait:          500 * time.Millisecond,
+		WaitTimeout:      10 * time.Second,
+		Transactions:     true,
+		Log:              log.New(log.WithModuleName("KafkaTestBroker")),
+	}
+}
+
+// Option represents options for configuring the broker.
+type Option func(*Config)
+
+// WithAddr sets the address the broker listens on.
+func WithAddr(addr string) Option {
+	return func(c *Config) {
+		c.Addr = addr
+	}
+}
+
+// WithPartitions sets the number of partitions of auto created topics.
+func WithPartitions(partitions int) Option {
+	return func(c *Config) {
+		c.Partitions = partitions
+	}
+}
+
+// WithAutoCreateTopics sets whether unknown topics are created on first use.
+func WithAutoCreateTopics(autoCreate bool) Option {
+	return func(c *Config) {
+		c.AutoCreateTopics = autoCreate
+	}
+}
+
+// WithTopic creates the topic with the number of partitions when the broker starts.
+func WithTopic(topic string, partitions int) Option {
+	return func(c *Config) {
+		c.Topics[topic] = partitions
+	}
+}
+
+// WithRebalanceTimeout sets the maximum time a rebalance waits for the known members to rejoin.
+func WithRebalanceTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.RebalanceTimeout = timeout
+	}
+}
+
+// WithMaxWait sets the upper bound of the time a fetch waits for new messages.
+func WithMaxWait(wait time.Duration) Option {
+	return func(c *Config) {
+		c.MaxWait = wait
+	}
+}
+
+// WithWaitTimeout sets the time the Expect helpers wait for messages and offsets.
+func WithWaitTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.WaitTimeout = timeout
+	}
+}
+
+// WithTransactions sets whether the idempotent and transactional producer requests are served, disable it to test
+// producers against a broker without support for them.
+func WithTransactions(enabled bool) Option {
+	return func(c *Config) {
+		c.Transactions = enabled
+	}
+}
+
+// WithLog sets the logger of the broker.
+func WithLog(logger log.Log) Option {
+	return func(c *Config) {
+		c.Log = logger
+	}
+}
@@ -0,0 +1,458 @@
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/describegroups"
+	"github.com/segmentio/kafka-go/protocol/heartbeat"
+	"github.com/segmentio/kafka-go/protocol/joingroup"
+	"github.com/segmentio/kafka-go/protocol/leavegroup"
+	"github.com/segmentio/kafka-go/protocol/offsetcommit"
+	"github.com/segmentio/kafka-go/protocol/offsetfetch"
+	"github.com/segmentio/kafka-go/protocol/syncgroup"
+)
+
+// Group states reported by DescribeGroups.
+const (
+	stateEmpty     = "Empty"
+	statePreparing = "PreparingRebalance"
+	stateSyncing   = "CompletingRebalance"
+	stateStable    = "Stable"
+	stateDead      = "Dead"
+)
+
+// group is a consumer group coordinated by the broker.
+type group struct {
+	id           string
+	state        string
+	generation   int32
+	protocolType string
+	protocol     string
+	leader       string
+	members      map[string]*member
+	join         *joinRound                 // Rebalance in progress, nil when the group is not rebalancing
+	sync         chan struct{}              // Closed when the leader sends the assignments of the generation
+	offsets      map[string]map[int32]int64 // Committed offsets by topic and partition
+	deadline     time.Time                  // Time the rebalance in progress completes without the members that did not rejoin
+}
+
+// member is a member of a consumer group.
+type member struct {
+	id             string
+	clientID       string
+	clientHost     string
+	protocols      []joingroup.RequestProtocol
+	assignment     []byte
+	joined         bool // Rejoined for the rebalance in progress
+	sessionTimeout time.Duration
+	lastHeartbeat  time.Time
+}
+
+// joinRound is a rebalance of a group, done is closed when every member has rejoined.
+type joinRound struct {
+	done       chan struct{}
+	generation int32
+	leader     string
+	protocol   string
+	members    []joingroup.ResponseMember
+}
+
+// getGroup returns the group, creating it when missing. Must be called with the lock held.
+func (b *Broker) getGroup(id string) *group {
+	g, ok := b.groups[id]
+	if !ok {
+		g = &group{id: id, state: stateEmpty, members: map[string]*member{}, offsets: map[string]map[int32]int64{}}
+		b.groups[id] = g
+	}
+	return g
+}
+
+// handleGroup handles the consumer group requests, returns false for other requests.
+func (b *Broker) handleGroup(ctx context.Context, c *client, req protocol.Message) (any, bool) {
+	switch req := req.(type) {
+	case *joingroup.Request:
+		return b.joinGroup(ctx, c, req), true
+	case *syncgroup.Request:
+		return b.syncGroup(ctx, req), true
+	case *heartbeat.Request:
+		return b.heartbeat(req), true
+	case *leavegroup.Request:
+		return b.leaveGroup(req), true
+	case *offsetcommit.Request:
+		return b.offsetCommit(req), true
+	case *offsetfetch.Request:
+		return b.offsetFetch(req), true
+	case *describegroups.Request:
+		return b.describeGroups(req), true
+	}
+	return nil, false
+}
+
+// joinGroup adds the member to the group and blocks till every known member has rejoined or the rebalance times out.
+func (b *Broker) joinGroup(ctx context.Context, c *client, req *joingroup.Request) *joingroup.Response {
+	b.lock.Lock()
+	g := b.getGroup(req.GroupID)
+	if g.protocolType != "" && len(g.members) > 0 && req.ProtocolType != g.protocolType {
+		b.lock.Unlock()
+		return &joingroup.Response{ErrorCode: int16(kafka.InconsistentGroupProtocol), GenerationID: -1}
+	}
+	memberID := req.MemberID
+	m, ok := g.members[memberID]
+	if memberID != "" && !ok {
+		b.lock.Unlock()
+		return &joingroup.Response{ErrorCode: int16(kafka.UnknownMemberId), GenerationID: -1}
+	}
+	round := b.prepareRebalance(g)
+	if !ok {
+		memberID = c.id + "-" + uuid.NewString()
+		m = &member{id: memberID, clientID: c.id, clientHost: c.host}
+		g.members[memberID] = m
+	}
+	m.protocols = req.Protocols
+	m.sessionTimeout = time.Duration(req.SessionTimeoutMS) * time.Millisecond
+	m.lastHeartbeat = time.Now()
+	m.joined = true
+	g.protocolType = req.ProtocolType
+	b.completeJoin(g)
+	b.lock.Unlock()
+	select {
+	case <-round.done:
+	case <-ctx.Done():
+		return &joingroup.Response{ErrorCode: int16(kafka.RebalanceInProgress), GenerationID: -1}
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, ok := g.members[memberID]; !ok || round.generation < 0 {
+		return &joingroup.Response{ErrorCode: int16(kafka.UnknownMemberId), GenerationID: -1}
+	}
+	res := &joingroup.Response{
+		GenerationID: round.generation,
+		ProtocolName: round.protocol,
+		LeaderID:     round.leader,
+		MemberID:     memberID,
+	}
+	if memberID == round.leader {
+		res.Members = round.members
+	}
+	return res
+}
+
+// prepareRebalance starts a rebalance of the group unless one is in progress, returns the rebalance.
+// Must be called with the lock held.
+func (b *Broker) prepareRebalance(g *group) *joinRound {
+	if g.join != nil {
+		return g.join
+	}
+	g.join = &joinRound{done: make(chan struct{}), generation: -1}
+	g.state = statePreparing
+	g.deadline = time.Now().Add(b.config.RebalanceTimeout)
+	for _, m := range g.members {
+		m.joined = false
+	}
+	return g.join
+}
+
+// completeJoin completes the rebalance in progress once every member has rejoined, or removes the members
+// that did not rejoin once the rebalance times out. Must be called with the lock held.
+func (b *Broker) completeJoin(g *group) {
+	if g.join == nil {
+		return
+	}
+	expired := time.Now().After(g.deadline)
+	for id, m := range g.members {
+		if m.joined {
+			continue
+		}
+		if !expired {
+			return
+		}
+		delete(g.members, id)
+	}
+	round := g.join
+	g.join = nil
+	if len(g.members) == 0 {
+		g.state = stateEmpty
+		g.generation++
+		close(round.done)
+		return
+	}
+	ids := make([]string, 0, len(g.members))
+	for id := range g.members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if _, ok := g.members[g.leader]; !ok {
+		g.leader = ids[0]
+	}
+	g.protocol = selectProtocol(g, ids)
+	g.generation++
+	g.state = stateSyncing
+	g.sync = make(chan struct{})
+	round.generation = g.generation
+	round.leader = g.leader
+	round.protocol = g.protocol
+	for _, id := range ids {
+		m := g.members[id]
+		m.assignment = nil
+		for _, p := range m.protocols {
+			if p.Name == g.protocol {
+				round.members = append(round.members, joingroup.ResponseMember{MemberID: id, Metadata: p.Metadata})
+			}
+		}
+	}
+	close(round.done)
+}
+
+// selectProtocol returns the first protocol of the leader that every member supports.
+func selectProtocol(g *group, ids []string) string {
+	for _, p := range g.members[g.leader].protocols {
+		supported := true
+		for _, id := range ids {
+			found := false
+			for _, mp := range g.members[id].protocols {
+				if mp.Name == p.Name {
+					found = true
+					break
+				}
+			}
+			supported = supported && found
+		}
+		if supported {
+			return p.Name
+		}
+	}
+	return g.members[g.leader].protocols[0].Name
+}
+
+// syncGroup stores the assignments sent by the leader and returns the assignment of the member once the leader has synced.
+func (b *Broker) syncGroup(ctx context.Context, req *syncgroup.Request) *syncgroup.Response {
+	b.lock.Lock()
+	g := b.getGroup(req.GroupID)
+	m, code := g.validate(req.MemberID, req.GenerationID)
+	if code == 0 && g.state == statePreparing {
+		code = int16(kafka.RebalanceInProgress)
+	}
+	if code != 0 {
+		b.lock.Unlock()
+		return &syncgroup.Response{ErrorCode: code}
+	}
+	sync := g.sync
+	if req.MemberID == g.leader && g.state == stateSyncing {
+		for _, a := range req.Assignments {
+			if am, ok := g.members[a.MemberID]; ok {
+				am.assignment = a.Assignment
+			}
+		}
+		g.state = stateStable
+		close(sync)
+	}
+	b.lock.Unlock()
+	select {
+	case <-sync:
+	case <-ctx.Done():
+		return &syncgroup.Response{ErrorCode: int16(kafka.RebalanceInProgress)}
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if g.generation != req.GenerationID || g.state != stateStable {
+		return &syncgroup.Response{ErrorCode: int16(kafka.RebalanceInProgress)}
+	}
+	return &syncgroup.Response{Assignments: m.assignment}
+}
+
+// validate returns the member and the error code of a request of the member for the generation.
+func (g *group) validate(memberID string, generation int32) (*member, int16) {
+	m, ok := g.members[memberID]
+	if !ok {
+		return nil, int16(kafka.UnknownMemberId)
+	}
+	if generation != g.generation {
+		return nil, int16(kafka.IllegalGeneration)
+	}
+	return m, 0
+}
+
+// heartbeat keeps the session of the member alive and tells it to rejoin when the group is rebalancing.
+func (b *Broker) heartbeat(req *heartbeat.Request) *heartbeat.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	g := b.getGroup(req.GroupID)
+	m, code := g.validate(req.MemberID, req.GenerationID)
+	if code != 0 {
+		return &heartbeat.Response{ErrorCode: code}
+	}
+	m.lastHeartbeat = time.Now()
+	if g.state == statePreparing {
+		return &heartbeat.Response{ErrorCode: int16(kafka.RebalanceInProgress)}
+	}
+	return &heartbeat.Response{}
+}
+
+// leaveGroup removes the member and rebalances the remaining members.
+func (b *Broker) leaveGroup(req *leavegroup.Request) *leavegroup.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	g := b.getGroup(req.GroupID)
+	if _, ok := g.members[req.MemberID]; !ok {
+		return &leavegroup.Response{ErrorCode: int16(kafka.UnknownMemberId)}
+	}
+	b.removeMember(g, req.MemberID)
+	return &leavegroup.Response{}
+}
+
+// removeMember removes the member and starts a rebalance of the remaining members. Must be called with the lock held.
+func (b *Broker) removeMember(g *group, memberID string) {
+	delete(g.members, memberID)
+	if g.state == stateSyncing && g.sync != nil {
+		close(g.sync)
+		g.sync = nil
+	}
+	if len(g.members) == 0 && g.join == nil {
+		g.state = stateEmpty
+		g.generation++
+		return
+	}
+	b.prepareRebalance(g)
+	b.completeJoin(g)
+}
+
+// expireGroup removes the members of the group whose session timed out and completes a timed out rebalance.
+// Must be called with the lock held.
+func (b *Broker) expireGroup(g *group, now time.Time) {
+	for id, m := range g.members {
+		if g.join == nil && m.sessionTimeout > 0 && now.Sub(m.lastHeartbeat) > m.sessionTimeout {
+			b.removeMember(g, id)
+		}
+	}
+	b.completeJoin(g)
+}
+
+// offsetCommit stores the committed offsets of the group.
+func (b *Broker) offsetCommit(req *offsetcommit.Request) *offsetcommit.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	g := b.getGroup(req.GroupID)
+	var code int16
+	if req.GenerationID >= 0 || req.MemberID != "" {
+		_, code = g.validate(req.MemberID, req.GenerationID)
+	}
+	res := &offsetcommit.Response{Topics: make([]offsetcommit.ResponseTopic, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		resTopic := offsetcommit.ResponseTopic{Name: rt.Name, Partitions: make([]offsetcommit.ResponsePartition, 0, len(rt.Partitions))}
+		for _, rp := range rt.Partitions {
+			if code == 0 {
+				if g.offsets[rt.Name] == nil {
+					g.offsets[rt.Name] = map[int32]int64{}
+				}
+				g.offsets[rt.Name][rp.PartitionIndex] = rp.CommittedOffset
+			}
+			resTopic.Partitions = append(resTopic.Partitions, offsetcommit.ResponsePartition{PartitionIndex: rp.PartitionIndex, ErrorCode: code})
+		}
+		res.Topics = append(res.Topics, resTopic)
+	}
+	b.signal()
+	return res
+}
+
+// offsetFetch returns the committed offsets of the group, -1 for partitions without a commit.
+func (b *Broker) offsetFetch(req *offsetfetch.Request) *offsetfetch.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	g := b.getGroup(req.GroupID)
+	topics := req.Topics
+	if topics == nil {
+		for name, pMap := range g.offsets {
+			rt := offsetfetch.RequestTopic{Name: name}
+			for partition := range pMap {
+				rt.PartitionIndexes = append(rt.PartitionIndexes, partition)
+			}
+			topics = append(topics, rt)
+		}
+	}
+	res := &offsetfetch.Response{Topics: make([]offsetfetch.ResponseTopic, 0, len(topics))}
+	for _, rt := range topics {
+		resTopic := offsetfetch.ResponseTopic{Name: rt.Name, Partitions: make([]offsetfetch.ResponsePartition, 0, len(rt.PartitionIndexes))}
+		for _, partition := range rt.PartitionIndexes {
+			offset, ok := g.offsets[rt.Name][partition]
+			if !ok {
+				offset = -1
+			}
+			resTopic.Partitions = append(resTopic.Partitions, offsetfetch.ResponsePartition{PartitionIndex: partition, CommittedOffset: offset, ComittedLeaderEpoch: -1})
+		}
+		res.Topics = append(res.Topics, resTopic)
+	}
+	return res
+}
+
+// describeGroups describes the state and members of the groups.
+func (b *Broker) describeGroups(req *describegroups.Request) *describegroups.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &describegroups.Response{Groups: make([]describegroups.ResponseGroup, 0, len(req.Groups))}
+	for _, id := range req.Groups {
+		g, ok := b.groups[id]
+		if !ok {
+			res.Groups = append(res.Groups, describegroups.ResponseGroup{GroupID: id, GroupState: stateDead})
+			continue
+		}
+		rg := describegroups.ResponseGroup{
+			GroupID:      id,
+			GroupState:   g.state,
+			ProtocolType: g.protocolType,
+			ProtocolData: g.protocol,
+		}
+		for _, m := range g.members {
+			rm := describegroups.ResponseGroupMember{
+				MemberID:         m.id,
+				ClientID:         m.clientID,
+				ClientHost:       m.clientHost,
+				MemberAssignment: m.assignment,
+			}
+			for _, p := range m.protocols {
+				if p.Name == g.protocol {
+					rm.MemberMetadata = p.Metadata
+				}
+			}
+			rg.Members = append(rg.Members, rm)
+		}
+		res.Groups = append(res.Groups, rg)
+	}
+	return res
+}
+
+// CommittedOffset returns the offset committed by the group for the partition, -1 when none is committed.
+func (b *Broker) CommittedOffset(groupID, topicName string, partition int) int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	g, ok := b.groups[groupID]
+	if !ok {
+		return -1
+	}
+	offset, ok := g.offsets[topicName][int32(partition)]
+	if !ok {
+		return -1
+	}
+	return offset
+}
+
+// WaitForCommit blocks till the group has committed at least the offset for the partition or the context is done.
+func (b *Broker) WaitForCommit(ctx context.Context, groupID, topicName string, partition int, offset int64) error {
+	for {
+		b.lock.Lock()
+		ch := b.changed
+		b.lock.Unlock()
+		committed := b.CommittedOffset(groupID, topicName, partition)
+		if committed >= offset {
+			return nil
+		}
+		if err := b.wait(ctx, ch); err != nil {
+			return fmt.Errorf("Broker.WaitForCommit: committed offset of partition %v of %v is %v, want %v: %w", partition, topicName, committed, offset, err)
+		}
+	}
+}
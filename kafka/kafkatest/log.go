@@ -0,0 +1,356 @@
+package kafkatest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/fetch"
+	"github.com/segmentio/kafka-go/protocol/listoffsets"
+	"github.com/segmentio/kafka-go/protocol/produce"
+)
+
+// handleLog handles the produce, fetch and list offsets requests, returns false for other requests.
+func (b *Broker) handleLog(ctx context.Context, req protocol.Message) (any, bool) {
+	switch req := req.(type) {
+	case *produce.Request:
+		res := b.produce(req)
+		if req.Acks == 0 {
+			return nil, true
+		}
+		return res, true
+	case *fetch.Request:
+		return b.fetch(ctx, req), true
+	case *listoffsets.Request:
+		return b.listOffsets(req), true
+	}
+	return nil, false
+}
+
+// Produce appends the messages to the topic, as if written by a producer. The topic is created when missing.
+// The partition of each message is taken from [kafka.Message.Partition].
+func (b *Broker) Produce(topicName string, msgs ...kafka.Message) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	t := b.topics[topicName]
+	if t == nil {
+		t = b.createTopic(topicName, b.config.Partitions)
+	}
+	for _, msg := range msgs {
+		if msg.Partition < 0 || msg.Partition >= len(t.partitions) {
+			return fmt.Errorf("Broker.Produce: partition %v of %v does not exist", msg.Partition, topicName)
+		}
+	}
+	for _, msg := range msgs {
+		t.append(topicName, msg.Partition, msg)
+	}
+	b.signal()
+	return nil
+}
+
+// append assigns the offset and time of the message and appends it to the partition, returns the offset.
+func (t *topic) append(name string, partition int, msg kafka.Message) int64 {
+	offset := int64(len(t.partitions[partition]))
+	msg.Topic = name
+	msg.Partition = partition
+	msg.Offset = offset
+	msg.HighWaterMark = 0
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	t.partitions[partition] = append(t.partitions[partition], msg)
+	t.appended = append(t.appended, msg)
+	return offset
+}
+
+// Messages returns the messages of the topic in the order they were appended.
+func (b *Broker) Messages(topicName string) []kafka.Message {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	t := b.topics[topicName]
+	if t == nil {
+		return nil
+	}
+	res := make([]kafka.Message, len(t.appended))
+	copy(res, t.appended)
+	return res
+}
+
+// PartitionMessages returns the messages of the partition of the topic in offset order.
+func (b *Broker) PartitionMessages(topicName string, partition int) []kafka.Message {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	t := b.topics[topicName]
+	if t == nil || partition < 0 || partition >= len(t.partitions) {
+		return nil
+	}
+	res := make([]kafka.Message, len(t.partitions[partition]))
+	copy(res, t.partitions[partition])
+	return res
+}
+
+// WaitForMessages blocks till the topic has at least n messages or the context is done, returns the messages of the topic.
+func (b *Broker) WaitForMessages(ctx context.Context, topicName string, n int) ([]kafka.Message, error) {
+	for {
+		b.lock.Lock()
+		var count int
+		if t := b.topics[topicName]; t != nil {
+			count = len(t.appended)
+		}
+		ch := b.changed
+		b.lock.Unlock()
+		if count >= n {
+			return b.Messages(topicName), nil
+		}
+		if err := b.wait(ctx, ch); err != nil {
+			return b.Messages(topicName), fmt.Errorf("Broker.WaitForMessages: %v of %v messages in %v: %w", count, n, topicName, err)
+		}
+	}
+}
+
+// produce appends the records of the request to their partitions, the records of a transaction are held back till it commits.
+func (b *Broker) produce(req *produce.Request) *produce.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &produce.Response{Topics: make([]produce.ResponseTopic, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		t := b.getTopic(rt.Topic)
+		resTopic := produce.ResponseTopic{Topic: rt.Topic, Partitions: make([]produce.ResponsePartition, 0, len(rt.Partitions))}
+		for _, rp := range rt.Partitions {
+			p := produce.ResponsePartition{Partition: rp.Partition, LogAppendTime: -1}
+			if t == nil || int(rp.Partition) >= len(t.partitions) {
+				p.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+				resTopic.Partitions = append(resTopic.Partitions, p)
+				continue
+			}
+			msgs, err := readRecords(rp.RecordSet.Records)
+			if err != nil {
+				p.ErrorCode = int16(kafka.InvalidMessage)
+				resTopic.Partitions = append(resTopic.Partitions, p)
+				continue
+			}
+			p.BaseOffset, p.ErrorCode = b.produceBatch(t, rt.Topic, rp.Partition, req.TransactionalID, recordBatch(rp.RecordSet.Records), msgs)
+			resTopic.Partitions = append(resTopic.Partitions, p)
+		}
+		res.Topics = append(res.Topics, resTopic)
+	}
+	b.signal()
+	return res
+}
+
+// readRecords copies the records of a produce request into messages.
+func readRecords(records protocol.RecordReader) ([]kafka.Message, error) {
+	var msgs []kafka.Message
+	if records == nil {
+		return nil, nil
+	}
+	for {
+		r, err := records.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			return msgs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		msg := kafka.Message{Time: r.Time}
+		if r.Key != nil {
+			if msg.Key, err = protocol.ReadAll(r.Key); err != nil {
+				return nil, err
+			}
+		}
+		if r.Value != nil {
+			if msg.Value, err = protocol.ReadAll(r.Value); err != nil {
+				return nil, err
+			}
+		}
+		for _, h := range r.Headers {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: h.Key, Value: append([]byte(nil), h.Value...)})
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+// listOffsets returns the first or last offset of the partitions, or the first offset at or after the timestamp.
+func (b *Broker) listOffsets(req *listoffsets.Request) *listoffsets.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := &listoffsets.Response{Topics: make([]listoffsets.ResponseTopic, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		t := b.getTopic(rt.Topic)
+		resTopic := listoffsets.ResponseTopic{Topic: rt.Topic, Partitions: make([]listoffsets.ResponsePartition, 0, len(rt.Partitions))}
+		for _, rp := range rt.Partitions {
+			p := listoffsets.ResponsePartition{Partition: rp.Partition, Timestamp: -1, LeaderEpoch: -1}
+			if t == nil || int(rp.Partition) >= len(t.partitions) {
+				p.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+				resTopic.Partitions = append(resTopic.Partitions, p)
+				continue
+			}
+			msgs := t.partitions[rp.Partition]
+			switch rp.Timestamp {
+			case kafka.FirstOffset:
+				p.Offset = 0
+			case kafka.LastOffset:
+				p.Offset = int64(len(msgs))
+			default:
+				p.Offset = int64(len(msgs))
+				for _, msg := range msgs {
+					if msg.Time.UnixMilli() >= rp.Timestamp {
+						p.Offset = msg.Offset
+						p.Timestamp = msg.Time.UnixMilli()
+						break
+					}
+				}
+			}
+			resTopic.Partitions = append(resTopic.Partitions, p)
+		}
+		res.Topics = append(res.Topics, resTopic)
+	}
+	return res
+}
+
+// fetchPartition is the result of a fetch of one partition.
+type fetchPartition struct {
+	partition     int32
+	errorCode     int16
+	highWaterMark int64
+	records       []byte
+}
+
+// fetchTopic is the result of a fetch of one topic.
+type fetchTopic struct {
+	topic      string
+	partitions []fetchPartition
+}
+
+// fetch returns the records of the requested partitions from the fetch offsets, waiting up to the max wait time
+// of the request, bounded by [Config.MaxWait], when there are none.
+func (b *Broker) fetch(ctx context.Context, req *fetch.Request) rawResponse {
+	wait := min(time.Duration(req.MaxWaitTime)*time.Millisecond, b.config.MaxWait)
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	for {
+		b.lock.Lock()
+		res, found := b.readPartitions(req)
+		ch := b.changed
+		b.lock.Unlock()
+		if found || b.wait(waitCtx, ch) != nil {
+			return encodeFetchResponse(res)
+		}
+	}
+}
+
+// readPartitions reads the records of the requested partitions, reports whether any record or error is found.
+// Must be called with the lock held.
+func (b *Broker) readPartitions(req *fetch.Request) ([]fetchTopic, bool) {
+	found := false
+	res := make([]fetchTopic, 0, len(req.Topics))
+	for _, rt := range req.Topics {
+		t := b.topics[rt.Topic]
+		resTopic := fetchTopic{topic: rt.Topic, partitions: make([]fetchPartition, 0, len(rt.Partitions))}
+		for _, rp := range rt.Partitions {
+			p := fetchPartition{partition: rp.Partition}
+			switch {
+			case t == nil || int(rp.Partition) >= len(t.partitions):
+				p.errorCode = int16(kafka.UnknownTopicOrPartition)
+				found = true
+			case rp.FetchOffset < 0 || rp.FetchOffset > int64(len(t.partitions[rp.Partition])):
+				p.highWaterMark = int64(len(t.partitions[rp.Partition]))
+				p.errorCode = int16(kafka.OffsetOutOfRange)
+				found = true
+			default:
+				msgs := t.partitions[rp.Partition]
+				p.highWaterMark = int64(len(msgs))
+				msgs = msgs[rp.FetchOffset:]
+				size := 0
+				for i, msg := range msgs {
+					size += len(msg.Key) + len(msg.Value) + 32
+					if i > 0 && size > int(rp.PartitionMaxBytes) {
+						msgs = msgs[:i]
+						break
+					}
+				}
+				if len(msgs) > 0 {
+					p.records = encodeBatch(msgs)
+					found = true
+				}
+			}
+			resTopic.partitions = append(resTopic.partitions, p)
+		}
+		res = append(res, resTopic)
+	}
+	return res, found
+}
+
+// encodeBatch encodes the messages as a size prefixed v2 record batch starting at the offset of the first message.
+func encodeBatch(msgs []kafka.Message) []byte {
+	records := make([]protocol.Record, len(msgs))
+	for i, msg := range msgs {
+		records[i] = protocol.Record{Offset: msg.Offset, Time: msg.Time}
+		if msg.Key != nil {
+			records[i].Key = protocol.NewBytes(msg.Key)
+		}
+		if msg.Value != nil {
+			records[i].Value = protocol.NewBytes(msg.Value)
+		}
+		for _, h := range msg.Headers {
+			records[i].Headers = append(records[i].Headers, protocol.Header{Key: h.Key, Value: h.Value})
+		}
+	}
+	rs := protocol.RecordSet{Version: 2, Records: protocol.NewRecordReader(records...)}
+	buf := &bytes.Buffer{}
+	rs.WriteTo(buf)
+	batch := buf.Bytes()
+	// the encoder always writes a base offset of 0, it follows the size prefix and is not covered by the CRC
+	binary.BigEndian.PutUint64(batch[4:12], uint64(msgs[0].Offset))
+	return batch
+}
+
+// rawResponse is a response encoded by the broker instead of the protocol package.
+type rawResponse []byte
+
+// writeTo writes the size prefixed response with the correlation ID.
+func (r rawResponse) writeTo(w *bufio.Writer, correlationID int32) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(r)+4))
+	binary.BigEndian.PutUint32(header[4:], uint32(correlationID))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(r)
+	return err
+}
+
+// encodeFetchResponse encodes a v10 fetch response, the protocol package cannot encode record batches at an offset.
+func encodeFetchResponse(topics []fetchTopic) rawResponse {
+	var b []byte
+	b = binary.BigEndian.AppendUint32(b, 0) // throttle time
+	b = binary.BigEndian.AppendUint16(b, 0) // error code
+	b = binary.BigEndian.AppendUint32(b, 0) // session ID
+	b = binary.BigEndian.AppendUint32(b, uint32(len(topics)))
+	for _, t := range topics {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(t.topic)))
+		b = append(b, t.topic...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(t.partitions)))
+		for _, p := range t.partitions {
+			b = binary.BigEndian.AppendUint32(b, uint32(p.partition))
+			b = binary.BigEndian.AppendUint16(b, uint16(p.errorCode))
+			b = binary.BigEndian.AppendUint64(b, uint64(p.highWaterMark)) // high-water mark
+			b = binary.BigEndian.AppendUint64(b, uint64(p.highWaterMark)) // last stable offset
+			b = binary.BigEndian.AppendUint64(b, 0)                       // log start offset
+			b = binary.BigEndian.AppendUint32(b, 0)                       // aborted transactions
+			if p.records == nil {
+				b = binary.BigEndian.AppendUint32(b, 0)
+				continue
+			}
+			b = append(b, p.records...) // size prefixed
+		}
+	}
+	return b
+}
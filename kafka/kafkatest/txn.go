@@ -0,0 +1,251 @@
+package kafkatest
+
+import (
+	"math"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/addoffsetstotxn"
+	"github.com/segmentio/kafka-go/protocol/addpartitionstotxn"
+	"github.com/segmentio/kafka-go/protocol/endtxn"
+	"github.com/segmentio/kafka-go/protocol/initproducerid"
+	"github.com/segmentio/kafka-go/protocol/txnoffsetcommit"
+)
+
+// producerPartition identifies the sequence numbers of a producer session on a partition.
+type producerPartition struct {
+	producerID int64
+	epoch      int16
+	topic      string
+	partition  int32
+}
+
+// pendingMessage is a message produced in a running transaction, appended to the partition when the transaction commits.
+type pendingMessage struct {
+	topic     string
+	partition int32
+	msg       kafka.Message
+}
+
+// transaction is the producer session of a transactional ID and its running transaction.
+type transaction struct {
+	producerID int64
+	epoch      int16
+	partitions map[string]map[int32]bool             // Partitions added to the running transaction
+	groups     map[string]bool                       // Consumer groups added to the running transaction
+	messages   []pendingMessage                      // Messages held back till the transaction ends
+	offsets    map[string]map[string]map[int32]int64 // Offsets by group, topic and partition, committed when the transaction commits
+}
+
+// reset ends the running transaction, dropping the messages and offsets it holds.
+func (txn *transaction) reset() {
+	txn.partitions = map[string]map[int32]bool{}
+	txn.groups = map[string]bool{}
+	txn.messages = nil
+	txn.offsets = map[string]map[string]map[int32]int64{}
+}
+
+// check validates the producer session of a request against the transaction, returns the error code.
+func (txn *transaction) check(producerID int64, epoch int16) int16 {
+	switch {
+	case txn == nil || txn.producerID != producerID:
+		return int16(kafka.InvalidProducerIDMapping)
+	case epoch != txn.epoch:
+		return int16(kafka.InvalidProducerEpoch)
+	}
+	return 0
+}
+
+// handleTxn handles the producer session and transaction requests, returns false for other requests.
+func (b *Broker) handleTxn(req protocol.Message) (any, bool) {
+	switch req := req.(type) {
+	case *initproducerid.Request:
+		return b.initProducerID(req), true
+	case *addpartitionstotxn.Request:
+		return b.addPartitionsToTxn(req), true
+	case *addoffsetstotxn.Request:
+		return b.addOffsetsToTxn(req), true
+	case *txnoffsetcommit.Request:
+		return b.txnOffsetCommit(req), true
+	case *endtxn.Request:
+		return b.endTxn(req), true
+	}
+	return nil, false
+}
+
+// initProducerID assigns a producer ID, a transactional ID keeps its producer ID and gets the next epoch,
+// fencing the previous session and aborting its running transaction.
+func (b *Broker) initProducerID(req *initproducerid.Request) *initproducerid.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if req.TransactionalID == "" {
+		b.pids++
+		return &initproducerid.Response{ProducerID: b.pids}
+	}
+	txn := b.txns[req.TransactionalID]
+	if txn == nil {
+		b.pids++
+		txn = &transaction{producerID: b.pids, epoch: -1}
+		b.txns[req.TransactionalID] = txn
+	}
+	txn.epoch++
+	txn.reset()
+	return &initproducerid.Response{ProducerID: txn.producerID, ProducerEpoch: txn.epoch}
+}
+
+// addPartitionsToTxn adds the partitions to the running transaction of the producer.
+func (b *Broker) addPartitionsToTxn(req *addpartitionstotxn.Request) *addpartitionstotxn.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	txn := b.txns[req.TransactionalID]
+	code := txn.check(req.ProducerID, req.ProducerEpoch)
+	res := &addpartitionstotxn.Response{Results: make([]addpartitionstotxn.ResponseResult, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		t := b.getTopic(rt.Name)
+		result := addpartitionstotxn.ResponseResult{Name: rt.Name, Results: make([]addpartitionstotxn.ResponsePartition, 0, len(rt.Partitions))}
+		for _, partition := range rt.Partitions {
+			p := addpartitionstotxn.ResponsePartition{PartitionIndex: partition, ErrorCode: code}
+			if code == 0 && (t == nil || int(partition) >= len(t.partitions)) {
+				p.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+			}
+			if p.ErrorCode == 0 {
+				if txn.partitions[rt.Name] == nil {
+					txn.partitions[rt.Name] = map[int32]bool{}
+				}
+				txn.partitions[rt.Name][partition] = true
+			}
+			result.Results = append(result.Results, p)
+		}
+		res.Results = append(res.Results, result)
+	}
+	return res
+}
+
+// addOffsetsToTxn adds the consumer group to the running transaction of the producer.
+func (b *Broker) addOffsetsToTxn(req *addoffsetstotxn.Request) *addoffsetstotxn.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	txn := b.txns[req.TransactionalID]
+	code := txn.check(req.ProducerID, req.ProducerEpoch)
+	if code == 0 {
+		txn.groups[req.GroupID] = true
+	}
+	return &addoffsetstotxn.Response{ErrorCode: code}
+}
+
+// txnOffsetCommit holds the offsets back till the transaction commits. The member and generation are validated when
+// either is set, as by OffsetCommit.
+func (b *Broker) txnOffsetCommit(req *txnoffsetcommit.Request) *txnoffsetcommit.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	txn := b.txns[req.TransactionalID]
+	code := txn.check(req.ProducerID, req.ProducerEpoch)
+	if code == 0 && !txn.groups[req.GroupID] {
+		code = int16(kafka.InvalidTransactionState)
+	}
+	if code == 0 && (req.GenerationID >= 0 || req.MemberID != "") {
+		_, code = b.getGroup(req.GroupID).validate(req.MemberID, req.GenerationID)
+	}
+	res := &txnoffsetcommit.Response{Topics: make([]txnoffsetcommit.ResponseTopic, 0, len(req.Topics))}
+	for _, rt := range req.Topics {
+		resTopic := txnoffsetcommit.ResponseTopic{Name: rt.Name, Partitions: make([]txnoffsetcommit.ResponsePartition, 0, len(rt.Partitions))}
+		for _, rp := range rt.Partitions {
+			if code == 0 {
+				if txn.offsets[req.GroupID] == nil {
+					txn.offsets[req.GroupID] = map[string]map[int32]int64{}
+				}
+				if txn.offsets[req.GroupID][rt.Name] == nil {
+					txn.offsets[req.GroupID][rt.Name] = map[int32]int64{}
+				}
+				txn.offsets[req.GroupID][rt.Name][rp.Partition] = rp.CommittedOffset
+			}
+			resTopic.Partitions = append(resTopic.Partitions, txnoffsetcommit.ResponsePartition{Partition: rp.Partition, ErrorCode: code})
+		}
+		res.Topics = append(res.Topics, resTopic)
+	}
+	return res
+}
+
+// endTxn appends the messages and commits the offsets of the running transaction on commit, drops them on abort.
+func (b *Broker) endTxn(req *endtxn.Request) *endtxn.Response {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	txn := b.txns[req.TransactionalID]
+	code := txn.check(req.ProducerID, req.ProducerEpoch)
+	if code != 0 {
+		return &endtxn.Response{ErrorCode: code}
+	}
+	if req.Committed {
+		for _, pm := range txn.messages {
+			if t := b.topics[pm.topic]; t != nil && int(pm.partition) < len(t.partitions) {
+				t.append(pm.topic, int(pm.partition), pm.msg)
+			}
+		}
+		for groupID, topics := range txn.offsets {
+			g := b.getGroup(groupID)
+			for name, partitions := range topics {
+				if g.offsets[name] == nil {
+					g.offsets[name] = map[int32]int64{}
+				}
+				for partition, offset := range partitions {
+					g.offsets[name][partition] = offset
+				}
+			}
+		}
+	}
+	txn.reset()
+	b.signal()
+	return &endtxn.Response{}
+}
+
+// produceBatch appends the messages of a record batch to the partition, returns the base offset and the error code.
+// The sequence number of an idempotent producer is checked, a duplicated batch is acknowledged without appending it;
+// the messages of a transactional batch are held back till the transaction ends. Must be called with the lock held.
+func (b *Broker) produceBatch(t *topic, name string, partition int32, transactionalID string, batch *protocol.RecordBatch, msgs []kafka.Message) (int64, int16) {
+	var txn *transaction
+	if batch != nil && batch.Attributes.Transactional() {
+		txn = b.txns[transactionalID]
+		if code := txn.check(batch.ProducerID, batch.ProducerEpoch); code != 0 {
+			return -1, code
+		}
+		if !txn.partitions[name][partition] {
+			return -1, int16(kafka.InvalidTransactionState)
+		}
+	}
+	if batch != nil && batch.ProducerID >= 0 {
+		key := producerPartition{producerID: batch.ProducerID, epoch: batch.ProducerEpoch, topic: name, partition: partition}
+		next := b.seqs[key]
+		switch {
+		case batch.BaseSequence < next:
+			return -1, 0
+		case batch.BaseSequence > next:
+			return -1, int16(kafka.OutOfOrderSequenceNumber)
+		}
+		b.seqs[key] = int32((int64(next) + int64(len(msgs))) % (math.MaxInt32 + 1))
+	}
+	if txn != nil {
+		for _, msg := range msgs {
+			txn.messages = append(txn.messages, pendingMessage{topic: name, partition: partition, msg: msg})
+		}
+		return -1, 0
+	}
+	offset := int64(len(t.partitions[partition]))
+	for _, msg := range msgs {
+		t.append(name, int(partition), msg)
+	}
+	return offset, 0
+}
+
+// recordBatch returns the first v2 record batch of a produce request, nil for v0 and v1 message sets.
+func recordBatch(records protocol.RecordReader) *protocol.RecordBatch {
+	switch r := records.(type) {
+	case *protocol.RecordBatch:
+		return r
+	case *protocol.RecordStream:
+		if len(r.Records) > 0 {
+			batch, _ := r.Records[0].(*protocol.RecordBatch)
+			return batch
+		}
+	}
+	return nil
+}
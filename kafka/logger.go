@@ -3,35 +3,42 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/sabariramc/goserverbase/v6/log"
+	m "github.com/sabariramc/goserverbase/v6/log/message"
 	"github.com/segmentio/kafka-go"
 )
 
-// kafkaLogger is a custom logger for Kafka operations.
-type kafkaLogger struct {
-	log.Log
-	ctx     context.Context
-	isError bool
-}
-
 var debugLogPrefix = []string{"no messages received from kafka within the allocated time for partition", "writing %d messages to"}
 
-// Printf logs a formatted message. Depending on the severity, it either logs an error or a debug/notice message.
-func (k *kafkaLogger) Printf(shortMessage string, logMessage ...interface{}) {
-	message := fmt.Sprintf(shortMessage, logMessage...)
-	if k.isError {
-		k.Error(k.ctx, message, nil)
-	} else {
+// newLoggers returns the kafka-go info and error loggers of the module, writing to the resource loggers of logger through [NewSlogLogger].
+func newLoggers(ctx context.Context, logger log.Log, moduleName string) (kafka.Logger, kafka.Logger) {
+	info := NewSlogLogger(ctx, slog.New(log.Handler(logger.NewResourceLogger(moduleName+":InfoLog"))), false)
+	errorLogger := NewSlogLogger(ctx, slog.New(log.Handler(logger.NewResourceLogger(moduleName+":ErrorLog"))), true)
+	return info, errorLogger
+}
+
+// NewSlogLogger creates a kafka-go logger that writes to logger with the levels used by the loggers of this package,
+// set it as the Logger and ErrorLogger of a reader or writer config to route the logs of kafka-go through log/slog.
+//
+//	readerConfig.ErrorLogger = kafka.NewSlogLogger(ctx, slog.New(log.Handler(logger)), true)
+func NewSlogLogger(ctx context.Context, logger *slog.Logger, isError bool) kafka.Logger {
+	return kafka.LoggerFunc(func(shortMessage string, logMessage ...interface{}) {
+		message := fmt.Sprintf(shortMessage, logMessage...)
+		if isError {
+			logger.Log(ctx, slog.LevelError, message)
+			return
+		}
 		for _, v := range debugLogPrefix {
 			if strings.HasPrefix(shortMessage, v) {
-				k.Debug(k.ctx, message, nil)
+				logger.Log(ctx, slog.LevelDebug, message)
 				return
 			}
 		}
-		k.Notice(k.ctx, message, nil)
-	}
+		logger.Log(ctx, m.NOTICE.SlogLevel(), message)
+	})
 }
 
 // kafkaDeliveryReportLogger is a custom logger for Kafka delivery reports.
@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// NewClient creates a [kafka.Client] for the brokers of the credential config.
+func NewClient(cred *CredConfig) *kafka.Client {
+	return &kafka.Client{
+		Addr: kafka.TCP(cred.Brokers...),
+		Transport: &kafka.Transport{
+			SASL: cred.SASLMechanism,
+			TLS:  cred.TLSConfig,
+		},
+	}
+}
+
+// ListTopics returns the sorted names of the topics in the cluster, internal topics are excluded.
+func ListTopics(ctx context.Context, cred *CredConfig) ([]string, error) {
+	res, err := NewClient(cred).Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("kafka.ListTopics: error fetching metadata: %w", err)
+	}
+	topics := make([]string, 0, len(res.Topics))
+	for _, topic := range res.Topics {
+		if topic.Internal || topic.Error != nil {
+			continue
+		}
+		topics = append(topics, topic.Name)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"github.com/sabariramc/goserverbase/v6/db/mongo"
+	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// Modes of detecting new outbox events.
+const (
+	ModeChangeStream = "CHANGE_STREAM" // Change stream on the collection wakes the relay, polling catches up on missed events
+	ModePoll         = "POLL"          // Relay polls the collection at the poll interval
+)
+
+// RelayConfig holds the configuration of the outbox relay.
+type RelayConfig struct {
+	Collection   *mongo.Collection // Outbox collection, the relay reads it with [NewMongoStore] when Store is nil
+	Store        Store             // Store of the outbox events, takes precedence over Collection
+	Producer     *kafka.Producer   // Producer publishing the events, must be a batch producer; created when nil
+	Mode         string            // [ModeChangeStream] or [ModePoll]
+	PollInterval uint              // Interval in milliseconds between polls
+	BatchSize    int               // Maximum number of events published at once
+	Retention    uint              // Seconds sent events are kept before the TTL index removes them
+	LeaseTTL     uint              // Milliseconds the relay lease is held without renewal, a standby relay takes over after it expires
+	Log          log.Log           // Logger instance
+}
+
+// GetDefaultRelayConfig creates a new RelayConfig with values from environment variables or default values.
+/*
+	Environment Variables
+	- OUTBOX__MODE: Sets [Mode]
+	- OUTBOX__POLL_INTERVAL: Sets [PollInterval]
+	- OUTBOX__BATCH_SIZE: Sets [BatchSize]
+	- OUTBOX__RETENTION: Sets [Retention]
+	- OUTBOX__LEASE_TTL: Sets [LeaseTTL], default 30000
+*/
+func GetDefaultRelayConfig() *RelayConfig {
+	return &RelayConfig{
+		Mode:         utils.GetEnv(env.OutboxMode, ModeChangeStream),
+		PollInterval: uint(utils.GetEnvInt(env.OutboxPollInterval, 1000)),
+		BatchSize:    utils.GetEnvInt(env.OutboxBatchSize, 100),
+		Retention:    uint(utils.GetEnvInt(env.OutboxRetention, 86400)),
+		LeaseTTL:     uint(utils.GetEnvInt(env.OutboxLeaseTTL, 30000)),
+		Log:          log.New(log.WithModuleName("OutboxRelay")),
+	}
+}
+
+// RelayOption represents options for configuring the outbox relay.
+type RelayOption func(*RelayConfig)
+
+// WithCollection sets the outbox collection of the relay.
+func WithCollection(coll *mongo.Collection) RelayOption {
+	return func(c *RelayConfig) {
+		c.Collection = coll
+	}
+}
+
+// WithStore sets the store of the outbox events read by the relay.
+func WithStore(store Store) RelayOption {
+	return func(c *RelayConfig) {
+		c.Store = store
+	}
+}
+
+// WithProducer sets the producer of the relay.
+func WithProducer(producer *kafka.Producer) RelayOption {
+	return func(c *RelayConfig) {
+		c.Producer = producer
+	}
+}
+
+// WithMode sets how the relay detects new events.
+func WithMode(mode string) RelayOption {
+	return func(c *RelayConfig) {
+		c.Mode = mode
+	}
+}
+
+// WithPollInterval sets the poll interval in milliseconds of the relay.
+func WithPollInterval(interval uint) RelayOption {
+	return func(c *RelayConfig) {
+		c.PollInterval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of events published at once.
+func WithBatchSize(size int) RelayOption {
+	return func(c *RelayConfig) {
+		c.BatchSize = size
+	}
+}
+
+// WithRetention sets the seconds sent events are kept.
+func WithRetention(retention uint) RelayOption {
+	return func(c *RelayConfig) {
+		c.Retention = retention
+	}
+}
+
+// WithLeaseTTL sets the milliseconds the relay lease is held without renewal.
+func WithLeaseTTL(ttl uint) RelayOption {
+	return func(c *RelayConfig) {
+		c.LeaseTTL = ttl
+	}
+}
+
+// WithLog sets the logger of the relay.
+func WithLog(logger log.Log) RelayOption {
+	return func(c *RelayConfig) {
+		c.Log = logger
+	}
+}
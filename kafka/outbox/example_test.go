@@ -0,0 +1,30 @@
+package outbox_test
+
+import (
+	"context"
+
+	"github.com/sabariramc/goserverbase/v6/app/server/httpserver"
+	"github.com/sabariramc/goserverbase/v6/db/mongo"
+	"github.com/sabariramc/goserverbase/v6/kafka/outbox"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/utils"
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+)
+
+func Example() {
+	ctx := context.Background()
+	srv := httpserver.New()
+	client, _ := mongo.NewWithDefaultOptions(log.New(), nil)
+	db := client.Database("GOBaseTest")
+	box := outbox.New(db.Collection("outbox"), log.New())
+	relay, _ := outbox.NewRelay(outbox.WithCollection(db.Collection("outbox")))
+	srv.RegisterHooks(relay)
+	relay.Start(ctx)
+	outbox.WithTransaction(ctx, client, func(sessCtx mongodrv.SessionContext) error {
+		_, err := db.Collection("order").InsertOne(sessCtx, map[string]any{"orderId": "o1"})
+		if err != nil {
+			return err
+		}
+		return box.AddMessage(sessCtx, "gobase.order", "o1", utils.NewMessage("order", "created"), nil)
+	})
+}
@@ -0,0 +1,100 @@
+// Package outbox implements the transactional outbox pattern, events are written to a Mongo collection in the same
+// transaction as the business write and published to Kafka by a relay.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/db/mongo"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+)
+
+// Status of an outbox event.
+const (
+	StatusPending = "PENDING" // Event is waiting to be published
+	StatusSent    = "SENT"    // Event is published, removed by the TTL index after the retention period
+)
+
+// Event is an outbox document.
+type Event struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Topic     string             `bson:"topic"`
+	Key       string             `bson:"key"`
+	Value     []byte             `bson:"value"`
+	Headers   map[string]string  `bson:"headers"`
+	Status    string             `bson:"status"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	SentAt    *time.Time         `bson:"sentAt,omitempty"`
+	Attempts  int                `bson:"attempts"`
+	LastError string             `bson:"lastError,omitempty"`
+}
+
+// Outbox writes events to the outbox collection.
+type Outbox struct {
+	coll *mongo.Collection
+	log  log.Log
+}
+
+// New creates a new Outbox writing to the collection.
+func New(coll *mongo.Collection, logger log.Log) *Outbox {
+	return &Outbox{coll: coll, log: logger.NewResourceLogger("Outbox")}
+}
+
+// Add writes the event to the outbox. Pass the [mongodrv.SessionContext] of the business write as ctx so that the event
+// is committed in the same transaction. The correlation and user identity headers of ctx are stored with the event.
+func (o *Outbox) Add(ctx context.Context, topic, key string, value []byte, headers map[string]string) error {
+	eventHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		eventHeaders[k] = v
+	}
+	for k, v := range correlation.GetHeader(ctx) {
+		eventHeaders[k] = v
+	}
+	event := &Event{
+		ID:        primitive.NewObjectID(),
+		Topic:     topic,
+		Key:       key,
+		Value:     value,
+		Headers:   eventHeaders,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	_, err := o.coll.InsertOne(ctx, event)
+	if err != nil {
+		o.log.Error(ctx, "error writing outbox event", err)
+		return fmt.Errorf("Outbox.Add: error writing event: %w", err)
+	}
+	return nil
+}
+
+// AddMessage writes the [utils.Message] to the outbox as JSON.
+func (o *Outbox) AddMessage(ctx context.Context, topic, key string, message *utils.Message, headers map[string]string) error {
+	blob, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("Outbox.AddMessage: error marshalling message: %w", err)
+	}
+	return o.Add(ctx, topic, key, blob, headers)
+}
+
+// WithTransaction runs fn in a transaction of the client, write the business documents and add the events with the session context passed to fn.
+func WithTransaction(ctx context.Context, client *mongo.Mongo, fn func(sessCtx mongodrv.SessionContext) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return fmt.Errorf("outbox.WithTransaction: error starting session: %w", err)
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sessCtx mongodrv.SessionContext) (any, error) {
+		return nil, fn(sessCtx)
+	})
+	if err != nil {
+		return fmt.Errorf("outbox.WithTransaction: %w", err)
+	}
+	return nil
+}
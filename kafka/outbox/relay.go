@@ -0,0 +1,321 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RelayStatus is the status of the relay reported by StatusCheck.
+type RelayStatus struct {
+	Mode            string    // Mode of detecting new events
+	Leader          bool      // Relay holds the lease and publishes the events, a standby relay takes over once the lease expires
+	Pending         int64     // Number of events waiting to be published
+	Published       uint64    // Number of events published since start
+	Failed          uint64    // Number of failed publish attempts since start
+	LastError       string    // Error of the last failed publish attempt
+	LastPublishedAt time.Time // Time of the last successful publish
+}
+
+// Relay publishes the pending events of the outbox to Kafka in insertion order and marks them sent.
+// Delivery is at least once, an event is published again if the relay stops between publishing and marking it sent.
+// Only the relay holding the lease of the [Store] publishes, so that the relays of multiple instances do not publish the same events.
+// Call Start after the application is initialized and register the relay with BaseApp.RegisterHooks for shutdown, health and status checks.
+type Relay struct {
+	c             *RelayConfig
+	id            string
+	store         Store
+	producer      *kafka.Producer
+	ownsProducer  bool
+	log           log.Log
+	wake          chan struct{}
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	drainLock     sync.Mutex
+	published     atomic.Uint64
+	failed        atomic.Uint64
+	leader        atomic.Bool
+	statusLock    sync.Mutex
+	lastError     string
+	lastPublished time.Time
+}
+
+// NewRelay creates a new Relay with the provided options.
+func NewRelay(options ...RelayOption) (*Relay, error) {
+	config := GetDefaultRelayConfig()
+	for _, opt := range options {
+		opt(config)
+	}
+	store := config.Store
+	if store == nil && config.Collection != nil {
+		store = NewMongoStore(config.Collection)
+	}
+	if store == nil {
+		return nil, fmt.Errorf("outbox.NewRelay: store or collection is required")
+	}
+	switch config.Mode {
+	case ModeChangeStream:
+		if _, ok := store.(Watcher); !ok {
+			return nil, fmt.Errorf("outbox.NewRelay: mode `%v` requires a store implementing Watcher", config.Mode)
+		}
+	case ModePoll:
+	default:
+		return nil, fmt.Errorf("outbox.NewRelay: invalid mode `%v`", config.Mode)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 1000
+	}
+	if config.LeaseTTL == 0 {
+		config.LeaseTTL = 30000
+	}
+	r := &Relay{
+		c:        config,
+		id:       uuid.NewString(),
+		store:    store,
+		producer: config.Producer,
+		log:      config.Log,
+		wake:     make(chan struct{}, 1),
+	}
+	if r.producer == nil {
+		producer, err := kafka.NewProducer(kafka.WithBatch(true), kafka.WithAsync(false), kafka.WithProducerModuleName("OutboxProducer"), kafka.WithProducerBuffer(config.BatchSize))
+		if err != nil {
+			return nil, fmt.Errorf("outbox.NewRelay: error creating producer: %w", err)
+		}
+		r.producer = producer
+		r.ownsProducer = true
+	}
+	return r, nil
+}
+
+// EnsureIndexes creates the indexes of the store, see [Store.EnsureIndexes].
+func (r *Relay) EnsureIndexes(ctx context.Context) error {
+	err := r.store.EnsureIndexes(ctx, time.Duration(r.c.Retention)*time.Second)
+	if err != nil {
+		return fmt.Errorf("Relay.EnsureIndexes: %w", err)
+	}
+	return nil
+}
+
+// Start creates the indexes and starts publishing events in the background.
+func (r *Relay) Start(ctx context.Context) error {
+	err := r.EnsureIndexes(ctx)
+	if err != nil {
+		r.log.Error(ctx, "error creating outbox indexes", err)
+		return fmt.Errorf("Relay.Start: %w", err)
+	}
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.cancel = cancel
+	r.wg.Add(1)
+	go r.run(runCtx)
+	if r.c.Mode == ModeChangeStream {
+		r.wg.Add(1)
+		go r.watch(runCtx)
+	}
+	r.log.Notice(ctx, "outbox relay started", map[string]any{"mode": r.c.Mode, "relay": r.id})
+	return nil
+}
+
+// run publishes pending events on every poll interval and whenever the change stream reports an insert.
+func (r *Relay) run(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(time.Duration(r.c.PollInterval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// watch wakes the relay on every insert reported by the store, watching again on error.
+func (r *Relay) watch(ctx context.Context) {
+	defer r.wg.Done()
+	watcher := r.store.(Watcher)
+	for {
+		err := watcher.Watch(ctx, func() {
+			select {
+			case r.wake <- struct{}{}:
+			default:
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		r.log.Warning(ctx, "outbox change stream failed, relying on polling till it is reopened", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(r.c.PollInterval) * time.Millisecond):
+		}
+	}
+}
+
+// drain publishes pending events in batches until none is left, a batch fails or another relay holds the lease.
+// The lease is renewed before every batch, a batch being published is completed when ctx is cancelled.
+func (r *Relay) drain(ctx context.Context) {
+	r.drainLock.Lock()
+	defer r.drainLock.Unlock()
+	for ctx.Err() == nil {
+		leader, err := r.store.AcquireLease(ctx, r.id, time.Duration(r.c.LeaseTTL)*time.Millisecond)
+		if err != nil {
+			r.recordError(ctx, err)
+			return
+		}
+		if r.leader.Swap(leader) != leader {
+			r.log.Notice(ctx, "outbox relay lease changed", map[string]any{"relay": r.id, "leader": leader})
+		}
+		if !leader {
+			return
+		}
+		events, err := r.store.Pending(ctx, r.c.BatchSize)
+		if err != nil {
+			r.recordError(ctx, err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+		err = r.publish(context.WithoutCancel(ctx), events)
+		if err != nil {
+			r.recordError(ctx, err)
+			return
+		}
+		if len(events) < r.c.BatchSize {
+			return
+		}
+	}
+}
+
+// publish produces the events with their stored correlation headers, flushes them and marks them sent.
+// When an event fails to be produced, the events produced before it are flushed and marked sent so that none is left
+// in the buffer of the producer, and the rest are recorded as failed.
+func (r *Relay) publish(ctx context.Context, events []*Event) error {
+	ids := make([]primitive.ObjectID, len(events))
+	produced := 0
+	var err error
+	for i, event := range events {
+		ids[i] = event.ID
+		if err != nil {
+			continue
+		}
+		headers := make(map[string]string, len(event.Headers))
+		for k, v := range event.Headers {
+			headers[k] = v
+		}
+		err = r.producer.Produce(eventContext(ctx, event), event.Topic, event.Key, event.Value, headers)
+		if err == nil {
+			produced++
+		}
+	}
+	flushErr := r.producer.Flush(ctx)
+	if flushErr != nil {
+		produced = 0
+		err = flushErr
+	}
+	now := time.Now()
+	if produced > 0 {
+		markErr := r.store.MarkSent(ctx, ids[:produced], now)
+		if markErr != nil {
+			return fmt.Errorf("Relay.publish: error marking events sent: %w", markErr)
+		}
+		r.published.Add(uint64(produced))
+		r.statusLock.Lock()
+		r.lastPublished = now
+		r.statusLock.Unlock()
+		r.log.Debug(ctx, fmt.Sprintf("published %v outbox events", produced), nil)
+	}
+	if err != nil {
+		markErr := r.store.MarkFailed(ctx, ids[produced:], err)
+		if markErr != nil {
+			r.log.Error(ctx, "error recording outbox publish failure", markErr)
+		}
+		return fmt.Errorf("Relay.publish: error publishing events: %w", err)
+	}
+	return nil
+}
+
+// eventContext returns a context carrying the correlation and user identity stored with the event.
+func eventContext(ctx context.Context, event *Event) context.Context {
+	corr := &correlation.CorrelationParam{}
+	corr.LoadFromHeader(event.Headers)
+	identity := &correlation.UserIdentifier{}
+	identity.LoadFromHeader(event.Headers)
+	ctx = correlation.GetContextWithCorrelationParam(ctx, corr)
+	return correlation.GetContextWithUserIdentifier(ctx, identity)
+}
+
+// recordError logs and records the failure.
+func (r *Relay) recordError(ctx context.Context, err error) {
+	r.failed.Add(1)
+	r.statusLock.Lock()
+	r.lastError = err.Error()
+	r.statusLock.Unlock()
+	r.log.Error(ctx, "error relaying outbox events", err)
+}
+
+// Name returns the name of the relay.
+func (r *Relay) Name(ctx context.Context) string {
+	return "OutboxRelay"
+}
+
+// Shutdown stops the relay after a final publish of the pending events, releases the lease and closes the producer it created.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+		r.wg.Wait()
+		r.drain(ctx)
+		if r.leader.Swap(false) {
+			err := r.store.ReleaseLease(ctx, r.id)
+			if err != nil {
+				r.log.Error(ctx, "error releasing outbox relay lease", err)
+			}
+		}
+	}
+	if r.ownsProducer {
+		return r.producer.Close(ctx)
+	}
+	return nil
+}
+
+// HealthCheck pings the store.
+func (r *Relay) HealthCheck(ctx context.Context) error {
+	err := r.store.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("Relay.HealthCheck: %w", err)
+	}
+	return nil
+}
+
+// StatusCheck returns the status of the relay.
+func (r *Relay) StatusCheck(ctx context.Context) (any, error) {
+	pending, err := r.store.CountPending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Relay.StatusCheck: error counting pending events: %w", err)
+	}
+	r.statusLock.Lock()
+	defer r.statusLock.Unlock()
+	return &RelayStatus{
+		Mode:            r.c.Mode,
+		Leader:          r.leader.Load(),
+		Pending:         pending,
+		Published:       r.published.Load(),
+		Failed:          r.failed.Load(),
+		LastError:       r.lastError,
+		LastPublishedAt: r.lastPublished,
+	}, nil
+}
@@ -0,0 +1,215 @@
+package outbox_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	"github.com/sabariramc/goserverbase/v6/kafka/outbox"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gotest.tools/assert"
+)
+
+// MemoryStore is an in-memory outbox store.
+type MemoryStore struct {
+	lock        sync.Mutex
+	events      map[primitive.ObjectID]*outbox.Event
+	owner       string
+	leaseExpiry time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: map[primitive.ObjectID]*outbox.Event{}}
+}
+
+func (m *MemoryStore) Add(topic, key, value string) *outbox.Event {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	event := &outbox.Event{ID: primitive.NewObjectID(), Topic: topic, Key: key, Value: []byte(value), Status: outbox.StatusPending, CreatedAt: time.Now()}
+	m.events[event.ID] = event
+	return event
+}
+
+func (m *MemoryStore) Get(id primitive.ObjectID) outbox.Event {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return *m.events[id]
+}
+
+func (m *MemoryStore) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+	return nil
+}
+
+func (m *MemoryStore) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	if m.owner != owner && now.Before(m.leaseExpiry) {
+		return false, nil
+	}
+	m.owner, m.leaseExpiry = owner, now.Add(ttl)
+	return true, nil
+}
+
+func (m *MemoryStore) ReleaseLease(ctx context.Context, owner string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.owner == owner {
+		m.leaseExpiry = time.Time{}
+	}
+	return nil
+}
+
+func (m *MemoryStore) Pending(ctx context.Context, limit int) ([]*outbox.Event, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var events []*outbox.Event
+	for _, event := range m.events {
+		if event.Status == outbox.StatusPending {
+			e := *event
+			events = append(events, &e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID.Hex() < events[j].ID.Hex() })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (m *MemoryStore) CountPending(ctx context.Context) (int64, error) {
+	events, _ := m.Pending(ctx, len(m.events))
+	return int64(len(events)), nil
+}
+
+func (m *MemoryStore) MarkSent(ctx context.Context, ids []primitive.ObjectID, at time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, id := range ids {
+		m.events[id].Status = outbox.StatusSent
+		m.events[id].SentAt = &at
+	}
+	return nil
+}
+
+func (m *MemoryStore) MarkFailed(ctx context.Context, ids []primitive.ObjectID, cause error) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, id := range ids {
+		m.events[id].Attempts++
+		m.events[id].LastError = cause.Error()
+	}
+	return nil
+}
+
+func (m *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func getContext() context.Context {
+	return correlation.GetContextWithCorrelationParam(context.Background(), correlation.NewCorrelationParam("OutboxTest"))
+}
+
+func newProducer(t *testing.T, broker *kafkatest.Broker, options ...kafka.ProducerOption) *kafka.Producer {
+	options = append([]kafka.ProducerOption{kafka.WithProducerCredConfig(broker.CredConfig()), kafka.WithAsync(false), kafka.WithBatch(true), kafka.WithProducerBuffer(10), kafka.WithAutoFlushInterval(60000)}, options...)
+	pr, err := kafka.NewProducer(options...)
+	assert.NilError(t, err)
+	t.Cleanup(func() { pr.Close(context.Background()) })
+	return pr
+}
+
+func TestRelayPublish(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := getContext()
+	store := NewMemoryStore()
+	var events []*outbox.Event
+	for i := 0; i < 5; i++ {
+		events = append(events, store.Add("orders", "o1", fmt.Sprintf("order-%v", i)))
+	}
+	relay, err := outbox.NewRelay(outbox.WithStore(store), outbox.WithMode(outbox.ModePoll), outbox.WithPollInterval(10), outbox.WithBatchSize(2), outbox.WithProducer(newProducer(t, broker)))
+	assert.NilError(t, err)
+	assert.NilError(t, relay.Start(ctx))
+	msgs := broker.ExpectMessages(t, "orders", 5)
+	for i, msg := range msgs {
+		assert.Equal(t, string(msg.Value), fmt.Sprintf("order-%v", i))
+	}
+	assert.NilError(t, relay.Shutdown(ctx))
+	for _, event := range events {
+		assert.Equal(t, store.Get(event.ID).Status, outbox.StatusSent)
+	}
+	status, err := relay.StatusCheck(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, status.(*outbox.RelayStatus).Published, uint64(5))
+	assert.Equal(t, status.(*outbox.RelayStatus).Pending, int64(0))
+}
+
+func TestRelayProduceError(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := getContext()
+	store := NewMemoryStore()
+	first := store.Add("orders", "o1", "order-0")
+	rejected := store.Add("payments", "p1", "payment-0")
+	last := store.Add("orders", "o1", "order-1")
+	relay, err := outbox.NewRelay(outbox.WithStore(store), outbox.WithMode(outbox.ModePoll), outbox.WithPollInterval(60000), outbox.WithProducer(newProducer(t, broker, kafka.WithProducerTopic("orders"))))
+	assert.NilError(t, err)
+	assert.NilError(t, relay.Start(ctx))
+	msgs := broker.ExpectMessages(t, "orders", 1)
+	assert.Equal(t, string(msgs[0].Value), "order-0")
+	assert.NilError(t, relay.Shutdown(ctx))
+	assert.Equal(t, len(broker.Messages("orders")), 1)
+	assert.Equal(t, store.Get(first.ID).Status, outbox.StatusSent)
+	for _, id := range []primitive.ObjectID{rejected.ID, last.ID} {
+		event := store.Get(id)
+		assert.Equal(t, event.Status, outbox.StatusPending)
+		assert.Assert(t, event.Attempts >= 1)
+		assert.Assert(t, event.LastError != "")
+	}
+}
+
+func TestRelayLease(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := getContext()
+	store := NewMemoryStore()
+	for i := 0; i < 3; i++ {
+		store.Add("orders", "o1", fmt.Sprintf("order-%v", i))
+	}
+	newRelay := func() *outbox.Relay {
+		relay, err := outbox.NewRelay(outbox.WithStore(store), outbox.WithMode(outbox.ModePoll), outbox.WithPollInterval(10), outbox.WithLeaseTTL(60000), outbox.WithProducer(newProducer(t, broker)))
+		assert.NilError(t, err)
+		return relay
+	}
+	leader, standby := newRelay(), newRelay()
+	assert.NilError(t, leader.Start(ctx))
+	broker.ExpectMessages(t, "orders", 3)
+	assert.NilError(t, standby.Start(ctx))
+	for i := 3; i < 6; i++ {
+		store.Add("orders", "o1", fmt.Sprintf("order-%v", i))
+	}
+	broker.ExpectMessages(t, "orders", 6)
+	status, _ := standby.StatusCheck(ctx)
+	assert.Assert(t, !status.(*outbox.RelayStatus).Leader)
+	assert.Equal(t, status.(*outbox.RelayStatus).Published, uint64(0))
+
+	assert.NilError(t, leader.Shutdown(ctx))
+	store.Add("orders", "o1", "order-6")
+	msgs := broker.ExpectMessages(t, "orders", 7)
+	assert.NilError(t, standby.Shutdown(ctx))
+	assert.Equal(t, len(broker.Messages("orders")), 7)
+	for i, msg := range msgs {
+		assert.Equal(t, string(msg.Value), fmt.Sprintf("order-%v", i))
+	}
+	status, _ = standby.StatusCheck(ctx)
+	assert.Equal(t, status.(*outbox.RelayStatus).Published, uint64(1))
+}
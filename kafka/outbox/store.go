@@ -0,0 +1,162 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store is the storage of the outbox events read by the [Relay], [MongoStore] is the store of the outbox collection.
+//
+// The relay lease makes a single relay the publisher of the store, so that relays of multiple instances do not
+// publish the same events and the events are published in insertion order.
+type Store interface {
+	// EnsureIndexes creates the indexes used to find pending events and to remove sent events after the retention period.
+	EnsureIndexes(ctx context.Context, retention time.Duration) error
+	// AcquireLease takes or renews the relay lease for the owner till ttl, returns false when another owner holds an unexpired lease.
+	AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease releases the relay lease when the owner holds it.
+	ReleaseLease(ctx context.Context, owner string) error
+	// Pending returns up to limit of the oldest pending events.
+	Pending(ctx context.Context, limit int) ([]*Event, error)
+	// CountPending returns the number of pending events.
+	CountPending(ctx context.Context) (int64, error)
+	// MarkSent marks the events sent at the time.
+	MarkSent(ctx context.Context, ids []primitive.ObjectID, at time.Time) error
+	// MarkFailed records a failed publish attempt of the events.
+	MarkFailed(ctx context.Context, ids []primitive.ObjectID, cause error) error
+	// Ping checks the connection to the store.
+	Ping(ctx context.Context) error
+}
+
+// Watcher is a [Store] that reports inserted events, required by [ModeChangeStream].
+type Watcher interface {
+	// Watch calls inserted for every inserted event until ctx is done or the watch fails.
+	Watch(ctx context.Context, inserted func()) error
+}
+
+// MongoStore is the [Store] of the outbox collection, the relay lease is a document of the lease collection.
+type MongoStore struct {
+	coll  *mongodrv.Collection
+	lease *mongodrv.Collection
+}
+
+// NewMongoStore creates a new MongoStore of the outbox collection, the lease is kept in the collection named <outbox collection>Lease.
+func NewMongoStore(coll *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		coll:  coll.Collection,
+		lease: coll.Database().Collection(coll.Name() + "Lease"),
+	}
+}
+
+// EnsureIndexes creates the index used to find pending events and the TTL index removing sent events after the retention period.
+func (s *MongoStore) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+	_, err := s.coll.Indexes().CreateMany(ctx, []mongodrv.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "sentAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds()))},
+	})
+	if err != nil {
+		return fmt.Errorf("MongoStore.EnsureIndexes: %w", err)
+	}
+	return nil
+}
+
+// AcquireLease upserts the lease document when the owner holds it or it is expired, the unique _id rejects the upsert
+// when another owner holds an unexpired lease.
+func (s *MongoStore) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := s.lease.UpdateOne(ctx,
+		bson.M{"_id": s.coll.Name(), "$or": bson.A{bson.M{"owner": owner}, bson.M{"expiresAt": bson.M{"$lt": now}}}},
+		bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongodrv.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("MongoStore.AcquireLease: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseLease expires the lease document when the owner holds it.
+func (s *MongoStore) ReleaseLease(ctx context.Context, owner string) error {
+	_, err := s.lease.UpdateOne(ctx, bson.M{"_id": s.coll.Name(), "owner": owner}, bson.M{"$set": bson.M{"expiresAt": time.Time{}}})
+	if err != nil {
+		return fmt.Errorf("MongoStore.ReleaseLease: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the oldest pending events.
+func (s *MongoStore) Pending(ctx context.Context, limit int) ([]*Event, error) {
+	cur, err := s.coll.Find(ctx, bson.M{"status": StatusPending}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, fmt.Errorf("MongoStore.Pending: error finding events: %w", err)
+	}
+	var events []*Event
+	err = cur.All(ctx, &events)
+	if err != nil {
+		return nil, fmt.Errorf("MongoStore.Pending: error decoding events: %w", err)
+	}
+	return events, nil
+}
+
+// CountPending returns the number of pending events.
+func (s *MongoStore) CountPending(ctx context.Context) (int64, error) {
+	count, err := s.coll.CountDocuments(ctx, bson.M{"status": StatusPending})
+	if err != nil {
+		return 0, fmt.Errorf("MongoStore.CountPending: %w", err)
+	}
+	return count, nil
+}
+
+// MarkSent marks the events sent at the time.
+func (s *MongoStore) MarkSent(ctx context.Context, ids []primitive.ObjectID, at time.Time) error {
+	_, err := s.coll.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"status": StatusSent, "sentAt": at}})
+	if err != nil {
+		return fmt.Errorf("MongoStore.MarkSent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed increments the attempts and sets the last error of the events.
+func (s *MongoStore) MarkFailed(ctx context.Context, ids []primitive.ObjectID, cause error) error {
+	_, err := s.coll.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$inc": bson.M{"attempts": 1}, "$set": bson.M{"lastError": cause.Error()}})
+	if err != nil {
+		return fmt.Errorf("MongoStore.MarkFailed: %w", err)
+	}
+	return nil
+}
+
+// Ping pings the Mongo server of the outbox collection.
+func (s *MongoStore) Ping(ctx context.Context) error {
+	err := s.coll.Database().Client().Ping(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("MongoStore.Ping: %w", err)
+	}
+	return nil
+}
+
+// Watch opens a change stream on the outbox collection and calls inserted for every insert.
+func (s *MongoStore) Watch(ctx context.Context, inserted func()) error {
+	pipeline := mongodrv.Pipeline{{{Key: "$match", Value: bson.D{{Key: "operationType", Value: "insert"}}}}}
+	stream, err := s.coll.Watch(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("MongoStore.Watch: %w", err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+	for stream.Next(ctx) {
+		inserted()
+	}
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("MongoStore.Watch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PauseStatus is the snapshot of the paused state of a Poller.
+type PauseStatus struct {
+	Paused bool           // Fetching is paused for every topic
+	Topics []string       // Topics paused individually
+	Parked map[string]int // Number of fetched messages held back for each paused topic, only with a reader set in [ConsumerConfig]
+}
+
+// pauseState holds the paused topics and the messages fetched for them while paused.
+type pauseState struct {
+	lock        sync.Mutex
+	global      bool
+	topics      map[string]bool
+	parked      map[string][]*kafka.Message
+	parkedCount int
+	wake        chan struct{}
+}
+
+// newPauseState creates an empty pauseState.
+func newPauseState() *pauseState {
+	return &pauseState{
+		topics: map[string]bool{},
+		parked: map[string][]*kafka.Message{},
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// signal wakes the poll loop if it is waiting.
+func (p *pauseState) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pause stops handing out messages of the topics, or of every topic when none is passed.
+// When the Poller joins the consumer group itself, the readers of the paused partitions are stopped so none of their
+// messages are fetched; messages fetched but not yet handed out are fetched again on resume. Pause returns once the
+// poll loop has dropped them, so no message of a paused topic is handed out after Pause returns.
+// With a reader set in [ConsumerConfig], the messages of a paused topic are fetched and held back, and handed out in
+// order on resume; fetching stops once [ConsumerConfig.MaxBuffer] messages are held back or when every topic is paused.
+// The consumer group keeps heartbeating while paused so the group is not rebalanced.
+func (k *Poller) Pause(topics ...string) {
+	k.pause.lock.Lock()
+	if len(topics) == 0 {
+		k.pause.global = true
+	}
+	for _, topic := range topics {
+		k.pause.topics[topic] = true
+	}
+	k.pause.lock.Unlock()
+	k.syncPause()
+}
+
+// Resume resumes the topics paused with Pause, or the global pause when no topic is passed.
+func (k *Poller) Resume(topics ...string) {
+	k.pause.lock.Lock()
+	if len(topics) == 0 {
+		k.pause.global = false
+	}
+	for _, topic := range topics {
+		delete(k.pause.topics, topic)
+	}
+	k.pause.lock.Unlock()
+	k.pause.signal()
+	k.syncPause()
+}
+
+// syncPause starts and stops the partition readers as per the paused topics and waits for the poll loop to observe it.
+func (k *Poller) syncPause() {
+	if k.group == nil {
+		return
+	}
+	k.group.lock.Lock()
+	k.syncFetchers(k.group.ctx)
+	k.group.epoch++
+	epoch := k.group.epoch
+	k.group.lock.Unlock()
+	k.group.notify(epoch)
+}
+
+// Delay stops fetching the partition of the message till the time, then the message and the messages following it are
+// fetched and handed out again. Use it to wait for the retry time of a message without blocking the other partitions.
+// Acknowledgements of the message and of the messages of the partition handed out after it are ignored, as those
+// messages are handed out again; drop them unprocessed, they are reported by Stale. A later Delay of the partition replaces the time. Returns false, leaving the partition
+// as is, when the Poller does not join the consumer group itself, i.e. with a reader set in [ConsumerConfig].
+func (k *Poller) Delay(msg *kafka.Message, until time.Time) bool {
+	if k.group == nil {
+		return false
+	}
+	k.group.lock.Lock()
+	p, ok := k.group.partitions[msg.Topic][msg.Partition]
+	if !ok {
+		// Revoked, the new owner fetches the message from the committed offset
+		k.group.lock.Unlock()
+		return true
+	}
+	if p.next < 0 || msg.Offset < p.next {
+		p.next = msg.Offset
+	}
+	if p.reader != nil {
+		k.stopFetcher(p)
+	} else if k.tracker != nil {
+		k.tracker.Rewind(p.topic, p.partition, p.next)
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.delayed = until
+	p.timer = time.AfterFunc(time.Until(until), func() { k.endDelay(p, until) })
+	k.group.epoch++
+	epoch := k.group.epoch
+	k.group.lock.Unlock()
+	k.group.notify(epoch)
+	return true
+}
+
+// Stale reports whether the message handed out by Poll was superseded by a Delay of its partition or by a revoke, such a
+// message is handed out again or fetched by the new owner of the partition and should be dropped unprocessed.
+// Only known in [CommitModeAck], where a handed out message is stale once it is no longer tracked; false otherwise.
+func (k *Poller) Stale(msg *kafka.Message) bool {
+	if k.tracker == nil {
+		return false
+	}
+	return !k.tracker.Tracked(msg)
+}
+
+// endDelay restarts the reader of the partition once its delay ends, unless the delay was replaced or the partition revoked.
+func (k *Poller) endDelay(p *partitionFetch, until time.Time) {
+	k.group.lock.Lock()
+	defer k.group.lock.Unlock()
+	if !p.delayed.Equal(until) || k.group.ctx.Err() != nil {
+		return
+	}
+	p.delayed, p.timer = time.Time{}, nil
+	if k.group.partitions[p.topic][p.partition] != p || p.reader != nil || k.paused(p.topic) {
+		return
+	}
+	k.startFetcher(k.group.ctx, p)
+	k.group.epoch++
+	k.group.signal()
+}
+
+// paused reports whether the topic is paused individually or by the global pause.
+func (k *Poller) paused(topic string) bool {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	return k.pause.global || k.pause.topics[topic]
+}
+
+// PauseStatus returns the paused state of the Poller.
+func (k *Poller) PauseStatus() PauseStatus {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	status := PauseStatus{
+		Paused: k.pause.global,
+		Topics: make([]string, 0, len(k.pause.topics)),
+		Parked: make(map[string]int, len(k.pause.parked)),
+	}
+	for topic := range k.pause.topics {
+		status.Topics = append(status.Topics, topic)
+	}
+	sort.Strings(status.Topics)
+	for topic, msgs := range k.pause.parked {
+		status.Parked[topic] = len(msgs)
+	}
+	return status
+}
+
+// park holds back the message if its topic is paused, returns false if the message must be handed out.
+// Messages of a topic that still has parked messages are parked as well to preserve the order.
+func (k *Poller) park(msg *kafka.Message) bool {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	if !k.pause.global && !k.pause.topics[msg.Topic] && len(k.pause.parked[msg.Topic]) == 0 {
+		return false
+	}
+	k.pause.parked[msg.Topic] = append(k.pause.parked[msg.Topic], msg)
+	k.pause.parkedCount++
+	return true
+}
+
+// unpark removes and returns the parked messages of the topics that are no longer paused.
+func (k *Poller) unpark() []*kafka.Message {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	if k.pause.global {
+		return nil
+	}
+	var res []*kafka.Message
+	for topic, msgs := range k.pause.parked {
+		if k.pause.topics[topic] {
+			continue
+		}
+		res = append(res, msgs...)
+		k.pause.parkedCount -= len(msgs)
+		delete(k.pause.parked, topic)
+	}
+	return res
+}
+
+// fetchBlocked reports whether fetching must wait, either because every topic is paused or too many messages are parked.
+func (k *Poller) fetchBlocked() bool {
+	k.pause.lock.Lock()
+	defer k.pause.lock.Unlock()
+	return k.pause.global || k.pause.parkedCount >= int(k.config.MaxBuffer)
+}
+
+// waitWhilePaused hands out the messages of resumed topics and blocks while fetching is blocked.
+// Returns false if the context is done.
+func (k *Poller) waitWhilePaused(ctx context.Context, ch chan<- *kafka.Message) bool {
+	for {
+		for _, msg := range k.unpark() {
+			k.deliver(ctx, ch, msg)
+		}
+		if !k.fetchBlocked() {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-k.pause.wake:
+		}
+	}
+}
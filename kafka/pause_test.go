@@ -0,0 +1,181 @@
+package kafka_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	cKafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+// receive returns the next message of the channel.
+func receive(t *testing.T, ch <-chan *cKafka.Message) *cKafka.Message {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(10 * time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+// expectNone fails if a message of the topic is received within the duration, messages of other topics are returned.
+func expectNone(t *testing.T, ch <-chan *cKafka.Message, topic string, d time.Duration) []*cKafka.Message {
+	t.Helper()
+	var res []*cKafka.Message
+	timeout := time.After(d)
+	for {
+		select {
+		case msg := <-ch:
+			if msg.Topic == topic {
+				t.Fatalf("received %v of paused topic %v", string(msg.Value), topic)
+			}
+			res = append(res, msg)
+		case <-timeout:
+			return res
+		}
+	}
+}
+
+func produce(t *testing.T, broker *kafkatest.Broker, topic string, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		assert.NilError(t, broker.Produce(topic, cKafka.Message{Value: []byte(fmt.Sprintf("%v-%v", topic, i))}))
+	}
+}
+
+func TestPollerPause(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1), kafkatest.WithTopic("payments", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	produce(t, broker, "orders", 0, 3)
+	produce(t, broker, "payments", 0, 3)
+	co, err := kafka.NewPoller(
+		kafka.WithConsumerCredConfig(broker.CredConfig()),
+		kafka.WithConsumerLogger(KafkaTestLogger),
+		kafka.WithGroupID("pause-group"),
+		kafka.WithConsumerTopic([]string{"orders", "payments"}),
+		kafka.WithCommitMode(kafka.CommitModeAck),
+		kafka.WithConsumerBuffer(1),
+	)
+	assert.NilError(t, err)
+	co.Pause("orders")
+	pollCtx, cancel := context.WithCancel(ctx)
+	ch := make(chan *cKafka.Message)
+	done := make(chan error, 1)
+	go func() { done <- co.Poll(pollCtx, ch) }()
+
+	for i := 0; i < 3; i++ {
+		msg := receive(t, ch)
+		assert.Equal(t, string(msg.Value), fmt.Sprintf("payments-%v", i))
+		assert.NilError(t, co.Ack(ctx, msg))
+	}
+	expectNone(t, ch, "orders", 500*time.Millisecond)
+	status := co.PauseStatus()
+	assert.DeepEqual(t, status.Topics, []string{"orders"})
+	assert.Equal(t, len(status.Parked), 0, "messages of a paused topic are not fetched")
+	broker.ExpectCommitted(t, "pause-group", "payments", 0, 3)
+	assert.Equal(t, broker.CommittedOffset("pause-group", "orders", 0), int64(-1))
+
+	co.Resume("orders")
+	for i := 0; i < 3; i++ {
+		msg := receive(t, ch)
+		assert.Equal(t, string(msg.Value), fmt.Sprintf("orders-%v", i))
+		assert.NilError(t, co.Ack(ctx, msg))
+	}
+
+	produce(t, broker, "orders", 3, 6)
+	msg := receive(t, ch)
+	assert.Equal(t, string(msg.Value), "orders-3")
+	co.Pause("orders")
+	produce(t, broker, "payments", 3, 4)
+	others := expectNone(t, ch, "orders", 500*time.Millisecond)
+	assert.Equal(t, len(others), 1)
+	assert.Equal(t, string(others[0].Value), "payments-3")
+	assert.NilError(t, co.Ack(ctx, others[0]))
+	assert.NilError(t, co.Ack(ctx, msg))
+	co.Resume("orders")
+	for i := 4; i < 6; i++ {
+		msg := receive(t, ch)
+		assert.Equal(t, string(msg.Value), fmt.Sprintf("orders-%v", i), "resumes after the last message handed out")
+		assert.NilError(t, co.Ack(ctx, msg))
+	}
+
+	co.Pause()
+	produce(t, broker, "payments", 4, 5)
+	expectNone(t, ch, "payments", 500*time.Millisecond)
+	assert.Assert(t, co.PauseStatus().Paused)
+	co.Resume()
+	msg = receive(t, ch)
+	assert.Equal(t, string(msg.Value), "payments-4")
+	assert.NilError(t, co.Ack(ctx, msg))
+
+	cancel()
+	assert.NilError(t, <-done)
+	assert.NilError(t, co.Close(ctx))
+	assert.Equal(t, broker.CommittedOffset("pause-group", "orders", 0), int64(6))
+	assert.Equal(t, broker.CommittedOffset("pause-group", "payments", 0), int64(5))
+}
+
+func TestPollerDelay(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("orders", 1), kafkatest.WithTopic("payments", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	produce(t, broker, "orders", 0, 3)
+	co, err := kafka.NewPoller(
+		kafka.WithConsumerCredConfig(broker.CredConfig()),
+		kafka.WithConsumerLogger(KafkaTestLogger),
+		kafka.WithGroupID("delay-group"),
+		kafka.WithConsumerTopic([]string{"orders", "payments"}),
+		kafka.WithCommitMode(kafka.CommitModeAck),
+		kafka.WithConsumerBuffer(1),
+	)
+	assert.NilError(t, err)
+	pollCtx, cancel := context.WithCancel(ctx)
+	ch := make(chan *cKafka.Message)
+	done := make(chan error, 1)
+	go func() { done <- co.Poll(pollCtx, ch) }()
+
+	msg := receive(t, ch)
+	assert.Equal(t, string(msg.Value), "orders-0")
+	assert.NilError(t, co.Ack(ctx, msg))
+	msg = receive(t, ch)
+	assert.Equal(t, string(msg.Value), "orders-1")
+	queued := receive(t, ch)
+	assert.Equal(t, string(queued.Value), "orders-2")
+	assert.Assert(t, !co.Stale(msg))
+	until := time.Now().Add(time.Second)
+	assert.Assert(t, co.Delay(msg, until))
+	assert.Assert(t, co.Stale(msg))
+	assert.Assert(t, co.Stale(queued), "messages handed out after the delayed message are superseded")
+	assert.NilError(t, co.Ack(ctx, msg))
+	produce(t, broker, "payments", 0, 2)
+	others := expectNone(t, ch, "orders", 500*time.Millisecond)
+	assert.Equal(t, len(others), 2, "other partitions are fetched while delayed")
+	for _, other := range others {
+		assert.NilError(t, co.Ack(ctx, other))
+	}
+	broker.ExpectCommitted(t, "delay-group", "orders", 0, 1)
+
+	for i := 1; i < 3; i++ {
+		msg := receive(t, ch)
+		assert.Equal(t, string(msg.Value), fmt.Sprintf("orders-%v", i), "resumes from the delayed message")
+		assert.Assert(t, !time.Now().Before(until))
+		assert.Assert(t, !co.Stale(msg))
+		assert.NilError(t, co.Ack(ctx, msg))
+	}
+	assert.Assert(t, co.Stale(queued), "a superseded message stays stale once its offset is handed out again")
+
+	cancel()
+	assert.NilError(t, <-done)
+	assert.NilError(t, co.Close(ctx))
+	assert.Equal(t, broker.CommittedOffset("delay-group", "orders", 0), int64(3))
+	assert.Equal(t, broker.CommittedOffset("delay-group", "payments", 0), int64(2))
+}
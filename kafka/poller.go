@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,44 +17,57 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
-// Poller is a high-level API that extends Reader with time and count-based auto commit
-// and implements a shutdown hook.
+// Poller is a high-level API that extends Reader with time and count-based auto commit, pause and resume,
+// partition assignment and lag tracking, and implements a shutdown hook.
+//
+// In [CommitModePoll] the offset of a message is stored as soon as it is handed to the channel.
+// In [CommitModeAck] the offset is stored only once the message and every message fetched before it
+// from the same partition are acknowledged with [Poller.Ack], giving at-least-once delivery.
 /*
-If Reader is not set in [ConsumerConfig] then creates a new [kafka.Reader] with the options passed to the function, and adds addition params
+If Reader is not set in [ConsumerConfig] then the Poller joins the consumer group with a [kafka.ConsumerGroup] and fetches
+every assigned partition with its own [kafka.Reader], so that a paused partition is not fetched. The embedded
+[kafka.Reader] is nil in this case, its methods are served by the Poller from the partition readers as [kafka.Reader]
+does with a GroupID, and the offsets are committed with the generation of the group.
 
-		readerConfig := kafka.ReaderConfig{
+		groupConfig := kafka.ConsumerGroupConfig{
+			ID:                config.GroupID,
 			Brokers:           config.Brokers,
-			GroupID:           config.GroupID,
-			GroupTopics:       config.Topics,
+			Topics:            config.Topics,
 			HeartbeatInterval: time.Second,
-			QueueCapacity:     int(config.MaxBuffer),
-			MaxBytes:          10e6, // 10MB,
+			Dialer:            dialer,
+			Logger:            infoLogger,
+			ErrorLogger:       errorLogger,
+		}
+		readerConfig := kafka.ReaderConfig{
+			Brokers:       config.Brokers,
+			Topic:         topic,
+			Partition:     partition,
+			QueueCapacity: int(config.MaxBuffer),
+			MaxBytes:      10e6, // 10MB,
+			MaxWait:       time.Second,
 			Dialer: &kafka.Dialer{
 				Timeout:       10 * time.Second,
 				DualStack:     true,
 				SASLMechanism: config.SASLMechanism,
 				TLS:           config.TLSConfig,
 			},
-			Logger: &kafkaLogger{
-				Log:     logger.NewResourceLogger(config.ModuleName + ":InfoLog"),
-				ctx:     ctx,
-				isError: false,
-			},
-			ErrorLogger: &kafkaLogger{
-				Log:     logger.NewResourceLogger(config.ModuleName + ":ErrorLog"),
-				ctx:     ctx,
-				isError: true,
-			},
+			Logger:      infoLogger,
+			ErrorLogger: errorLogger,
 		}
-		config.Reader = kafka.NewReader(readerConfig)
 */
 type Poller struct {
 	*Reader
-	consumerCount    uint // Number of consumers in the group
+	consumerCount    atomic.Uint64   // Number of offsets stored since the last commit
+	tracker          *OffsetTracker  // Tracks acknowledgements in CommitModeAck
+	pause            *pauseState     // Paused topics and the messages held back for them
+	partitions       *partitionState // Assigned partitions, their offsets and lag
+	group            *groupState     // Consumer group and partition readers, set when the Poller creates the consumer
+	clientID         string          // Client ID of the reader, set when the Poller creates the reader
 	config           *ConsumerConfig
 	log              log.Log
 	topics           []string
 	autoCommitCancel context.CancelFunc
+	refreshCancel    context.CancelFunc
 	wg               sync.WaitGroup
 }
 
@@ -64,42 +78,69 @@ func NewPoller(options ...ConsumerOption) (*Poller, error) {
 	for _, opt := range options {
 		opt(config)
 	}
+	err := ValidateConsumerConfig(config)
+	if err != nil {
+		return nil, err
+	}
 	logger := config.Log
 	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: config.ModuleName})
+	var clientID string
+	var group *groupState
 	if config.Reader == nil {
+		clientID = fmt.Sprintf("%v-%v", config.ClientId, uuid.NewString())
+		dialer := &kafka.Dialer{
+			Timeout:       10 * time.Second,
+			DualStack:     true,
+			SASLMechanism: config.SASLMechanism,
+			TLS:           config.TLSConfig,
+			ClientID:      clientID,
+		}
+		infoLogger, errorLogger := newLoggers(ctx, logger, config.ModuleName)
 		readerConfig := kafka.ReaderConfig{
+			Brokers:       config.Brokers,
+			QueueCapacity: int(config.MaxBuffer),
+			MaxBytes:      10e6, // 10MB,
+			MaxWait:       time.Second,
+			Dialer:        dialer,
+			Logger:        infoLogger,
+			ErrorLogger:   errorLogger,
+		}
+		if config.ReadCommitted {
+			readerConfig.IsolationLevel = kafka.ReadCommitted
+		}
+		cg, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+			ID:                config.GroupID,
 			Brokers:           config.Brokers,
-			GroupID:           config.GroupID,
-			GroupTopics:       config.Topics,
+			Topics:            config.Topics,
 			HeartbeatInterval: time.Second,
-			QueueCapacity:     int(config.MaxBuffer),
-			MaxBytes:          10e6, // 10MB,
-			Dialer: &kafka.Dialer{
-				Timeout:       10 * time.Second,
-				DualStack:     true,
-				SASLMechanism: config.SASLMechanism,
-				TLS:           config.TLSConfig,
-				ClientID:      fmt.Sprintf("%v-%v", config.ClientId, uuid.NewString()),
-			},
-			Logger: &kafkaLogger{
-				Log:     logger.NewResourceLogger(config.ModuleName + ":InfoLog"),
-				ctx:     ctx,
-				isError: false,
-			},
-			ErrorLogger: &kafkaLogger{
-				Log:     logger.NewResourceLogger(config.ModuleName + ":ErrorLog"),
-				ctx:     ctx,
-				isError: true,
-			},
+			Dialer:            dialer,
+			Logger:            infoLogger,
+			ErrorLogger:       errorLogger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("NewPoller: error creating consumer group: %w", err)
 		}
-		config.Reader = kafka.NewReader(readerConfig)
+		group = newGroupState(ctx, cg, readerConfig)
 	}
 	k := &Poller{
-		log:           config.Log,
-		consumerCount: 0,
-		config:        config,
-		Reader:        NewReader(ctx, logger, config.Reader, config.Trace),
-		topics:        config.Topics,
+		log:        config.Log,
+		config:     config,
+		Reader:     NewReader(ctx, logger, config.Reader, config.Trace),
+		topics:     config.Topics,
+		pause:      newPauseState(),
+		partitions: newPartitionState(),
+		group:      group,
+		clientID:   clientID,
+	}
+	k.Reader.onCommit = k.recordCommit
+	if group != nil {
+		k.Reader.commitOffsets = k.commitGeneration
+		group.wg.Add(1)
+		go k.runGroup()
+	}
+	if config.CommitMode == CommitModeAck {
+		k.tracker = NewOffsetTracker()
+		logger.Notice(ctx, config.ModuleName+" is set to acknowledge commit mode", nil)
 	}
 	if k.config.AutoCommit {
 		commitCtx, cancel := context.WithCancel(ctx)
@@ -107,6 +148,16 @@ func NewPoller(options ...ConsumerOption) (*Poller, error) {
 		k.wg.Add(1)
 		go k.autoCommit(commitCtx)
 	}
+	if config.StatsInterval > 0 {
+		var client *kafka.Client
+		if clientID != "" && config.GroupID != "" && config.CredConfig != nil {
+			client = NewClient(config.CredConfig)
+		}
+		refreshCtx, cancel := context.WithCancel(ctx)
+		k.refreshCancel = cancel
+		k.wg.Add(1)
+		go k.refreshLoop(refreshCtx, client)
+	}
 	return k, nil
 }
 
@@ -117,30 +168,14 @@ func (k *Poller) Poll(ctx context.Context, ch chan<- *kafka.Message) error {
 	defer close(ch)
 	k.log.Info(ctx, fmt.Sprintf("Polling started for topics : %v", k.topics), nil)
 	nCtx := context.WithoutCancel(ctx)
-outer:
-	for {
-		select {
-		case <-ctx.Done():
-			commitErr = k.commit(nCtx)
+	if k.group != nil {
+		pollErr = k.pollGroup(ctx, ch)
+		commitErr = k.commit(nCtx, true)
+		if pollErr == nil {
 			k.log.Notice(ctx, "Polling Timeout/cancelled", nil)
-			break outer
-		default:
-			msg, err := k.FetchMessage(ctx)
-			if err != nil {
-				if errors.Is(err, context.Canceled) {
-					commitErr = k.commit(nCtx)
-					break outer
-				}
-				k.log.Error(ctx, "error fetching message", err)
-				pollErr = fmt.Errorf("Consumer.Poll: error fetching message: %w", err)
-				commitErr = k.commit(nCtx)
-				break outer
-			}
-			ch <- &msg
-			k.consumerCount++
-			k.StoreOffset(ctx, &msg)
-			k.commit(ctx)
 		}
+	} else {
+		pollErr, commitErr = k.pollReader(ctx, ch)
 	}
 	if commitErr != nil || pollErr != nil {
 		if pollErr == nil {
@@ -154,14 +189,117 @@ outer:
 	return pollErr
 }
 
-// commit commits the current state if auto-commit is enabled.
-func (k *Poller) commit(ctx context.Context) error {
-	if k.config.AutoCommit && k.consumerCount >= k.config.MaxBuffer {
-		_, err := k.Commit(ctx)
-		if err != nil {
-			return fmt.Errorf("Poller.commit: error committing message: %w", err)
+// pollReader fetches the messages with the reader set in [ConsumerConfig] and passes them to the channel till the context is done.
+func (k *Poller) pollReader(ctx context.Context, ch chan<- *kafka.Message) (pollErr, commitErr error) {
+	nCtx := context.WithoutCancel(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			commitErr = k.commit(nCtx, true)
+			k.log.Notice(ctx, "Polling Timeout/cancelled", nil)
+			return
+		default:
+			if !k.waitWhilePaused(ctx, ch) {
+				continue
+			}
+			msg, err := k.Reader.FetchMessage(ctx)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					commitErr = k.commit(nCtx, true)
+					return
+				}
+				k.log.Error(ctx, "error fetching message", err)
+				pollErr = fmt.Errorf("Consumer.Poll: error fetching message: %w", err)
+				commitErr = k.commit(nCtx, true)
+				return
+			}
+			k.recordFetch(ctx, &msg)
+			if k.park(&msg) {
+				continue
+			}
+			k.deliver(ctx, ch, &msg)
 		}
-		k.consumerCount = 0
+	}
+}
+
+// deliver hands the message to the channel and stores or tracks its offset as per the commit mode.
+func (k *Poller) deliver(ctx context.Context, ch chan<- *kafka.Message, msg *kafka.Message) {
+	if k.tracker != nil {
+		k.tracker.Track(msg)
+		ch <- msg
+		return
+	}
+	ch <- msg
+	k.StoreOffset(ctx, msg)
+	k.consumerCount.Add(1)
+	k.commit(ctx, false)
+}
+
+// Ack acknowledges that the message is processed. In [CommitModeAck] the offsets that became contiguous
+// are stored for commit; in [CommitModePoll] it is a no-op.
+func (k *Poller) Ack(ctx context.Context, msg *kafka.Message) error {
+	if k.tracker == nil {
+		return nil
+	}
+	if _, advanced := k.tracker.Done(msg); !advanced {
+		return nil
+	}
+	k.storeOffsets(k.tracker.Committable())
+	k.consumerCount.Add(1)
+	return k.commit(ctx, false)
+}
+
+// TxnOffsets returns the offsets to send with the transaction that processes the message, see [TxnProducer.SendOffsets].
+// In [CommitModeAck] the offset moves past the message only once every message fetched before it from the partition is
+// acknowledged, nil is returned when it does not move; in [CommitModePoll] it is the offset of the message + 1.
+func (k *Poller) TxnOffsets(msg *kafka.Message) OffsetMap {
+	if k.tracker == nil {
+		return OffsetMap{msg.Topic: {msg.Partition: msg.Offset + 1}}
+	}
+	offset, ok := k.tracker.CommittableWith(msg)
+	if !ok {
+		return nil
+	}
+	return OffsetMap{msg.Topic: {msg.Partition: offset}}
+}
+
+// Stats returns the stats of the reader. When the Poller joins the consumer group itself, the stats of the partition
+// readers are summed up. The counters are reset on every call.
+func (k *Poller) Stats() kafka.ReaderStats {
+	if k.group != nil {
+		return k.groupStats()
+	}
+	return k.Reader.Stats()
+}
+
+// OffsetStatus returns the acknowledgement status of every partition in [CommitModeAck], nil otherwise.
+func (k *Poller) OffsetStatus() map[string]map[int]PartitionStatus {
+	if k.tracker == nil {
+		return nil
+	}
+	return k.tracker.Status()
+}
+
+// InFlight returns the number of messages handed out but not yet committable in [CommitModeAck].
+func (k *Poller) InFlight() int {
+	if k.tracker == nil {
+		return 0
+	}
+	return k.tracker.InFlight()
+}
+
+// commit commits the stored offsets if auto-commit is enabled and either the buffer is full or force is set.
+func (k *Poller) commit(ctx context.Context, force bool) error {
+	if !k.config.AutoCommit {
+		return nil
+	}
+	if !force && k.consumerCount.Load() < uint64(k.config.MaxBuffer) {
+		return nil
+	}
+	k.consumerCount.Store(0)
+	_, err := k.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("Poller.commit: error committing message: %w", err)
 	}
 	return nil
 }
@@ -175,13 +313,13 @@ func (k *Poller) autoCommit(ctx context.Context) {
 	for {
 		select {
 		case <-timeout.Done():
-			err := k.commit(ctx)
+			err := k.commit(ctx, true)
 			if err != nil {
 				k.log.Emergency(ctx, "Error while writing kafka message", fmt.Errorf("Consumer.autoCommit: %w", err))
 			}
 			timeout, _ = context.WithTimeout(context.Background(), time.Duration(k.config.AutoCommitInterval*uint64(time.Millisecond)))
 		case <-ctx.Done():
-			err := k.commit(nCtx)
+			err := k.commit(nCtx, true)
 			if err != nil {
 				k.log.Error(nCtx, "error in auto commit", err)
 			}
@@ -196,12 +334,18 @@ func (k *Poller) Close(ctx context.Context) error {
 	if k.config.AutoCommit {
 		k.autoCommitCancel()
 	}
+	if k.refreshCancel != nil {
+		k.refreshCancel()
+	}
+	k.wg.Wait() // auto commit does the final commit before the reader is closed
+	if k.group != nil {
+		k.closeGroup()
+	}
 	closeErr := k.Reader.Close(ctx)
 	if closeErr != nil {
 		k.log.Error(ctx, fmt.Sprintf("Consumer closed with error for topic : %v", k.topics), closeErr)
 		return fmt.Errorf("Consumer.Close: %w", closeErr)
 	}
-	k.wg.Wait()
 	k.log.Notice(ctx, "Consumer closed for topic", k.topics)
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/kafka/spool"
 	"github.com/sabariramc/goserverbase/v6/log"
 	"github.com/sabariramc/goserverbase/v6/utils"
 	"github.com/segmentio/kafka-go"
@@ -15,6 +16,10 @@ import (
 
 // Producer is a high-level API that extends Writer with time and count based auto flush
 // and implements a Shutdown hook.
+//
+// With a [spool.Spool] the messages that fail after the retries are written to disk and replayed in order in the background;
+// the messages waiting in the batch buffer are moved to the spool behind them, and while the spool has messages new messages
+// are appended to it as well so that they are not delivered ahead of the spooled ones.
 type Producer struct {
 	*Writer
 	config          ProducerConfig
@@ -24,6 +29,10 @@ type Producer struct {
 	isTopicSpecific bool
 	wg              sync.WaitGroup
 	isBatch         bool
+	spool           *spool.Spool  // Messages that failed, replayed in order by replayWriter
+	replayWriter    *kafka.Writer // Synchronous writer replaying the spool
+	spoolLock       sync.RWMutex  // Held for writing while the failed messages and the batch buffer are moved to the spool
+	replayCancel    context.CancelFunc
 }
 
 // NewProducer creates a new Producer instance with the provided configuration options.
@@ -41,16 +50,8 @@ If Writer is not set in [ProducerConfig] then creates a new [kafka.Writer] with
 			Completion:   kLog.DeliveryReport,
 			RequiredAcks: kafka.RequiredAcks(config.RequiredAcks),
 			Async:        config.Async,
-			Logger: &kafkaLogger{
-				Log:     logger.NewResourceLogger(config.ModuleName + ":InfoLog"),
-				ctx:     ctx,
-				isError: false,
-			},
-			ErrorLogger: &kafkaLogger{
-				isError: true,
-				Log:     logger.NewResourceLogger(config.ModuleName + ":ErrorLog"),
-				ctx:     ctx,
-			},
+			Logger:       infoLogger,
+			ErrorLogger:  errorLogger,
 		}
 */
 func NewProducer(options ...ProducerOption) (*Producer, error) {
@@ -73,6 +74,7 @@ func NewProducer(options ...ProducerOption) (*Producer, error) {
 		logger.Warning(ctx, "Kafka replica acknowledgement is set to None", nil)
 	}
 	if config.Writer == nil {
+		infoLogger, errorLogger := newLoggers(ctx, logger, config.ModuleName)
 		config.Writer = &kafka.Writer{
 			Addr:     kafka.TCP(config.Brokers...),
 			Topic:    config.Topic,
@@ -84,19 +86,15 @@ func NewProducer(options ...ProducerOption) (*Producer, error) {
 			Completion:   kLog.DeliveryReport,
 			RequiredAcks: kafka.RequiredAcks(config.RequiredAcks),
 			Async:        config.Async,
-			Logger: &kafkaLogger{
-				Log:     logger.NewResourceLogger(config.ModuleName + ":InfoLog"),
-				ctx:     ctx,
-				isError: false,
-			},
-			ErrorLogger: &kafkaLogger{
-				isError: true,
-				Log:     logger.NewResourceLogger(config.ModuleName + ":ErrorLog"),
-				ctx:     ctx,
-			},
+			Logger:       infoLogger,
+			ErrorLogger:  errorLogger,
 		}
 	}
 	writer := NewWriter(ctx, config.Writer, config.MaxBuffer, logger, config.Trace)
+	writer.MaxRetries = config.MaxRetries
+	writer.RetryBackoff = time.Duration(config.RetryBackoff) * time.Millisecond
+	writer.OnDelivery = config.OnDelivery
+	writer.OnFailure = config.OnFailure
 	isTopicSpecificProducer := false
 	if config.Topic != "" {
 		isTopicSpecificProducer = true
@@ -109,6 +107,9 @@ func NewProducer(options ...ProducerOption) (*Producer, error) {
 		isTopicSpecific: isTopicSpecificProducer,
 		isBatch:         config.Batch,
 	}
+	if config.Spool != nil {
+		k.startSpool(ctx)
+	}
 	if config.Batch {
 		autoFlushContext, cancel := context.WithCancel(ctx)
 		k.autoFlushCancel = cancel
@@ -145,12 +146,49 @@ func (k *Producer) ProduceMessageWithTopic(ctx context.Context, topic, key strin
 
 // Produce writes a message to a specific topic with the given key and headers.
 // Appends correlation and user identity header.
+// The error reports a failure to write or buffer the message, use ProduceWithDelivery to know whether a message
+// buffered in batch or async mode reached the broker.
 func (k *Producer) Produce(ctx context.Context, topic, key string, message []byte, headers map[string]string) (err error) {
+	msg, err := k.newMessage(ctx, topic, key, message, headers)
+	if err != nil {
+		return err
+	}
+	return k.write(ctx, msg)
+}
+
+// ProduceWithDelivery writes a message like Produce and returns its [Delivery], completed once the message is written
+// to the broker or fails after the retries.
+func (k *Producer) ProduceWithDelivery(ctx context.Context, topic, key string, message []byte, headers map[string]string) (*Delivery, error) {
+	msg, err := k.newMessage(ctx, topic, key, message, headers)
+	if err != nil {
+		return nil, err
+	}
+	delivery := newDelivery(msg)
+	msg.WriterData = &envelope{ctx: context.WithoutCancel(ctx), delivery: delivery}
+	err = k.write(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// newMessage creates the message with the correlation and user identity header.
+func (k *Producer) newMessage(ctx context.Context, topic, key string, message []byte, headers map[string]string) (*kafka.Message, error) {
 	if k.isTopicSpecific && topic != k.topic {
 		err := fmt.Errorf("Producer.Produce: topic is set for producer use `Producer.ProduceMessage` method")
 		k.log.Error(ctx, "topic is set for producer use `Producer.ProduceMessage` method", err)
-		return err
+		return nil, err
+	}
+	msg, headers := buildMessage(ctx, key, message, headers)
+	k.log.Info(ctx, "MessageMeta", map[string]any{"key": key, "headers": headers, "topic": topic})
+	if !k.isTopicSpecific {
+		msg.Topic = topic
 	}
+	return msg, nil
+}
+
+// buildMessage creates a message with the headers, the correlation and user identity header of the context are added to headers.
+func buildMessage(ctx context.Context, key string, message []byte, headers map[string]string) (*kafka.Message, map[string]string) {
 	if headers == nil {
 		headers = make(map[string]string, 0)
 	}
@@ -165,27 +203,47 @@ func (k *Producer) Produce(ctx context.Context, topic, key string, message []byt
 			Value: []byte(v),
 		})
 	}
-	k.log.Info(ctx, "MessageMeta", map[string]any{"key": key, "headers": headers, "topic": topic})
-	msg := &kafka.Message{
+	return &kafka.Message{
 		Key:     []byte(key),
 		Value:   message,
 		Headers: messageHeader,
 		Time:    time.Now(),
-	}
-	if !k.isTopicSpecific {
-		msg.Topic = topic
-	}
-	err = k.WriteMessage(ctx, msg)
+	}, headers
+}
+
+// write writes the message, flushing the buffer first when it is full.
+func (k *Producer) write(ctx context.Context, msg *kafka.Message) error {
+	spooled, err := k.buffer(ctx, msg)
 	if err == ErrWriterBufferFull {
 		err = k.Flush(ctx)
-		if err != nil {
-			return err
+		if err == nil {
+			spooled, err = k.buffer(ctx, msg)
 		}
-		return k.WriteMessage(ctx, msg)
+	}
+	if spooled {
+		return err
+	}
+	if err != nil {
+		k.log.Error(ctx, "error producing message", err)
+		return fmt.Errorf("Producer.Produce: %w", err)
 	}
 	return nil
 }
 
+// buffer writes the message to the writer, or to the spool while the spool has messages waiting for replay.
+func (k *Producer) buffer(ctx context.Context, msg *kafka.Message) (spooled bool, err error) {
+	if k.spool == nil {
+		return false, k.WriteMessage(ctx, msg)
+	}
+	// the failure hook moves the batch buffer to the spool under the write lock, a message is not buffered after it
+	k.spoolLock.RLock()
+	defer k.spoolLock.RUnlock()
+	if k.spool.Pending() > 0 {
+		return true, k.spoolMessage(ctx, msg)
+	}
+	return false, k.WriteMessage(ctx, msg)
+}
+
 // autoFlush handles time-based background writes to the broker in case of batch producer.
 func (k *Producer) autoFlush(ctx context.Context) {
 	defer k.wg.Done()
@@ -216,8 +274,19 @@ func (k *Producer) Close(ctx context.Context) error {
 	if k.isBatch {
 		k.autoFlushCancel()
 	}
+	if k.replayCancel != nil {
+		k.replayCancel()
+	}
 	k.wg.Wait()
 	err := k.Writer.Close(ctx)
+	if k.spool != nil {
+		k.replay(ctx)
+		k.replayWriter.Close()
+		spoolErr := k.spool.Close()
+		if err == nil {
+			err = spoolErr
+		}
+	}
 	if err == nil {
 		k.log.Notice(ctx, "Producer closed for topic", k.topic)
 	}
@@ -15,7 +15,8 @@ type ConsumerTracer interface {
 	span.SpanOp
 }
 
-type OffsetMap map[string]map[int]int64 // Map to store offsets for each topic and partition
+// OffsetMap maps topic and partition to the offset to commit, which is the offset of the next message to read.
+type OffsetMap map[string]map[int]int64
 
 // Reader extends kafka.Reader with batch commit, tracing, and a StatusCheck hook.
 type Reader struct {
@@ -24,6 +25,9 @@ type Reader struct {
 	commitLock sync.Mutex
 	offsetMap  OffsetMap // Buffer to store messages for committing
 	tr         ConsumerTracer
+	onCommit   func(offsets OffsetMap) // Called with the offsets once they are committed
+	// Commits the offsets in place of the reader, set when the offsets are committed with a consumer group generation
+	commitOffsets func(ctx context.Context, offsets OffsetMap) error
 }
 
 // ErrReaderBufferFull is returned when the buffer is full and a message cannot be stored.
@@ -53,17 +57,7 @@ func (k *Reader) Commit(ctx context.Context) (OffsetMap, error) {
 		defer crSpan.Finish()
 	}
 	k.log.Notice(ctx, "committing messages", k.offsetMap)
-	msgList := make([]kafka.Message, len(k.offsetMap))
-	for topic, partitionMap := range k.offsetMap {
-		for partition, msg := range partitionMap {
-			msgList = append(msgList, kafka.Message{
-				Topic:     topic,
-				Partition: partition,
-				Offset:    msg,
-			})
-		}
-	}
-	err := k.CommitMessages(ctx, msgList...)
+	err := k.commitOffsetMap(ctx, k.offsetMap)
 	if err != nil {
 		k.log.Error(ctx, "error in commit", err)
 		return nil, fmt.Errorf("kafka.Reader.Commit: error committing message: %w", err)
@@ -74,18 +68,92 @@ func (k *Reader) Commit(ctx context.Context) (OffsetMap, error) {
 	return res, nil
 }
 
-// StoreOffset stores a offset for commit
+// CommitOffsets commits the given offsets directly, bypassing the offsets stored with StoreOffset.
+func (k *Reader) CommitOffsets(ctx context.Context, offsets OffsetMap) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+	if k.tr != nil {
+		var crSpan span.Span
+		ctx, crSpan = k.tr.NewSpanFromContext(ctx, "kafka.consumer.commit", span.SpanKindConsumer, "")
+		defer crSpan.Finish()
+	}
+	k.log.Notice(ctx, "committing offsets", offsets)
+	err := k.commitOffsetMap(ctx, offsets)
+	if err != nil {
+		k.log.Error(ctx, "error in commit", err)
+		return fmt.Errorf("kafka.Reader.CommitOffsets: error committing offsets: %w", err)
+	}
+	return nil
+}
+
+// commitOffsetMap commits the offsets in the map to the broker.
+func (k *Reader) commitOffsetMap(ctx context.Context, offsets OffsetMap) error {
+	if k.commitOffsets != nil {
+		err := k.commitOffsets(ctx, offsets)
+		if err == nil && k.onCommit != nil {
+			k.onCommit(offsets)
+		}
+		return err
+	}
+	msgList := make([]kafka.Message, 0, len(offsets))
+	for topic, partitionMap := range offsets {
+		for partition, offset := range partitionMap {
+			msgList = append(msgList, kafka.Message{
+				Topic:     topic,
+				Partition: partition,
+				Offset:    offset - 1, // CommitMessages commits the offset following the message
+			})
+		}
+	}
+	err := k.CommitMessages(ctx, msgList...)
+	if err == nil && k.onCommit != nil {
+		k.onCommit(offsets)
+	}
+	return err
+}
+
+// StoreOffset stores the offset following the message for commit.
 func (k *Reader) StoreOffset(ctx context.Context, msg *kafka.Message) {
+	k.storeOffset(msg.Topic, msg.Partition, msg.Offset+1)
+}
+
+// storeOffsets stores the offsets of the map for commit.
+func (k *Reader) storeOffsets(offsets OffsetMap) {
+	for topic, partitionMap := range offsets {
+		for partition, offset := range partitionMap {
+			k.storeOffset(topic, partition, offset)
+		}
+	}
+}
+
+// storeOffset stores the next offset to read of the topic partition for commit.
+func (k *Reader) storeOffset(topic string, partition int, offset int64) {
 	k.commitLock.Lock()
 	defer k.commitLock.Unlock()
-	if k.offsetMap[msg.Topic] == nil {
-		k.offsetMap[msg.Topic] = map[int]int64{}
+	if k.offsetMap[topic] == nil {
+		k.offsetMap[topic] = map[int]int64{}
+	}
+	k.offsetMap[topic][partition] = offset
+}
+
+// dropOffset removes the offset stored for commit of the topic partition, used when the partition is revoked.
+func (k *Reader) dropOffset(topic string, partition int) {
+	k.commitLock.Lock()
+	defer k.commitLock.Unlock()
+	if pMap, ok := k.offsetMap[topic]; ok {
+		delete(pMap, partition)
+		if len(pMap) == 0 {
+			delete(k.offsetMap, topic)
+		}
 	}
-	k.offsetMap[msg.Topic][msg.Partition] = msg.Offset
 }
 
 // Close closes the Kafka reader.
 func (k *Reader) Close(ctx context.Context) error {
+	if k.Reader == nil {
+		return nil
+	}
 	err := k.Reader.Close()
 	if err != nil {
 		k.log.Error(ctx, "error in closing reader", err)
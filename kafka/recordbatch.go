@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// attrTransactional marks a record batch written in a transaction.
+const attrTransactional = 1 << 4
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeRecordSet encodes the messages as a size prefixed record set of one v2 record batch carrying the producer ID,
+// epoch and base sequence, which [kafka.Writer] always leaves unset. Pass -1 for producerID, epoch and baseSequence for a plain batch.
+func encodeRecordSet(msgs []kafka.Message, producerID int64, epoch int16, baseSequence int32, transactional bool) []byte {
+	now := time.Now()
+	first := msgs[0].Time
+	if first.IsZero() {
+		first = now
+	}
+	maxTimestamp := first.UnixMilli()
+	var records []byte
+	for i, msg := range msgs {
+		ts := msg.Time
+		if ts.IsZero() {
+			ts = now
+		}
+		if ts.UnixMilli() > maxTimestamp {
+			maxTimestamp = ts.UnixMilli()
+		}
+		var rec []byte
+		rec = append(rec, 0) // record attributes, unused
+		rec = binary.AppendVarint(rec, ts.UnixMilli()-first.UnixMilli())
+		rec = binary.AppendVarint(rec, int64(i))
+		rec = appendVarBytes(rec, msg.Key)
+		rec = appendVarBytes(rec, msg.Value)
+		rec = binary.AppendVarint(rec, int64(len(msg.Headers)))
+		for _, h := range msg.Headers {
+			rec = appendVarBytes(rec, []byte(h.Key))
+			rec = appendVarBytes(rec, h.Value)
+		}
+		records = binary.AppendVarint(records, int64(len(rec)))
+		records = append(records, rec...)
+	}
+	var attributes int16
+	if transactional {
+		attributes |= attrTransactional
+	}
+	// everything from attributes to the end is covered by the CRC
+	var body []byte
+	body = binary.BigEndian.AppendUint16(body, uint16(attributes))
+	body = binary.BigEndian.AppendUint32(body, uint32(len(msgs)-1)) // last offset delta
+	body = binary.BigEndian.AppendUint64(body, uint64(first.UnixMilli()))
+	body = binary.BigEndian.AppendUint64(body, uint64(maxTimestamp))
+	body = binary.BigEndian.AppendUint64(body, uint64(producerID))
+	body = binary.BigEndian.AppendUint16(body, uint16(epoch))
+	body = binary.BigEndian.AppendUint32(body, uint32(baseSequence))
+	body = binary.BigEndian.AppendUint32(body, uint32(len(msgs)))
+	body = append(body, records...)
+	var batch []byte
+	batch = binary.BigEndian.AppendUint32(batch, uint32(8+4+4+1+4+len(body))) // record set size
+	batch = binary.BigEndian.AppendUint64(batch, 0)                           // base offset, assigned by the broker
+	batch = binary.BigEndian.AppendUint32(batch, uint32(4+1+4+len(body)))     // length after this field
+	batch = binary.BigEndian.AppendUint32(batch, 0xffffffff)                  // partition leader epoch
+	batch = append(batch, 2)                                                  // magic
+	batch = binary.BigEndian.AppendUint32(batch, crc32.Checksum(body, castagnoli))
+	return append(batch, body...)
+}
+
+// appendVarBytes appends the length prefixed bytes, nil is encoded as length -1.
+func appendVarBytes(b, v []byte) []byte {
+	if v == nil {
+		return binary.AppendVarint(b, -1)
+	}
+	b = binary.AppendVarint(b, int64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,75 @@
+package kafka_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	cKafka "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"gotest.tools/assert"
+)
+
+func TestEncodeRecordSet(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	msgs := []cKafka.Message{
+		{Key: []byte("k1"), Value: []byte("v1"), Time: now, Headers: []cKafka.Header{{Key: "h1", Value: []byte("a")}, {Key: "h2", Value: []byte("b")}}},
+		{Value: []byte("v2"), Time: now.Add(5 * time.Millisecond)},
+		{Key: []byte("k3"), Value: []byte{}, Time: now.Add(2 * time.Millisecond)},
+	}
+	tests := []struct {
+		name          string
+		producerID    int64
+		epoch         int16
+		baseSequence  int32
+		transactional bool
+	}{
+		{name: "Plain", producerID: -1, epoch: -1, baseSequence: -1},
+		{name: "Idempotent", producerID: 42, epoch: 3, baseSequence: 17},
+		{name: "Transactional", producerID: 7, epoch: 1, baseSequence: 0, transactional: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rs protocol.RecordSet
+			_, err := rs.ReadFrom(bytes.NewReader(kafka.EncodeRecordSet(msgs, tt.producerID, tt.epoch, tt.baseSequence, tt.transactional)))
+			assert.NilError(t, err)
+			assert.Equal(t, rs.Version, int8(2))
+			assert.Equal(t, rs.Attributes.Transactional(), tt.transactional)
+			stream, ok := rs.Records.(*protocol.RecordStream)
+			assert.Assert(t, ok)
+			assert.Equal(t, len(stream.Records), 1)
+			batch, ok := stream.Records[0].(*protocol.RecordBatch)
+			assert.Assert(t, ok)
+			assert.Equal(t, batch.ProducerID, tt.producerID)
+			assert.Equal(t, batch.ProducerEpoch, tt.epoch)
+			assert.Equal(t, batch.BaseSequence, tt.baseSequence)
+			assert.Equal(t, batch.Attributes.Transactional(), tt.transactional)
+			for i, msg := range msgs {
+				r, err := batch.ReadRecord()
+				assert.NilError(t, err)
+				assert.Equal(t, r.Offset, int64(i))
+				assert.Equal(t, r.Time.UnixMilli(), msg.Time.UnixMilli())
+				if msg.Key == nil {
+					assert.Assert(t, r.Key == nil)
+				} else {
+					key, err := protocol.ReadAll(r.Key)
+					assert.NilError(t, err)
+					assert.DeepEqual(t, key, msg.Key)
+				}
+				value, err := protocol.ReadAll(r.Value)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, value, msg.Value)
+				assert.Equal(t, len(r.Headers), len(msg.Headers))
+				for j, h := range msg.Headers {
+					assert.Equal(t, r.Headers[j].Key, h.Key)
+					assert.DeepEqual(t, r.Headers[j].Value, h.Value)
+				}
+			}
+			_, err = batch.ReadRecord()
+			assert.Assert(t, errors.Is(err, io.EOF))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka/spool"
+	"github.com/segmentio/kafka-go"
+)
+
+// ProducerStatus is the status of the Producer reported by StatusCheck.
+type ProducerStatus struct {
+	WriterStatus
+	Spool *spool.Status // Status of the spool, nil without one
+}
+
+// startSpool routes the messages that fail after the retries to the spool and starts replaying it.
+// The FailureHook of the config is called only when the spool cannot take the messages.
+// The messages waiting in the batch buffer are moved to the spool behind the failed ones, so that they are not delivered
+// ahead of them.
+func (k *Producer) startSpool(ctx context.Context) {
+	k.spool = k.config.Spool
+	w := k.Writer.Writer
+	k.replayWriter = &kafka.Writer{
+		Addr:         w.Addr,
+		Topic:        w.Topic,
+		Balancer:     w.Balancer,
+		Transport:    w.Transport,
+		RequiredAcks: w.RequiredAcks,
+		Logger:       w.Logger,
+		ErrorLogger:  w.ErrorLogger,
+	}
+	hook := k.Writer.OnFailure
+	k.Writer.OnFailure = func(ctx context.Context, msgs []kafka.Message, err error) error {
+		k.spoolLock.Lock()
+		defer k.spoolLock.Unlock()
+		spoolErr := k.spool.Append(msgs)
+		if spoolErr == nil {
+			k.spoolBuffered(ctx, hook, err)
+			return nil
+		}
+		k.log.Error(ctx, "error writing failed messages to spool", spoolErr)
+		if hook != nil {
+			return hook(ctx, msgs, err)
+		}
+		return spoolErr
+	}
+	replayCtx, cancel := context.WithCancel(ctx)
+	k.replayCancel = cancel
+	k.wg.Add(1)
+	go k.runReplay(replayCtx)
+	k.log.Notice(ctx, fmt.Sprintf("%v spooled messages pending replay", k.spool.Pending()), nil)
+}
+
+// spoolBuffered moves the messages of the batch buffer to the spool, written after the failed messages of a flush they are
+// replayed in the order they were produced. The messages are passed to the hook when the spool cannot take them.
+func (k *Producer) spoolBuffered(ctx context.Context, hook FailureHook, err error) {
+	buffered := k.Writer.takeBuffered()
+	if len(buffered) == 0 {
+		return
+	}
+	spoolErr := k.spool.Append(buffered)
+	if spoolErr != nil {
+		k.log.Error(ctx, "error writing buffered messages to spool", spoolErr)
+		k.failed.Add(uint64(len(buffered)))
+		for i := range buffered {
+			k.report(&buffered[i], err)
+		}
+		if hook != nil {
+			if hookErr := hook(ctx, buffered, err); hookErr != nil {
+				k.log.Error(ctx, "error in producer failure hook", hookErr)
+			}
+		}
+		return
+	}
+	for i := range buffered {
+		k.report(&buffered[i], ErrSpooled)
+	}
+	k.log.Warning(ctx, fmt.Sprintf("%v buffered messages moved to spool behind the failed messages", len(buffered)), nil)
+}
+
+// runReplay replays the spool at every replay interval.
+func (k *Producer) runReplay(ctx context.Context) {
+	defer k.wg.Done()
+	ticker := time.NewTicker(time.Duration(k.config.ReplayInterval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			k.replay(ctx)
+		}
+	}
+}
+
+// replay writes the spooled messages to the broker in order till the spool is empty or a write fails.
+func (k *Producer) replay(ctx context.Context) {
+	batch := k.config.MaxBuffer
+	if batch <= 0 {
+		batch = 100
+	}
+	for k.spool.Pending() > 0 && ctx.Err() == nil {
+		n, err := k.spool.Replay(ctx, batch, k.writeSpooled)
+		if err != nil {
+			k.log.Warning(ctx, "error replaying spool, retrying at the next interval", err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+		k.delivered.Add(uint64(n))
+		k.log.Notice(ctx, fmt.Sprintf("replayed %v spooled messages", n), nil)
+	}
+}
+
+// writeSpooled writes the spooled messages synchronously.
+func (k *Producer) writeSpooled(ctx context.Context, msgs []kafka.Message) error {
+	if k.replayWriter.Topic != "" {
+		for i := range msgs {
+			msgs[i].Topic = ""
+		}
+	}
+	return k.replayWriter.WriteMessages(ctx, msgs...)
+}
+
+// spoolMessage appends the message to the spool to keep it behind the messages waiting for replay.
+func (k *Producer) spoolMessage(ctx context.Context, msg *kafka.Message) error {
+	getEnvelope(ctx, msg)
+	err := k.spool.Append([]kafka.Message{*msg})
+	if err != nil {
+		k.log.Error(ctx, "error writing message to spool", err)
+		return fmt.Errorf("Producer.Produce: error writing message to spool: %w", err)
+	}
+	k.report(msg, ErrSpooled)
+	return nil
+}
+
+// StatusCheck returns the delivery metrics, stats and spool status of the Producer.
+func (k *Producer) StatusCheck(ctx context.Context) (any, error) {
+	status := &ProducerStatus{WriterStatus: *k.status()}
+	if k.spool != nil {
+		status.Spool = k.spool.Status()
+	}
+	return status, nil
+}
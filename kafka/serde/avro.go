@@ -0,0 +1,67 @@
+package serde
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+)
+
+// AvroSerializer marshals values with the Avro schema of the registry, a schema is required.
+type AvroSerializer struct {
+	parsed sync.Map // Parsed Avro schema keyed by subject and version
+}
+
+// NewAvroSerializer creates a new AvroSerializer.
+func NewAvroSerializer() *AvroSerializer {
+	return &AvroSerializer{}
+}
+
+// Format returns FormatAvro.
+func (s *AvroSerializer) Format() string {
+	return FormatAvro
+}
+
+// Marshal encodes the value with the Avro schema.
+func (s *AvroSerializer) Marshal(schema *Schema, v any) ([]byte, error) {
+	parsed, err := s.parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	data, err := avro.Marshal(parsed, v)
+	if err != nil {
+		return nil, fmt.Errorf("AvroSerializer.Marshal: %w", err)
+	}
+	return data, nil
+}
+
+// Unmarshal decodes the data with the Avro schema it was written with into the value.
+func (s *AvroSerializer) Unmarshal(schema *Schema, data []byte, v any) error {
+	parsed, err := s.parse(schema)
+	if err != nil {
+		return err
+	}
+	err = avro.Unmarshal(parsed, data, v)
+	if err != nil {
+		return fmt.Errorf("AvroSerializer.Unmarshal: %w", err)
+	}
+	return nil
+}
+
+// parse parses the Avro schema, caching the result.
+func (s *AvroSerializer) parse(schema *Schema) (avro.Schema, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("AvroSerializer.parse: avro requires a schema registry")
+	}
+	key := schema.Subject + "/" + strconv.Itoa(schema.Version)
+	if parsed, ok := s.parsed.Load(key); ok {
+		return parsed.(avro.Schema), nil
+	}
+	parsed, err := avro.ParseBytes(schema.Definition)
+	if err != nil {
+		return nil, fmt.Errorf("AvroSerializer.parse: error parsing schema %v: %w", key, err)
+	}
+	s.parsed.Store(key, parsed)
+	return parsed, nil
+}
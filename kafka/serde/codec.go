@@ -0,0 +1,135 @@
+package serde
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// codecConfig holds the options of a Codec.
+type codecConfig struct {
+	registry Registry
+	subject  string
+	version  int
+	validate bool
+}
+
+// CodecOption configures a Codec.
+type CodecOption func(*codecConfig)
+
+// WithSchema sets the registry and the schema subject and version used for encoding, version 0 uses the latest version.
+func WithSchema(registry Registry, subject string, version int) CodecOption {
+	return func(c *codecConfig) {
+		c.registry = registry
+		c.subject = subject
+		c.version = version
+	}
+}
+
+// WithValidation validates the encoded data against the schema on encode and decode, for serializers implementing [Validator].
+func WithValidation(validate bool) CodecOption {
+	return func(c *codecConfig) {
+		c.validate = validate
+	}
+}
+
+// Codec encodes and decodes values of type T with a Serializer and an optional schema.
+type Codec[T any] struct {
+	serializer Serializer
+	c          *codecConfig
+}
+
+// NewCodec creates a new Codec for the serializer.
+func NewCodec[T any](serializer Serializer, options ...CodecOption) *Codec[T] {
+	config := &codecConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+	return &Codec[T]{serializer: serializer, c: config}
+}
+
+// Encode encodes the value and returns the data and the value of the content type header.
+func (c *Codec[T]) Encode(ctx context.Context, v T) ([]byte, string, error) {
+	schema, err := c.schema(ctx, c.c.subject, c.c.version)
+	if err != nil {
+		return nil, "", fmt.Errorf("Codec.Encode: %w", err)
+	}
+	data, err := c.serializer.Marshal(schema, v)
+	if err != nil {
+		return nil, "", fmt.Errorf("Codec.Encode: %w", err)
+	}
+	err = c.validate(schema, data)
+	if err != nil {
+		return nil, "", fmt.Errorf("Codec.Encode: %w", err)
+	}
+	contentType := ContentType{Format: c.serializer.Format()}
+	if schema != nil {
+		contentType.Subject = schema.Subject
+		contentType.Version = schema.Version
+	}
+	return data, contentType.String(), nil
+}
+
+// Decode decodes the data using the schema identified by the content type header value.
+// When the content type is empty the schema of the codec is used.
+func (c *Codec[T]) Decode(ctx context.Context, data []byte, contentType string) (T, error) {
+	var v T
+	subject, version := c.c.subject, c.c.version
+	if contentType != "" {
+		ct, err := ParseContentType(contentType)
+		if err != nil {
+			return v, fmt.Errorf("Codec.Decode: %w", err)
+		}
+		if ct.Format != c.serializer.Format() {
+			return v, fmt.Errorf("Codec.Decode: expected format %v, got %v", c.serializer.Format(), ct.Format)
+		}
+		if ct.Subject != "" {
+			subject, version = ct.Subject, ct.Version
+		}
+	}
+	schema, err := c.schema(ctx, subject, version)
+	if err != nil {
+		return v, fmt.Errorf("Codec.Decode: %w", err)
+	}
+	err = c.validate(schema, data)
+	if err != nil {
+		return v, fmt.Errorf("Codec.Decode: %w", err)
+	}
+	var target any = &v
+	if rt := reflect.TypeOf(v); rt != nil && rt.Kind() == reflect.Pointer {
+		v = reflect.New(rt.Elem()).Interface().(T)
+		target = v
+	}
+	err = c.serializer.Unmarshal(schema, data, target)
+	if err != nil {
+		return v, fmt.Errorf("Codec.Decode: %w", err)
+	}
+	return v, nil
+}
+
+// schema returns the schema of the subject from the registry, nil when the codec has no registry.
+func (c *Codec[T]) schema(ctx context.Context, subject string, version int) (*Schema, error) {
+	if c.c.registry == nil || subject == "" {
+		return nil, nil
+	}
+	schema, err := c.c.registry.GetSchema(ctx, subject, version)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Format != c.serializer.Format() {
+		return nil, fmt.Errorf("schema %v version %v is %v, serializer is %v", schema.Subject, schema.Version, schema.Format, c.serializer.Format())
+	}
+	return schema, nil
+}
+
+// validate validates the data against the schema when validation is enabled.
+func (c *Codec[T]) validate(schema *Schema, data []byte) error {
+	if !c.c.validate || schema == nil {
+		return nil
+	}
+	validator, ok := c.serializer.(Validator)
+	if !ok {
+		return nil
+	}
+	return validator.Validate(schema, data)
+}
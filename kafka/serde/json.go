@@ -0,0 +1,82 @@
+package serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// JSONSerializer marshals values with encoding/json and validates them against JSON Schema.
+type JSONSerializer struct {
+	compiled sync.Map // Compiled JSON Schema keyed by subject and version
+}
+
+// NewJSONSerializer creates a new JSONSerializer.
+func NewJSONSerializer() *JSONSerializer {
+	return &JSONSerializer{}
+}
+
+// Format returns FormatJSON.
+func (s *JSONSerializer) Format() string {
+	return FormatJSON
+}
+
+// Marshal encodes the value as JSON.
+func (s *JSONSerializer) Marshal(schema *Schema, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("JSONSerializer.Marshal: %w", err)
+	}
+	return data, nil
+}
+
+// Unmarshal decodes the JSON data into the value.
+func (s *JSONSerializer) Unmarshal(schema *Schema, data []byte, v any) error {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("JSONSerializer.Unmarshal: %w", err)
+	}
+	return nil
+}
+
+// Validate validates the JSON data against the JSON Schema.
+func (s *JSONSerializer) Validate(schema *Schema, data []byte) error {
+	compiled, err := s.compile(schema)
+	if err != nil {
+		return err
+	}
+	var doc any
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return fmt.Errorf("JSONSerializer.Validate: invalid json: %w", err)
+	}
+	err = compiled.Validate(doc)
+	if err != nil {
+		return fmt.Errorf("JSONSerializer.Validate: %w", err)
+	}
+	return nil
+}
+
+// compile compiles the JSON Schema, caching the result.
+func (s *JSONSerializer) compile(schema *Schema) (*jsonschema.Schema, error) {
+	key := schema.Subject + "/" + strconv.Itoa(schema.Version)
+	if compiled, ok := s.compiled.Load(key); ok {
+		return compiled.(*jsonschema.Schema), nil
+	}
+	compiler := jsonschema.NewCompiler()
+	url := "mem://" + key
+	err := compiler.AddResource(url, bytes.NewReader(schema.Definition))
+	if err != nil {
+		return nil, fmt.Errorf("JSONSerializer.compile: error loading schema %v: %w", key, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("JSONSerializer.compile: error compiling schema %v: %w", key, err)
+	}
+	s.compiled.Store(key, compiled)
+	return compiled, nil
+}
@@ -0,0 +1,46 @@
+package serde
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufSerializer marshals generated protobuf messages. The schema only identifies the message, it is not used for encoding.
+type ProtobufSerializer struct{}
+
+// NewProtobufSerializer creates a new ProtobufSerializer.
+func NewProtobufSerializer() *ProtobufSerializer {
+	return &ProtobufSerializer{}
+}
+
+// Format returns FormatProtobuf.
+func (s *ProtobufSerializer) Format() string {
+	return FormatProtobuf
+}
+
+// Marshal encodes the value, which must be a [proto.Message].
+func (s *ProtobufSerializer) Marshal(schema *Schema, v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("ProtobufSerializer.Marshal: %T is not a proto.Message", v)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("ProtobufSerializer.Marshal: %w", err)
+	}
+	return data, nil
+}
+
+// Unmarshal decodes the data into the value, which must be a [proto.Message].
+func (s *ProtobufSerializer) Unmarshal(schema *Schema, data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("ProtobufSerializer.Unmarshal: %T is not a proto.Message", v)
+	}
+	err := proto.Unmarshal(data, msg)
+	if err != nil {
+		return fmt.Errorf("ProtobufSerializer.Unmarshal: %w", err)
+	}
+	return nil
+}
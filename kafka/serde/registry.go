@@ -0,0 +1,165 @@
+package serde
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSchemaNotFound is returned when the registry has no schema for the subject and version.
+var ErrSchemaNotFound = errors.New("serde: schema not found")
+
+// Registry resolves versioned schemas, implement it to use a remote schema registry.
+type Registry interface {
+	// GetSchema returns the schema of the subject with the version, the latest version when version is 0.
+	GetSchema(ctx context.Context, subject string, version int) (*Schema, error)
+}
+
+// extFormats maps the file extensions of the FileRegistry to the formats.
+var extFormats = map[string]string{
+	".json":  FormatJSON,
+	".avsc":  FormatAvro,
+	".proto": FormatProtobuf,
+}
+
+// FileRegistry is a local stand-in for a schema registry that reads schemas from a directory laid out as
+// <dir>/<subject>/<version>.<ext>, e.g. schemas/order/2.avsc. The extension sets the format, .json for JSON Schema,
+// .avsc for Avro and .proto for Protobuf. Schemas are cached after the first read, the latest version of a subject is
+// resolved again once its TTL expires so that a newly added version is picked up, see [WithLatestTTL].
+type FileRegistry struct {
+	dir       string
+	latestTTL time.Duration
+	lock      sync.RWMutex
+	cache     map[string]*Schema
+	latest    map[string]latestVersion
+}
+
+// latestVersion is the cached latest version of a subject.
+type latestVersion struct {
+	version int
+	expires time.Time
+}
+
+// DefaultLatestTTL is the default duration the latest version of a subject is cached by the FileRegistry.
+const DefaultLatestTTL = time.Minute
+
+// FileRegistryOption configures a FileRegistry.
+type FileRegistryOption func(*FileRegistry)
+
+// WithLatestTTL sets the duration the latest version of a subject is cached, 0 resolves it on every call.
+func WithLatestTTL(ttl time.Duration) FileRegistryOption {
+	return func(r *FileRegistry) {
+		r.latestTTL = ttl
+	}
+}
+
+// NewFileRegistry creates a new FileRegistry reading from dir.
+func NewFileRegistry(dir string, options ...FileRegistryOption) *FileRegistry {
+	r := &FileRegistry{
+		dir:       dir,
+		latestTTL: DefaultLatestTTL,
+		cache:     map[string]*Schema{},
+		latest:    map[string]latestVersion{},
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// GetSchema returns the schema of the subject with the version, the latest version when version is 0.
+func (r *FileRegistry) GetSchema(ctx context.Context, subject string, version int) (*Schema, error) {
+	if version == 0 {
+		var err error
+		version, err = r.latestVersion(subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := subject + "/" + strconv.Itoa(version)
+	r.lock.RLock()
+	schema, ok := r.cache[key]
+	r.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	files, err := r.files(subject)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := files[version]
+	if !ok {
+		return nil, fmt.Errorf("FileRegistry.GetSchema: %v version %v: %w", subject, version, ErrSchemaNotFound)
+	}
+	definition, err := os.ReadFile(filepath.Join(r.dir, subject, file))
+	if err != nil {
+		return nil, fmt.Errorf("FileRegistry.GetSchema: error reading %v: %w", file, err)
+	}
+	schema = &Schema{
+		Subject:    subject,
+		Version:    version,
+		Format:     extFormats[filepath.Ext(file)],
+		Definition: definition,
+	}
+	r.lock.Lock()
+	r.cache[key] = schema
+	r.lock.Unlock()
+	return schema, nil
+}
+
+// latestVersion returns the highest version of the subject, cached for the latest TTL.
+func (r *FileRegistry) latestVersion(subject string) (int, error) {
+	r.lock.RLock()
+	latest, ok := r.latest[subject]
+	r.lock.RUnlock()
+	if ok && time.Now().Before(latest.expires) {
+		return latest.version, nil
+	}
+	files, err := r.files(subject)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range files {
+		if v > version {
+			version = v
+		}
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("FileRegistry.latestVersion: %v: %w", subject, ErrSchemaNotFound)
+	}
+	r.lock.Lock()
+	r.latest[subject] = latestVersion{version: version, expires: time.Now().Add(r.latestTTL)}
+	r.lock.Unlock()
+	return version, nil
+}
+
+// files returns the schema files of the subject keyed by version.
+func (r *FileRegistry) files(subject string) (map[int]string, error) {
+	entries, err := os.ReadDir(filepath.Join(r.dir, subject))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("FileRegistry.files: %v: %w", subject, ErrSchemaNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("FileRegistry.files: error reading %v: %w", subject, err)
+	}
+	res := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || extFormats[ext] == "" {
+			continue
+		}
+		version, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil || version <= 0 {
+			continue
+		}
+		res[version] = entry.Name()
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+// Package serde provides pluggable serializers, schema validation and a schema registry for typed Kafka messages.
+package serde
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+)
+
+// Serialization formats.
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+// HeaderContentType is the message header identifying the format, schema subject and schema version of the value.
+const HeaderContentType = "content-type"
+
+// mediaTypes maps the formats to the media type of the content type header.
+var mediaTypes = map[string]string{
+	FormatJSON:     "application/json",
+	FormatProtobuf: "application/x-protobuf",
+	FormatAvro:     "application/avro",
+}
+
+// Schema is a versioned schema of a subject.
+type Schema struct {
+	Subject    string // Name of the schema
+	Version    int    // Version of the schema
+	Format     string // Format the schema describes, one of FormatJSON, FormatProtobuf or FormatAvro
+	Definition []byte // Schema document, JSON Schema for FormatJSON, Avro schema for FormatAvro and the .proto file for FormatProtobuf
+}
+
+// Serializer marshals values to and from a format.
+// The schema is nil when the codec has no registry.
+type Serializer interface {
+	Format() string
+	Marshal(schema *Schema, v any) ([]byte, error)
+	Unmarshal(schema *Schema, data []byte, v any) error
+}
+
+// Validator is implemented by serializers that can validate the encoded data against the schema.
+type Validator interface {
+	Validate(schema *Schema, data []byte) error
+}
+
+// ContentType is the decoded value of the content type header, e.g. `application/avro; subject=order; version=2`.
+type ContentType struct {
+	Format  string // Format of the value
+	Subject string // Schema subject, empty when the value has no schema
+	Version int    // Schema version, 0 when the value has no schema
+}
+
+// String encodes the content type as a header value.
+func (c ContentType) String() string {
+	params := map[string]string{}
+	if c.Subject != "" {
+		params["subject"] = c.Subject
+		params["version"] = strconv.Itoa(c.Version)
+	}
+	return mime.FormatMediaType(mediaTypes[c.Format], params)
+}
+
+// ParseContentType decodes a content type header value.
+func ParseContentType(value string) (ContentType, error) {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ContentType{}, fmt.Errorf("serde.ParseContentType: invalid content type `%v`: %w", value, err)
+	}
+	res := ContentType{Subject: params["subject"]}
+	for format, t := range mediaTypes {
+		if t == mediaType {
+			res.Format = format
+		}
+	}
+	if res.Format == "" {
+		return ContentType{}, fmt.Errorf("serde.ParseContentType: unsupported media type `%v`", mediaType)
+	}
+	if version, ok := params["version"]; ok {
+		res.Version, err = strconv.Atoi(version)
+		if err != nil {
+			return ContentType{}, fmt.Errorf("serde.ParseContentType: invalid schema version `%v`: %w", version, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package serde_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/kafka/serde"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/assert"
+)
+
+type order struct {
+	ID     string `json:"id" avro:"id"`
+	Amount int64  `json:"amount" avro:"amount"`
+}
+
+func newRegistry(t *testing.T) serde.Registry {
+	dir := t.TempDir()
+	files := map[string]string{
+		"order/1.json":     `{"type": "object", "required": ["id"], "properties": {"id": {"type": "string", "minLength": 1}, "amount": {"type": "integer"}}}`,
+		"order/2.json":     `{"type": "object", "required": ["id", "amount"], "properties": {"id": {"type": "string", "minLength": 1}, "amount": {"type": "integer", "minimum": 1}}}`,
+		"orderavro/1.avsc": `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}, {"name": "amount", "type": "long"}]}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return serde.NewFileRegistry(dir)
+}
+
+func TestFileRegistry(t *testing.T) {
+	ctx := context.Background()
+	registry := newRegistry(t)
+	schema, err := registry.GetSchema(ctx, "order", 0)
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Version, 2)
+	assert.Equal(t, schema.Format, serde.FormatJSON)
+	schema, err = registry.GetSchema(ctx, "orderavro", 1)
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Format, serde.FormatAvro)
+	_, err = registry.GetSchema(ctx, "order", 3)
+	assert.Assert(t, errors.Is(err, serde.ErrSchemaNotFound))
+	_, err = registry.GetSchema(ctx, "missing", 0)
+	assert.Assert(t, errors.Is(err, serde.ErrSchemaNotFound))
+}
+
+func TestFileRegistryLatestTTL(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name string) {
+		path := filepath.Join(dir, "order", name)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte(`{"type": "object"}`), 0o644))
+	}
+	write("1.json")
+	cached := serde.NewFileRegistry(dir)
+	uncached := serde.NewFileRegistry(dir, serde.WithLatestTTL(0))
+	for _, registry := range []*serde.FileRegistry{cached, uncached} {
+		schema, err := registry.GetSchema(ctx, "order", 0)
+		assert.NilError(t, err)
+		assert.Equal(t, schema.Version, 1)
+	}
+	write("2.json")
+	schema, err := cached.GetSchema(ctx, "order", 0)
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Version, 1)
+	schema, err = uncached.GetSchema(ctx, "order", 0)
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Version, 2)
+}
+
+func TestJSONCodec(t *testing.T) {
+	ctx := context.Background()
+	registry := newRegistry(t)
+	codec := serde.NewCodec[order](serde.NewJSONSerializer(), serde.WithSchema(registry, "order", 0), serde.WithValidation(true))
+	data, contentType, err := codec.Encode(ctx, order{ID: "o1", Amount: 10})
+	assert.NilError(t, err)
+	assert.Equal(t, contentType, "application/json; subject=order; version=2")
+	value, err := codec.Decode(ctx, data, contentType)
+	assert.NilError(t, err)
+	assert.Equal(t, value, order{ID: "o1", Amount: 10})
+	_, _, err = codec.Encode(ctx, order{ID: "o1"})
+	assert.ErrorContains(t, err, "JSONSerializer.Validate")
+	// the writer schema of the header is used for validation
+	value, err = codec.Decode(ctx, []byte(`{"id": "o2"}`), "application/json; subject=order; version=1")
+	assert.NilError(t, err)
+	assert.Equal(t, value.ID, "o2")
+	_, err = codec.Decode(ctx, []byte(`{"id": "o2"}`), "application/avro")
+	assert.ErrorContains(t, err, "expected format json")
+}
+
+func TestAvroCodec(t *testing.T) {
+	ctx := context.Background()
+	codec := serde.NewCodec[*order](serde.NewAvroSerializer(), serde.WithSchema(newRegistry(t), "orderavro", 1))
+	data, contentType, err := codec.Encode(ctx, &order{ID: "o1", Amount: 10})
+	assert.NilError(t, err)
+	assert.Equal(t, contentType, "application/avro; subject=orderavro; version=1")
+	value, err := codec.Decode(ctx, data, contentType)
+	assert.NilError(t, err)
+	assert.Equal(t, *value, order{ID: "o1", Amount: 10})
+	_, _, err = serde.NewCodec[*order](serde.NewAvroSerializer()).Encode(ctx, &order{})
+	assert.ErrorContains(t, err, "avro requires a schema registry")
+}
+
+func TestProtobufCodec(t *testing.T) {
+	ctx := context.Background()
+	codec := serde.NewCodec[*wrapperspb.StringValue](serde.NewProtobufSerializer())
+	data, contentType, err := codec.Encode(ctx, wrapperspb.String("hello"))
+	assert.NilError(t, err)
+	assert.Equal(t, contentType, "application/x-protobuf")
+	value, err := codec.Decode(ctx, data, contentType)
+	assert.NilError(t, err)
+	assert.Equal(t, value.GetValue(), "hello")
+}
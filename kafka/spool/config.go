@@ -0,0 +1,78 @@
+package spool
+
+import (
+	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// Overflow policies applied when an append would take the spool over its maximum size.
+const (
+	OverflowReject     = "REJECT"      // The append fails with ErrFull
+	OverflowDropOldest = "DROP_OLDEST" // The oldest segments are deleted to make room, their messages are lost
+)
+
+// Config holds the configuration of the spool.
+type Config struct {
+	Dir         string  // Directory of the segment files, created when missing
+	SegmentSize int64   // Size in bytes after which a new segment is started
+	MaxSize     int64   // Maximum size in bytes of all segments
+	Overflow    string  // [OverflowReject] or [OverflowDropOldest]
+	Log         log.Log // Logger instance
+}
+
+// GetDefaultConfig creates a new Config with values from environment variables or default values.
+/*
+	Environment Variables
+	- KAFKA__PRODUCER__SPOOL__DIR: Sets [Dir]
+	- KAFKA__PRODUCER__SPOOL__SEGMENT_SIZE: Sets [SegmentSize]
+	- KAFKA__PRODUCER__SPOOL__MAX_SIZE: Sets [MaxSize]
+	- KAFKA__PRODUCER__SPOOL__OVERFLOW: Sets [Overflow]
+*/
+func GetDefaultConfig() *Config {
+	return &Config{
+		Dir:         utils.GetEnv(env.KafkaProducerSpoolDir, "kafka-spool"),
+		SegmentSize: int64(utils.GetEnvInt(env.KafkaProducerSpoolSegmentSize, 16<<20)),
+		MaxSize:     int64(utils.GetEnvInt(env.KafkaProducerSpoolMaxSize, 1<<30)),
+		Overflow:    utils.GetEnv(env.KafkaProducerSpoolOverflow, OverflowReject),
+		Log:         log.New(log.WithModuleName("KafkaSpool")),
+	}
+}
+
+// Option represents options for configuring the spool.
+type Option func(*Config)
+
+// WithDir sets the directory of the spool.
+func WithDir(dir string) Option {
+	return func(c *Config) {
+		c.Dir = dir
+	}
+}
+
+// WithSegmentSize sets the size in bytes after which a new segment is started.
+func WithSegmentSize(size int64) Option {
+	return func(c *Config) {
+		c.SegmentSize = size
+	}
+}
+
+// WithMaxSize sets the maximum size in bytes of the spool.
+func WithMaxSize(size int64) Option {
+	return func(c *Config) {
+		c.MaxSize = size
+	}
+}
+
+// WithOverflow sets the overflow policy of the spool.
+func WithOverflow(policy string) Option {
+	return func(c *Config) {
+		c.Overflow = policy
+	}
+}
+
+// WithLog sets the logger of the spool.
+func WithLog(logger log.Log) Option {
+	return func(c *Config) {
+		c.Log = logger
+	}
+}
@@ -0,0 +1,508 @@
+// Package spool implements a disk-backed queue of Kafka messages made of segmented append-only files with checksums.
+// kafka.Producer writes to the spool when the broker is unreachable and replays the messages in order once it is back.
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrFull is returned by Append when the message does not fit in the spool under the overflow policy.
+var ErrFull = fmt.Errorf("Spool.Append: spool is full")
+
+// errCorrupt marks a record that is truncated or fails its checksum.
+var errCorrupt = fmt.Errorf("spool: corrupt record")
+
+const (
+	segmentExt    = ".seg"
+	cursorFile    = "cursor"
+	headerSize    = 8        // Length and CRC-32 of the record
+	maxRecordSize = 64 << 20 // Records larger than this are treated as corrupt
+)
+
+// record is the persisted form of a message.
+type record struct {
+	Topic   string         `json:"topic,omitempty"`
+	Key     []byte         `json:"key,omitempty"`
+	Value   []byte         `json:"value"`
+	Headers []kafka.Header `json:"headers,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// position is the replay cursor, the record at Offset of Segment is the next to replay and Index records precede it.
+type position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+	Index   int64  `json:"index"`
+}
+
+// after reports whether p is past o.
+func (p position) after(o position) bool {
+	return p.Segment > o.Segment || (p.Segment == o.Segment && p.Offset > o.Offset)
+}
+
+// segment is a segment file, size and records cover the valid records only.
+type segment struct {
+	seq     uint64
+	size    int64
+	records int64
+}
+
+// Status is the status of the spool reported by the StatusCheck of the producer.
+type Status struct {
+	Records   int64  // Messages waiting to be replayed
+	Bytes     int64  // Bytes waiting to be replayed
+	Segments  int    // Segment files on disk
+	Replayed  uint64 // Messages replayed since open
+	Dropped   uint64 // Messages deleted by the overflow policy since open
+	Corrupted uint64 // Corrupt records skipped since open
+}
+
+// Spool is a disk-backed FIFO of Kafka messages.
+//
+// Messages are appended to the last segment file, each record framed by its length and CRC-32 and synced to disk before Append returns.
+// Replay reads from the cursor, which is persisted once the replayed messages are accepted, so a message is replayed at least once.
+// A truncated or corrupt record found on open or replay is skipped with the rest of its segment.
+type Spool struct {
+	c          *Config
+	log        log.Log
+	lock       sync.Mutex
+	replayLock sync.Mutex
+	segments   []*segment
+	active     *os.File
+	cursor     position
+	replayed   uint64
+	dropped    uint64
+	corrupted  uint64
+	closed     bool
+}
+
+// New opens the spool in the configured directory with the provided options, resuming from the persisted cursor.
+func New(options ...Option) (*Spool, error) {
+	config := GetDefaultConfig()
+	for _, opt := range options {
+		opt(config)
+	}
+	if config.Dir == "" {
+		return nil, fmt.Errorf("spool.New: directory is required")
+	}
+	switch config.Overflow {
+	case OverflowReject, OverflowDropOldest:
+	default:
+		return nil, fmt.Errorf("spool.New: invalid overflow policy `%v`", config.Overflow)
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 16 << 20
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 1 << 30
+	}
+	err := os.MkdirAll(config.Dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("spool.New: error creating directory: %w", err)
+	}
+	s := &Spool{c: config, log: config.Log}
+	err = s.load()
+	if err != nil {
+		return nil, fmt.Errorf("spool.New: %w", err)
+	}
+	return s, nil
+}
+
+// path returns the file path of the segment.
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.c.Dir, fmt.Sprintf("%020d%v", seq, segmentExt))
+}
+
+// load reads the cursor, scans the segments and opens the last one for append.
+func (s *Spool) load() error {
+	blob, err := os.ReadFile(filepath.Join(s.c.Dir, cursorFile))
+	if err == nil {
+		err = json.Unmarshal(blob, &s.cursor)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Spool.load: error reading cursor: %w", err)
+	}
+	entries, err := os.ReadDir(s.c.Dir)
+	if err != nil {
+		return fmt.Errorf("Spool.load: error listing segments: %w", err)
+	}
+	var seqs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for _, seq := range seqs {
+		if seq < s.cursor.Segment {
+			os.Remove(s.path(seq))
+			continue
+		}
+		seg, err := s.scan(seq)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seg)
+	}
+	if len(s.segments) == 0 {
+		seq := s.cursor.Segment
+		if seq == 0 {
+			seq = 1
+		}
+		s.segments = append(s.segments, &segment{seq: seq})
+	}
+	first := s.segments[0]
+	if s.cursor.Segment < first.seq {
+		s.cursor = position{Segment: first.seq}
+	}
+	if s.cursor.Segment == first.seq && s.cursor.Offset > first.size {
+		s.cursor = position{Segment: first.seq, Offset: first.size, Index: first.records}
+	}
+	s.active, err = os.OpenFile(s.path(s.segments[len(s.segments)-1].seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("Spool.load: error opening segment: %w", err)
+	}
+	return nil
+}
+
+// scan counts the valid records of the segment and truncates it at the first corrupt record.
+func (s *Spool) scan(seq uint64) (*segment, error) {
+	f, err := os.OpenFile(s.path(seq), os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("Spool.scan: error opening segment: %w", err)
+	}
+	defer f.Close()
+	seg := &segment{seq: seq}
+	r := bufio.NewReader(f)
+	for {
+		payload, err := readRecord(r)
+		if err == io.EOF {
+			return seg, nil
+		}
+		if err != nil {
+			s.corrupted++
+			s.log.Warning(context.Background(), fmt.Sprintf("truncating spool segment %v at offset %v", seq, seg.size), err)
+			err = f.Truncate(seg.size)
+			if err != nil {
+				return nil, fmt.Errorf("Spool.scan: error truncating segment: %w", err)
+			}
+			return seg, nil
+		}
+		seg.size += int64(headerSize + len(payload))
+		seg.records++
+	}
+}
+
+// readRecord reads a record, returns io.EOF at a clean end of the segment.
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [headerSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, errCorrupt
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > maxRecordSize {
+		return nil, errCorrupt
+	}
+	payload := make([]byte, size)
+	_, err = io.ReadFull(r, payload)
+	if err != nil || crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errCorrupt
+	}
+	return payload, nil
+}
+
+// encode frames the messages as records.
+func encode(msgs []kafka.Message) ([]byte, error) {
+	var buf []byte
+	for _, msg := range msgs {
+		payload, err := json.Marshal(&record{Topic: msg.Topic, Key: msg.Key, Value: msg.Value, Headers: msg.Headers, Time: msg.Time})
+		if err != nil {
+			return nil, err
+		}
+		var header [headerSize]byte
+		binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+		buf = append(buf, header[:]...)
+		buf = append(buf, payload...)
+	}
+	return buf, nil
+}
+
+// Append writes the messages to the spool and syncs them to disk.
+// Returns ErrFull when they do not fit and the overflow policy is OverflowReject or dropping segments is not enough.
+func (s *Spool) Append(msgs []kafka.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	buf, err := encode(msgs)
+	if err != nil {
+		return fmt.Errorf("Spool.Append: error encoding message: %w", err)
+	}
+	size := int64(len(buf))
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return fmt.Errorf("Spool.Append: spool is closed")
+	}
+	if s.c.Overflow == OverflowDropOldest {
+		for s.diskSize()+size > s.c.MaxSize && len(s.segments) > 1 {
+			s.dropOldest()
+		}
+	}
+	if s.diskSize()+size > s.c.MaxSize {
+		return ErrFull
+	}
+	last := s.segments[len(s.segments)-1]
+	if last.size > 0 && last.size+size > s.c.SegmentSize {
+		last, err = s.roll()
+		if err != nil {
+			return fmt.Errorf("Spool.Append: %w", err)
+		}
+	}
+	_, err = s.active.Write(buf)
+	if err == nil {
+		err = s.active.Sync()
+	}
+	if err != nil {
+		s.active.Truncate(last.size)
+		return fmt.Errorf("Spool.Append: error writing segment: %w", err)
+	}
+	last.size += size
+	last.records += int64(len(msgs))
+	return nil
+}
+
+// roll starts a new segment.
+func (s *Spool) roll() (*segment, error) {
+	seg := &segment{seq: s.segments[len(s.segments)-1].seq + 1}
+	f, err := os.OpenFile(s.path(seg.seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("Spool.roll: error creating segment: %w", err)
+	}
+	s.active.Close()
+	s.active = f
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+// dropOldest deletes the oldest segment and moves the cursor past it.
+func (s *Spool) dropOldest() {
+	seg := s.segments[0]
+	dropped := seg.records
+	if s.cursor.Segment == seg.seq {
+		dropped -= s.cursor.Index
+	}
+	os.Remove(s.path(seg.seq))
+	s.segments = s.segments[1:]
+	s.cursor = position{Segment: s.segments[0].seq}
+	s.saveCursor()
+	s.dropped += uint64(dropped)
+	s.log.Warning(context.Background(), fmt.Sprintf("spool is full, dropped %v messages of segment %v", dropped, seg.seq), nil)
+}
+
+// diskSize returns the size of every segment.
+func (s *Spool) diskSize() int64 {
+	var size int64
+	for _, seg := range s.segments {
+		size += seg.size
+	}
+	return size
+}
+
+// Pending returns the number of messages waiting to be replayed.
+func (s *Spool) Pending() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pending()
+}
+
+func (s *Spool) pending() int64 {
+	var records int64
+	for _, seg := range s.segments {
+		if seg.seq >= s.cursor.Segment {
+			records += seg.records
+		}
+	}
+	return records - s.cursor.Index
+}
+
+// Replay reads up to max messages from the cursor in the order they were appended and passes them to fn.
+// The cursor moves past the messages only when fn returns nil, returns the number of messages replayed.
+func (s *Spool) Replay(ctx context.Context, max int, fn func(ctx context.Context, msgs []kafka.Message) error) (int, error) {
+	s.replayLock.Lock()
+	defer s.replayLock.Unlock()
+	s.lock.Lock()
+	from := s.cursor
+	segs := make([]segment, 0, len(s.segments))
+	for _, seg := range s.segments {
+		if seg.seq >= from.Segment {
+			segs = append(segs, *seg)
+		}
+	}
+	s.lock.Unlock()
+	msgs, end, err := s.read(from, segs, max)
+	if err != nil {
+		return 0, fmt.Errorf("Spool.Replay: %w", err)
+	}
+	if len(msgs) > 0 {
+		err = fn(ctx, msgs)
+		if err != nil {
+			return 0, fmt.Errorf("Spool.Replay: %w", err)
+		}
+	}
+	if end.after(from) {
+		s.commit(end, len(msgs))
+	}
+	return len(msgs), nil
+}
+
+// read reads up to max messages starting at the position, segs is a snapshot so records appended meanwhile are not read partially.
+func (s *Spool) read(pos position, segs []segment, max int) ([]kafka.Message, position, error) {
+	var msgs []kafka.Message
+	for _, seg := range segs {
+		if len(msgs) >= max {
+			break
+		}
+		if seg.seq > pos.Segment {
+			pos = position{Segment: seg.seq}
+		}
+		if pos.Offset >= seg.size {
+			continue
+		}
+		f, err := os.Open(s.path(seg.seq))
+		if err != nil {
+			return nil, pos, fmt.Errorf("error opening segment: %w", err)
+		}
+		_, err = f.Seek(pos.Offset, io.SeekStart)
+		if err != nil {
+			f.Close()
+			return nil, pos, fmt.Errorf("error seeking segment: %w", err)
+		}
+		r := bufio.NewReader(f)
+		for pos.Offset < seg.size && len(msgs) < max {
+			payload, err := readRecord(r)
+			rec := &record{}
+			if err == nil {
+				err = json.Unmarshal(payload, rec)
+			}
+			if err != nil {
+				s.lock.Lock()
+				s.corrupted++
+				s.lock.Unlock()
+				s.log.Error(context.Background(), fmt.Sprintf("skipping corrupt records of spool segment %v from offset %v", seg.seq, pos.Offset), err)
+				pos = position{Segment: seg.seq, Offset: seg.size, Index: seg.records}
+				break
+			}
+			msgs = append(msgs, kafka.Message{Topic: rec.Topic, Key: rec.Key, Value: rec.Value, Headers: rec.Headers, Time: rec.Time})
+			pos.Offset += int64(headerSize + len(payload))
+			pos.Index++
+		}
+		f.Close()
+	}
+	return msgs, pos, nil
+}
+
+// commit moves the cursor to the position, deletes the replayed segments and empties the last segment once it is replayed.
+func (s *Spool) commit(pos position, replayed int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.replayed += uint64(replayed)
+	if pos.after(s.cursor) {
+		s.cursor = pos
+	}
+	for len(s.segments) > 1 {
+		first := s.segments[0]
+		if first.seq >= s.cursor.Segment && !(first.seq == s.cursor.Segment && s.cursor.Offset >= first.size) {
+			break
+		}
+		os.Remove(s.path(first.seq))
+		s.segments = s.segments[1:]
+		if s.cursor.Segment <= first.seq {
+			s.cursor = position{Segment: s.segments[0].seq}
+		}
+	}
+	last := s.segments[len(s.segments)-1]
+	if len(s.segments) == 1 && last.size > 0 && s.cursor.Offset >= last.size && !s.closed {
+		err := s.active.Truncate(0)
+		if err == nil {
+			last.size, last.records = 0, 0
+			s.cursor = position{Segment: last.seq}
+		}
+	}
+	s.saveCursor()
+}
+
+// saveCursor persists the cursor, replacing the file atomically.
+func (s *Spool) saveCursor() {
+	blob, _ := json.Marshal(&s.cursor)
+	tmp := filepath.Join(s.c.Dir, cursorFile+".tmp")
+	err := os.WriteFile(tmp, blob, 0o644)
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(s.c.Dir, cursorFile))
+	}
+	if err != nil {
+		s.log.Error(context.Background(), "error saving spool cursor", err)
+	}
+}
+
+// Status returns the status of the spool.
+func (s *Spool) Status() *Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var bytes int64
+	for _, seg := range s.segments {
+		if seg.seq >= s.cursor.Segment {
+			bytes += seg.size
+		}
+	}
+	return &Status{
+		Records:   s.pending(),
+		Bytes:     bytes - s.cursor.Offset,
+		Segments:  len(s.segments),
+		Replayed:  s.replayed,
+		Dropped:   s.dropped,
+		Corrupted: s.corrupted,
+	}
+}
+
+// Close persists the cursor and closes the segment file, messages left are replayed when the spool is opened again.
+func (s *Spool) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.saveCursor()
+	err := s.active.Close()
+	if err != nil {
+		return fmt.Errorf("Spool.Close: %w", err)
+	}
+	return nil
+}
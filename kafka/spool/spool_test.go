@@ -0,0 +1,108 @@
+package spool_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/kafka/spool"
+	"github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func messages(from, to int) []kafka.Message {
+	msgs := make([]kafka.Message, 0, to-from)
+	for i := from; i < to; i++ {
+		msgs = append(msgs, kafka.Message{Topic: "gobase.test.spool", Key: []byte(fmt.Sprint(i)), Value: []byte(fmt.Sprintf("value-%v", i))})
+	}
+	return msgs
+}
+
+func replayAll(t *testing.T, s *spool.Spool) []string {
+	var keys []string
+	for {
+		n, err := s.Replay(context.Background(), 3, func(ctx context.Context, msgs []kafka.Message) error {
+			for _, msg := range msgs {
+				keys = append(keys, string(msg.Key))
+			}
+			return nil
+		})
+		assert.NilError(t, err)
+		if n == 0 {
+			return keys
+		}
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	s, err := spool.New(spool.WithDir(dir), spool.WithSegmentSize(256))
+	assert.NilError(t, err)
+	assert.NilError(t, s.Append(messages(0, 5)))
+	assert.NilError(t, s.Append(messages(5, 10)))
+	assert.Equal(t, s.Pending(), int64(10))
+	assert.Assert(t, s.Status().Segments > 1)
+	_, err = s.Replay(context.Background(), 4, func(ctx context.Context, msgs []kafka.Message) error {
+		return fmt.Errorf("broker down")
+	})
+	assert.Assert(t, err != nil)
+	assert.Equal(t, s.Pending(), int64(10))
+	n, err := s.Replay(context.Background(), 4, func(ctx context.Context, msgs []kafka.Message) error { return nil })
+	assert.NilError(t, err)
+	assert.Equal(t, n, 4)
+	assert.NilError(t, s.Close())
+
+	s, err = spool.New(spool.WithDir(dir), spool.WithSegmentSize(256))
+	assert.NilError(t, err)
+	assert.Equal(t, s.Pending(), int64(6))
+	assert.DeepEqual(t, replayAll(t, s), []string{"4", "5", "6", "7", "8", "9"})
+	status := s.Status()
+	assert.Equal(t, status.Records, int64(0))
+	assert.Equal(t, status.Bytes, int64(0))
+	assert.Equal(t, status.Segments, 1)
+	assert.NilError(t, s.Append(messages(10, 12)))
+	assert.DeepEqual(t, replayAll(t, s), []string{"10", "11"})
+	assert.NilError(t, s.Close())
+}
+
+func TestSpoolOverflow(t *testing.T) {
+	s, err := spool.New(spool.WithDir(t.TempDir()), spool.WithSegmentSize(100), spool.WithMaxSize(400))
+	assert.NilError(t, err)
+	for err == nil {
+		err = s.Append(messages(0, 1))
+	}
+	assert.Equal(t, err, spool.ErrFull)
+	s.Close()
+
+	s, err = spool.New(spool.WithDir(t.TempDir()), spool.WithSegmentSize(100), spool.WithMaxSize(400), spool.WithOverflow(spool.OverflowDropOldest))
+	assert.NilError(t, err)
+	for i := 0; i < 20; i++ {
+		assert.NilError(t, s.Append(messages(i, i+1)))
+	}
+	status := s.Status()
+	assert.Assert(t, status.Dropped > 0)
+	assert.Equal(t, status.Records+int64(status.Dropped), int64(20))
+	keys := replayAll(t, s)
+	assert.Equal(t, keys[len(keys)-1], "19")
+	s.Close()
+}
+
+func TestSpoolCorruption(t *testing.T) {
+	dir := t.TempDir()
+	s, err := spool.New(spool.WithDir(dir))
+	assert.NilError(t, err)
+	assert.NilError(t, s.Append(messages(0, 3)))
+	s.Close()
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	assert.Equal(t, len(segments), 1)
+	info, _ := os.Stat(segments[0])
+	assert.NilError(t, os.Truncate(segments[0], info.Size()-5))
+
+	s, err = spool.New(spool.WithDir(dir))
+	assert.NilError(t, err)
+	assert.Equal(t, s.Status().Corrupted, uint64(1))
+	assert.DeepEqual(t, replayAll(t, s), []string{"0", "1"})
+	s.Close()
+}
@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// partitionOffset holds the in-flight and completed offsets of a single topic partition.
+type partitionOffset struct {
+	inFlight    []int64                  // Offsets handed out for processing, in fetch order
+	msgs        map[int64]*kafka.Message // Message handed out for every in-flight offset
+	done        map[int64]struct{}       // Offsets that completed but are not yet contiguous
+	committable int64                    // Highest offset below which every message completed, -1 if none
+}
+
+// PartitionStatus is the snapshot of a tracked partition.
+type PartitionStatus struct {
+	InFlight    int   // Number of messages handed out but not yet contiguous-complete
+	Committable int64 // Offset of the last message below which every message completed, -1 if none
+}
+
+// OffsetTracker tracks messages that are processed out of order and reports, per partition,
+// the highest offset up to which every message has completed.
+// Messages of a partition must be tracked in the order they are fetched.
+type OffsetTracker struct {
+	lock       sync.Mutex
+	partitions map[string]map[int]*partitionOffset
+	pending    OffsetMap // Committable offsets not yet handed out by Committable
+}
+
+// NewOffsetTracker creates a new OffsetTracker.
+func NewOffsetTracker() *OffsetTracker {
+	return &OffsetTracker{
+		partitions: map[string]map[int]*partitionOffset{},
+		pending:    OffsetMap{},
+	}
+}
+
+// partition returns the state of the topic partition, creating it if needed. Caller must hold the lock.
+func (t *OffsetTracker) partition(topic string, partition int) *partitionOffset {
+	pMap, ok := t.partitions[topic]
+	if !ok {
+		pMap = map[int]*partitionOffset{}
+		t.partitions[topic] = pMap
+	}
+	p, ok := pMap[partition]
+	if !ok {
+		p = &partitionOffset{done: map[int64]struct{}{}, msgs: map[int64]*kafka.Message{}, committable: -1}
+		pMap[partition] = p
+	}
+	return p
+}
+
+// Track marks the message as in flight.
+func (t *OffsetTracker) Track(msg *kafka.Message) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := t.partition(msg.Topic, msg.Partition)
+	p.inFlight = append(p.inFlight, msg.Offset)
+	p.msgs[msg.Offset] = msg
+}
+
+// Tracked reports whether the message is in flight. A message forgotten by Reset or Rewind is not, even after the message
+// of its offset is fetched and tracked again, as messages are compared by identity.
+func (t *OffsetTracker) Tracked(msg *kafka.Message) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, ok := t.partitions[msg.Topic][msg.Partition]
+	return ok && p.msgs[msg.Offset] == msg
+}
+
+// Done marks the message as completed and returns the highest contiguous completed offset of its partition.
+// The boolean is true when the contiguous offset moved forward because of this call.
+// Messages that are not tracked, such as messages of a partition reset after a rebalance, are ignored.
+func (t *OffsetTracker) Done(msg *kafka.Message) (int64, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, ok := t.partitions[msg.Topic][msg.Partition]
+	if !ok {
+		return -1, false
+	}
+	i := sort.Search(len(p.inFlight), func(i int) bool { return p.inFlight[i] >= msg.Offset })
+	if i == len(p.inFlight) || p.inFlight[i] != msg.Offset {
+		return p.committable, false
+	}
+	p.done[msg.Offset] = struct{}{}
+	advanced := false
+	for len(p.inFlight) > 0 {
+		head := p.inFlight[0]
+		if _, ok := p.done[head]; !ok {
+			break
+		}
+		delete(p.done, head)
+		delete(p.msgs, head)
+		p.inFlight = p.inFlight[1:]
+		p.committable = head
+		advanced = true
+	}
+	if advanced {
+		if t.pending[msg.Topic] == nil {
+			t.pending[msg.Topic] = map[int]int64{}
+		}
+		t.pending[msg.Topic][msg.Partition] = p.committable + 1
+	}
+	return p.committable, advanced
+}
+
+// CommittableWith returns the next offset to read of the partition of msg as it would be once msg completes, without
+// marking it completed. The boolean is false when msg is not tracked or completing it would not move the offset forward,
+// i.e. a message fetched before it from the partition has not completed.
+func (t *OffsetTracker) CommittableWith(msg *kafka.Message) (int64, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, ok := t.partitions[msg.Topic][msg.Partition]
+	if !ok || len(p.inFlight) == 0 || p.inFlight[0] != msg.Offset {
+		return -1, false
+	}
+	next := msg.Offset + 1
+	for _, offset := range p.inFlight[1:] {
+		if _, ok := p.done[offset]; !ok {
+			break
+		}
+		next = offset + 1
+	}
+	return next, true
+}
+
+// Committable returns the offsets that became committable since the last call and resets them.
+// The offsets are the next offsets to read, as expected by [Reader.CommitOffsets].
+func (t *OffsetTracker) Committable() OffsetMap {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.pending) == 0 {
+		return nil
+	}
+	res := t.pending
+	t.pending = OffsetMap{}
+	return res
+}
+
+// Restore puts back offsets returned by Committable, used when committing them failed.
+// Offsets that were superseded in the meantime are ignored.
+func (t *OffsetTracker) Restore(offsets OffsetMap) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for topic, partitionMap := range offsets {
+		for partition, offset := range partitionMap {
+			if t.pending[topic] == nil {
+				t.pending[topic] = map[int]int64{}
+			}
+			if current, ok := t.pending[topic][partition]; !ok || current < offset {
+				t.pending[topic][partition] = offset
+			}
+		}
+	}
+}
+
+// Reset forgets the in-flight messages and the pending offset of the topic partition, used when the partition is revoked.
+// Acknowledgements of messages fetched before the reset are ignored by Done.
+func (t *OffsetTracker) Reset(topic string, partition int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if pMap, ok := t.partitions[topic]; ok {
+		delete(pMap, partition)
+		if len(pMap) == 0 {
+			delete(t.partitions, topic)
+		}
+	}
+	if pMap, ok := t.pending[topic]; ok {
+		delete(pMap, partition)
+		if len(pMap) == 0 {
+			delete(t.pending, topic)
+		}
+	}
+}
+
+// Rewind forgets the in-flight messages of the topic partition at or after the offset, used when the messages are
+// fetched again from the offset. Acknowledgements of the forgotten messages are ignored by Done till they are tracked again.
+func (t *OffsetTracker) Rewind(topic string, partition int, offset int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, ok := t.partitions[topic][partition]
+	if !ok {
+		return
+	}
+	i := sort.Search(len(p.inFlight), func(i int) bool { return p.inFlight[i] >= offset })
+	for _, o := range p.inFlight[i:] {
+		delete(p.done, o)
+		delete(p.msgs, o)
+	}
+	p.inFlight = p.inFlight[:i]
+}
+
+// InFlight returns the total number of messages that are tracked but not yet contiguous-complete.
+func (t *OffsetTracker) InFlight() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	count := 0
+	for _, pMap := range t.partitions {
+		for _, p := range pMap {
+			count += len(p.inFlight)
+		}
+	}
+	return count
+}
+
+// Status returns the snapshot of every tracked partition.
+func (t *OffsetTracker) Status() map[string]map[int]PartitionStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	res := make(map[string]map[int]PartitionStatus, len(t.partitions))
+	for topic, pMap := range t.partitions {
+		res[topic] = make(map[int]PartitionStatus, len(pMap))
+		for partition, p := range pMap {
+			res[topic][partition] = PartitionStatus{InFlight: len(p.inFlight), Committable: p.committable}
+		}
+	}
+	return res
+}
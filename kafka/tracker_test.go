@@ -0,0 +1,82 @@
+package kafka_test
+
+import (
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	cKafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tr := kafka.NewOffsetTracker()
+	msgList := make([]*cKafka.Message, 5)
+	for i := range msgList {
+		msgList[i] = &cKafka.Message{Topic: "test.topic", Partition: 1, Offset: int64(10 + i)}
+		tr.Track(msgList[i])
+	}
+	offset, advanced := tr.Done(msgList[1])
+	assert.Equal(t, advanced, false)
+	assert.Equal(t, offset, int64(-1))
+	assert.Assert(t, tr.Committable() == nil)
+	offset, advanced = tr.Done(msgList[0])
+	assert.Equal(t, advanced, true)
+	assert.Equal(t, offset, int64(11))
+	tr.Done(msgList[3])
+	assert.Equal(t, tr.InFlight(), 3)
+	assert.DeepEqual(t, tr.Committable(), kafka.OffsetMap{"test.topic": {1: 12}})
+	assert.Assert(t, tr.Committable() == nil)
+	tr.Done(msgList[2])
+	tr.Done(msgList[4])
+	offsets := tr.Committable()
+	assert.DeepEqual(t, offsets, kafka.OffsetMap{"test.topic": {1: 15}})
+	tr.Restore(kafka.OffsetMap{"test.topic": {1: 13}})
+	tr.Restore(offsets)
+	assert.DeepEqual(t, tr.Committable(), kafka.OffsetMap{"test.topic": {1: 15}})
+	assert.Equal(t, tr.InFlight(), 0)
+}
+
+func TestOffsetTrackerReset(t *testing.T) {
+	tr := kafka.NewOffsetTracker()
+	msgList := make([]*cKafka.Message, 3)
+	for i := range msgList {
+		msgList[i] = &cKafka.Message{Topic: "test.topic", Partition: 1, Offset: int64(10 + i)}
+		tr.Track(msgList[i])
+	}
+	tr.Done(msgList[0])
+	tr.Reset("test.topic", 1)
+	assert.Assert(t, tr.Committable() == nil, "pending offset of a revoked partition is dropped")
+	assert.Equal(t, tr.InFlight(), 0)
+	_, advanced := tr.Done(msgList[1])
+	assert.Equal(t, advanced, false, "ack of the old generation is ignored")
+	assert.Assert(t, tr.Committable() == nil)
+
+	reassigned := &cKafka.Message{Topic: "test.topic", Partition: 1, Offset: 11}
+	tr.Track(reassigned)
+	_, advanced = tr.Done(&cKafka.Message{Topic: "test.topic", Partition: 1, Offset: 10})
+	assert.Equal(t, advanced, false, "ack of an offset that is not in flight is ignored")
+	offset, advanced := tr.Done(reassigned)
+	assert.Equal(t, advanced, true)
+	assert.Equal(t, offset, int64(11))
+	assert.DeepEqual(t, tr.Committable(), kafka.OffsetMap{"test.topic": {1: 12}})
+}
+
+func TestOffsetTrackerCommittableWith(t *testing.T) {
+	tr := kafka.NewOffsetTracker()
+	msgList := make([]*cKafka.Message, 4)
+	for i := range msgList {
+		msgList[i] = &cKafka.Message{Topic: "test.topic", Partition: 1, Offset: int64(10 + i)}
+		tr.Track(msgList[i])
+	}
+	_, ok := tr.CommittableWith(msgList[1])
+	assert.Equal(t, ok, false, "offset does not move past an unacknowledged message")
+	tr.Done(msgList[1])
+	tr.Done(msgList[2])
+	offset, ok := tr.CommittableWith(msgList[0])
+	assert.Equal(t, ok, true)
+	assert.Equal(t, offset, int64(13))
+	assert.Assert(t, tr.Committable() == nil, "peeking does not complete the message")
+	assert.Equal(t, tr.InFlight(), 4)
+	_, ok = tr.CommittableWith(&cKafka.Message{Topic: "test.topic", Partition: 2, Offset: 10})
+	assert.Equal(t, ok, false)
+}
@@ -0,0 +1,552 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+)
+
+// Produce modes of TxnProducer.
+const (
+	ProduceModePlain         = "PLAIN"         // Writes without producer ID, retries can duplicate messages
+	ProduceModeIdempotent    = "IDEMPOTENT"    // Writes with producer ID and sequence numbers, the broker drops duplicated retries
+	ProduceModeTransactional = "TRANSACTIONAL" // Idempotent writes grouped with consumer offsets in transactions
+)
+
+// ErrProducerFenced is returned when another producer with the same transactional ID has started, the producer must be closed.
+var ErrProducerFenced = fmt.Errorf("TxnProducer: producer fenced by a newer instance with the same transactional ID")
+
+// metadataTTL is the time the partitions of a topic are cached, as the metadata of [kafka.Transport].
+const metadataTTL = 6 * time.Second
+
+// topicPartition identifies a partition of a topic.
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// GroupMetadata identifies the member of the consumer group generation whose offsets are sent in a transaction,
+// the broker rejects the offsets of a member fenced by a rebalance. Get it from [Poller.GroupMetadata].
+type GroupMetadata struct {
+	GroupID      string // Consumer group ID
+	GenerationID int    // Generation of the group, -1 skips the generation check
+	MemberID     string // Member ID of the consumer in the generation, empty skips the member check
+}
+
+// topicMetadata holds the cached partitions of a topic.
+type topicMetadata struct {
+	partitions []int
+	expires    time.Time
+}
+
+// txnContextKey marks the context of a running transaction.
+type txnContextKey struct{}
+
+// txnState holds the partitions and consumer groups added to the running transaction.
+type txnState struct {
+	partitions map[topicPartition]bool
+	groups     map[string]bool
+}
+
+// TxnStatus is the status of the TxnProducer reported by StatusCheck.
+type TxnStatus struct {
+	Mode          string // One of the produce modes
+	ProducerID    int64  // Producer ID assigned by the broker, -1 in ProduceModePlain
+	ProducerEpoch int16  // Epoch of the producer ID
+	InTransaction bool   // Whether a transaction is running
+}
+
+// TxnProducer is a synchronous producer supporting idempotent writes and Kafka transactions, which [kafka.Writer] does not.
+// It speaks the Kafka protocol through [kafka.Client]: the producer ID comes from InitProducerId and every record batch carries
+// the producer ID, epoch and the sequence number of its partition, so a retried write is stored once.
+//
+// With a transactional ID the messages and the consumer offsets passed to SendOffsets within WithTransaction are committed
+// atomically; consumers of the output must read with [kafka.ReadCommitted] isolation to skip aborted messages.
+//
+// When Fallback is set and the broker rejects idempotence or transactions (old protocol version or missing ACL),
+// the producer logs a warning and writes in ProduceModePlain: transactions only run the function and SendOffsets is a no-op,
+// leaving the offsets to the consumer commit, i.e. at-least-once delivery.
+type TxnProducer struct {
+	config     *ProducerConfig
+	client     *kafka.Client
+	log        log.Log
+	balancer   kafka.Balancer
+	lock       sync.Mutex // Guards the producer session, held for the duration of a write
+	txLock     sync.Mutex // One transaction at a time
+	ready      bool
+	mode       string
+	producerID int64
+	epoch      int16
+	sequences  map[topicPartition]int32
+	partitions map[string]topicMetadata
+	txn        *txnState
+}
+
+// NewTxnProducer creates a new TxnProducer with the provided producer options, the session is initialized on first use or by Init.
+func NewTxnProducer(options ...ProducerOption) (*TxnProducer, error) {
+	config := GetDefaultProducerConfig()
+	config.ModuleName = "KafkaTxnProducer"
+	for _, opt := range options {
+		opt(config)
+	}
+	if config.TransactionalID != "" {
+		config.Idempotent = true
+	}
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: config.ModuleName})
+	if config.Idempotent && config.RequiredAcks != int(kafka.RequireAll) {
+		config.Log.Warning(ctx, "idempotent producer requires acknowledgement from all replicas, overriding RequiredAcks", config.RequiredAcks)
+		config.RequiredAcks = int(kafka.RequireAll)
+	}
+	if config.TxnTimeout == 0 {
+		config.TxnTimeout = 60000
+	}
+	return &TxnProducer{
+		config:     config,
+		client:     NewClient(config.CredConfig),
+		log:        config.Log.NewResourceLogger(config.ModuleName),
+		balancer:   &kafka.Hash{},
+		partitions: make(map[string]topicMetadata),
+	}, nil
+}
+
+// Init obtains the producer ID from the broker, call it at startup to fail fast; it is called on first use otherwise.
+func (p *TxnProducer) Init(ctx context.Context) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.init(ctx)
+}
+
+// init initializes the session if needed, a new session resets the sequence numbers.
+func (p *TxnProducer) init(ctx context.Context) error {
+	if p.ready {
+		return nil
+	}
+	p.producerID, p.epoch = -1, -1
+	p.sequences = make(map[topicPartition]int32)
+	if !p.config.Idempotent {
+		p.mode, p.ready = ProduceModePlain, true
+		return nil
+	}
+	if p.config.Fallback {
+		supported, err := p.supported(ctx)
+		if err == nil && !supported {
+			p.log.Warning(ctx, "broker does not support idempotent producer, falling back to plain writes", nil)
+			p.mode, p.ready = ProduceModePlain, true
+			return nil
+		}
+	}
+	res, err := p.client.InitProducerID(ctx, &kafka.InitProducerIDRequest{
+		TransactionalID:      p.config.TransactionalID,
+		TransactionTimeoutMs: int(p.config.TxnTimeout),
+		ProducerID:           -1,
+		ProducerEpoch:        -1,
+	})
+	if err == nil && res.Error != nil {
+		err = res.Error
+	}
+	if err != nil {
+		if p.config.Fallback && unsupported(err) {
+			p.log.Warning(ctx, "broker does not support idempotent producer, falling back to plain writes", err)
+			p.mode, p.ready = ProduceModePlain, true
+			return nil
+		}
+		p.log.Error(ctx, "error initializing producer id", err)
+		return fmt.Errorf("TxnProducer.init: error initializing producer id: %w", err)
+	}
+	p.producerID, p.epoch = int64(res.Producer.ProducerID), int16(res.Producer.ProducerEpoch)
+	p.mode = ProduceModeIdempotent
+	if p.config.TransactionalID != "" {
+		p.mode = ProduceModeTransactional
+	}
+	p.ready = true
+	p.log.Notice(ctx, "producer session initialized", map[string]any{"mode": p.mode, "producerId": p.producerID, "epoch": p.epoch})
+	return nil
+}
+
+// supported reports whether the broker serves the APIs of the produce mode in a version the client speaks.
+func (p *TxnProducer) supported(ctx context.Context) (bool, error) {
+	res, err := p.client.ApiVersions(ctx, &kafka.ApiVersionsRequest{})
+	if err == nil && res.Error != nil {
+		err = res.Error
+	}
+	if err != nil {
+		return false, fmt.Errorf("error fetching api versions: %w", err)
+	}
+	keys := []protocol.ApiKey{protocol.InitProducerId}
+	if p.config.TransactionalID != "" {
+		keys = append(keys, protocol.AddPartitionsToTxn, protocol.AddOffsetsToTxn, protocol.TxnOffsetCommit, protocol.EndTxn)
+	}
+	versions := make(map[int]kafka.ApiVersionsResponseApiKey, len(res.ApiKeys))
+	for _, key := range res.ApiKeys {
+		versions[key.ApiKey] = key
+	}
+	for _, key := range keys {
+		version, ok := versions[int(key)]
+		if !ok || version.MaxVersion < int(key.MinVersion()) || version.MinVersion > int(key.MaxVersion()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// unsupported reports whether the error means the broker cannot serve idempotent or transactional producers.
+func unsupported(err error) bool {
+	for _, kerr := range []kafka.Error{kafka.UnsupportedVersion, kafka.UnsupportedForMessageFormat, kafka.ClusterAuthorizationFailed, kafka.TransactionalIDAuthorizationFailed} {
+		if errors.Is(err, kerr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mode returns the produce mode, known after the session is initialized.
+func (p *TxnProducer) Mode() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.mode
+}
+
+// Produce writes a message to the topic with the given key and headers and waits for the acknowledgement.
+// Appends correlation and user identity header. Outside WithTransaction a transactional producer writes the message in a transaction of its own.
+func (p *TxnProducer) Produce(ctx context.Context, topic, key string, message []byte, headers map[string]string) error {
+	msg, _ := buildMessage(ctx, key, message, headers)
+	msg.Topic = topic
+	return p.ProduceMessages(ctx, *msg)
+}
+
+// ProduceMessages writes the messages, the messages of a partition are written in order in one record batch.
+func (p *TxnProducer) ProduceMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	if ctx.Value(txnContextKey{}) != p {
+		err := p.Init(ctx)
+		if err != nil {
+			return err
+		}
+		if p.Mode() == ProduceModeTransactional {
+			return p.WithTransaction(ctx, func(txCtx context.Context) error {
+				return p.ProduceMessages(txCtx, msgs...)
+			})
+		}
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	inTxn := ctx.Value(txnContextKey{}) == p
+	if inTxn && (p.txn == nil || !p.ready) {
+		return fmt.Errorf("TxnProducer.ProduceMessages: transaction is no longer active")
+	}
+	err := p.init(ctx)
+	if err != nil {
+		return err
+	}
+	batches, order, err := p.partition(ctx, msgs)
+	if err != nil {
+		return fmt.Errorf("TxnProducer.ProduceMessages: %w", err)
+	}
+	if inTxn {
+		err = p.addPartitions(ctx, order)
+		if err != nil {
+			return fmt.Errorf("TxnProducer.ProduceMessages: %w", err)
+		}
+	}
+	for _, tp := range order {
+		err = p.write(ctx, tp, batches[tp])
+		if err != nil {
+			return fmt.Errorf("TxnProducer.ProduceMessages: %w", err)
+		}
+	}
+	return nil
+}
+
+// partition assigns the messages to partitions with the hash balancer, order lists the partitions by first message.
+func (p *TxnProducer) partition(ctx context.Context, msgs []kafka.Message) (map[topicPartition][]kafka.Message, []topicPartition, error) {
+	batches := make(map[topicPartition][]kafka.Message)
+	var order []topicPartition
+	for _, msg := range msgs {
+		partitions, err := p.topicPartitions(ctx, msg.Topic)
+		if err != nil {
+			return nil, nil, err
+		}
+		tp := topicPartition{topic: msg.Topic, partition: p.balancer.Balance(msg, partitions...)}
+		if _, ok := batches[tp]; !ok {
+			order = append(order, tp)
+		}
+		batches[tp] = append(batches[tp], msg)
+	}
+	return batches, order, nil
+}
+
+// topicPartitions returns the partitions of the topic, cached for [metadataTTL] so that added partitions are picked up.
+func (p *TxnProducer) topicPartitions(ctx context.Context, topic string) ([]int, error) {
+	if meta, ok := p.partitions[topic]; ok && time.Now().Before(meta.expires) {
+		return meta.partitions, nil
+	}
+	res, err := p.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching metadata of topic `%v`: %w", topic, err)
+	}
+	if len(res.Topics) == 0 || res.Topics[0].Error != nil || len(res.Topics[0].Partitions) == 0 {
+		return nil, fmt.Errorf("topic `%v` not found", topic)
+	}
+	partitions := make([]int, len(res.Topics[0].Partitions))
+	for i, partition := range res.Topics[0].Partitions {
+		partitions[i] = partition.ID
+	}
+	p.partitions[topic] = topicMetadata{partitions: partitions, expires: time.Now().Add(metadataTTL)}
+	return partitions, nil
+}
+
+// write writes the record batch to the partition, retrying with the same sequence number so the broker stores it once.
+// A write that fails after the retries ends the session as the broker state of the sequence is unknown.
+func (p *TxnProducer) write(ctx context.Context, tp topicPartition, msgs []kafka.Message) error {
+	seq := int32(-1)
+	if p.mode != ProduceModePlain {
+		seq = p.sequences[tp]
+	}
+	records := encodeRecordSet(msgs, p.producerID, p.epoch, seq, p.mode == ProduceModeTransactional)
+	var err error
+	for attempt := 1; ; attempt++ {
+		var res *kafka.ProduceResponse
+		res, err = p.client.RawProduce(ctx, &kafka.RawProduceRequest{
+			Topic:           tp.topic,
+			Partition:       tp.partition,
+			RequiredAcks:    kafka.RequiredAcks(p.config.RequiredAcks),
+			TransactionalID: p.config.TransactionalID,
+			RawRecords:      protocol.RawRecordSet{Reader: bytes.NewReader(records)},
+		})
+		if err == nil && res != nil && res.Error != nil {
+			err = res.Error
+		}
+		if err == nil || errors.Is(err, kafka.DuplicateSequenceNumber) {
+			if seq >= 0 {
+				p.sequences[tp] = nextSequence(seq, len(msgs))
+			}
+			return nil
+		}
+		if errors.Is(err, kafka.UnknownTopicOrPartition) || errors.Is(err, kafka.NotLeaderForPartition) {
+			delete(p.partitions, tp.topic)
+		}
+		if !retriable(err) || attempt > p.config.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		p.log.Warning(ctx, fmt.Sprintf("retrying write to %v/%v, attempt %v", tp.topic, tp.partition, attempt), err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(p.config.RetryBackoff*uint64(attempt)) * time.Millisecond):
+		}
+	}
+	if p.mode != ProduceModePlain {
+		p.ready = false
+	}
+	if errors.Is(err, kafka.ProducerFenced) || errors.Is(err, kafka.InvalidProducerEpoch) {
+		err = fmt.Errorf("%w: %w", ErrProducerFenced, err)
+	}
+	p.log.Error(ctx, fmt.Sprintf("error writing to %v/%v", tp.topic, tp.partition), err)
+	return fmt.Errorf("TxnProducer.write: %w", err)
+}
+
+// nextSequence returns the sequence number following a batch of n messages, wrapping to 0 after [math.MaxInt32] as the broker does.
+func nextSequence(seq int32, n int) int32 {
+	return int32((int64(seq) + int64(n)) % (math.MaxInt32 + 1))
+}
+
+// retriable reports whether the write can be retried, network errors and temporary broker errors are.
+func retriable(err error) bool {
+	var kerr kafka.Error
+	if errors.As(err, &kerr) {
+		return kerr.Temporary()
+	}
+	return true
+}
+
+// WithTransaction runs fn in a transaction, the messages produced with the context passed to fn and the offsets sent with
+// SendOffsets are committed when fn returns nil and aborted otherwise. Transactions of a producer run one at a time.
+// Without transaction support fn runs as is.
+func (p *TxnProducer) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txnContextKey{}) == p {
+		return fn(ctx)
+	}
+	err := p.Init(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Mode() != ProduceModeTransactional {
+		return fn(ctx)
+	}
+	p.txLock.Lock()
+	defer p.txLock.Unlock()
+	p.lock.Lock()
+	err = p.init(ctx)
+	if err == nil {
+		p.txn = &txnState{partitions: make(map[topicPartition]bool), groups: make(map[string]bool)}
+	}
+	p.lock.Unlock()
+	if err != nil {
+		return fmt.Errorf("TxnProducer.WithTransaction: %w", err)
+	}
+	err = fn(context.WithValue(ctx, txnContextKey{}, p))
+	if err != nil {
+		abortErr := p.end(ctx, false)
+		if abortErr != nil {
+			p.log.Error(ctx, "error aborting transaction", abortErr)
+		}
+		return err
+	}
+	err = p.end(ctx, true)
+	if err != nil {
+		return fmt.Errorf("TxnProducer.WithTransaction: error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// addPartitions adds the partitions not yet part of the transaction.
+func (p *TxnProducer) addPartitions(ctx context.Context, tps []topicPartition) error {
+	topics := make(map[string][]kafka.AddPartitionToTxn)
+	for _, tp := range tps {
+		if !p.txn.partitions[tp] {
+			topics[tp.topic] = append(topics[tp.topic], kafka.AddPartitionToTxn{Partition: tp.partition})
+		}
+	}
+	if len(topics) == 0 {
+		return nil
+	}
+	res, err := p.client.AddPartitionsToTxn(ctx, &kafka.AddPartitionsToTxnRequest{
+		TransactionalID: p.config.TransactionalID,
+		ProducerID:      int(p.producerID),
+		ProducerEpoch:   int(p.epoch),
+		Topics:          topics,
+	})
+	if err != nil {
+		return fmt.Errorf("error adding partitions to transaction: %w", err)
+	}
+	for topic, partitions := range res.Topics {
+		for _, partition := range partitions {
+			if partition.Error != nil {
+				return fmt.Errorf("error adding partition %v/%v to transaction: %w", topic, partition.Partition, partition.Error)
+			}
+			p.txn.partitions[topicPartition{topic: topic, partition: partition.Partition}] = true
+		}
+	}
+	return nil
+}
+
+// SendOffsets adds the consumer offsets of the group to the running transaction, offsets are the next offsets to consume,
+// i.e. the offset of the last processed message + 1. The commit fails when the member is no longer part of the generation
+// in group. A no-op outside a transaction or without transaction support.
+func (p *TxnProducer) SendOffsets(ctx context.Context, group GroupMetadata, offsets OffsetMap) error {
+	if ctx.Value(txnContextKey{}) != p || len(offsets) == 0 {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.txn == nil {
+		return nil
+	}
+	if !p.txn.groups[group.GroupID] {
+		res, err := p.client.AddOffsetsToTxn(ctx, &kafka.AddOffsetsToTxnRequest{
+			TransactionalID: p.config.TransactionalID,
+			ProducerID:      int(p.producerID),
+			ProducerEpoch:   int(p.epoch),
+			GroupID:         group.GroupID,
+		})
+		if err == nil && res.Error != nil {
+			err = res.Error
+		}
+		if err != nil {
+			return fmt.Errorf("TxnProducer.SendOffsets: error adding group to transaction: %w", err)
+		}
+		p.txn.groups[group.GroupID] = true
+	}
+	topics := make(map[string][]kafka.TxnOffsetCommit, len(offsets))
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			topics[topic] = append(topics[topic], kafka.TxnOffsetCommit{Partition: partition, Offset: offset})
+		}
+	}
+	res, err := p.client.TxnOffsetCommit(ctx, &kafka.TxnOffsetCommitRequest{
+		TransactionalID: p.config.TransactionalID,
+		GroupID:         group.GroupID,
+		ProducerID:      int(p.producerID),
+		ProducerEpoch:   int(p.epoch),
+		GenerationID:    group.GenerationID,
+		MemberID:        group.MemberID,
+		Topics:          topics,
+	})
+	if err != nil {
+		return fmt.Errorf("TxnProducer.SendOffsets: %w", err)
+	}
+	for topic, partitions := range res.Topics {
+		for _, partition := range partitions {
+			if partition.Error != nil {
+				return fmt.Errorf("TxnProducer.SendOffsets: error committing offset of %v/%v: %w", topic, partition.Partition, partition.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// end commits or aborts the running transaction; a transaction that added nothing needs no request.
+// A failed end request ends the session, re-initializing it aborts the transaction on the broker.
+func (p *TxnProducer) end(ctx context.Context, commit bool) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	txn := p.txn
+	p.txn = nil
+	if txn == nil || (len(txn.partitions) == 0 && len(txn.groups) == 0) {
+		return nil
+	}
+	res, err := p.client.EndTxn(ctx, &kafka.EndTxnRequest{
+		TransactionalID: p.config.TransactionalID,
+		ProducerID:      int(p.producerID),
+		ProducerEpoch:   int(p.epoch),
+		Committed:       commit,
+	})
+	if err == nil && res.Error != nil {
+		err = res.Error
+	}
+	if err != nil {
+		p.ready = false
+		if errors.Is(err, kafka.ProducerFenced) || errors.Is(err, kafka.InvalidProducerEpoch) {
+			err = fmt.Errorf("%w: %w", ErrProducerFenced, err)
+		}
+		return fmt.Errorf("TxnProducer.end: %w", err)
+	}
+	return nil
+}
+
+// Name returns the module name of the TxnProducer.
+func (p *TxnProducer) Name(ctx context.Context) string {
+	return p.config.ModuleName
+}
+
+// Shutdown waits for the running transaction and closes the idle broker connections.
+func (p *TxnProducer) Shutdown(ctx context.Context) error {
+	p.txLock.Lock()
+	defer p.txLock.Unlock()
+	if transport, ok := p.client.Transport.(*kafka.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+	return nil
+}
+
+// StatusCheck returns the mode and session of the TxnProducer.
+func (p *TxnProducer) StatusCheck(ctx context.Context) (any, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return &TxnStatus{
+		Mode:          p.mode,
+		ProducerID:    p.producerID,
+		ProducerEpoch: p.epoch,
+		InTransaction: p.txn != nil,
+	}, nil
+}
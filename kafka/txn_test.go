@@ -0,0 +1,179 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/kafka"
+	"github.com/sabariramc/goserverbase/v6/kafka/kafkatest"
+	cKafka "github.com/segmentio/kafka-go"
+	"gotest.tools/assert"
+)
+
+func TestTxnProducerPlain(t *testing.T) {
+	ctx := GetCorrelationContext()
+	pr, err := kafka.NewTxnProducer(kafka.WithPoducerLogger(KafkaTestLogger), kafka.WithIdempotent(false))
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Init(ctx))
+	assert.Equal(t, pr.Mode(), kafka.ProduceModePlain)
+	called := false
+	err = pr.WithTransaction(ctx, func(ctx context.Context) error {
+		called = true
+		return pr.SendOffsets(ctx, kafka.GroupMetadata{GroupID: "cg-test", GenerationID: -1}, kafka.OffsetMap{"gobase.test.txn": {0: 10}})
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, called)
+	status, err := pr.StatusCheck(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, status, &kafka.TxnStatus{Mode: kafka.ProduceModePlain, ProducerID: -1, ProducerEpoch: -1})
+}
+
+func TestTxnProducerInitError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(GetCorrelationContext(), 2*time.Second)
+	defer cancel()
+	pr, err := kafka.NewTxnProducer(
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithProducerCredConfig(&kafka.CredConfig{Brokers: []string{"127.0.0.1:1"}}),
+		kafka.WithTransactionalID("gobase.test.txn"),
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, pr.Init(ctx) != nil)
+	assert.Assert(t, pr.Produce(ctx, "gobase.test.txn", "key", []byte("value"), nil) != nil)
+}
+
+func TestTxnProducerFallback(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("txn.out", 1), kafkatest.WithTransactions(false))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	pr, err := kafka.NewTxnProducer(
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithProducerCredConfig(broker.CredConfig()),
+		kafka.WithTransactionalID("txn-test"),
+		kafka.WithFallback(true),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Init(ctx))
+	assert.Equal(t, pr.Mode(), kafka.ProduceModePlain)
+	assert.NilError(t, pr.Produce(ctx, "txn.out", "key", []byte("plain"), nil))
+	assert.Equal(t, len(broker.Messages("txn.out")), 1)
+
+	strict, err := kafka.NewTxnProducer(
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithProducerCredConfig(broker.CredConfig()),
+		kafka.WithTransactionalID("txn-test"),
+		kafka.WithFallback(false),
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, strict.Init(ctx) != nil)
+}
+
+func TestTxnProducerSequenceWrap(t *testing.T) {
+	assert.Equal(t, kafka.NextSequence(10, 5), int32(15))
+	assert.Equal(t, kafka.NextSequence(math.MaxInt32-1, 1), int32(math.MaxInt32))
+	assert.Equal(t, kafka.NextSequence(math.MaxInt32-1, 3), int32(1))
+}
+
+func TestTxnProducerTransactional(t *testing.T) {
+	broker, err := kafkatest.New(kafkatest.WithTopic("txn.in", 1), kafkatest.WithTopic("txn.out", 1))
+	assert.NilError(t, err)
+	defer broker.Close()
+	ctx := GetCorrelationContext()
+	produce(t, broker, "txn.in", 0, 1)
+	co, err := kafka.NewPoller(
+		kafka.WithConsumerCredConfig(broker.CredConfig()),
+		kafka.WithConsumerLogger(KafkaTestLogger),
+		kafka.WithGroupID("cg-txn"),
+		kafka.WithConsumerTopic([]string{"txn.in"}),
+		kafka.WithCommitMode(kafka.CommitModeAck),
+	)
+	assert.NilError(t, err)
+	defer co.Close(ctx)
+	assert.DeepEqual(t, co.GroupMetadata(), kafka.GroupMetadata{GroupID: "cg-txn", GenerationID: -1})
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan *cKafka.Message)
+	go co.Poll(pollCtx, ch)
+	msg := receive(t, ch)
+	group := co.GroupMetadata()
+	assert.Equal(t, group.GroupID, "cg-txn")
+	assert.Assert(t, group.GenerationID > 0)
+	assert.Assert(t, group.MemberID != "")
+
+	pr, err := kafka.NewTxnProducer(
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithProducerCredConfig(broker.CredConfig()),
+		kafka.WithTransactionalID("txn-test"),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, pr.Init(ctx))
+	assert.Equal(t, pr.Mode(), kafka.ProduceModeTransactional)
+	transform := func(txCtx context.Context, group kafka.GroupMetadata, values ...string) error {
+		for _, value := range values {
+			err := pr.Produce(txCtx, "txn.out", "key", []byte(value), nil)
+			if err != nil {
+				return err
+			}
+		}
+		return pr.SendOffsets(txCtx, group, co.TxnOffsets(msg))
+	}
+
+	err = pr.WithTransaction(ctx, func(txCtx context.Context) error {
+		err := transform(txCtx, group, "committed-1", "committed-2")
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, len(broker.Messages("txn.out")), 0, "messages of a running transaction are not visible")
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(broker.Messages("txn.out")), 2)
+	assert.Equal(t, broker.CommittedOffset("cg-txn", "txn.in", 0), int64(1))
+
+	err = pr.WithTransaction(ctx, func(txCtx context.Context) error {
+		err := transform(txCtx, group, "aborted")
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("handler failed")
+	})
+	assert.Error(t, err, "handler failed")
+
+	stale := kafka.GroupMetadata{GroupID: "cg-txn", GenerationID: group.GenerationID, MemberID: "fenced-member"}
+	err = pr.WithTransaction(ctx, func(txCtx context.Context) error {
+		return transform(txCtx, stale, "stale")
+	})
+	assert.Assert(t, errors.Is(err, cKafka.UnknownMemberId), err)
+	assert.Equal(t, len(broker.Messages("txn.out")), 2, "aborted messages are dropped")
+
+	assert.NilError(t, pr.Produce(ctx, "txn.out", "key", []byte("single"), nil))
+	assert.Equal(t, len(broker.Messages("txn.out")), 3)
+
+	next, err := kafka.NewTxnProducer(
+		kafka.WithPoducerLogger(KafkaTestLogger),
+		kafka.WithProducerCredConfig(broker.CredConfig()),
+		kafka.WithTransactionalID("txn-test"),
+	)
+	assert.NilError(t, err)
+	err = pr.WithTransaction(ctx, func(txCtx context.Context) error {
+		err := pr.Produce(txCtx, "txn.out", "key", []byte("fenced"), nil)
+		if err != nil {
+			return err
+		}
+		return next.Init(ctx)
+	})
+	assert.Assert(t, errors.Is(err, kafka.ErrProducerFenced), err)
+	assert.NilError(t, next.Produce(ctx, "txn.out", "key", []byte("after-fence"), nil))
+	msgs := broker.Messages("txn.out")
+	assert.Equal(t, len(msgs), 4)
+	for i, value := range []string{"committed-1", "committed-2", "single", "after-fence"} {
+		assert.Equal(t, string(msgs[i].Value), value)
+	}
+	status, err := next.StatusCheck(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, status.(*kafka.TxnStatus).ProducerEpoch, int16(1))
+}
@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sabariramc/goserverbase/v6/kafka/serde"
+)
+
+// ProduceTyped encodes the value with the codec and writes it to the topic with the key, headers and the content type header.
+// The headers of the caller are not modified.
+func ProduceTyped[T any](ctx context.Context, p *Producer, codec *serde.Codec[T], topic, key string, value T, headers map[string]string) error {
+	data, contentType, err := codec.Encode(ctx, value)
+	if err != nil {
+		p.log.Error(ctx, "Failed to encode message", err)
+		return fmt.Errorf("kafka.ProduceTyped: error encoding message: %w", err)
+	}
+	msgHeaders := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		msgHeaders[k] = v
+	}
+	msgHeaders[serde.HeaderContentType] = contentType
+	return p.Produce(ctx, topic, key, data, msgHeaders)
+}
+
+// DecodeMessage decodes the value of the message with the codec, using the schema identified by the content type header.
+func DecodeMessage[T any](ctx context.Context, codec *serde.Codec[T], msg *Message) (T, error) {
+	v, err := codec.Decode(ctx, msg.Value, msg.GetHeaders()[serde.HeaderContentType])
+	if err != nil {
+		return v, fmt.Errorf("kafka.DecodeMessage: %w", err)
+	}
+	return v, nil
+}
@@ -3,8 +3,11 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
 	"github.com/sabariramc/goserverbase/v6/log"
@@ -17,17 +20,40 @@ type ProduceTracer interface {
 	span.SpanOp
 }
 
-// Writer extends [kafka.Writer] with batch writing, tracing, and StatusCheck hook.
+// Writer extends [kafka.Writer] with batch writing, tracing, bounded retry of failed messages, delivery reporting and StatusCheck hook.
+//
+// A message that fails is written again up to MaxRetries times, then it is reported as failed to its [Delivery],
+// the DeliveryCallback and the FailureHook.
 type Writer struct {
 	*kafka.Writer
-	messageList []kafka.Message // Storage for batch messages
-	produceLock sync.Mutex
-	bufferLen   int // Max size of the batch
-	log         log.Log
-	msgCh       chan kafka.Message
-	wg          sync.WaitGroup
-	idx         int
-	tr          ProduceTracer
+	MaxRetries   int              // Number of times a failed message is written again
+	RetryBackoff time.Duration    // Wait before a retry, multiplied by the attempt number
+	OnDelivery   DeliveryCallback // Called with the result of every message
+	OnFailure    FailureHook      // Called with the messages that failed after the retries
+	messageList  []kafka.Message  // Storage for batch messages
+	produceLock  sync.Mutex       // Guards the batch buffer
+	flushLock    sync.Mutex       // Serializes the flushes so that batches are written in order
+	bufferLen    int              // Max size of the batch
+	log          log.Log
+	ctx          context.Context
+	msgCh        chan kafka.Message
+	wg           sync.WaitGroup
+	idx          int
+	tr           ProduceTracer
+	delivered    atomic.Uint64
+	failed       atomic.Uint64
+	retried      atomic.Uint64
+	buffered     atomic.Int64
+	retryLock    sync.Mutex
+	closing      bool
+	retryWG      sync.WaitGroup
+}
+
+// WriterStatus is the status of the Writer reported by StatusCheck.
+type WriterStatus struct {
+	DeliveryMetrics
+	Buffered int               // Messages waiting for the next flush in batch mode
+	Stats    kafka.WriterStats // Stats of the underlying writer
 }
 
 // ErrWriterBufferFull is an error indicating that the writer buffer is full.
@@ -38,14 +64,25 @@ func NewWriter(ctx context.Context, w *kafka.Writer, bufferLen int, log log.Log,
 	if w.Async {
 		log.Notice(ctx, "Kafka writer is set to async mode", nil)
 	}
-	return &Writer{
+	writer := &Writer{
 		Writer:      w,
 		messageList: make([]kafka.Message, bufferLen),
 		bufferLen:   bufferLen,
 		log:         log.NewResourceLogger("KafkaWriter"),
+		ctx:         ctx,
 		idx:         0,
 		tr:          tr,
 	}
+	if w.Async {
+		completion := w.Completion
+		w.Completion = func(messages []kafka.Message, err error) {
+			writer.complete(messages, err)
+			if completion != nil {
+				completion(messages, err)
+			}
+		}
+	}
+	return writer
 }
 
 // WriteMessage writes the message to the broker in async mode or batch mode.
@@ -59,6 +96,7 @@ func (w *Writer) WriteMessage(ctx context.Context, msg *kafka.Message) error {
 		defer crSpan.Finish()
 		w.tr.KafkaInject(ctx, msg)
 	}
+	getEnvelope(ctx, msg)
 	if w.Async {
 		return w.WriteMessages(ctx, *msg)
 	}
@@ -69,14 +107,18 @@ func (w *Writer) WriteMessage(ctx context.Context, msg *kafka.Message) error {
 	}
 	w.messageList[w.idx] = *msg
 	w.idx++
+	w.buffered.Store(int64(w.idx))
 	return nil
 }
 
-// Flush writes the message batch to the broker.
+// Flush writes the message batch to the broker, retrying the failed messages up to MaxRetries times.
+// The messages that still fail are passed to the FailureHook; the error is returned when there is no hook or the hook fails.
+// The batch is taken out of the buffer before it is written, so that WriteMessage is not blocked by the retries.
 func (w *Writer) Flush(ctx context.Context) error {
-	w.produceLock.Lock()
-	defer w.produceLock.Unlock()
-	if w.idx == 0 {
+	w.flushLock.Lock()
+	defer w.flushLock.Unlock()
+	batch := w.takeBuffered()
+	if len(batch) == 0 {
 		return nil
 	}
 	if w.tr != nil {
@@ -84,9 +126,29 @@ func (w *Writer) Flush(ctx context.Context) error {
 		ctx, crSpan = w.tr.NewSpanFromContext(ctx, "kafka.producer.flush", span.SpanKindProducer, "")
 		defer crSpan.Finish()
 	}
-	w.log.Notice(ctx, "Flushing messages", w.idx)
-	err := w.WriteMessages(context.Background(), w.messageList[:w.idx]...)
-	w.idx = 0
+	w.log.Notice(ctx, "Flushing messages", len(batch))
+	pending := batch
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = w.WriteMessages(ctx, pending...)
+		var failed []kafka.Message
+		pending, failed = splitFailed(pending, err)
+		w.succeeded(pending)
+		pending = failed
+		if len(pending) == 0 {
+			return nil
+		}
+		if attempt > w.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		w.retried.Add(uint64(len(pending)))
+		w.log.Warning(ctx, fmt.Sprintf("retrying %v failed messages, attempt %v", len(pending), attempt), err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.RetryBackoff * time.Duration(attempt)):
+		}
+	}
+	err = w.fail(ctx, pending, err)
 	if err != nil {
 		w.log.Error(ctx, "Failed to flush message", err)
 		return fmt.Errorf("Writer.Flush: error in flushing message: %w", err)
@@ -94,12 +156,144 @@ func (w *Writer) Flush(ctx context.Context) error {
 	return nil
 }
 
+// takeBuffered takes the messages out of the batch buffer in the order they were written.
+func (w *Writer) takeBuffered() []kafka.Message {
+	w.produceLock.Lock()
+	defer w.produceLock.Unlock()
+	if w.idx == 0 {
+		return nil
+	}
+	batch := make([]kafka.Message, w.idx)
+	copy(batch, w.messageList[:w.idx])
+	clear(w.messageList[:w.idx])
+	w.idx = 0
+	w.buffered.Store(0)
+	return batch
+}
+
+// splitFailed splits the messages of a write into delivered and failed using the per message errors of [kafka.WriteErrors].
+func splitFailed(msgs []kafka.Message, err error) (delivered, failed []kafka.Message) {
+	if err == nil {
+		return msgs, nil
+	}
+	var writeErrs kafka.WriteErrors
+	if !errors.As(err, &writeErrs) || len(writeErrs) != len(msgs) {
+		return nil, msgs
+	}
+	for i, msgErr := range writeErrs {
+		if msgErr == nil {
+			delivered = append(delivered, msgs[i])
+		} else {
+			failed = append(failed, msgs[i])
+		}
+	}
+	return delivered, failed
+}
+
+// complete handles the delivery report of the async writer, scheduling the retry of failed messages that have attempts left.
+func (w *Writer) complete(msgs []kafka.Message, err error) {
+	if err == nil {
+		w.succeeded(msgs)
+		return
+	}
+	var retry, failed []kafka.Message
+	w.retryLock.Lock()
+	for _, msg := range msgs {
+		env, _ := msg.WriterData.(*envelope)
+		if w.closing || env == nil || env.attempts >= w.MaxRetries {
+			failed = append(failed, msg)
+			continue
+		}
+		env.attempts++
+		if w.Writer.Topic != "" {
+			msg.Topic = ""
+		}
+		retry = append(retry, msg)
+	}
+	if len(retry) > 0 {
+		w.retryWG.Add(1)
+	}
+	w.retryLock.Unlock()
+	if len(failed) > 0 {
+		w.fail(w.ctx, failed, err)
+	}
+	if len(retry) == 0 {
+		return
+	}
+	w.retried.Add(uint64(len(retry)))
+	go func() {
+		defer w.retryWG.Done()
+		attempt := retry[0].WriterData.(*envelope).attempts
+		w.log.Warning(w.ctx, fmt.Sprintf("retrying %v failed messages, attempt %v", len(retry), attempt), err)
+		time.Sleep(w.RetryBackoff * time.Duration(attempt))
+		writeErr := w.WriteMessages(w.ctx, retry...)
+		if writeErr != nil {
+			w.fail(w.ctx, retry, writeErr)
+		}
+	}()
+}
+
+// succeeded reports the messages as delivered.
+func (w *Writer) succeeded(msgs []kafka.Message) {
+	w.delivered.Add(uint64(len(msgs)))
+	for i := range msgs {
+		w.report(&msgs[i], nil)
+	}
+}
+
+// fail reports the messages as failed and passes them to the FailureHook, returns nil if the hook handled them.
+func (w *Writer) fail(ctx context.Context, msgs []kafka.Message, err error) error {
+	w.failed.Add(uint64(len(msgs)))
+	for i := range msgs {
+		w.report(&msgs[i], err)
+	}
+	if w.OnFailure == nil {
+		w.log.Error(ctx, fmt.Sprintf("%v messages failed after retries", len(msgs)), err)
+		return err
+	}
+	hookErr := w.OnFailure(ctx, msgs, err)
+	if hookErr != nil {
+		w.log.Error(ctx, "error in producer failure hook", hookErr)
+		return fmt.Errorf("%w, failure hook error: %w", err, hookErr)
+	}
+	w.log.Warning(ctx, fmt.Sprintf("%v failed messages passed to the failure hook", len(msgs)), err)
+	return nil
+}
+
+// report completes the delivery of the message and calls the DeliveryCallback.
+func (w *Writer) report(msg *kafka.Message, err error) {
+	env, _ := msg.WriterData.(*envelope)
+	ctx := w.ctx
+	if env != nil {
+		ctx = env.ctx
+		if env.delivery != nil {
+			env.delivery.complete(err)
+		}
+	}
+	if w.OnDelivery != nil {
+		w.OnDelivery(ctx, msg, err)
+	}
+}
+
+// DeliveryMetrics returns the delivery counts since the Writer was created.
+func (w *Writer) DeliveryMetrics() DeliveryMetrics {
+	return DeliveryMetrics{
+		Delivered: w.delivered.Load(),
+		Failed:    w.failed.Load(),
+		Retried:   w.retried.Load(),
+	}
+}
+
 // Close closes the Writer, ensuring all messages are flushed.
 func (w *Writer) Close(ctx context.Context) error {
 	if w.msgCh != nil {
 		close(w.msgCh)
 	}
 	w.wg.Wait()
+	w.retryLock.Lock()
+	w.closing = true
+	w.retryLock.Unlock()
+	w.retryWG.Wait()
 	err := w.Writer.Close()
 	if err != nil {
 		w.log.Error(ctx, "Error in closing writer", err)
@@ -110,5 +304,14 @@ func (w *Writer) Close(ctx context.Context) error {
 
 // StatusCheck returns the current status of the Writer.
 func (w *Writer) StatusCheck(ctx context.Context) (any, error) {
-	return w.Stats(), nil
+	return w.status(), nil
+}
+
+// status returns the delivery metrics, buffered messages and stats of the Writer.
+func (w *Writer) status() *WriterStatus {
+	return &WriterStatus{
+		DeliveryMetrics: w.DeliveryMetrics(),
+		Buffered:        int(w.buffered.Load()),
+		Stats:           w.Stats(),
+	}
 }
@@ -2,6 +2,7 @@ package log
 
 import "context"
 
+// AuditLogWriter writes audit messages, see the writers of [github.com/sabariramc/goserverbase/v6/log/audit].
 type AuditLogWriter interface {
 	WriteMessage(context.Context, interface{}) error
 }
@@ -0,0 +1,107 @@
+package audit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/log/audit"
+	"gotest.tools/assert"
+)
+
+func writeRecords(t *testing.T, path string, n int) {
+	ctx := context.Background()
+	w := audit.NewFileWriter(audit.WithFilePath(path), audit.WithFileSync(false))
+	for i := 0; i < n; i++ {
+		assert.NilError(t, w.WriteMessage(ctx, audit.NewRecord(ctx, "UPDATE", "account", audit.OutcomeSuccess, map[string]any{"index": i})))
+	}
+	assert.NilError(t, w.Close())
+}
+
+func TestAuditFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeRecords(t, path, 3)
+	writeRecords(t, path, 2)
+	report, err := audit.VerifyFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, report.Records, 5)
+	assert.Assert(t, report.Valid(), report.Issues)
+	info, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+}
+
+func TestAuditVerifyTamper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeRecords(t, path, 4)
+	blob, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(blob)), "\n")
+
+	tampered := append([]string{}, lines...)
+	tampered[1] = strings.Replace(tampered[1], `"UPDATE"`, `"DELETE"`, 1)
+	report, err := audit.Verify(strings.NewReader(strings.Join(tampered, "\n")))
+	assert.NilError(t, err)
+	assert.Equal(t, len(report.Issues), 1)
+	assert.Equal(t, report.Issues[0].Line, 2)
+	assert.Equal(t, report.Issues[0].Problem, "hash mismatch, record is tampered")
+
+	removed := append(append([]string{}, lines[:2]...), lines[3:]...)
+	report, err = audit.Verify(strings.NewReader(strings.Join(removed, "\n")))
+	assert.NilError(t, err)
+	assert.Equal(t, report.Records, 3)
+	assert.Equal(t, len(report.Issues), 1)
+	assert.Equal(t, report.Issues[0].Sequence, uint64(4))
+	assert.Equal(t, report.Issues[0].Problem, "sequence gap, expected 3")
+
+	report, err = audit.Verify(strings.NewReader(strings.Join(lines[1:], "\n")))
+	assert.NilError(t, err)
+	assert.Equal(t, len(report.Issues), 1)
+	assert.Equal(t, report.Issues[0].Problem, "chain does not start at sequence 1")
+}
+
+func TestAuditFileWriterTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeRecords(t, path, 2)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.NilError(t, err)
+	_, err = file.WriteString(`{"sequence":3,"timestamp":"2024-`)
+	assert.NilError(t, err)
+	assert.NilError(t, file.Close())
+
+	writeRecords(t, path, 1)
+	report, err := audit.VerifyFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, report.Records, 4)
+	assert.Equal(t, len(report.Issues), 1)
+	assert.Equal(t, report.Issues[0].Line, 3)
+	assert.Equal(t, report.Issues[0].Sequence, uint64(3))
+	assert.Assert(t, strings.HasPrefix(report.Issues[0].Problem, "gap, partial record truncated"), report.Issues[0].Problem)
+	blob, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(blob), `"timestamp":"2024-{`))
+	assert.Assert(t, strings.Contains(string(blob), `"truncatedBytes":32`))
+}
+
+func TestAuditSharedFileWriter(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	first := audit.SharedFileWriter(audit.WithFilePath(path), audit.WithFileSync(false))
+	second := audit.SharedFileWriter(audit.WithFilePath(filepath.Join(dir, ".", "audit.log")))
+	assert.Assert(t, first == second)
+	for i := 0; i < 4; i++ {
+		w := first
+		if i%2 == 1 {
+			w = second
+		}
+		assert.NilError(t, w.WriteMessage(ctx, audit.NewRecord(ctx, "UPDATE", "account", audit.OutcomeSuccess, map[string]any{"index": i})))
+	}
+	assert.NilError(t, first.Close())
+	report, err := audit.VerifyFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, report.Records, 4)
+	assert.Assert(t, report.Valid(), report.Issues)
+}
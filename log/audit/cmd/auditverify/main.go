@@ -0,0 +1,41 @@
+// Command auditverify verifies the hash chain of audit log files written by [audit.FileWriter].
+//
+//	auditverify audit.log [audit-2.log ...]
+//
+// It prints the issues found and exits with status 1 when a file is not intact and 2 when a file cannot be read.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sabariramc/goserverbase/v6/log/audit"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: auditverify <audit log file>...")
+		os.Exit(2)
+	}
+	status := 0
+	for _, path := range os.Args[1:] {
+		report, err := audit.VerifyFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", path, err)
+			status = 2
+			continue
+		}
+		if report.Valid() {
+			fmt.Printf("%v: %v records, intact\n", path, report.Records)
+			continue
+		}
+		fmt.Printf("%v: %v records, %v issues\n", path, report.Records, len(report.Issues))
+		for _, issue := range report.Issues {
+			fmt.Printf("  %v\n", issue)
+		}
+		if status == 0 {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
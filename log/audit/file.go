@@ -0,0 +1,211 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// FileConfig represents the configuration options for the [FileWriter].
+type FileConfig struct {
+	Path string // Path is the path of the audit log file.
+	Sync bool   // Sync syncs the file after every record.
+}
+
+// GetDefaultFileConfig returns the new FileConfig with values from environment variables or default values.
+/*
+	Environment Variables
+	- LOG__AUDIT__FILE__PATH: Sets [Path], default audit.log
+	- LOG__AUDIT__FILE__SYNC: Sets [Sync], default true
+*/
+func GetDefaultFileConfig() FileConfig {
+	return FileConfig{
+		Path: utils.GetEnv(env.LogAuditFilePath, "audit.log"),
+		Sync: utils.GetEnvBool(env.LogAuditFileSync, true),
+	}
+}
+
+// FileOption represents an option function for configuring the [FileWriter].
+type FileOption func(*FileConfig)
+
+// WithFilePath sets the path of the audit log file.
+func WithFilePath(path string) FileOption {
+	return func(c *FileConfig) {
+		c.Path = path
+	}
+}
+
+// WithFileSync sets whether the file is synced after every record.
+func WithFileSync(sync bool) FileOption {
+	return func(c *FileConfig) {
+		c.Sync = sync
+	}
+}
+
+// FileWriter appends audit records to a file as JSON lines with hash chaining, implements [log.AuditLogWriter].
+//
+// Every record carries the next sequence number and the hash of the previous record, and is sealed with its own hash,
+// so that a modified, removed or reordered record breaks the chain, see [Verify].
+// The file is opened on the first write and the chain continues from the last record of an existing file,
+// a partial record left by a crash is replaced with a [ActionGap] record.
+type FileWriter struct {
+	c        FileConfig
+	lock     sync.Mutex
+	file     *os.File
+	sequence uint64
+	prevHash string
+}
+
+// NewFileWriter creates a new FileWriter.
+func NewFileWriter(options ...FileOption) *FileWriter {
+	config := GetDefaultFileConfig()
+	for _, opt := range options {
+		opt(&config)
+	}
+	return &FileWriter{c: config}
+}
+
+var (
+	sharedLock    sync.Mutex
+	sharedWriters = map[string]*FileWriter{}
+)
+
+// SharedFileWriter returns the process-wide FileWriter of the path, creating it with the options on first use.
+//
+// Writers created with [NewFileWriter] for the same file keep their own sequence and hash and break the chain of each other,
+// every logger of the process that writes to a file should use the shared writer of the path.
+func SharedFileWriter(options ...FileOption) *FileWriter {
+	w := NewFileWriter(options...)
+	key, err := filepath.Abs(w.c.Path)
+	if err != nil {
+		key = filepath.Clean(w.c.Path)
+	}
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+	if shared, ok := sharedWriters[key]; ok {
+		return shared
+	}
+	sharedWriters[key] = w
+	return w
+}
+
+// WriteMessage appends the message as a chained record, see [FromMessage].
+func (f *FileWriter) WriteMessage(ctx context.Context, msg interface{}) error {
+	rec := FromMessage(ctx, msg)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return fmt.Errorf("FileWriter.WriteMessage: %w", err)
+		}
+	}
+	if err := f.append(rec); err != nil {
+		return fmt.Errorf("FileWriter.WriteMessage: %w", err)
+	}
+	return nil
+}
+
+// append chains the record to the last record and writes it to the file.
+func (f *FileWriter) append(rec *Record) error {
+	rec.Sequence = f.sequence + 1
+	rec.PrevHash = f.prevHash
+	rec.Hash = rec.ComputeHash()
+	blob, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("error marshalling record: %w", err)
+	}
+	if _, err := f.file.Write(append(blob, '\n')); err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+	if f.c.Sync {
+		if err := f.file.Sync(); err != nil {
+			return fmt.Errorf("error syncing file: %w", err)
+		}
+	}
+	f.sequence, f.prevHash = rec.Sequence, rec.Hash
+	return nil
+}
+
+// open opens the file for appending and reads the sequence and hash of its last record.
+//
+// A trailing partial line left by a crash during a write is truncated and a [ActionGap] record is chained in its place,
+// so that the chain continues and [Verify] reports the lost record.
+func (f *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.c.Path), 0o755); err != nil {
+		return fmt.Errorf("error creating audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.c.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening audit log file: %w", err)
+	}
+	reader := bufio.NewReader(file)
+	var last Record
+	var size, torn int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			torn = int64(len(line))
+			break
+		}
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("error reading audit log file: %w", err)
+		}
+		if len(line) > maxRecordSize {
+			file.Close()
+			return fmt.Errorf("error reading audit log file: %w", bufio.ErrTooLong)
+		}
+		if err := json.Unmarshal(line, &last); err != nil {
+			file.Close()
+			return fmt.Errorf("error reading last audit record: %w", err)
+		}
+		size += int64(len(line))
+	}
+	f.file, f.sequence, f.prevHash = file, last.Sequence, last.Hash
+	if torn == 0 {
+		return nil
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		f.file = nil
+		return fmt.Errorf("error truncating partial audit record: %w", err)
+	}
+	gap := &Record{
+		Timestamp: time.Now().UTC().Round(0),
+		Action:    ActionGap,
+		Resource:  f.c.Path,
+		Outcome:   OutcomeUnknown,
+	}
+	gap.Details, _ = json.Marshal(map[string]any{"truncatedBytes": torn, "offset": size})
+	if err := f.append(gap); err != nil {
+		file.Close()
+		f.file = nil
+		return fmt.Errorf("error recording audit gap: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (f *FileWriter) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("FileWriter.Close: %w", err)
+	}
+	return nil
+}
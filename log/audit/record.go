@@ -0,0 +1,99 @@
+// Package audit implements the audit record schema and tamper-evident audit log writers for [log.AuditLogWriter].
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+)
+
+// Outcome is the result of an audited action.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "SUCCESS" // OutcomeSuccess is an action that completed.
+	OutcomeFailure Outcome = "FAILURE" // OutcomeFailure is an action that was rejected or failed.
+	OutcomeUnknown Outcome = "UNKNOWN" // OutcomeUnknown is an action audited without an outcome.
+)
+
+// ActionGap is the action of the record chained by [FileWriter] in place of a partial record left by a crash,
+// its details hold the number of truncated bytes and their offset.
+const ActionGap = "AUDIT_GAP"
+
+// Actor identifies who performed an audited action, it is taken from [correlation.UserIdentifier].
+type Actor struct {
+	UserID    string `json:"userId,omitempty" bson:"userId,omitempty"`
+	AppUserID string `json:"appUserId,omitempty" bson:"appUserId,omitempty"`
+	EntityID  string `json:"entityId,omitempty" bson:"entityId,omitempty"`
+}
+
+// Record is an audit record.
+//
+// Sequence, PrevHash and Hash are set by the writers that chain the records, see [FileWriter].
+type Record struct {
+	Sequence      uint64          `json:"sequence,omitempty"`
+	Timestamp     time.Time       `json:"timestamp"`
+	CorrelationID string          `json:"correlationId"`
+	Actor         Actor           `json:"actor"`
+	Action        string          `json:"action"`
+	Resource      string          `json:"resource"`
+	Outcome       Outcome         `json:"outcome"`
+	Details       json.RawMessage `json:"details,omitempty"`
+	PrevHash      string          `json:"prevHash,omitempty"`
+	Hash          string          `json:"hash,omitempty"`
+}
+
+// NewRecord creates a new Record with the actor and correlation ID of ctx, details is encoded as JSON.
+func NewRecord(ctx context.Context, action, resource string, outcome Outcome, details any) *Record {
+	cr := correlation.ExtractCorrelationParam(ctx)
+	id := correlation.ExtractUserIdentifier(ctx)
+	rec := &Record{
+		Timestamp:     time.Now().UTC().Round(0),
+		CorrelationID: cr.CorrelationID,
+		Actor: Actor{
+			UserID:    deref(id.UserID),
+			AppUserID: deref(id.AppUserID),
+			EntityID:  deref(id.EntityID),
+		},
+		Action:   action,
+		Resource: resource,
+		Outcome:  outcome,
+	}
+	if details != nil {
+		rec.Details, _ = json.Marshal(details)
+	}
+	return rec
+}
+
+// FromMessage returns the message as a Record, a message of any other type is the details of a Record with [OutcomeUnknown].
+func FromMessage(ctx context.Context, msg interface{}) *Record {
+	switch v := msg.(type) {
+	case *Record:
+		rec := *v
+		return &rec
+	case Record:
+		return &v
+	}
+	return NewRecord(ctx, "", "", OutcomeUnknown, msg)
+}
+
+// ComputeHash returns the hex encoded SHA-256 of the JSON encoding of the record without its hash.
+func (r *Record) ComputeHash() string {
+	rec := *r
+	rec.Hash = ""
+	blob, _ := json.Marshal(&rec)
+	sum := sha256.Sum256(blob)
+	return hex.EncodeToString(sum[:])
+}
+
+// deref returns the value of s, empty for nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxRecordSize is the maximum size in bytes of a record line.
+const maxRecordSize = 16 * 1024 * 1024
+
+// Issue is a problem found in an audit log.
+type Issue struct {
+	Line     int    // Line is the line number of the record, starting at 1.
+	Sequence uint64 // Sequence is the sequence number of the record, 0 when the line cannot be parsed.
+	Problem  string // Problem describes the problem.
+}
+
+// String returns the issue in the form line <line> (sequence <sequence>): <problem>.
+func (i Issue) String() string {
+	return fmt.Sprintf("line %v (sequence %v): %v", i.Line, i.Sequence, i.Problem)
+}
+
+// Report is the result of the verification of an audit log.
+type Report struct {
+	Records int     // Records is the number of records read.
+	Issues  []Issue // Issues are the problems found, empty for an intact audit log.
+}
+
+// Valid reports whether the audit log is intact.
+func (r *Report) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Verify reads the chained records written by [FileWriter] and reports the records that were modified, removed or reordered.
+//
+// A record whose hash does not match its content is reported as tampered, a record whose previous hash or sequence
+// does not follow the record before it is reported as a gap, as is a [ActionGap] record chained in place of a partial record.
+func Verify(r io.Reader) (*Report, error) {
+	report := &Report{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	var prev *Record
+	line := 0
+	for scanner.Scan() {
+		line++
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			report.Issues = append(report.Issues, Issue{Line: line, Problem: "unreadable record: " + err.Error()})
+			prev = nil
+			continue
+		}
+		report.Records++
+		if rec.ComputeHash() != rec.Hash {
+			report.Issues = append(report.Issues, Issue{Line: line, Sequence: rec.Sequence, Problem: "hash mismatch, record is tampered"})
+		}
+		if rec.Action == ActionGap {
+			report.Issues = append(report.Issues, Issue{Line: line, Sequence: rec.Sequence, Problem: "gap, partial record truncated: " + string(rec.Details)})
+		}
+		switch {
+		case prev == nil && line == 1 && (rec.Sequence != 1 || rec.PrevHash != ""):
+			report.Issues = append(report.Issues, Issue{Line: line, Sequence: rec.Sequence, Problem: "chain does not start at sequence 1"})
+		case prev != nil && rec.Sequence != prev.Sequence+1:
+			report.Issues = append(report.Issues, Issue{Line: line, Sequence: rec.Sequence, Problem: fmt.Sprintf("sequence gap, expected %v", prev.Sequence+1)})
+		case prev != nil && rec.PrevHash != prev.Hash:
+			report.Issues = append(report.Issues, Issue{Line: line, Sequence: rec.Sequence, Problem: "previous hash mismatch, chain is broken"})
+		}
+		prev = &rec
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("audit.Verify: %w", err)
+	}
+	return report, nil
+}
+
+// VerifyFile verifies the audit log file at path, see [Verify].
+func VerifyFile(path string) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit.VerifyFile: %w", err)
+	}
+	defer file.Close()
+	return Verify(file)
+}
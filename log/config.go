@@ -1,7 +1,11 @@
 package log
 
 import (
+	"time"
+
 	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
+	"github.com/sabariramc/goserverbase/v6/log/audit"
 	"github.com/sabariramc/goserverbase/v6/log/logwriter"
 	"github.com/sabariramc/goserverbase/v6/log/message"
 	"github.com/sabariramc/goserverbase/v6/utils"
@@ -15,6 +19,14 @@ type Config struct {
 	Mux         Mux              // Mux represents the multiplexer for handling log messages.
 	FileTrace   bool             // FileTrace indicates whether file tracing is enabled.
 	Audit       AuditLogWriter   // Audit represents the audit log writer.
+	ModuleLevel string           // ModuleLevel represents the per module level rules, parsed with [ParseLevelRules].
+	Resolver    *LevelResolver   // Resolver resolves the level of the modules, created from [LogLevel] and [ModuleLevel] when nil.
+	Override    bool             // Override allows the LogLevel of the correlation param to override the level of a request.
+	Sampling    string           // Sampling represents the sampling rules, parsed with [ParseSamplingRules], empty disables sampling.
+	SampleEvery int              // SampleEvery is the interval in milliseconds of the sampling windows.
+	Sampler     *Sampler         // Sampler samples the messages, created from [Sampling] and [SampleEvery] when nil.
+	Redactor    *Redactor        // Redactor redacts sensitive values from the messages before they reach [Mux], nil disables redaction.
+	SpanOp      span.SpanOp      // SpanOp reads the span of the context, the trace and span IDs of the span are added to the messages.
 }
 
 // GetDefaultConfig returns the new Config with values from environment variables or default values.
@@ -30,10 +42,19 @@ type Config struct {
 		- ERROR
 		- CRITICAL
 		- EMERGENCY
+	- LOG__MODULE_LEVEL: Sets [ModuleLevel], e.g. MongoClient:*=DEBUG,KafkaReader=DEBUG
+	- LOG__LEVEL_OVERRIDE: Sets [Override]
+	- LOG__SAMPLING__RULES: Sets [Sampling], e.g. *@DEBUG=10/100,KafkaReader@*=100/0
+	- LOG__SAMPLING__INTERVAL: Sets [SampleEvery], default 1000
+	- LOG__REDACT__ENABLED: Enables [Redactor] with [GetDefaultRedactConfig], default false
+	- LOG__AUDIT__WRITER: Sets [Audit], following are the valid options
+		- FILE, the [audit.SharedFileWriter] configured with [audit.GetDefaultFileConfig]
 	- LOG__FILE_TRACE: Sets [FileTrace]
 	- LOG__WRITER: Sets the log writer for [Mux], supports following values by default, can be extended
 		- CONSOLE
 		- JSONL
+		- FILE, configured with [logwriter.GetDefaultFileConfig]
+		- LOGFMT, PRETTY, JSON, ECS, GCP, DATADOG, the formatters of [logwriter.GetFormatter] written to the console
 
 For custom [LOG__WRITER] use [logwriter.AddLogWriter] before the package initialization
 */
@@ -43,13 +64,26 @@ func GetDefaultConfig() Config {
 	if w == nil {
 		w = logwriter.NewConsoleWriter()
 	}
+	var redactor *Redactor
+	if utils.GetEnvBool(env.LogRedactEnabled, false) {
+		redactor = NewRedactor()
+	}
+	var auditWriter AuditLogWriter
+	if utils.GetEnv(env.LogAuditWriter, "") == "FILE" {
+		auditWriter = audit.SharedFileWriter()
+	}
 	return Config{
+		Redactor:    redactor,
 		ServiceName: utils.GetEnv(env.ServiceName, "default"),
 		ModuleName:  "log",
 		LogLevel:    message.GetLogLevelWithName(utils.GetEnv(env.LogLevel, "ERROR")),
 		FileTrace:   utils.GetEnvBool(env.LogFileTrace, false),
+		ModuleLevel: utils.GetEnv(env.LogModuleLevel, ""),
+		Override:    utils.GetEnvBool(env.LogLevelOverride, false),
+		Sampling:    utils.GetEnv(env.LogSamplingRules, ""),
+		SampleEvery: utils.GetEnvInt(env.LogSamplingInterval, 1000),
 		Mux:         NewDefaultLogMux(w),
-		Audit:       nil,
+		Audit:       auditWriter,
 	}
 }
 
@@ -77,6 +111,56 @@ func WithLogLevelName(logLevelName string) Option {
 	}
 }
 
+// WithModuleLevel sets the per module level rules, e.g. MongoClient:*=DEBUG,KafkaReader=DEBUG.
+func WithModuleLevel(rules string) Option {
+	return func(c *Config) {
+		c.ModuleLevel = rules
+	}
+}
+
+// WithLevelResolver sets the resolver of the level of the modules, for loggers that share the level rules.
+func WithLevelResolver(resolver *LevelResolver) Option {
+	return func(c *Config) {
+		c.Resolver = resolver
+	}
+}
+
+// WithLevelOverride sets whether the LogLevel of the correlation param overrides the level of a request.
+func WithLevelOverride(override bool) Option {
+	return func(c *Config) {
+		c.Override = override
+	}
+}
+
+// WithSampling sets the sampling rules and the interval of the sampling windows, e.g. *@DEBUG=10/100,KafkaReader@*=100/0.
+func WithSampling(rules string, interval time.Duration) Option {
+	return func(c *Config) {
+		c.Sampling = rules
+		c.SampleEvery = int(interval.Milliseconds())
+	}
+}
+
+// WithSampler sets the sampler of the messages, for loggers that share the sampling state.
+func WithSampler(sampler *Sampler) Option {
+	return func(c *Config) {
+		c.Sampler = sampler
+	}
+}
+
+// WithRedactor sets the redactor of sensitive values, nil disables redaction.
+func WithRedactor(redactor *Redactor) Option {
+	return func(c *Config) {
+		c.Redactor = redactor
+	}
+}
+
+// WithSpanOp sets the span operations used to add the trace and span IDs of the context to the messages.
+func WithSpanOp(op span.SpanOp) Option {
+	return func(c *Config) {
+		c.SpanOp = op
+	}
+}
+
 // WithMux sets the Mux for the logger.
 func WithMux(mux Mux) Option {
 	return func(c *Config) {
@@ -97,3 +181,113 @@ func WithAudit(audit AuditLogWriter) Option {
 		c.Audit = audit
 	}
 }
+
+// ChanneledMuxConfig represents the configuration options for the [ChanneledLogMux].
+type ChanneledMuxConfig struct {
+	BufferSize int                 // BufferSize is the number of messages buffered for each writer.
+	BatchSize  int                 // BatchSize is the maximum number of messages written to a writer at once.
+	DropPolicy DropPolicy          // DropPolicy is the behaviour of Print when the buffer of a writer is full.
+	Fallback   logwriter.LogWriter // Fallback receives the messages printed after Close in place of the closed writers.
+}
+
+// GetDefaultChanneledMuxConfig returns the new ChanneledMuxConfig with values from environment variables or default values.
+/*
+	Environment Variables
+	- LOG__MUX__BUFFER_SIZE: Sets [BufferSize], default 1000
+	- LOG__MUX__BATCH_SIZE: Sets [BatchSize], default 100
+	- LOG__MUX__DROP_POLICY: Sets [DropPolicy], following are the valid options
+		- BLOCK
+		- DROP_NEWEST (default)
+		- DROP_OLDEST
+*/
+func GetDefaultChanneledMuxConfig() ChanneledMuxConfig {
+	return ChanneledMuxConfig{
+		BufferSize: utils.GetEnvInt(env.LogMuxBufferSize, 1000),
+		BatchSize:  utils.GetEnvInt(env.LogMuxBatchSize, 100),
+		DropPolicy: DropPolicy(utils.GetEnv(env.LogMuxDropPolicy, string(DropPolicyDropNewest))),
+		Fallback:   logwriter.NewConsoleWriter(),
+	}
+}
+
+// ChanneledMuxOption represents an option function for configuring the [ChanneledLogMux].
+type ChanneledMuxOption func(*ChanneledMuxConfig)
+
+// WithMuxBufferSize sets the number of messages buffered for each writer.
+func WithMuxBufferSize(bufferSize int) ChanneledMuxOption {
+	return func(c *ChanneledMuxConfig) {
+		c.BufferSize = bufferSize
+	}
+}
+
+// WithMuxBatchSize sets the maximum number of messages written to a writer at once.
+func WithMuxBatchSize(batchSize int) ChanneledMuxOption {
+	return func(c *ChanneledMuxConfig) {
+		c.BatchSize = batchSize
+	}
+}
+
+// WithMuxDropPolicy sets the behaviour of Print when the buffer of a writer is full.
+func WithMuxDropPolicy(policy DropPolicy) ChanneledMuxOption {
+	return func(c *ChanneledMuxConfig) {
+		c.DropPolicy = policy
+	}
+}
+
+// WithMuxFallback sets the writer that receives the messages printed after Close in place of the closed writers.
+func WithMuxFallback(writer logwriter.LogWriter) ChanneledMuxOption {
+	return func(c *ChanneledMuxConfig) {
+		c.Fallback = writer
+	}
+}
+
+// GetDefaultRedactConfig returns the new RedactConfig with values from environment variables or default values.
+/*
+	Environment Variables
+	- LOG__REDACT__ACTION: Sets [Action], following are the valid options
+		- MASK (default)
+		- HASH
+		- DROP
+	- LOG__REDACT__MASK: Sets [Mask], default ****
+	- LOG__REDACT__FIELD_NAMES: Sets [FieldNames], default *password*,*secret*,*token*,authorization,*apikey*,cookie,*email*,*phone*,mobile,*cardnumber*,cvv,ssn
+
+[Detectors] is set with [DefaultDetectors].
+*/
+func GetDefaultRedactConfig() RedactConfig {
+	return RedactConfig{
+		Action:     RedactAction(utils.GetEnv(env.LogRedactAction, string(RedactActionMask))),
+		Mask:       utils.GetEnv(env.LogRedactMask, "****"),
+		FieldNames: utils.GetEnvAsSlice(env.LogRedactFieldNames, []string{"*password*", "*secret*", "*token*", "authorization", "*apikey*", "cookie", "*email*", "*phone*", "mobile", "*cardnumber*", "cvv", "ssn"}, ","),
+		Detectors:  DefaultDetectors(),
+	}
+}
+
+// RedactOption represents an option function for configuring the [Redactor].
+type RedactOption func(*RedactConfig)
+
+// WithRedactAction sets the replacement of the redacted values.
+func WithRedactAction(action RedactAction) RedactOption {
+	return func(c *RedactConfig) {
+		c.Action = action
+	}
+}
+
+// WithRedactMask sets the mask that replaces the redacted values with [RedactActionMask].
+func WithRedactMask(mask string) RedactOption {
+	return func(c *RedactConfig) {
+		c.Mask = mask
+	}
+}
+
+// WithRedactFieldNames sets the patterns of the field names whose values are redacted.
+func WithRedactFieldNames(names ...string) RedactOption {
+	return func(c *RedactConfig) {
+		c.FieldNames = names
+	}
+}
+
+// WithRedactDetectors sets the detectors of sensitive values in text.
+func WithRedactDetectors(detectors ...Detector) RedactOption {
+	return func(c *RedactConfig) {
+		c.Detectors = detectors
+	}
+}
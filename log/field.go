@@ -0,0 +1,85 @@
+package log
+
+import (
+	"time"
+
+	m "github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// Field is a typed key-value pair, pass it in place of a log object or bind it to a logger with [Logger.With].
+//
+//	logger.Info(ctx, "message consumed", log.String("topic", msg.Topic), log.Int("partition", msg.Partition))
+type Field = m.Field
+
+// String creates a field with a string value.
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates a field with an integer value.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: int64(value)}
+}
+
+// Int64 creates a field with an integer value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 creates a field with a floating point value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a field with a boolean value.
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Time creates a field with a time value.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a field with the duration in milliseconds.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: float64(value) / float64(time.Millisecond)}
+}
+
+// Err creates a field with the key error and the message of the error, nil errors have an empty message.
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: ""}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// Any creates a field with a value of any JSON encodable type.
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// splitFields separates the fields from the log objects and appends them to the bound fields.
+// The log objects are nil when every log object is a field.
+func splitFields(bound []Field, logObject []interface{}) ([]Field, []interface{}) {
+	n := 0
+	for _, obj := range logObject {
+		if _, ok := obj.(Field); ok {
+			n++
+		}
+	}
+	if n == 0 {
+		return bound, logObject
+	}
+	fields := make([]Field, len(bound), len(bound)+n)
+	copy(fields, bound)
+	var objects []interface{}
+	for _, obj := range logObject {
+		if f, ok := obj.(Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return fields, objects
+}
@@ -0,0 +1,186 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	m "github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// LevelResolver resolves the log level of a module from rules keyed by module name.
+//
+// A rule pattern ending with * matches the module names with the prefix, any other pattern matches the module name exactly,
+// the longest matching pattern wins and modules without a match take the default level.
+// The resolver is shared by a [Logger] and the loggers derived from it, updates take effect in all of them.
+//
+//	resolver.SetModuleLevel("MongoClient:*", "DEBUG")
+type LevelResolver struct {
+	lock  sync.Mutex                 // lock serialises the updates.
+	state atomic.Pointer[levelState] // state is replaced on every update.
+}
+
+// levelState is an immutable snapshot of the rules with a cache of the resolved modules.
+type levelState struct {
+	defaultLevel m.LogLevel
+	rules        map[string]m.LogLevel
+	patterns     []string // patterns are the rule patterns, longest first.
+	cache        sync.Map // cache maps the module name to the resolved level.
+}
+
+// NewLevelResolver creates a new LevelResolver with the default level and the rules.
+func NewLevelResolver(defaultLevel m.LogLevel, rules map[string]m.LogLevel) *LevelResolver {
+	r := &LevelResolver{}
+	r.state.Store(newLevelState(defaultLevel, rules))
+	return r
+}
+
+// newLevelState creates the snapshot of the rules.
+func newLevelState(defaultLevel m.LogLevel, rules map[string]m.LogLevel) *levelState {
+	s := &levelState{defaultLevel: defaultLevel, rules: make(map[string]m.LogLevel, len(rules))}
+	for pattern, level := range rules {
+		s.rules[pattern] = level
+		s.patterns = append(s.patterns, pattern)
+	}
+	sortPatterns(s.patterns)
+	return s
+}
+
+// ParseLevelRules parses rules of the form pattern=LEVEL separated by commas, e.g. MongoClient:*=DEBUG,KafkaReader=TRACE.
+func ParseLevelRules(spec string) (map[string]m.LogLevel, error) {
+	rules := map[string]m.LogLevel{}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		pattern, name, ok := strings.Cut(rule, "=")
+		if !ok || strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("log.ParseLevelRules: invalid rule %q", rule)
+		}
+		level, ok := m.LookupLogLevelWithName(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("log.ParseLevelRules: invalid level in rule %q", rule)
+		}
+		rules[strings.TrimSpace(pattern)] = level
+	}
+	return rules, nil
+}
+
+// Level returns the log level of the module.
+func (r *LevelResolver) Level(moduleName string) m.LogLevel {
+	s := r.state.Load()
+	if level, ok := s.cache.Load(moduleName); ok {
+		return level.(m.LogLevel)
+	}
+	level := s.defaultLevel
+	for _, pattern := range s.patterns {
+		if matchPattern(pattern, moduleName) {
+			level = s.rules[pattern]
+			break
+		}
+	}
+	s.cache.Store(moduleName, level)
+	return level
+}
+
+// DefaultLevel returns the level of the modules without a matching rule.
+func (r *LevelResolver) DefaultLevel() m.LogLevel {
+	return r.state.Load().defaultLevel
+}
+
+// Rules returns a copy of the rules.
+func (r *LevelResolver) Rules() map[string]m.LogLevel {
+	s := r.state.Load()
+	rules := make(map[string]m.LogLevel, len(s.rules))
+	for pattern, level := range s.rules {
+		rules[pattern] = level
+	}
+	return rules
+}
+
+// SetDefaultLevel sets the level of the modules without a matching rule.
+func (r *LevelResolver) SetDefaultLevel(levelName string) error {
+	level, ok := m.LookupLogLevelWithName(levelName)
+	if !ok {
+		return fmt.Errorf("LevelResolver.SetDefaultLevel: invalid level %q", levelName)
+	}
+	r.update(func(s *levelState) (m.LogLevel, map[string]m.LogLevel) { return level, s.rules })
+	return nil
+}
+
+// SetModuleLevel sets the level of the modules matching the pattern.
+func (r *LevelResolver) SetModuleLevel(pattern, levelName string) error {
+	level, ok := m.LookupLogLevelWithName(levelName)
+	if !ok {
+		return fmt.Errorf("LevelResolver.SetModuleLevel: invalid level %q", levelName)
+	}
+	r.update(func(s *levelState) (m.LogLevel, map[string]m.LogLevel) {
+		rules := make(map[string]m.LogLevel, len(s.rules)+1)
+		for p, l := range s.rules {
+			rules[p] = l
+		}
+		rules[pattern] = level
+		return s.defaultLevel, rules
+	})
+	return nil
+}
+
+// RemoveModuleLevel removes the rule of the pattern.
+func (r *LevelResolver) RemoveModuleLevel(pattern string) {
+	r.update(func(s *levelState) (m.LogLevel, map[string]m.LogLevel) {
+		rules := make(map[string]m.LogLevel, len(s.rules))
+		for p, l := range s.rules {
+			if p != pattern {
+				rules[p] = l
+			}
+		}
+		return s.defaultLevel, rules
+	})
+}
+
+// SetRules replaces the rules.
+func (r *LevelResolver) SetRules(rules map[string]m.LogLevel) {
+	r.update(func(s *levelState) (m.LogLevel, map[string]m.LogLevel) { return s.defaultLevel, rules })
+}
+
+// update replaces the state with the default level and rules returned by fn.
+func (r *LevelResolver) update(fn func(*levelState) (m.LogLevel, map[string]m.LogLevel)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.state.Store(newLevelState(fn(r.state.Load())))
+}
+
+// contextKeyLogLevel is the context key of the log level override.
+type contextKeyLogLevel struct{}
+
+// ContextWithLogLevel returns a context that logs the messages up to the level regardless of the level of the module,
+// levels below the level of the module are ignored.
+func ContextWithLogLevel(ctx context.Context, levelName string) context.Context {
+	level, ok := m.LookupLogLevelWithName(levelName)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyLogLevel{}, level)
+}
+
+// levelOverride returns the log level override of the context, the LogLevel of the correlation param is considered when allowCorrelation is set.
+func levelOverride(ctx context.Context, allowCorrelation bool) (m.LogLevel, bool) {
+	if ctx == nil {
+		return m.LogLevel{}, false
+	}
+	if level, ok := ctx.Value(contextKeyLogLevel{}).(m.LogLevel); ok {
+		return level, true
+	}
+	if !allowCorrelation {
+		return m.LogLevel{}, false
+	}
+	cr := correlation.ExtractCorrelationParam(ctx)
+	if cr.LogLevel == nil {
+		return m.LogLevel{}, false
+	}
+	return m.LookupLogLevelWithName(*cr.LogLevel)
+}
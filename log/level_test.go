@@ -0,0 +1,61 @@
+package log_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"gotest.tools/assert"
+)
+
+func TestLevelResolver(t *testing.T) {
+	w := &CaptureLogWriter{}
+	logger := log.New(log.WithLogLevelName("ERROR"), log.WithMux(log.NewDefaultLogMux(w)), log.WithModuleLevel("MongoClient:*=DEBUG, MongoClient:InternalLog=WARNING"))
+	mongo := logger.NewResourceLogger("MongoClient:Database")
+	internal := logger.NewResourceLogger("MongoClient:InternalLog")
+	kafka := logger.NewResourceLogger("KafkaReader")
+	ctx := context.Background()
+	assert.Equal(t, mongo.GetLogLevel().LogLevelName, "DEBUG")
+	assert.Equal(t, internal.GetLogLevel().LogLevelName, "WARNING")
+	assert.Equal(t, kafka.GetLogLevel().LogLevelName, "ERROR")
+	mongo.Debug(ctx, "mongo debug")
+	internal.Info(ctx, "internal info")
+	kafka.Info(ctx, "kafka info")
+
+	resolver := logger.LevelResolver()
+	assert.NilError(t, resolver.SetModuleLevel("Kafka*", "INFO"))
+	assert.ErrorContains(t, resolver.SetModuleLevel("Kafka*", "VERBOSE"), "invalid level")
+	kafka.Info(ctx, "kafka info after update")
+	kafka.Debug(ctx, "kafka debug after update")
+	resolver.RemoveModuleLevel("MongoClient:*")
+	mongo.Debug(ctx, "mongo debug after removal")
+	assert.NilError(t, resolver.SetDefaultLevel("INFO"))
+	mongo.Info(ctx, "mongo info after default")
+
+	msgs := make([]string, 0, len(w.msg))
+	for _, msg := range w.msg {
+		msgs = append(msgs, msg.Message)
+	}
+	assert.DeepEqual(t, msgs, []string{"mongo debug", "kafka info after update", "mongo info after default"})
+
+	_, err := log.ParseLevelRules("MongoClient")
+	assert.ErrorContains(t, err, "invalid rule")
+}
+
+func TestLevelOverride(t *testing.T) {
+	w := &CaptureLogWriter{}
+	mux := log.NewDefaultLogMux(w)
+	level := "DEBUG"
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: "debug-me", LogLevel: &level})
+	log.New(log.WithLogLevelName("ERROR"), log.WithMux(mux)).Debug(ctx, "ignored without override")
+	logger := log.New(log.WithLogLevelName("ERROR"), log.WithMux(mux), log.WithLevelOverride(true))
+	logger.Debug(ctx, "correlation override")
+	logger.Trace(ctx, "below override")
+	logger.Info(context.Background(), "no override")
+	logger.Trace(log.ContextWithLogLevel(context.Background(), "TRACE"), "context override")
+	assert.Equal(t, len(w.msg), 2)
+	assert.Equal(t, w.msg[0].Message, "correlation override")
+	assert.Equal(t, w.cr[0], "debug-me")
+	assert.Equal(t, w.msg[1].Message, "context override")
+}
@@ -8,6 +8,8 @@ import (
 )
 
 // Log defines the interface for logging used throughout the package.
+//
+// The log objects of a message may include [Field] values, they are emitted as typed key-value pairs instead of a serialised log object.
 type Log interface {
 	// NewResourceLogger creates a new logger instance with the specified resource name.
 	NewResourceLogger(resourceName string) Log
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
 	"github.com/sabariramc/goserverbase/v6/log/logwriter"
 	m "github.com/sabariramc/goserverbase/v6/log/message"
 )
@@ -15,12 +16,16 @@ import (
 // Logger represents the implementation of the log interface.
 
 type Logger struct {
-	logLevel    m.LogLevel     // logLevel represents the log level.
+	resolver    *LevelResolver // resolver resolves the log level of the module, shared with the derived loggers.
+	override    bool           // override allows the correlation param to override the log level.
+	sampler     *Sampler       // sampler samples the messages, shared with the derived loggers.
 	mux         Mux            // mux represents the multiplexer for handling log messages.
 	moduleName  string         // moduleName represents the name of the module.
 	serviceName string         // serviceName represents the name of the service.
 	audit       AuditLogWriter // audit represents the audit log writer.
 	fileTrace   bool           // fileTrace indicates whether file tracing is enabled.
+	fields      []m.Field      // fields are bound to every message of the logger.
+	spanOp      span.SpanOp    // spanOp reads the span of the context for the trace and span IDs.
 }
 
 // New creates a new Logger instance with the specified options.
@@ -29,17 +34,46 @@ func New(options ...Option) *Logger {
 	for _, opt := range options {
 		opt(&config)
 	}
+	resolver := config.Resolver
+	var ruleErr error
+	if resolver == nil {
+		rules, err := ParseLevelRules(config.ModuleLevel)
+		ruleErr = err
+		resolver = NewLevelResolver(config.LogLevel, rules)
+	}
+	sampler := config.Sampler
+	var samplingErr error
+	if sampler == nil && config.Sampling != "" {
+		rules, err := ParseSamplingRules(config.Sampling)
+		samplingErr = err
+		if err == nil {
+			sampler = NewSampler(time.Duration(config.SampleEvery)*time.Millisecond, rules)
+		}
+	}
+	mux := config.Mux
+	if config.Redactor != nil {
+		mux = NewRedactMux(mux, config.Redactor)
+	}
 	l := &Logger{
-		logLevel:    config.LogLevel,
-		mux:         config.Mux,
+		resolver:    resolver,
+		sampler:     sampler,
+		override:    config.Override,
+		mux:         mux,
 		serviceName: config.ServiceName,
 		moduleName:  config.ModuleName,
 		fileTrace:   config.FileTrace,
 		audit:       config.Audit,
+		spanOp:      config.SpanOp,
 	}
 	if config.LogLevel.Level == m.TRACE {
 		l.Notice(context.Background(), "log level is set as TRACE", nil)
 	}
+	if ruleErr != nil {
+		l.Error(context.Background(), "invalid module level rules, module levels are ignored", ruleErr)
+	}
+	if samplingErr != nil {
+		l.Error(context.Background(), "invalid sampling rules, sampling is disabled", samplingErr)
+	}
 	return l
 }
 
@@ -50,14 +84,48 @@ func (l *Logger) NewResourceLogger(resourceName string) Log {
 	return &newLog
 }
 
+// With creates a child Logger that adds the fields to every message, on top of the fields of the parent.
+func (l *Logger) With(fields ...Field) *Logger {
+	newLog := *l
+	newLog.fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	return &newLog
+}
+
 // AddLogWriter adds a log writer to the logger.
 func (l *Logger) AddLogWriter(ctx context.Context, w logwriter.LogWriter) {
 	l.mux.AddLogWriter(ctx, w)
 }
 
-// GetLogLevel returns the current log level.
+// Name returns the name of the logger for the shutdown hook.
+func (l *Logger) Name(ctx context.Context) string {
+	return "Logger"
+}
+
+// Shutdown flushes and closes the mux when it is a [FlushableMux], implementation of the shutdown hook.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	if mux, ok := l.mux.(FlushableMux); ok {
+		return mux.Close(ctx)
+	}
+	return nil
+}
+
+// GetLogLevel returns the current log level of the module of the logger.
 func (l *Logger) GetLogLevel() m.LogLevel {
-	return l.logLevel
+	return l.resolver.Level(l.moduleName)
+}
+
+// LevelResolver returns the resolver of the log level, updates to it apply to the logger and the loggers derived from it.
+func (l *Logger) LevelResolver() *LevelResolver {
+	return l.resolver
+}
+
+// enabled reports whether a message of the level is logged for the module of the logger or with the level override of ctx.
+func (l *Logger) enabled(ctx context.Context, level m.LogLevelCode) bool {
+	if level <= l.resolver.Level(l.moduleName).Level {
+		return true
+	}
+	override, ok := levelOverride(ctx, l.override)
+	return ok && level <= override.Level
 }
 
 // SetModuleName sets the module name for the logger.
@@ -65,7 +133,7 @@ func (l *Logger) SetModuleName(moduleName string) {
 	l.moduleName = moduleName
 }
 
-// Audit writes an audit log message.
+// Audit writes an audit log message, the message is dropped when the logger has no audit log writer.
 func (l *Logger) Audit(ctx context.Context, msg interface{}) error {
 	if l.audit == nil {
 		return nil
@@ -120,13 +188,15 @@ func (l *Logger) Fatal(ctx context.Context, message string, exitCode int, logObj
 
 // print prints the log message with the specified level.
 func (l *Logger) print(ctx context.Context, level m.LogLevelCode, message string, logObject []interface{}) {
-	if level > l.logLevel.Level {
+	if !l.enabled(ctx, level) {
 		return
 	}
+	fields, logObject := splitFields(l.fields, logObject)
 	msg := &m.LogMessage{
 		LogLevel:    m.GetLogLevel(level),
 		Message:     message,
 		LogObject:   logObject,
+		Fields:      fields,
 		Timestamp:   time.Now(),
 		ModuleName:  l.moduleName,
 		ServiceName: l.serviceName,
@@ -135,5 +205,36 @@ func (l *Logger) print(ctx context.Context, level m.LogLevelCode, message string
 		_, fileName, lineNumber, _ := runtime.Caller(2)
 		msg.File = fmt.Sprintf("%v:%v", fileName, lineNumber)
 	}
-	l.mux.Print(ctx, msg)
+	l.write(ctx, msg)
+}
+
+// write prints the message to the mux unless it is suppressed by the sampler,
+// a summary of the messages suppressed in the previous window is printed before the message.
+// The trace and span IDs of the span of ctx are added to the message when the logger has a span.SpanOp.
+func (l *Logger) write(ctx context.Context, msg *m.LogMessage) {
+	if l.spanOp != nil {
+		if sp, ok := l.spanOp.GetSpanFromContext(ctx); ok {
+			msg.TraceID, msg.SpanID = sp.TraceID(), sp.SpanID()
+		}
+	}
+	if l.sampler == nil {
+		l.mux.Print(ctx, msg)
+		return
+	}
+	ok, suppressed := l.sampler.Sample(l.moduleName, msg.LogLevel, msg.Message)
+	if suppressed > 0 {
+		l.mux.Print(ctx, &m.LogMessage{
+			LogLevel:    msg.LogLevel,
+			Message:     fmt.Sprintf("suppressed %v messages: %v", suppressed, msg.Message),
+			Fields:      []m.Field{String("sampled.message", msg.Message), Int("sampled.suppressed", suppressed)},
+			Timestamp:   msg.Timestamp,
+			TraceID:     msg.TraceID,
+			SpanID:      msg.SpanID,
+			ModuleName:  msg.ModuleName,
+			ServiceName: msg.ServiceName,
+		})
+	}
+	if ok {
+		l.mux.Print(ctx, msg)
+	}
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/sabariramc/goserverbase/v6/log/logwriter"
 	"github.com/sabariramc/goserverbase/v6/log/message"
 	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/instrumentation/span"
 	"github.com/shopspring/decimal"
 	"gotest.tools/assert"
 )
@@ -114,3 +115,87 @@ func BenchmarkParseLogObject(b *testing.B) {
 		})
 	}
 }
+
+type FieldLogWriter struct {
+	ch chan map[string]interface{}
+}
+
+func (f *FieldLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	f.ch <- logwriter.DefaultLogMapper(ctx, l)
+	return nil
+}
+
+func TestLoggerFields(t *testing.T) {
+	ch := make(chan map[string]interface{}, 3)
+	lmux := log.NewDefaultLogMux(&FieldLogWriter{ch: ch})
+	logger := log.New(log.WithLogLevelName("DEBUG"), log.WithMux(lmux), log.WithServiceName("Test Logger"))
+	child := logger.With(log.String("topic", "orders"), log.Int("partition", 2))
+	child.With(log.Bool("retry", true)).Info(context.Background(), "consumed", log.Duration("latency", 1500*time.Microsecond), log.String("Message", "clash"))
+	child.Info(context.Background(), "consumed", "payload")
+	logger.Info(context.Background(), "plain")
+	close(ch)
+	entry := <-ch
+	assert.Equal(t, entry["topic"], "orders")
+	assert.Equal(t, entry["partition"], int64(2))
+	assert.Equal(t, entry["retry"], true)
+	assert.Equal(t, entry["latency"], 1.5)
+	assert.Equal(t, entry["Message"], "consumed")
+	assert.Equal(t, entry["Field.Message"], "clash")
+	assert.Equal(t, entry["LogObject"], "[]")
+	entry = <-ch
+	assert.Equal(t, entry["topic"], "orders")
+	assert.Equal(t, entry["LogObject"], "payload")
+	_, ok := entry["retry"]
+	assert.Assert(t, !ok)
+	entry = <-ch
+	_, ok = entry["topic"]
+	assert.Assert(t, !ok)
+}
+
+type TestSpan struct {
+	traceID, spanID string
+}
+
+func (s *TestSpan) SetAttribute(key string, value any) {}
+
+func (s *TestSpan) SetStatus(statusCode int, description string) {}
+
+func (s *TestSpan) SetError(err error, stackTrace string) {}
+
+func (s *TestSpan) Finish() {}
+
+func (s *TestSpan) TraceID() string { return s.traceID }
+
+func (s *TestSpan) SpanID() string { return s.spanID }
+
+type spanKey struct{}
+
+type TestSpanOp struct{}
+
+func (TestSpanOp) NewSpanFromContext(ctx context.Context, operationName string, kind string, resourceName string) (context.Context, span.Span) {
+	sp := &TestSpan{traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7"}
+	return context.WithValue(ctx, spanKey{}, sp), sp
+}
+
+func (TestSpanOp) GetSpanFromContext(ctx context.Context) (span.Span, bool) {
+	sp, ok := ctx.Value(spanKey{}).(*TestSpan)
+	return sp, ok
+}
+
+func TestLoggerSpanIDs(t *testing.T) {
+	w := &CaptureLogWriter{}
+	logger := log.New(log.WithLogLevelName("INFO"), log.WithMux(log.NewDefaultLogMux(w)), log.WithSpanOp(TestSpanOp{}))
+	ctx, _ := TestSpanOp{}.NewSpanFromContext(context.Background(), "op", span.SpanKindInternal, "test")
+	logger.Info(ctx, "in span")
+	logger.Info(context.Background(), "no span")
+	logger.NewResourceLogger("child").Info(ctx, "child in span")
+	assert.Equal(t, len(w.msg), 3)
+	assert.Equal(t, w.msg[0].TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, w.msg[0].SpanID, "00f067aa0ba902b7")
+	assert.Equal(t, w.msg[1].TraceID, "")
+	assert.Equal(t, w.msg[2].SpanID, "00f067aa0ba902b7")
+	entry := logwriter.DefaultLogMapper(ctx, &w.msg[0])
+	assert.Equal(t, entry["TraceID"], "4bf92f3577b34da6a3ce929d0e0e4736")
+	_, ok := logwriter.DefaultLogMapper(ctx, &w.msg[1])["TraceID"]
+	assert.Assert(t, !ok)
+}
@@ -35,11 +35,17 @@ func (c *ConsoleLogWriter) WriteMessage(ctx context.Context, l *message.LogMessa
 	if l.File != "" {
 		fmt.Println(l.File)
 	}
+	line := fmt.Sprintf("[%v] [%v] [%v] [%v] [%v] [%v]", l.Timestamp.Format(timeFormat), l.LogLevelName, cr.CorrelationID, l.ServiceName, l.ModuleName, l.Message)
+	if l.TraceID != "" {
+		line += fmt.Sprintf(" [trace=%v span=%v]", l.TraceID, l.SpanID)
+	}
+	if len(l.Fields) > 0 {
+		line += fmt.Sprintf(" [%v]", FormatFields(l.Fields))
+	}
 	if l.LogObject != nil {
-		fmt.Printf("[%v] [%v] [%v] [%v] [%v] [%v] [%v] [%v]\n", l.Timestamp.Format(timeFormat), l.LogLevelName, cr.CorrelationID, l.ServiceName, l.ModuleName, l.Message, GetLogObjectType(l.LogObject), ParseLogObject(l.LogObject, true))
-	} else {
-		fmt.Printf("[%v] [%v] [%v] [%v] [%v] [%v]\n", l.Timestamp.Format(timeFormat), l.LogLevelName, cr.CorrelationID, l.ServiceName, l.ModuleName, l.Message)
+		line += fmt.Sprintf(" [%v] [%v]", GetLogObjectType(l.LogObject), ParseLogObject(l.LogObject, true))
 	}
+	fmt.Println(line)
 	return nil
 }
 
@@ -0,0 +1,235 @@
+package logwriter
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// correlationKey is the context key of the correlation param, boxed once so that the lookup does not allocate.
+var correlationKey any = correlation.ContextKeyCorrelation
+
+// correlationID returns the correlation ID of the correlation param of ctx, empty when ctx has none.
+func correlationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if cr, ok := ctx.Value(correlationKey).(*correlation.CorrelationParam); ok && cr != nil {
+		return cr.CorrelationID
+	}
+	return ""
+}
+
+// appendJSONString appends s as a JSON string, invalid UTF-8 is replaced with U+FFFD.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendJSONStringContent(buf, s)
+	return append(buf, '"')
+}
+
+// appendJSONStringContent appends s escaped for a JSON string without the quotes.
+func appendJSONStringContent(buf []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, s[start:i]...)
+				buf = append(buf, "\ufffd"...)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if c >= 0x20 && c != '"' && c != '\\' {
+			i++
+			continue
+		}
+		buf = append(buf, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		}
+		i++
+		start = i
+	}
+	return append(buf, s[start:]...)
+}
+
+// appendFloat appends f in the format of encoding/json, NaN and infinities are not valid JSON numbers and are appended as strings.
+func appendFloat(buf []byte, f float64, quoteInvalid bool) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if quoteInvalid {
+			buf = append(buf, '"')
+			buf = strconv.AppendFloat(buf, f, 'g', -1, 64)
+			return append(buf, '"')
+		}
+		return strconv.AppendFloat(buf, f, 'g', -1, 64)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	return strconv.AppendFloat(buf, f, format, -1, 64)
+}
+
+// appendScalar appends the values of the types of the field constructors without allocation, ok is false for other types.
+func appendScalar(buf []byte, v any, quote bool) ([]byte, bool) {
+	switch val := v.(type) {
+	case int64:
+		return strconv.AppendInt(buf, val, 10), true
+	case int:
+		return strconv.AppendInt(buf, int64(val), 10), true
+	case int32:
+		return strconv.AppendInt(buf, int64(val), 10), true
+	case uint64:
+		return strconv.AppendUint(buf, val, 10), true
+	case uint:
+		return strconv.AppendUint(buf, uint64(val), 10), true
+	case uint32:
+		return strconv.AppendUint(buf, uint64(val), 10), true
+	case float64:
+		return appendFloat(buf, val, quote), true
+	case float32:
+		return appendFloat(buf, float64(val), quote), true
+	case bool:
+		return strconv.AppendBool(buf, val), true
+	case time.Time:
+		if quote {
+			buf = append(buf, '"')
+			buf = val.AppendFormat(buf, time.RFC3339Nano)
+			return append(buf, '"'), true
+		}
+		return val.AppendFormat(buf, time.RFC3339Nano), true
+	}
+	return buf, false
+}
+
+// appendJSONValue appends v as a JSON value, values other than the scalars and strings are encoded with encoding/json.
+func appendJSONValue(buf []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, val)
+	case error:
+		return appendJSONString(buf, val.Error())
+	}
+	if buf, ok := appendScalar(buf, v, true); ok {
+		return buf
+	}
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, ParseObject(v, false))
+	}
+	return append(buf, blob...)
+}
+
+// appendLogfmtString appends s, quoted when it is empty or contains a space, a quote, an equals sign or a control character.
+func appendLogfmtString(buf []byte, s string) []byte {
+	if s == "" {
+		return append(buf, `""`...)
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == '"' || c == '=' || c == 0x7f {
+			return appendJSONString(buf, s)
+		}
+	}
+	return append(buf, s...)
+}
+
+// appendLogfmtValue appends v as a logfmt value.
+func appendLogfmtValue(buf []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendLogfmtString(buf, val)
+	case error:
+		return appendLogfmtString(buf, val.Error())
+	}
+	if buf, ok := appendScalar(buf, v, false); ok {
+		return buf
+	}
+	return appendLogfmtString(buf, ParseObject(v, false))
+}
+
+// fieldShadowed reports whether the field at i is replaced by a later field with the same key.
+func fieldShadowed(fields []message.Field, i int) bool {
+	for j := i + 1; j < len(fields); j++ {
+		if fields[j].Key == fields[i].Key {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedKey reports whether key is one of the keys written by the formatter.
+func reservedKey(reserved []string, key string) bool {
+	for _, r := range reserved {
+		if r == key {
+			return true
+		}
+	}
+	return false
+}
+
+// appendJSONFields appends the fields as members of a JSON object, a field whose key is reserved is written as Field.<key>,
+// see [AddFields].
+func appendJSONFields(buf []byte, fields []message.Field, reserved []string) []byte {
+	for i := range fields {
+		if fieldShadowed(fields, i) {
+			continue
+		}
+		f := &fields[i]
+		buf = append(buf, ',', '"')
+		if reservedKey(reserved, f.Key) {
+			buf = append(buf, fieldPrefix...)
+		}
+		buf = appendJSONStringContent(buf, f.Key)
+		buf = append(buf, '"', ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return buf
+}
+
+// appendJSONMember appends ,"key":"value".
+func appendJSONMember(buf []byte, key, value string) []byte {
+	buf = append(buf, ',', '"')
+	buf = append(buf, key...)
+	buf = append(buf, '"', ':')
+	return appendJSONString(buf, value)
+}
+
+// splitFile splits the file pointer path:line of a message.
+func splitFile(file string) (string, int64) {
+	i := strings.LastIndexByte(file, ':')
+	if i < 0 {
+		return file, 0
+	}
+	line, err := strconv.ParseInt(file[i+1:], 10, 64)
+	if err != nil {
+		return file, 0
+	}
+	return file[:i], line
+}
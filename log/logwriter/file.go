@@ -0,0 +1,465 @@
+package logwriter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// backupTimeFormat is the timestamp format of the name of a rotated file, it sorts in the order of rotation.
+const backupTimeFormat = "20060102T150405.000"
+
+// FileConfig represents the configuration options for the [FileLogWriter].
+type FileConfig struct {
+	Path           string    // Path is the path of the log file, rotated files are created in the same directory.
+	MaxSize        int64     // MaxSize is the size in bytes at which the file is rotated, 0 disables size based rotation.
+	RotateInterval int       // RotateInterval is the interval in milliseconds at which the file is rotated, 0 disables time based rotation.
+	MaxBackups     int       // MaxBackups is the number of rotated files retained, 0 retains all.
+	MaxAge         int       // MaxAge is the age in milliseconds after which rotated files are deleted, 0 retains all.
+	Compress       bool      // Compress enables gzip compression of rotated files.
+	BufferSize     int       // BufferSize is the size in bytes of the write buffer.
+	SyncInterval   int       // SyncInterval is the interval in milliseconds at which the buffer is flushed and the file synced.
+	ReopenOnSIGHUP bool      // ReopenOnSIGHUP reopens the file on SIGHUP, for files rotated by an external logrotate.
+	Mapper         LogMapper // Mapper maps the message to the JSON object written as a line.
+	Formatter      Formatter // Formatter encodes the message as a line, replaces Mapper when set.
+}
+
+// GetDefaultFileConfig returns the new FileConfig with values from environment variables or default values.
+/*
+	Environment Variables
+	- LOG__FILE__PATH: Sets [Path], default app.log
+	- LOG__FILE__MAX_SIZE: Sets [MaxSize], default 104857600 (100MB)
+	- LOG__FILE__ROTATE_INTERVAL: Sets [RotateInterval], default 0
+	- LOG__FILE__MAX_BACKUPS: Sets [MaxBackups], default 7
+	- LOG__FILE__MAX_AGE: Sets [MaxAge], default 0
+	- LOG__FILE__COMPRESS: Sets [Compress], default true
+	- LOG__FILE__BUFFER_SIZE: Sets [BufferSize], default 65536
+	- LOG__FILE__SYNC_INTERVAL: Sets [SyncInterval], default 1000
+	- LOG__FILE__REOPEN_ON_SIGHUP: Sets [ReopenOnSIGHUP], default false
+	- LOG__FILE__FORMAT: Sets [Formatter] with [GetFormatter], e.g. LOGFMT, ECS, the lines are mapped with [Mapper] when it is not set
+*/
+func GetDefaultFileConfig() FileConfig {
+	return FileConfig{
+		Path:           utils.GetEnv(env.LogFilePath, "app.log"),
+		MaxSize:        int64(utils.GetEnvInt(env.LogFileMaxSize, 100*1024*1024)),
+		RotateInterval: utils.GetEnvInt(env.LogFileRotateInterval, 0),
+		MaxBackups:     utils.GetEnvInt(env.LogFileMaxBackups, 7),
+		MaxAge:         utils.GetEnvInt(env.LogFileMaxAge, 0),
+		Compress:       utils.GetEnvBool(env.LogFileCompress, true),
+		BufferSize:     utils.GetEnvInt(env.LogFileBufferSize, 64*1024),
+		SyncInterval:   utils.GetEnvInt(env.LogFileSyncInterval, 1000),
+		ReopenOnSIGHUP: utils.GetEnvBool(env.LogFileReopenOnSIGHUP, false),
+		Mapper:         DefaultLogMapper,
+		Formatter:      GetFormatter(utils.GetEnv(env.LogFileFormat, "")),
+	}
+}
+
+// FileOption represents an option function for configuring the [FileLogWriter].
+type FileOption func(*FileConfig)
+
+// WithFilePath sets the path of the log file.
+func WithFilePath(path string) FileOption {
+	return func(c *FileConfig) {
+		c.Path = path
+	}
+}
+
+// WithFileRotation sets the size in bytes and the interval at which the file is rotated, 0 disables the respective rotation.
+func WithFileRotation(maxSize int64, interval time.Duration) FileOption {
+	return func(c *FileConfig) {
+		c.MaxSize = maxSize
+		c.RotateInterval = int(interval.Milliseconds())
+	}
+}
+
+// WithFileRetention sets the number and the age of the rotated files retained, 0 disables the respective limit.
+func WithFileRetention(maxBackups int, maxAge time.Duration) FileOption {
+	return func(c *FileConfig) {
+		c.MaxBackups = maxBackups
+		c.MaxAge = int(maxAge.Milliseconds())
+	}
+}
+
+// WithFileCompress sets gzip compression of rotated files.
+func WithFileCompress(compress bool) FileOption {
+	return func(c *FileConfig) {
+		c.Compress = compress
+	}
+}
+
+// WithFileBuffer sets the size in bytes of the write buffer and the interval at which it is flushed and the file synced.
+func WithFileBuffer(bufferSize int, syncInterval time.Duration) FileOption {
+	return func(c *FileConfig) {
+		c.BufferSize = bufferSize
+		c.SyncInterval = int(syncInterval.Milliseconds())
+	}
+}
+
+// WithFileReopenOnSIGHUP sets reopening of the file on SIGHUP.
+func WithFileReopenOnSIGHUP(reopen bool) FileOption {
+	return func(c *FileConfig) {
+		c.ReopenOnSIGHUP = reopen
+	}
+}
+
+// WithFileFormatter sets the formatter of the message, it replaces the mapper.
+func WithFileFormatter(f Formatter) FileOption {
+	return func(c *FileConfig) {
+		c.Formatter = f
+	}
+}
+
+// WithFileMapper sets the mapper of the message to the JSON object written as a line.
+func WithFileMapper(mapper LogMapper) FileOption {
+	return func(c *FileConfig) {
+		c.Mapper = mapper
+	}
+}
+
+// FileLogWriter writes logs to a file in JSONL format with size and time based rotation.
+//
+// A rotated file is renamed to <name>-<timestamp><ext>, compressed to <name>-<timestamp><ext>.gz when [FileConfig.Compress] is set,
+// and deleted once it is beyond [FileConfig.MaxBackups] or older than [FileConfig.MaxAge].
+// The file is opened on the first write, writes are buffered and flushed every [FileConfig.SyncInterval].
+type FileLogWriter struct {
+	c        FileConfig
+	lock     sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	size     int64
+	rotateAt time.Time
+	closed   bool
+	stop     chan struct{}
+	wg       sync.WaitGroup // wg tracks the sync goroutine and the compression of rotated files.
+	bgLock   sync.Mutex     // bgLock serialises the compression and pruning of rotated files.
+	line     []byte         // line is the buffer of the line encoded by the formatter.
+}
+
+// NewFileWriter creates a new FileLogWriter, the file is opened on the first write.
+func NewFileWriter(options ...FileOption) *FileLogWriter {
+	config := GetDefaultFileConfig()
+	for _, opt := range options {
+		opt(&config)
+	}
+	if config.Mapper == nil {
+		config.Mapper = DefaultLogMapper
+	}
+	return &FileLogWriter{c: config}
+}
+
+// WriteMessage writes a log message as a line.
+func (f *FileLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	return f.write([]message.MuxLogMessage{{Ctx: ctx, LogMessage: *l}})
+}
+
+// WriteBatch writes the log messages as lines.
+func (f *FileLogWriter) WriteBatch(messages []message.MuxLogMessage) error {
+	return f.write(messages)
+}
+
+// write writes the messages to the file, rotating it before a line that would exceed the size or after the rotation time.
+func (f *FileLogWriter) write(messages []message.MuxLogMessage) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed {
+		return fmt.Errorf("FileLogWriter.WriteMessage: %w", os.ErrClosed)
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return fmt.Errorf("FileLogWriter.WriteMessage: %w", err)
+		}
+		f.start()
+	}
+	for i := range messages {
+		blob, err := f.encode(&messages[i])
+		if err != nil {
+			return fmt.Errorf("FileLogWriter.WriteMessage: error marshalling message: %w", err)
+		}
+		if f.rotationDue(int64(len(blob))) {
+			if err := f.rotate(); err != nil {
+				return fmt.Errorf("FileLogWriter.WriteMessage: %w", err)
+			}
+		}
+		n, err := f.buf.Write(blob)
+		f.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("FileLogWriter.WriteMessage: %w", err)
+		}
+	}
+	return nil
+}
+
+// encode encodes the message as a line with the formatter, or the JSON of the mapped message when there is no formatter.
+func (f *FileLogWriter) encode(msg *message.MuxLogMessage) ([]byte, error) {
+	if f.c.Formatter != nil {
+		f.line = append(f.c.Formatter.Format(msg.Ctx, f.line[:0], &msg.LogMessage), '\n')
+		return f.line, nil
+	}
+	blob, err := json.Marshal(f.c.Mapper(msg.Ctx, &msg.LogMessage))
+	if err != nil {
+		return nil, err
+	}
+	return append(blob, '\n'), nil
+}
+
+// rotationDue reports whether the file has to be rotated before writing n bytes.
+func (f *FileLogWriter) rotationDue(n int64) bool {
+	if f.c.MaxSize > 0 && f.size > 0 && f.size+n > f.c.MaxSize {
+		return true
+	}
+	return !f.rotateAt.IsZero() && !time.Now().Before(f.rotateAt)
+}
+
+// open opens the file for appending, the time based rotation counts from the modification time of an existing file.
+func (f *FileLogWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.c.Path), 0o755); err != nil {
+		return fmt.Errorf("error creating log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.c.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error reading log file info: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.buf == nil {
+		f.buf = bufio.NewWriterSize(file, f.c.BufferSize)
+	} else {
+		f.buf.Reset(file)
+	}
+	f.rotateAt = time.Time{}
+	if f.c.RotateInterval > 0 {
+		interval := time.Duration(f.c.RotateInterval) * time.Millisecond
+		openedAt := time.Now()
+		if info.Size() > 0 {
+			openedAt = info.ModTime()
+		}
+		f.rotateAt = openedAt.Truncate(interval).Add(interval)
+	}
+	return nil
+}
+
+// closeFile flushes the buffer, syncs and closes the file.
+func (f *FileLogWriter) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.buf.Flush()
+	if syncErr := f.file.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.file = nil
+	return err
+}
+
+// rotate renames the file with the rotation timestamp, opens a new file and compresses and prunes the rotated files in the background.
+func (f *FileLogWriter) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return fmt.Errorf("error closing log file: %w", err)
+	}
+	backup := f.backupPath(time.Now())
+	if err := os.Rename(f.c.Path, backup); err != nil {
+		return fmt.Errorf("error renaming log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.bgLock.Lock()
+		defer f.bgLock.Unlock()
+		// the backup is gone when the prune of a later rotation ran first
+		if f.c.Compress {
+			if err := compressFile(backup); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "FileLogWriter.rotate: %v\n", err)
+			}
+		}
+		f.prune()
+	}()
+	return nil
+}
+
+// backupPath returns the path of the rotated file for the rotation time, the time is advanced by a millisecond until the path is unused.
+func (f *FileLogWriter) backupPath(t time.Time) string {
+	ext := filepath.Ext(f.c.Path)
+	for {
+		backup := fmt.Sprintf("%v-%v%v", strings.TrimSuffix(f.c.Path, ext), t.Format(backupTimeFormat), ext)
+		_, err := os.Stat(backup)
+		_, gzErr := os.Stat(backup + ".gz")
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			return backup
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// prune deletes the rotated files beyond the retention count or age.
+func (f *FileLogWriter) prune() {
+	backups := f.Backups()
+	now := time.Now()
+	maxAge := time.Duration(f.c.MaxAge) * time.Millisecond
+	for i, backup := range backups {
+		remove := f.c.MaxBackups > 0 && i < len(backups)-f.c.MaxBackups
+		if !remove && maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && now.Sub(info.ModTime()) > maxAge {
+				remove = true
+			}
+		}
+		if remove {
+			os.Remove(backup)
+		}
+	}
+}
+
+// Backups returns the paths of the rotated files from the oldest to the newest.
+func (f *FileLogWriter) Backups() []string {
+	ext := filepath.Ext(f.c.Path)
+	prefix := strings.TrimSuffix(f.c.Path, ext) + "-"
+	matches, _ := filepath.Glob(prefix + "*")
+	backups := matches[:0]
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(match, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+// compressFile compresses the file to <path>.gz and deletes it.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening rotated log file: %w", err)
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating compressed log file: %w", err)
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return fmt.Errorf("error compressing log file: %w", err)
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// start starts the goroutine that flushes the buffer periodically and reopens the file on SIGHUP.
+func (f *FileLogWriter) start() {
+	if f.stop != nil {
+		return
+	}
+	f.stop = make(chan struct{})
+	hup := make(chan os.Signal, 1)
+	if f.c.ReopenOnSIGHUP {
+		signal.Notify(hup, syscall.SIGHUP)
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer signal.Stop(hup)
+		var tick <-chan time.Time
+		if f.c.SyncInterval > 0 {
+			ticker := time.NewTicker(time.Duration(f.c.SyncInterval) * time.Millisecond)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-tick:
+				f.Sync()
+			case <-hup:
+				f.Reopen()
+			}
+		}
+	}()
+}
+
+// Sync flushes the buffer and syncs the file.
+func (f *FileLogWriter) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	if err := f.buf.Flush(); err != nil {
+		return fmt.Errorf("FileLogWriter.Sync: %w", err)
+	}
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("FileLogWriter.Sync: %w", err)
+	}
+	return nil
+}
+
+// Reopen closes and reopens the file at the path, for use after the file is moved by an external logrotate.
+func (f *FileLogWriter) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	if err := f.closeFile(); err != nil {
+		return fmt.Errorf("FileLogWriter.Reopen: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return fmt.Errorf("FileLogWriter.Reopen: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the file and waits for the compression of rotated files, writes after Close fail.
+func (f *FileLogWriter) Close() error {
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		return nil
+	}
+	f.closed = true
+	if f.stop != nil {
+		close(f.stop)
+	}
+	err := f.closeFile()
+	f.lock.Unlock()
+	f.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("FileLogWriter.Close: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package logwriter_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/logwriter"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"gotest.tools/assert"
+)
+
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	var r io.Reader
+	file, err := os.Open(path)
+	assert.NilError(t, err)
+	defer file.Close()
+	r = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		assert.NilError(t, err)
+		r = zr
+	}
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func newFileLogger(w *logwriter.FileLogWriter) *log.Logger {
+	return log.New(log.WithLogLevelName("INFO"), log.WithMux(log.NewDefaultLogMux(w)))
+}
+
+func TestFileLogWriterSizeRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := logwriter.NewFileWriter(logwriter.WithFilePath(path), logwriter.WithFileRotation(1024, 0), logwriter.WithFileRetention(2, 0), logwriter.WithFileCompress(true))
+	logger := newFileLogger(w)
+	ctx := context.Background()
+	for i := 0; i < 30; i++ {
+		logger.Info(ctx, "rotation", log.Int("index", i))
+	}
+	assert.NilError(t, logger.Shutdown(ctx))
+	backups := w.Backups()
+	assert.Equal(t, len(backups), 2)
+	count := 0
+	for _, backup := range backups {
+		assert.Assert(t, strings.HasSuffix(backup, ".log.gz"), backup)
+		count += len(readLines(t, backup))
+	}
+	lines := readLines(t, path)
+	assert.Assert(t, len(lines) > 0)
+	assert.Equal(t, lines[len(lines)-1]["index"], float64(29))
+	info, err := os.Stat(backups[0])
+	assert.NilError(t, err)
+	assert.Assert(t, count > 0 && info.Size() > 0)
+	assert.ErrorContains(t, w.WriteMessage(ctx, &message.LogMessage{}), "file already closed")
+}
+
+func TestFileLogWriterTimeRotationAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	w := logwriter.NewFileWriter(logwriter.WithFilePath(path), logwriter.WithFileRotation(0, 50*time.Millisecond), logwriter.WithFileRetention(0, 0), logwriter.WithFileCompress(false))
+	logger := newFileLogger(w)
+	ctx := context.Background()
+	logger.Info(ctx, "first")
+	time.Sleep(60 * time.Millisecond)
+	logger.Info(ctx, "second")
+	assert.NilError(t, w.Sync())
+	backups := w.Backups()
+	assert.Equal(t, len(backups), 1)
+	assert.Equal(t, readLines(t, backups[0])[0]["Message"], "first")
+
+	moved := path + ".1"
+	assert.NilError(t, os.Rename(path, moved))
+	assert.NilError(t, w.Reopen())
+	logger.Info(ctx, "third")
+	assert.NilError(t, w.Close())
+	assert.Equal(t, readLines(t, moved)[0]["Message"], "second")
+	assert.Equal(t, readLines(t, path)[0]["Message"], "third")
+}
+
+func TestFileLogWriterRapidRotation(t *testing.T) {
+	r, stderrW, err := os.Pipe()
+	assert.NilError(t, err)
+	stderr := os.Stderr
+	os.Stderr = stderrW
+	defer func() { os.Stderr = stderr }()
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := logwriter.NewFileWriter(logwriter.WithFilePath(path), logwriter.WithFileRotation(256, 0), logwriter.WithFileRetention(1, 0), logwriter.WithFileCompress(true))
+	logger := newFileLogger(w)
+	ctx := context.Background()
+	for i := 0; i < 200; i++ {
+		logger.Info(ctx, "rotation", log.Int("index", i))
+	}
+	assert.NilError(t, logger.Shutdown(ctx))
+	os.Stderr = stderr
+	assert.NilError(t, stderrW.Close())
+	out, err := io.ReadAll(r)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "")
+	assert.Equal(t, len(w.Backups()), 1)
+}
@@ -0,0 +1,124 @@
+package logwriter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// Formatter encodes a log message as a single line.
+//
+// Format appends the encoded message to buf without the trailing newline and returns the extended buffer,
+// the formatters of this package do not allocate for messages with fields of the types of the field constructors and no log objects.
+type Formatter interface {
+	Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte
+}
+
+// formatters stores the registered formatters.
+var formatters = map[string]Formatter{
+	"LOGFMT":  &LogfmtFormatter{},
+	"PRETTY":  &PrettyFormatter{Color: utils.GetEnv("NO_COLOR", "") == ""},
+	"JSON":    &JSONFormatter{},
+	"ECS":     &ECSFormatter{},
+	"GCP":     &GCPFormatter{ProjectID: utils.GetEnv("GOOGLE_CLOUD_PROJECT", "")},
+	"DATADOG": &DatadogFormatter{Env: utils.GetEnv("DD_ENV", ""), Version: utils.GetEnv("DD_VERSION", "")},
+}
+
+// GetFormatter retrieves a formatter by its name, nil when no formatter with the name is registered.
+//
+// The following formatters are registered by default
+//   - LOGFMT, [LogfmtFormatter]
+//   - PRETTY, [PrettyFormatter], colourised unless NO_COLOR is set
+//   - JSON, [JSONFormatter]
+//   - ECS, [ECSFormatter]
+//   - GCP, [GCPFormatter] for the project GOOGLE_CLOUD_PROJECT
+//   - DATADOG, [DatadogFormatter] for the environment DD_ENV and version DD_VERSION
+func GetFormatter(name string) Formatter {
+	f, ok := formatters[name]
+	if !ok {
+		return nil
+	}
+	return f
+}
+
+// AddFormatter adds a formatter with the specified name, returns an error if a formatter with the name exists.
+func AddFormatter(name string, f Formatter) error {
+	if _, ok := formatters[name]; ok {
+		return fmt.Errorf("'%v' is a duplicate formatter name", name)
+	}
+	formatters[name] = f
+	return nil
+}
+
+// maxPooledBuffer is the capacity above which a buffer is not returned to the pool.
+const maxPooledBuffer = 64 * 1024
+
+// bufferPool holds the buffers of the formatted lines.
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
+// FormatWriter writes the log messages encoded with a [Formatter], one message per line.
+type FormatWriter struct {
+	f    Formatter
+	lock sync.Mutex
+	out  io.Writer
+}
+
+// NewFormatWriter creates a new FormatWriter that writes to out, os.Stdout when out is nil.
+func NewFormatWriter(f Formatter, out io.Writer) *FormatWriter {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &FormatWriter{f: f, out: out}
+}
+
+// WriteMessage writes the formatted log message.
+func (w *FormatWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	bp := bufferPool.Get().(*[]byte)
+	buf := w.f.Format(ctx, (*bp)[:0], l)
+	buf = append(buf, '\n')
+	err := w.write(buf)
+	putBuffer(bp, buf)
+	return err
+}
+
+// WriteBatch writes the formatted log messages with a single write.
+func (w *FormatWriter) WriteBatch(messages []message.MuxLogMessage) error {
+	bp := bufferPool.Get().(*[]byte)
+	buf := (*bp)[:0]
+	for i := range messages {
+		buf = w.f.Format(messages[i].Ctx, buf, &messages[i].LogMessage)
+		buf = append(buf, '\n')
+	}
+	err := w.write(buf)
+	putBuffer(bp, buf)
+	return err
+}
+
+// write writes buf to the output.
+func (w *FormatWriter) write(buf []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, err := w.out.Write(buf); err != nil {
+		return fmt.Errorf("FormatWriter.write: %w", err)
+	}
+	return nil
+}
+
+// putBuffer returns the buffer to the pool unless it has grown above [maxPooledBuffer].
+func putBuffer(bp *[]byte, buf []byte) {
+	if cap(buf) > maxPooledBuffer {
+		return
+	}
+	*bp = buf
+	bufferPool.Put(bp)
+}
@@ -0,0 +1,130 @@
+package logwriter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/logwriter"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"gotest.tools/assert"
+)
+
+func formatMessage() (context.Context, *message.LogMessage) {
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: "corr-1"})
+	return ctx, &message.LogMessage{
+		LogLevel:    message.GetLogLevel(message.WARNING),
+		Message:     `slow "request"`,
+		Timestamp:   time.Date(2024, 5, 1, 10, 15, 30, 123456789, time.UTC),
+		ServiceName: "orders",
+		ModuleName:  "http",
+		File:        "/app/handler.go:42",
+		TraceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:      "00f067aa0ba902b7",
+		Fields:      []message.Field{log.Int("status", 200), log.String("path", "/v1/orders"), log.Float64("latency", 1.5), log.Bool("cached", false), log.String("message", "clash"), log.Int("status", 201)},
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	ctx, msg := formatMessage()
+	tests := []struct {
+		name     string
+		f        logwriter.Formatter
+		expected string
+	}{
+		{
+			name:     "logfmt",
+			f:        logwriter.LogfmtFormatter{},
+			expected: `time=2024-05-01T10:15:30.123Z level=WARNING service=orders module=http correlation_id=corr-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 msg="slow \"request\"" path=/v1/orders latency=1.5 cached=false message=clash status=201 file=/app/handler.go:42`,
+		},
+		{
+			name:     "pretty",
+			f:        &logwriter.PrettyFormatter{},
+			expected: `2024-05-01T10:15:30.123Z WARNING   [orders/http] slow "request" path=/v1/orders latency=1.5 cached=false message=clash status=201 correlation_id=corr-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 file=/app/handler.go:42`,
+		},
+		{
+			name:     "json",
+			f:        logwriter.JSONFormatter{},
+			expected: `{"Timestamp":"2024-05-01T10:15:30.123456789Z","Level":"WARNING","Message":"slow \"request\"","ServiceName":"orders","ModuleName":"http","CorrelationID":"corr-1","TraceID":"4bf92f3577b34da6a3ce929d0e0e4736","SpanID":"00f067aa0ba902b7","FilePtr":"/app/handler.go:42","path":"/v1/orders","latency":1.5,"cached":false,"message":"clash","status":201}`,
+		},
+		{
+			name:     "ecs",
+			f:        logwriter.ECSFormatter{},
+			expected: `{"@timestamp":"2024-05-01T10:15:30.123Z","log.level":"warning","message":"slow \"request\"","ecs.version":"8.11.0","service.name":"orders","log.logger":"http","labels.correlation_id":"corr-1","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","span.id":"00f067aa0ba902b7","log.origin.file.name":"/app/handler.go","log.origin.file.line":42,"path":"/v1/orders","latency":1.5,"cached":false,"Field.message":"clash","status":201}`,
+		},
+		{
+			name:     "gcp",
+			f:        &logwriter.GCPFormatter{ProjectID: "acme"},
+			expected: `{"timestamp":"2024-05-01T10:15:30.123456789Z","severity":"WARNING","message":"slow \"request\"","logging.googleapis.com/labels":{"service":"orders","module":"http","correlation_id":"corr-1"},"logging.googleapis.com/trace":"projects/acme/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"logging.googleapis.com/sourceLocation":{"file":"/app/handler.go","line":"42"},"path":"/v1/orders","latency":1.5,"cached":false,"Field.message":"clash","status":201}`,
+		},
+		{
+			name:     "datadog",
+			f:        &logwriter.DatadogFormatter{Env: "prod", Version: "1.2.0"},
+			expected: `{"timestamp":"2024-05-01T10:15:30.123Z","status":"warning","message":"slow \"request\"","service":"orders","logger.name":"http","correlation_id":"corr-1","dd.service":"orders","dd.env":"prod","dd.version":"1.2.0","dd.trace_id":"11803532876627986230","dd.span_id":"67667974448284343","logger.file_name":"/app/handler.go","logger.line":42,"path":"/v1/orders","latency":1.5,"cached":false,"Field.message":"clash","Field.status":201}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := string(tt.f.Format(ctx, nil, msg))
+			assert.Equal(t, line, tt.expected)
+			if strings.HasPrefix(line, "{") {
+				assert.Assert(t, json.Valid([]byte(line)))
+			}
+		})
+	}
+}
+
+func TestFormatterEscaping(t *testing.T) {
+	msg := &message.LogMessage{
+		LogLevel: message.GetLogLevel(message.INFO),
+		Message:  "line1\nline2\t\x01 \xff",
+		Fields:   []message.Field{log.Any("obj", map[string]int{"a": 1}), log.Float64("nan", 0), log.String("empty", "")},
+	}
+	line := logwriter.JSONFormatter{}.Format(context.Background(), nil, msg)
+	var decoded map[string]any
+	assert.NilError(t, json.Unmarshal(line, &decoded))
+	assert.Equal(t, decoded["Message"], "line1\nline2\t\x01 �")
+	assert.DeepEqual(t, decoded["obj"], map[string]any{"a": float64(1)})
+	line = logwriter.LogfmtFormatter{}.Format(context.Background(), nil, msg)
+	assert.Assert(t, strings.Contains(string(line), `msg="line1\nline2\t\u0001 �" obj="{\"a\":1}" nan=0 empty=""`), string(line))
+}
+
+func TestFormatterAllocations(t *testing.T) {
+	ctx, msg := formatMessage()
+	buf := make([]byte, 0, 4096)
+	for _, name := range []string{"LOGFMT", "PRETTY", "JSON", "ECS", "GCP", "DATADOG"} {
+		f := logwriter.GetFormatter(name)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf = f.Format(ctx, buf[:0], msg)
+		})
+		assert.Equal(t, allocs, float64(0), name)
+	}
+}
+
+func TestFormatWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := logwriter.NewFormatWriter(logwriter.LogfmtFormatter{}, &out)
+	ctx, msg := formatMessage()
+	assert.NilError(t, w.WriteMessage(ctx, msg))
+	assert.NilError(t, w.WriteBatch([]message.MuxLogMessage{{Ctx: ctx, LogMessage: *msg}, {Ctx: ctx, LogMessage: *msg}}))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, lines[2], string(logwriter.LogfmtFormatter{}.Format(ctx, nil, msg)))
+	assert.Assert(t, logwriter.GetLogWriter("ECS") != nil)
+	assert.Assert(t, logwriter.AddFormatter("ECS", logwriter.ECSFormatter{}) != nil)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	fw := logwriter.NewFileWriter(logwriter.WithFilePath(path), logwriter.WithFileFormatter(logwriter.ECSFormatter{}))
+	assert.NilError(t, fw.WriteMessage(ctx, msg))
+	assert.NilError(t, fw.Close())
+	blob, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(blob), string(logwriter.ECSFormatter{}.Format(ctx, nil, msg))+"\n")
+}
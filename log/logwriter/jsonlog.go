@@ -1,19 +1,23 @@
 package logwriter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/sabariramc/goserverbase/v6/correlation"
 	"github.com/sabariramc/goserverbase/v6/log/message"
 )
 
 // DefaultLogMapper is the default log mapper function.
+//
+// The fields of the message are added as top-level keys, a field whose key clashes with a key of the mapper is added as Field.<key>.
 func DefaultLogMapper(ctx context.Context, msg *message.LogMessage) map[string]interface{} {
 	cr := correlation.ExtractCorrelationParam(ctx)
 
-	return map[string]interface{}{
+	res := map[string]interface{}{
 		"LogMessage":       msg,
 		"CorrelationParam": cr,
 		"CorrelationID":    cr.CorrelationID,
@@ -23,6 +27,12 @@ func DefaultLogMapper(ctx context.Context, msg *message.LogMessage) map[string]i
 		"FilePtr":          msg.File,
 		"Timestamp":        msg.Timestamp,
 	}
+	if msg.TraceID != "" {
+		res["TraceID"] = msg.TraceID
+		res["SpanID"] = msg.SpanID
+	}
+	AddFields(res, msg.Fields)
+	return res
 }
 
 // LogMapper defines the function signature for log mapping.
@@ -50,3 +60,15 @@ func (c *JSONLLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage
 	fmt.Println(string(blob))
 	return nil
 }
+
+// WriteBatch writes the log messages in JSONL format with a single write.
+func (c *JSONLLogWriter) WriteBatch(messages []message.MuxLogMessage) error {
+	var buf bytes.Buffer
+	for i := range messages {
+		blob, _ := json.Marshal(c.logMapper(messages[i].Ctx, &messages[i].LogMessage))
+		buf.Write(blob)
+		buf.WriteByte('\n')
+	}
+	_, err := os.Stdout.Write(buf.Bytes())
+	return err
+}
@@ -29,3 +29,24 @@ type LogWriter interface {
 	// WriteMessage writes a log message to the log writer.
 	WriteMessage(context.Context, *message.LogMessage) error
 }
+
+// BatchLogWriter represents a log writer that can write several log messages at once.
+//
+// The [log.ChanneledLogMux] writes the buffered messages of a BatchLogWriter with a single call.
+type BatchLogWriter interface {
+	LogWriter
+
+	// WriteBatch writes the log messages to the log writer.
+	WriteBatch([]message.MuxLogMessage) error
+}
+
+// CloseLogWriter represents a log writer that buffers log messages or holds resources, the log muxes sync it on flush and close it on shutdown.
+type CloseLogWriter interface {
+	LogWriter
+
+	// Sync writes the buffered log messages.
+	Sync() error
+
+	// Close writes the buffered log messages and releases the resources of the log writer.
+	Close() error
+}
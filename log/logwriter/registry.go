@@ -2,6 +2,7 @@ package logwriter
 
 import (
 	"fmt"
+	"os"
 )
 
 // registry stores registered log writers.
@@ -34,8 +35,13 @@ func AddLogWriter(name string, l LogWriter) error {
 
 // init initializes the registry by adding default log writers.
 //
-// Default log writers include "CONSOLE" and "JSONL" log writers.
+// Default log writers include "CONSOLE", "JSONL" and "FILE" log writers, the "FILE" log writer is configured with [GetDefaultFileConfig].
+// A [FormatWriter] to os.Stdout is added for each of the formatters of [GetFormatter] with the name of the formatter.
 func init() {
 	AddLogWriter("CONSOLE", NewConsoleWriter())
 	AddLogWriter("JSONL", NewJSONLConsoleWriter(DefaultLogMapper))
+	AddLogWriter("FILE", NewFileWriter())
+	for name, f := range formatters {
+		AddLogWriter(name, NewFormatWriter(f, os.Stdout))
+	}
 }
@@ -0,0 +1,50 @@
+package logwriter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// SlogLogWriter forwards the logs to a slog.Handler.
+//
+// The service, module, correlation ID, file and trace and span IDs of the message are added as attributes along with the fields of the message,
+// the log objects are added as the attribute LogObject.
+type SlogLogWriter struct {
+	handler slog.Handler
+}
+
+// NewSlogWriter creates a new SlogLogWriter that forwards to handler.
+func NewSlogWriter(handler slog.Handler) *SlogLogWriter {
+	return &SlogLogWriter{handler: handler}
+}
+
+// WriteMessage forwards the log message to the handler, messages below the level of the handler are dropped.
+func (s *SlogLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	level := l.LogLevel.Level.SlogLevel()
+	if !s.handler.Enabled(ctx, level) {
+		return nil
+	}
+	cr := correlation.ExtractCorrelationParam(ctx)
+	r := slog.NewRecord(l.Timestamp, level, l.Message, 0)
+	r.AddAttrs(
+		slog.String("ServiceName", l.ServiceName),
+		slog.String("ModuleName", l.ModuleName),
+		slog.String("CorrelationID", cr.CorrelationID),
+	)
+	if l.File != "" {
+		r.AddAttrs(slog.String("FilePtr", l.File))
+	}
+	if l.TraceID != "" {
+		r.AddAttrs(slog.String("TraceID", l.TraceID), slog.String("SpanID", l.SpanID))
+	}
+	for _, f := range l.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if len(l.LogObject) > 0 {
+		r.AddAttrs(slog.String("LogObject", ParseLogObject(l.LogObject, false)))
+	}
+	return s.handler.Handle(ctx, r)
+}
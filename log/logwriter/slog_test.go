@@ -0,0 +1,31 @@
+package logwriter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/logwriter"
+	"gotest.tools/assert"
+)
+
+func TestSlogLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := log.New(log.WithLogLevelName("DEBUG"), log.WithMux(log.NewDefaultLogMux(logwriter.NewSlogWriter(handler))), log.WithServiceName("slog-service"))
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: "slog-writer"})
+	logger.Debug(ctx, "dropped")
+	logger.With(log.Int("partition", 3)).Warning(ctx, "lagging", "payload")
+	var entry map[string]interface{}
+	assert.NilError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, entry["level"], "WARN")
+	assert.Equal(t, entry["msg"], "lagging")
+	assert.Equal(t, entry["ServiceName"], "slog-service")
+	assert.Equal(t, entry["CorrelationID"], "slog-writer")
+	assert.Equal(t, entry["partition"], float64(3))
+	assert.Equal(t, entry["LogObject"], "payload")
+}
@@ -0,0 +1,253 @@
+package logwriter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// jsonKeys are the keys written by the [JSONFormatter].
+var jsonKeys = []string{"Timestamp", "Level", "Message", "ServiceName", "ModuleName", "CorrelationID", "TraceID", "SpanID", "FilePtr", "LogObject"}
+
+// JSONFormatter encodes the log messages as JSON objects with the keys of [DefaultLogMapper],
+// without the LogMessage and CorrelationParam copies of the message.
+type JSONFormatter struct{}
+
+// Format appends the message as a JSON object.
+func (JSONFormatter) Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte {
+	corrID := correlationID(ctx)
+	buf = append(buf, `{"Timestamp":"`...)
+	buf = l.Timestamp.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, '"')
+	buf = appendJSONMember(buf, "Level", l.LogLevelName)
+	buf = appendJSONMember(buf, "Message", l.Message)
+	buf = appendJSONMember(buf, "ServiceName", l.ServiceName)
+	buf = appendJSONMember(buf, "ModuleName", l.ModuleName)
+	buf = appendJSONMember(buf, "CorrelationID", corrID)
+	if l.TraceID != "" {
+		buf = appendJSONMember(buf, "TraceID", l.TraceID)
+		buf = appendJSONMember(buf, "SpanID", l.SpanID)
+	}
+	if l.File != "" {
+		buf = appendJSONMember(buf, "FilePtr", l.File)
+	}
+	if len(l.LogObject) > 0 {
+		buf = appendJSONMember(buf, "LogObject", ParseLogObject(l.LogObject, false))
+	}
+	buf = appendJSONFields(buf, l.Fields, jsonKeys)
+	return append(buf, '}')
+}
+
+// ecsVersion is the version of the Elastic Common Schema of the [ECSFormatter].
+const ecsVersion = "8.11.0"
+
+// ecsTimeFormat is the timestamp format of the [ECSFormatter].
+const ecsTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// ecsKeys are the keys written by the [ECSFormatter].
+var ecsKeys = []string{"@timestamp", "log.level", "message", "ecs.version", "service.name", "log.logger", "labels.correlation_id", "trace.id", "span.id", "log.origin.file.name", "log.origin.file.line", "LogObject"}
+
+// ecsLevels are the log.level values of the [ECSFormatter], indexed by [message.LogLevelCode].
+var ecsLevels = [...]string{
+	message.FATAL:     "fatal",
+	message.EMERGENCY: "emergency",
+	message.ERROR:     "error",
+	message.WARNING:   "warning",
+	message.NOTICE:    "notice",
+	message.INFO:      "info",
+	message.DEBUG:     "debug",
+	message.TRACE:     "trace",
+}
+
+// ECSFormatter encodes the log messages in the Elastic Common Schema layout of the ecs-logging libraries.
+//
+// The timestamp is @timestamp in UTC with milliseconds, the level is the lowercase log.level, the module is log.logger,
+// the correlation ID is labels.correlation_id and the trace and span IDs are trace.id and span.id.
+type ECSFormatter struct{}
+
+// Format appends the message as an ECS JSON object.
+func (ECSFormatter) Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte {
+	corrID := correlationID(ctx)
+	buf = append(buf, `{"@timestamp":"`...)
+	buf = l.Timestamp.UTC().AppendFormat(buf, ecsTimeFormat)
+	buf = append(buf, '"')
+	buf = appendJSONMember(buf, "log.level", levelName(ecsLevels[:], l.LogLevel, "error"))
+	buf = appendJSONMember(buf, "message", l.Message)
+	buf = appendJSONMember(buf, "ecs.version", ecsVersion)
+	buf = appendJSONMember(buf, "service.name", l.ServiceName)
+	buf = appendJSONMember(buf, "log.logger", l.ModuleName)
+	if corrID != "" {
+		buf = appendJSONMember(buf, "labels.correlation_id", corrID)
+	}
+	if l.TraceID != "" {
+		buf = appendJSONMember(buf, "trace.id", l.TraceID)
+		buf = appendJSONMember(buf, "span.id", l.SpanID)
+	}
+	if l.File != "" {
+		file, line := splitFile(l.File)
+		buf = appendJSONMember(buf, "log.origin.file.name", file)
+		buf = append(buf, `,"log.origin.file.line":`...)
+		buf = strconv.AppendInt(buf, line, 10)
+	}
+	if len(l.LogObject) > 0 {
+		buf = appendJSONMember(buf, "LogObject", ParseLogObject(l.LogObject, false))
+	}
+	buf = appendJSONFields(buf, l.Fields, ecsKeys)
+	return append(buf, '}')
+}
+
+// gcpKeys are the keys written by the [GCPFormatter].
+var gcpKeys = []string{"timestamp", "severity", "message", "logging.googleapis.com/labels", "logging.googleapis.com/trace", "logging.googleapis.com/spanId", "logging.googleapis.com/trace_sampled", "logging.googleapis.com/sourceLocation", "LogObject"}
+
+// gcpSeverities are the severity values of the [GCPFormatter], indexed by [message.LogLevelCode].
+var gcpSeverities = [...]string{
+	message.FATAL:     "EMERGENCY",
+	message.EMERGENCY: "ALERT",
+	message.ERROR:     "ERROR",
+	message.WARNING:   "WARNING",
+	message.NOTICE:    "NOTICE",
+	message.INFO:      "INFO",
+	message.DEBUG:     "DEBUG",
+	message.TRACE:     "DEBUG",
+}
+
+// GCPFormatter encodes the log messages in the structured logging layout of Google Cloud Logging.
+//
+// The timestamp is timestamp in UTC with nanoseconds, the level is mapped to severity, the service, module and correlation ID
+// are labels and the file is the sourceLocation. The trace ID is written as projects/<ProjectID>/traces/<trace ID> so that
+// Cloud Logging links the entry to Cloud Trace, the bare trace ID is written when ProjectID is empty.
+type GCPFormatter struct {
+	ProjectID string // ProjectID is the Google Cloud project of the traces.
+}
+
+// Format appends the message as a Cloud Logging JSON object.
+func (g *GCPFormatter) Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte {
+	corrID := correlationID(ctx)
+	buf = append(buf, `{"timestamp":"`...)
+	buf = l.Timestamp.UTC().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, '"')
+	buf = appendJSONMember(buf, "severity", levelName(gcpSeverities[:], l.LogLevel, "DEFAULT"))
+	buf = appendJSONMember(buf, "message", l.Message)
+	buf = append(buf, `,"logging.googleapis.com/labels":{"service":`...)
+	buf = appendJSONString(buf, l.ServiceName)
+	buf = appendJSONMember(buf, "module", l.ModuleName)
+	if corrID != "" {
+		buf = appendJSONMember(buf, "correlation_id", corrID)
+	}
+	buf = append(buf, '}')
+	if l.TraceID != "" {
+		buf = append(buf, `,"logging.googleapis.com/trace":"`...)
+		if g.ProjectID != "" {
+			buf = append(buf, "projects/"...)
+			buf = appendJSONStringContent(buf, g.ProjectID)
+			buf = append(buf, "/traces/"...)
+		}
+		buf = appendJSONStringContent(buf, l.TraceID)
+		buf = append(buf, '"')
+		buf = appendJSONMember(buf, "logging.googleapis.com/spanId", l.SpanID)
+		buf = append(buf, `,"logging.googleapis.com/trace_sampled":true`...)
+	}
+	if l.File != "" {
+		file, line := splitFile(l.File)
+		buf = append(buf, `,"logging.googleapis.com/sourceLocation":{"file":`...)
+		buf = appendJSONString(buf, file)
+		buf = append(buf, `,"line":"`...)
+		buf = strconv.AppendInt(buf, line, 10)
+		buf = append(buf, `"}`...)
+	}
+	if len(l.LogObject) > 0 {
+		buf = appendJSONMember(buf, "LogObject", ParseLogObject(l.LogObject, false))
+	}
+	buf = appendJSONFields(buf, l.Fields, gcpKeys)
+	return append(buf, '}')
+}
+
+// datadogKeys are the keys written by the [DatadogFormatter].
+var datadogKeys = []string{"timestamp", "status", "message", "service", "logger.name", "correlation_id", "dd.service", "dd.env", "dd.version", "dd.trace_id", "dd.span_id", "logger.file_name", "logger.line", "LogObject"}
+
+// datadogStatuses are the status values of the [DatadogFormatter], indexed by [message.LogLevelCode].
+var datadogStatuses = [...]string{
+	message.FATAL:     "emergency",
+	message.EMERGENCY: "alert",
+	message.ERROR:     "error",
+	message.WARNING:   "warning",
+	message.NOTICE:    "notice",
+	message.INFO:      "info",
+	message.DEBUG:     "debug",
+	message.TRACE:     "debug",
+}
+
+// DatadogFormatter encodes the log messages in the JSON layout of the Datadog log pipeline.
+//
+// The level is mapped to status and the trace and span IDs are written as the decimal dd.trace_id and dd.span_id of the
+// Datadog tracer, the lower 64 bits of a 128-bit trace ID, so that Datadog links the entry to the trace.
+// Env and Version are written as dd.env and dd.version for unified service tagging.
+type DatadogFormatter struct {
+	Env     string // Env is the environment of the service.
+	Version string // Version is the version of the service.
+}
+
+// Format appends the message as a Datadog JSON object.
+func (d *DatadogFormatter) Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte {
+	corrID := correlationID(ctx)
+	buf = append(buf, `{"timestamp":"`...)
+	buf = l.Timestamp.UTC().AppendFormat(buf, ecsTimeFormat)
+	buf = append(buf, '"')
+	buf = appendJSONMember(buf, "status", levelName(datadogStatuses[:], l.LogLevel, "error"))
+	buf = appendJSONMember(buf, "message", l.Message)
+	buf = appendJSONMember(buf, "service", l.ServiceName)
+	buf = appendJSONMember(buf, "logger.name", l.ModuleName)
+	if corrID != "" {
+		buf = appendJSONMember(buf, "correlation_id", corrID)
+	}
+	buf = appendJSONMember(buf, "dd.service", l.ServiceName)
+	if d.Env != "" {
+		buf = appendJSONMember(buf, "dd.env", d.Env)
+	}
+	if d.Version != "" {
+		buf = appendJSONMember(buf, "dd.version", d.Version)
+	}
+	if traceID, ok := lower64(l.TraceID); ok {
+		buf = append(buf, `,"dd.trace_id":"`...)
+		buf = strconv.AppendUint(buf, traceID, 10)
+		buf = append(buf, '"')
+		if spanID, ok := lower64(l.SpanID); ok {
+			buf = append(buf, `,"dd.span_id":"`...)
+			buf = strconv.AppendUint(buf, spanID, 10)
+			buf = append(buf, '"')
+		}
+	}
+	if l.File != "" {
+		file, line := splitFile(l.File)
+		buf = appendJSONMember(buf, "logger.file_name", file)
+		buf = append(buf, `,"logger.line":`...)
+		buf = strconv.AppendInt(buf, line, 10)
+	}
+	if len(l.LogObject) > 0 {
+		buf = appendJSONMember(buf, "LogObject", ParseLogObject(l.LogObject, false))
+	}
+	buf = appendJSONFields(buf, l.Fields, datadogKeys)
+	return append(buf, '}')
+}
+
+// lower64 returns the lower 64 bits of the hex encoded ID.
+func lower64(id string) (uint64, bool) {
+	if id == "" {
+		return 0, false
+	}
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	v, err := strconv.ParseUint(id, 16, 64)
+	return v, err == nil
+}
+
+// levelName returns the name of the level in names, def for a level without a name.
+func levelName(names []string, level message.LogLevel, def string) string {
+	if int(level.Level) < len(names) && names[level.Level] != "" {
+		return names[level.Level]
+	}
+	return def
+}
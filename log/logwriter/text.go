@@ -0,0 +1,163 @@
+package logwriter
+
+import (
+	"context"
+
+	"github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// logfmtKeys are the keys written by the [LogfmtFormatter].
+var logfmtKeys = []string{"time", "level", "service", "module", "correlation_id", "trace_id", "span_id", "msg", "file", "object"}
+
+// LogfmtFormatter encodes the log messages as logfmt, e.g.
+//
+//	time=2024-05-01T10:15:30.123+05:30 level=INFO service=orders module=http correlation_id=abc msg="request served" status=200
+//
+// The fields follow the message, the log objects are written as object and a field whose key is one of the keys
+// of the formatter is written as Field.<key>.
+type LogfmtFormatter struct{}
+
+// Format appends the message as a logfmt line.
+func (LogfmtFormatter) Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte {
+	corrID := correlationID(ctx)
+	buf = append(buf, "time="...)
+	buf = l.Timestamp.AppendFormat(buf, timeFormat)
+	buf = append(buf, " level="...)
+	buf = append(buf, l.LogLevelName...)
+	buf = append(buf, " service="...)
+	buf = appendLogfmtString(buf, l.ServiceName)
+	buf = append(buf, " module="...)
+	buf = appendLogfmtString(buf, l.ModuleName)
+	if corrID != "" {
+		buf = append(buf, " correlation_id="...)
+		buf = appendLogfmtString(buf, corrID)
+	}
+	if l.TraceID != "" {
+		buf = append(buf, " trace_id="...)
+		buf = appendLogfmtString(buf, l.TraceID)
+		buf = append(buf, " span_id="...)
+		buf = appendLogfmtString(buf, l.SpanID)
+	}
+	buf = append(buf, " msg="...)
+	buf = appendLogfmtString(buf, l.Message)
+	buf = appendLogfmtFields(buf, l.Fields, logfmtKeys)
+	if l.File != "" {
+		buf = append(buf, " file="...)
+		buf = appendLogfmtString(buf, l.File)
+	}
+	if len(l.LogObject) > 0 {
+		buf = append(buf, " object="...)
+		buf = appendLogfmtString(buf, ParseLogObject(l.LogObject, false))
+	}
+	return buf
+}
+
+// appendLogfmtFields appends the fields as space separated key=value pairs, a field whose key is reserved is written as Field.<key>.
+func appendLogfmtFields(buf []byte, fields []message.Field, reserved []string) []byte {
+	for i := range fields {
+		if fieldShadowed(fields, i) {
+			continue
+		}
+		f := &fields[i]
+		buf = append(buf, ' ')
+		if reservedKey(reserved, f.Key) {
+			buf = append(buf, fieldPrefix...)
+		}
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		buf = appendLogfmtValue(buf, f.Value)
+	}
+	return buf
+}
+
+// ANSI escape codes of the [PrettyFormatter].
+const (
+	colorReset = "\x1b[0m"
+	colorFaint = "\x1b[2m"
+)
+
+// levelColors are the ANSI colours of the levels, indexed by [message.LogLevelCode].
+var levelColors = [...]string{
+	message.FATAL:     "\x1b[1;31m",
+	message.EMERGENCY: "\x1b[1;31m",
+	message.ERROR:     "\x1b[31m",
+	message.WARNING:   "\x1b[33m",
+	message.NOTICE:    "\x1b[36m",
+	message.INFO:      "\x1b[32m",
+	message.DEBUG:     "\x1b[90m",
+	message.TRACE:     "\x1b[90m",
+}
+
+// levelNameWidth is the width of the longest level name, EMERGENCY.
+const levelNameWidth = 9
+
+// PrettyFormatter encodes the log messages for reading in a terminal, e.g.
+//
+//	2024-05-01T10:15:30.123+05:30 INFO      [orders/http] request served status=200 correlation_id=abc
+//
+// The level is colourised and the keys are faint when Color is set, the log objects are indented on the lines after the message.
+type PrettyFormatter struct {
+	Color bool // Color enables the ANSI colours.
+}
+
+// Format appends the message as a human readable line.
+func (p *PrettyFormatter) Format(ctx context.Context, buf []byte, l *message.LogMessage) []byte {
+	corrID := correlationID(ctx)
+	buf = l.Timestamp.AppendFormat(buf, timeFormat)
+	buf = append(buf, ' ')
+	if p.Color && int(l.Level) < len(levelColors) {
+		buf = append(buf, levelColors[l.Level]...)
+		buf = append(buf, l.LogLevelName...)
+		buf = append(buf, colorReset...)
+	} else {
+		buf = append(buf, l.LogLevelName...)
+	}
+	for i := len(l.LogLevelName); i < levelNameWidth; i++ {
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, " ["...)
+	buf = append(buf, l.ServiceName...)
+	buf = append(buf, '/')
+	buf = append(buf, l.ModuleName...)
+	buf = append(buf, "] "...)
+	buf = append(buf, l.Message...)
+	for i := range l.Fields {
+		if fieldShadowed(l.Fields, i) {
+			continue
+		}
+		buf = p.appendKey(buf, l.Fields[i].Key)
+		buf = appendLogfmtValue(buf, l.Fields[i].Value)
+	}
+	if corrID != "" {
+		buf = p.appendKey(buf, "correlation_id")
+		buf = append(buf, corrID...)
+	}
+	if l.TraceID != "" {
+		buf = p.appendKey(buf, "trace_id")
+		buf = append(buf, l.TraceID...)
+		buf = p.appendKey(buf, "span_id")
+		buf = append(buf, l.SpanID...)
+	}
+	if l.File != "" {
+		buf = p.appendKey(buf, "file")
+		buf = append(buf, l.File...)
+	}
+	if len(l.LogObject) > 0 {
+		buf = append(buf, '\n')
+		buf = append(buf, ParseLogObject(l.LogObject, true)...)
+	}
+	return buf
+}
+
+// appendKey appends a space and key=, the key is faint when Color is set.
+func (p *PrettyFormatter) appendKey(buf []byte, key string) []byte {
+	buf = append(buf, ' ')
+	if p.Color {
+		buf = append(buf, colorFaint...)
+		buf = append(buf, key...)
+		buf = append(buf, '=')
+		return append(buf, colorReset...)
+	}
+	buf = append(buf, key...)
+	return append(buf, '=')
+}
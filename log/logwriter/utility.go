@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
+
+	"github.com/sabariramc/goserverbase/v6/log/message"
 )
 
 const timeFormat = "2006-01-02T15:04:05.000Z07:00"
@@ -75,3 +78,34 @@ func GetObjectType(obj any) string {
 	}
 	return msgType
 }
+
+// fieldPrefix prefixes the key of a field that clashes with a key of the log entry.
+const fieldPrefix = "Field."
+
+// AddFields adds the fields to the log entry as top-level keys, a later field replaces an earlier field with the same key.
+// A field whose key clashes with a key of the entry that is not a field is added as Field.<key>.
+func AddFields(entry map[string]interface{}, fields []message.Field) {
+	reserved := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		key := f.Key
+		if _, ok := entry[key]; ok && !reserved[key] {
+			key = fieldPrefix + key
+		}
+		entry[key] = f.Value
+		reserved[key] = true
+	}
+}
+
+// FormatFields formats the fields as space separated key=value pairs.
+func FormatFields(fields []message.Field) string {
+	var sb strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(f.Key)
+		sb.WriteByte('=')
+		sb.WriteString(ParseObject(f.Value, false))
+	}
+	return sb.String()
+}
@@ -0,0 +1,7 @@
+package message
+
+// Field is a typed key-value pair attached to a log message, writers emit it as a top-level key.
+type Field struct {
+	Key   string // Key is the name of the field.
+	Value any    // Value is the value of the field, one of string, int64, float64, bool, time.Time or any other JSON encodable value.
+}
@@ -52,6 +52,15 @@ func GetLogLevelWithName(level string) LogLevel {
 	return *logLevel
 }
 
+// LookupLogLevelWithName returns the LogLevel for the given log level name and whether the name is valid.
+func LookupLogLevelWithName(level string) (LogLevel, bool) {
+	logLevel, ok := logLevelInverseMap[level]
+	if !ok {
+		return LogLevel{}, false
+	}
+	return *logLevel, true
+}
+
 // GetLogLevel returns the LogLevel for the given LogLevelCode.
 // If the LogLevelCode is not found, it returns the LogLevel for ERROR.
 func GetLogLevel(level LogLevelCode) LogLevel {
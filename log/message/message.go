@@ -14,6 +14,9 @@ type LogMessage struct {
 	ModuleName  string        // ModuleName is the name of the module generating the log.
 	ServiceName string        // ServiceName is the name of the service generating the log.
 	File        string        // File is the file name and line number where the log was generated.
+	Fields      []Field       `json:"-"` // Fields are the fields bound to the logger followed by the fields of the call.
+	TraceID     string        // TraceID is the hex encoded trace ID of the span of the context, empty without a span.
+	SpanID      string        // SpanID is the hex encoded span ID of the span of the context, empty without a span.
 }
 
 // MuxLogMessage represents a log entry along with its context.
@@ -0,0 +1,23 @@
+package message
+
+// severityMap maps LogLevelCode to the severity number of the OpenTelemetry log data model.
+var severityMap = map[LogLevelCode]int32{
+	TRACE:     1,  // TRACE
+	DEBUG:     5,  // DEBUG
+	INFO:      9,  // INFO
+	NOTICE:    10, // INFO2
+	WARNING:   13, // WARN
+	ERROR:     17, // ERROR
+	EMERGENCY: 21, // FATAL
+	FATAL:     24, // FATAL4
+}
+
+// SeverityNumber returns the OpenTelemetry severity number for the LogLevelCode.
+// If the LogLevelCode is not found, it returns the severity number of ERROR.
+func (l LogLevelCode) SeverityNumber() int32 {
+	severity, ok := severityMap[l]
+	if !ok {
+		return severityMap[ERROR]
+	}
+	return severity
+}
@@ -0,0 +1,36 @@
+package message
+
+import "log/slog"
+
+// slogLevelMap maps LogLevelCode to slog.Level, levels without a slog counterpart sit between the slog levels.
+var slogLevelMap = map[LogLevelCode]slog.Level{
+	TRACE:     slog.LevelDebug - 4,
+	DEBUG:     slog.LevelDebug,
+	INFO:      slog.LevelInfo,
+	NOTICE:    slog.LevelInfo + 2,
+	WARNING:   slog.LevelWarn,
+	ERROR:     slog.LevelError,
+	EMERGENCY: slog.LevelError + 4,
+	FATAL:     slog.LevelError + 8,
+}
+
+// SlogLevel returns the slog.Level for the LogLevelCode.
+// If the LogLevelCode is not found, it returns slog.LevelError.
+func (l LogLevelCode) SlogLevel() slog.Level {
+	level, ok := slogLevelMap[l]
+	if !ok {
+		return slog.LevelError
+	}
+	return level
+}
+
+// GetLogLevelWithSlogLevel returns the LogLevel for the given slog.Level, a slog.Level between two levels maps to the lower of the two.
+func GetLogLevelWithSlogLevel(level slog.Level) LogLevel {
+	code := TRACE
+	for c, l := range slogLevelMap {
+		if level >= l && c < code {
+			code = c
+		}
+	}
+	return GetLogLevel(code)
+}
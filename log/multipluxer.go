@@ -2,6 +2,10 @@ package log
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sabariramc/goserverbase/v6/log/logwriter"
 	m "github.com/sabariramc/goserverbase/v6/log/message"
@@ -16,6 +20,7 @@ type Mux interface {
 // DefaultLogMux is a implementation of LogMux and calls the associated log handlers sequentially over a for loop
 type DefaultLogMux struct {
 	writer []logwriter.LogWriter
+	closed atomic.Bool
 }
 
 func NewDefaultLogMux(logWriterList ...logwriter.LogWriter) *DefaultLogMux {
@@ -23,7 +28,12 @@ func NewDefaultLogMux(logWriterList ...logwriter.LogWriter) *DefaultLogMux {
 	return ls
 }
 
+// Print writes the message to the writers, once closed the messages of the closed writers are written to the console.
 func (ls *DefaultLogMux) Print(ctx context.Context, msg *m.LogMessage) {
+	if ls.closed.Load() {
+		printClosed(ctx, msg, ls.writer, logwriter.NewConsoleWriter())
+		return
+	}
 	for _, w := range ls.writer {
 		_ = w.WriteMessage(ctx, msg)
 	}
@@ -33,38 +43,320 @@ func (ls *DefaultLogMux) AddLogWriter(ctx context.Context, writer logwriter.LogW
 	ls.writer = append(ls.writer, writer)
 }
 
-type ChanneledLogMux struct {
-	inChannel  chan m.MuxLogMessage
-	outChannel []chan m.MuxLogMessage
+// Flush syncs the writers that implement [logwriter.CloseLogWriter].
+func (ls *DefaultLogMux) Flush(ctx context.Context) error {
+	return syncWriters(ls.writer)
+}
+
+// Close closes the writers that implement [logwriter.CloseLogWriter].
+func (ls *DefaultLogMux) Close(ctx context.Context) error {
+	ls.closed.Store(true)
+	return closeWriters(ls.writer)
+}
+
+// printClosed writes the message to the writers that are not closed on Close and once to fallback for the closed writers.
+func printClosed(ctx context.Context, msg *m.LogMessage, writers []logwriter.LogWriter, fallback logwriter.LogWriter) {
+	useFallback := false
+	for _, w := range writers {
+		if _, ok := w.(logwriter.CloseLogWriter); ok {
+			useFallback = true
+			continue
+		}
+		_ = w.WriteMessage(ctx, msg)
+	}
+	if useFallback && fallback != nil {
+		_ = fallback.WriteMessage(ctx, msg)
+	}
+}
+
+// syncWriters syncs the writers that implement [logwriter.CloseLogWriter] and returns the first error.
+func syncWriters(writers []logwriter.LogWriter) error {
+	var res error
+	for _, w := range writers {
+		if cw, ok := w.(logwriter.CloseLogWriter); ok {
+			if err := cw.Sync(); err != nil && res == nil {
+				res = err
+			}
+		}
+	}
+	return res
 }
 
-func NewChanneledLogMux(bufferSize uint8, logWriterList ...logwriter.ChanneledLogWriter) *ChanneledLogMux {
-	outChannelList := make([]chan m.MuxLogMessage, len(logWriterList))
-	for i, logWriter := range logWriterList {
-		lBufferSize := logWriter.GetBufferSize()
-		if lBufferSize < 1 {
-			lBufferSize = int(bufferSize)
+// closeWriters closes the writers that implement [logwriter.CloseLogWriter] and returns the first error.
+func closeWriters(writers []logwriter.LogWriter) error {
+	var res error
+	for _, w := range writers {
+		if cw, ok := w.(logwriter.CloseLogWriter); ok {
+			if err := cw.Close(); err != nil && res == nil {
+				res = err
+			}
 		}
-		outChannel := make(chan m.MuxLogMessage, lBufferSize)
-		outChannelList[i] = outChannel
-		go logWriter.Start(outChannel)
 	}
-	ls := &ChanneledLogMux{inChannel: make(chan m.MuxLogMessage, bufferSize), outChannel: outChannelList}
-	go ls.start()
+	return res
+}
+
+// DropPolicy is the behaviour of [ChanneledLogMux.Print] when the buffer of a writer is full.
+type DropPolicy string
+
+const (
+	DropPolicyBlock      DropPolicy = "BLOCK"       // DropPolicyBlock waits for space in the buffer.
+	DropPolicyDropNewest DropPolicy = "DROP_NEWEST" // DropPolicyDropNewest drops the message being printed.
+	DropPolicyDropOldest DropPolicy = "DROP_OLDEST" // DropPolicyDropOldest drops the oldest buffered message to make space.
+)
+
+// FlushableMux is a Mux that buffers messages and can be flushed and closed, [Logger.Shutdown] closes it.
+type FlushableMux interface {
+	Mux
+	Flush(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// WriterStats is the delivery stats of a writer of the [ChanneledLogMux].
+type WriterStats struct {
+	Writer   string // Writer is the type of the writer.
+	Buffered int    // Buffered is the number of messages waiting to be written.
+	Dropped  uint64 // Dropped is the number of messages dropped as per the drop policy.
+	Failed   uint64 // Failed is the number of messages the writer returned an error or panicked for.
+}
+
+// ChanneledLogMux is an asynchronous implementation of Mux, each writer has its own bounded buffer and goroutine
+// so that a slow or failing writer does not stall Print or the other writers.
+//
+// When the buffer of a writer is full the message is handled as per [ChanneledMuxConfig.DropPolicy].
+// Buffered messages are handed to a [logwriter.BatchLogWriter] in batches of up to [ChanneledMuxConfig.BatchSize].
+// A panic in a writer is recovered and counted as a failure.
+// Once closed, Print writes synchronously to the writers that are not closed and to [ChanneledMuxConfig.Fallback] in place of the closed writers.
+type ChanneledLogMux struct {
+	c        ChanneledMuxConfig
+	lock     sync.RWMutex
+	queues   []*writerQueue
+	closed   bool
+	inFlight sync.WaitGroup // inFlight counts the Print calls buffering messages, Close waits for them before closing the buffers.
+}
+
+// writerQueue is the buffer and stats of a writer.
+type writerQueue struct {
+	writer  logwriter.LogWriter
+	ch      chan m.MuxLogMessage
+	pending atomic.Int64  // pending is the number of messages buffered or being written.
+	dropped atomic.Uint64 // dropped is the number of messages dropped as per the drop policy.
+	failed  atomic.Uint64 // failed is the number of messages that failed to be written.
+	done    chan struct{} // done is closed when the goroutine of the writer exits.
+}
+
+// NewChanneledLogMux creates a new ChanneledLogMux with the writers and starts a goroutine for each writer.
+func NewChanneledLogMux(logWriterList []logwriter.LogWriter, options ...ChanneledMuxOption) *ChanneledLogMux {
+	config := GetDefaultChanneledMuxConfig()
+	for _, opt := range options {
+		opt(&config)
+	}
+	if config.BufferSize < 1 {
+		config.BufferSize = 1
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	ls := &ChanneledLogMux{c: config}
+	for _, w := range logWriterList {
+		ls.AddLogWriter(context.Background(), w)
+	}
 	return ls
 }
 
+// AddLogWriter adds a writer and starts its goroutine, a writer added after Close is written to synchronously.
+func (ls *ChanneledLogMux) AddLogWriter(ctx context.Context, writer logwriter.LogWriter) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	q := &writerQueue{
+		writer: writer,
+		ch:     make(chan m.MuxLogMessage, ls.c.BufferSize),
+		done:   make(chan struct{}),
+	}
+	ls.queues = append(ls.queues, q)
+	if ls.closed {
+		close(q.ch)
+	}
+	go ls.start(q)
+}
+
+// Print buffers the message for every writer, the lock is released before buffering so that a blocked Print does not block Close.
 func (ls *ChanneledLogMux) Print(ctx context.Context, msg *m.LogMessage) {
-	ls.inChannel <- m.MuxLogMessage{
+	ls.lock.RLock()
+	queues := ls.queues
+	if ls.closed {
+		ls.lock.RUnlock()
+		printClosed(ctx, msg, ls.writers(queues), ls.c.Fallback)
+		return
+	}
+	ls.inFlight.Add(1)
+	ls.lock.RUnlock()
+	defer ls.inFlight.Done()
+	muxMsg := m.MuxLogMessage{
 		Ctx:        ctx,
 		LogMessage: *msg,
 	}
+	for _, q := range queues {
+		ls.enqueue(q, muxMsg)
+	}
+}
+
+// enqueue adds the message to the buffer of the writer as per the drop policy.
+func (ls *ChanneledLogMux) enqueue(q *writerQueue, msg m.MuxLogMessage) {
+	q.pending.Add(1)
+	switch ls.c.DropPolicy {
+	case DropPolicyBlock:
+		q.ch <- msg
+		return
+	case DropPolicyDropOldest:
+		for {
+			select {
+			case q.ch <- msg:
+				return
+			default:
+			}
+			select {
+			case <-q.ch:
+				q.pending.Add(-1)
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.ch <- msg:
+		default:
+			q.pending.Add(-1)
+			q.dropped.Add(1)
+		}
+	}
+}
+
+// start writes the buffered messages of the writer in batches until the buffer is closed.
+func (ls *ChanneledLogMux) start(q *writerQueue) {
+	defer close(q.done)
+	batch := make([]m.MuxLogMessage, 0, ls.c.BatchSize)
+	for msg := range q.ch {
+		batch = append(batch[:0], msg)
+	drain:
+		for len(batch) < ls.c.BatchSize {
+			select {
+			case msg, ok := <-q.ch:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, msg)
+			default:
+				break drain
+			}
+		}
+		q.write(batch)
+		q.pending.Add(-int64(len(batch)))
+	}
 }
 
-func (ls *ChanneledLogMux) start() {
-	for log := range ls.inChannel {
-		for _, outChannel := range ls.outChannel {
-			outChannel <- log
+// write writes the messages to the writer, errors and panics of the writer are counted as failures.
+func (q *writerQueue) write(batch []m.MuxLogMessage) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			q.failed.Add(uint64(len(batch)))
 		}
+	}()
+	if bw, ok := q.writer.(logwriter.BatchLogWriter); ok {
+		if err := bw.WriteBatch(batch); err != nil {
+			q.failed.Add(uint64(len(batch)))
+		}
+		return
 	}
+	for i := range batch {
+		if err := q.writer.WriteMessage(batch[i].Ctx, &batch[i].LogMessage); err != nil {
+			q.failed.Add(1)
+		}
+	}
+}
+
+// Flush waits until the messages buffered so far are written and syncs the writers, returns the error of ctx when it is done first.
+func (ls *ChanneledLogMux) Flush(ctx context.Context) error {
+	ls.lock.RLock()
+	queues := ls.queues
+	ls.lock.RUnlock()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for _, q := range queues {
+		for q.pending.Load() > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("ChanneledLogMux.Flush: %w", ctx.Err())
+			case <-ticker.C:
+			}
+		}
+	}
+	return syncWriters(ls.writers(queues))
+}
+
+// Close stops buffering, waits until the buffered messages are written and closes the writers, returns the error of ctx when it is done first.
+// Messages printed after Close are written synchronously to the writers that are not closed and to [ChanneledMuxConfig.Fallback].
+func (ls *ChanneledLogMux) Close(ctx context.Context) error {
+	ls.lock.Lock()
+	closing := !ls.closed
+	ls.closed = true
+	queues := ls.queues
+	ls.lock.Unlock()
+	if closing {
+		printed := make(chan struct{})
+		go func() {
+			ls.inFlight.Wait()
+			for _, q := range queues {
+				close(q.ch)
+			}
+			close(printed)
+		}()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("ChanneledLogMux.Close: %w", ctx.Err())
+		case <-printed:
+		}
+	}
+	for _, q := range queues {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("ChanneledLogMux.Close: %w", ctx.Err())
+		case <-q.done:
+		}
+	}
+	return closeWriters(ls.writers(queues))
+}
+
+// writers returns the writers of the queues.
+func (ls *ChanneledLogMux) writers(queues []*writerQueue) []logwriter.LogWriter {
+	writers := make([]logwriter.LogWriter, len(queues))
+	for i, q := range queues {
+		writers[i] = q.writer
+	}
+	return writers
+}
+
+// Stats returns the delivery stats of each writer in the order they were added.
+func (ls *ChanneledLogMux) Stats() []WriterStats {
+	ls.lock.RLock()
+	defer ls.lock.RUnlock()
+	stats := make([]WriterStats, 0, len(ls.queues))
+	for _, q := range ls.queues {
+		stats = append(stats, WriterStats{
+			Writer:   fmt.Sprintf("%T", q.writer),
+			Buffered: int(q.pending.Load()),
+			Dropped:  q.dropped.Load(),
+			Failed:   q.failed.Load(),
+		})
+	}
+	return stats
+}
+
+// Name returns the name of the mux for the shutdown hook.
+func (ls *ChanneledLogMux) Name(ctx context.Context) string {
+	return "ChanneledLogMux"
+}
+
+// Shutdown closes the mux, implementation of the shutdown hook.
+func (ls *ChanneledLogMux) Shutdown(ctx context.Context) error {
+	return ls.Close(ctx)
 }
@@ -0,0 +1,164 @@
+package log_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/logwriter"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"gotest.tools/assert"
+)
+
+type GateLogWriter struct {
+	lock    sync.Mutex
+	entered chan struct{}
+	gate    chan struct{}
+	msg     []string
+	batches []int
+}
+
+func (g *GateLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	return g.WriteBatch([]message.MuxLogMessage{{Ctx: ctx, LogMessage: *l}})
+}
+
+func (g *GateLogWriter) WriteBatch(messages []message.MuxLogMessage) error {
+	g.entered <- struct{}{}
+	<-g.gate
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, msg := range messages {
+		g.msg = append(g.msg, msg.Message)
+	}
+	g.batches = append(g.batches, len(messages))
+	return nil
+}
+
+func (g *GateLogWriter) Messages() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return append([]string(nil), g.msg...)
+}
+
+type PanicLogWriter struct{}
+
+func (p *PanicLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	panic("writer failed")
+}
+
+func TestChanneledLogMuxBatchAndFlush(t *testing.T) {
+	w := &GateLogWriter{entered: make(chan struct{}, 10), gate: make(chan struct{})}
+	mux := log.NewChanneledLogMux([]logwriter.LogWriter{w, &PanicLogWriter{}}, log.WithMuxBufferSize(10), log.WithMuxBatchSize(4), log.WithMuxDropPolicy(log.DropPolicyBlock))
+	logger := log.New(log.WithLogLevelName("INFO"), log.WithMux(mux))
+	ctx := context.Background()
+	logger.Info(ctx, "0")
+	<-w.entered
+	for _, msg := range []string{"1", "2", "3", "4", "5"} {
+		logger.Info(ctx, msg)
+	}
+	close(w.gate)
+	flushCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.NilError(t, mux.Flush(flushCtx))
+	assert.DeepEqual(t, w.Messages(), []string{"0", "1", "2", "3", "4", "5"})
+	assert.DeepEqual(t, w.batches, []int{1, 4, 1})
+	stats := mux.Stats()
+	assert.Equal(t, stats[0].Failed, uint64(0))
+	assert.Equal(t, stats[1].Failed, uint64(6))
+	assert.Equal(t, stats[1].Buffered, 0)
+}
+
+func TestChanneledLogMuxDropPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   log.DropPolicy
+		expected []string
+	}{
+		{policy: log.DropPolicyDropNewest, expected: []string{"0", "1", "2"}},
+		{policy: log.DropPolicyDropOldest, expected: []string{"0", "4", "5"}},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			w := &GateLogWriter{entered: make(chan struct{}, 10), gate: make(chan struct{})}
+			mux := log.NewChanneledLogMux([]logwriter.LogWriter{w}, log.WithMuxBufferSize(2), log.WithMuxBatchSize(1), log.WithMuxDropPolicy(tc.policy))
+			logger := log.New(log.WithLogLevelName("INFO"), log.WithMux(mux))
+			ctx := context.Background()
+			logger.Info(ctx, "0")
+			<-w.entered
+			for _, msg := range []string{"1", "2", "3", "4", "5"} {
+				logger.Info(ctx, msg)
+			}
+			close(w.gate)
+			closeCtx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			assert.NilError(t, logger.Shutdown(closeCtx))
+			assert.DeepEqual(t, w.Messages(), tc.expected)
+			assert.Equal(t, mux.Stats()[0].Dropped, uint64(3))
+			logger.Info(ctx, "after close")
+			assert.Equal(t, w.Messages()[len(w.Messages())-1], "after close")
+		})
+	}
+}
+
+type ClosingLogWriter struct {
+	GateLogWriter
+	closed bool
+}
+
+func (c *ClosingLogWriter) WriteBatch(messages []message.MuxLogMessage) error {
+	c.lock.Lock()
+	closed := c.closed
+	c.lock.Unlock()
+	if closed {
+		return os.ErrClosed
+	}
+	return c.GateLogWriter.WriteBatch(messages)
+}
+
+func (c *ClosingLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	return c.WriteBatch([]message.MuxLogMessage{{Ctx: ctx, LogMessage: *l}})
+}
+
+func (c *ClosingLogWriter) Sync() error { return nil }
+
+func (c *ClosingLogWriter) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+	return nil
+}
+
+func TestChanneledLogMuxClose(t *testing.T) {
+	w := &ClosingLogWriter{GateLogWriter: GateLogWriter{entered: make(chan struct{}, 10), gate: make(chan struct{})}}
+	fallback := &GateLogWriter{entered: make(chan struct{}, 10), gate: make(chan struct{})}
+	close(fallback.gate)
+	mux := log.NewChanneledLogMux([]logwriter.LogWriter{w}, log.WithMuxBufferSize(1), log.WithMuxBatchSize(1), log.WithMuxDropPolicy(log.DropPolicyBlock), log.WithMuxFallback(fallback))
+	logger := log.New(log.WithLogLevelName("INFO"), log.WithMux(mux))
+	ctx := context.Background()
+	logger.Info(ctx, "0")
+	<-w.entered
+	logger.Info(ctx, "1")
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		logger.Info(ctx, "2")
+	}()
+	for mux.Stats()[0].Buffered < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	closed := make(chan error)
+	go func() {
+		closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		closed <- logger.Shutdown(closeCtx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(w.gate)
+	assert.NilError(t, <-closed)
+	<-blocked
+	assert.DeepEqual(t, w.Messages(), []string{"0", "1", "2"})
+	logger.Info(ctx, "after close")
+	assert.DeepEqual(t, fallback.Messages(), []string{"after close"})
+	assert.Equal(t, mux.Stats()[0].Failed, uint64(0))
+}
@@ -0,0 +1,384 @@
+package log
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/sabariramc/goserverbase/v6/log/logwriter"
+	m "github.com/sabariramc/goserverbase/v6/log/message"
+	"github.com/sabariramc/goserverbase/v6/utils"
+)
+
+// RedactAction is the replacement of a redacted value.
+type RedactAction string
+
+const (
+	RedactActionMask RedactAction = "MASK" // RedactActionMask replaces the value with [RedactConfig.Mask].
+	RedactActionHash RedactAction = "HASH" // RedactActionHash replaces the value with its hash, see [utils.GetHash].
+	RedactActionDrop RedactAction = "DROP" // RedactActionDrop removes the field, a value detected in a text is removed from the text.
+)
+
+// redactTag is the struct tag value that redacts a field, `log:"redact"`.
+const redactTag = "redact"
+
+// maxRedactDepth is the depth beyond which nested values are not walked.
+const maxRedactDepth = 32
+
+// Detector detects sensitive values in text.
+type Detector struct {
+	Name     string            // Name is the name of the detector.
+	Pattern  *regexp.Regexp    // Pattern matches the candidate values.
+	Validate func(string) bool // Validate confirms a match, nil accepts every match.
+}
+
+// DefaultDetectors returns the detectors for emails, card numbers, E.164 phone numbers, US social security numbers and Indian PAN.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		{Name: "email", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+		{Name: "card", Pattern: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), Validate: LuhnValid},
+		{Name: "phone", Pattern: regexp.MustCompile(`\+[1-9]\d{9,14}\b`)},
+		{Name: "ssn", Pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+		{Name: "pan", Pattern: regexp.MustCompile(`\b[A-Z]{5}\d{4}[A-Z]\b`)},
+	}
+}
+
+// LuhnValid reports whether the digits of the value pass the Luhn check, separators other than digits are ignored.
+func LuhnValid(value string) bool {
+	sum, n := 0, 0
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 1 && sum%10 == 0
+}
+
+// RedactConfig represents the configuration options for the [Redactor].
+type RedactConfig struct {
+	Action     RedactAction // Action is the replacement of the redacted values.
+	Mask       string       // Mask replaces the redacted values with [RedactActionMask].
+	FieldNames []string     // FieldNames are the patterns of the field names whose values are redacted, matched ignoring case, - and _, * matches any text.
+	Detectors  []Detector   // Detectors detect the sensitive values in text.
+}
+
+// Redactor redacts sensitive values from the log objects, fields and message of log messages.
+//
+// A value is redacted when its field name matches [RedactConfig.FieldNames] or its struct field is tagged `log:"redact"`,
+// and the parts of text values detected by [RedactConfig.Detectors] are redacted.
+// Log objects without sensitive values are left untouched, a log object with sensitive values is replaced with its redacted JSON form.
+type Redactor struct {
+	c     RedactConfig
+	names []*regexp.Regexp
+}
+
+// NewRedactor creates a new Redactor with the options applied to [GetDefaultRedactConfig].
+func NewRedactor(options ...RedactOption) *Redactor {
+	config := GetDefaultRedactConfig()
+	for _, opt := range options {
+		opt(&config)
+	}
+	r := &Redactor{c: config}
+	if r.c.Mask == "" {
+		r.c.Mask = "****"
+	}
+	for _, name := range config.FieldNames {
+		pattern := regexp.QuoteMeta(normaliseFieldName(name))
+		pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+		r.names = append(r.names, regexp.MustCompile("^"+pattern+"$"))
+	}
+	return r
+}
+
+// normaliseFieldName lowercases the name and removes - _ and . so that apiKey, api_key and x-api-key compare alike.
+func normaliseFieldName(name string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(name))
+}
+
+// sensitiveName reports whether the field name matches the field name patterns.
+func (r *Redactor) sensitiveName(name string) bool {
+	name = normaliseFieldName(name)
+	for _, pattern := range r.names {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns the replacement of a sensitive value, ok is false when the value is dropped.
+func (r *Redactor) replace(value any) (any, bool) {
+	switch r.c.Action {
+	case RedactActionDrop:
+		return nil, false
+	case RedactActionHash:
+		return utils.GetHash(fmt.Sprint(value)), true
+	}
+	return r.c.Mask, true
+}
+
+// RedactText redacts the values detected in the text.
+func (r *Redactor) RedactText(text string) string {
+	for _, d := range r.c.Detectors {
+		text = d.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+			replacement, ok := r.replace(match)
+			if !ok {
+				return ""
+			}
+			return replacement.(string)
+		})
+	}
+	return text
+}
+
+// Redact returns a copy of the message with the sensitive values redacted, the message is returned as is when nothing is redacted.
+func (r *Redactor) Redact(msg *m.LogMessage) *m.LogMessage {
+	res := *msg
+	changed := false
+	if text := r.RedactText(msg.Message); text != msg.Message {
+		res.Message, changed = text, true
+	}
+	copied := false
+	for i, obj := range msg.LogObject {
+		value, objChanged := r.redactObject(obj)
+		if !objChanged {
+			continue
+		}
+		if !copied {
+			res.LogObject, copied = append([]interface{}(nil), msg.LogObject...), true
+		}
+		res.LogObject[i], changed = value, true
+	}
+	if len(msg.Fields) > 0 {
+		if fields, ok := r.redactFields(msg.Fields); ok {
+			res.Fields, changed = fields, true
+		}
+	}
+	if !changed {
+		return msg
+	}
+	return &res
+}
+
+// redactFields redacts the fields, returns false when no field is redacted.
+func (r *Redactor) redactFields(fields []m.Field) ([]m.Field, bool) {
+	res := make([]m.Field, 0, len(fields))
+	changed := false
+	for _, f := range fields {
+		if r.sensitiveName(f.Key) {
+			changed = true
+			if value, ok := r.replace(f.Value); ok {
+				res = append(res, m.Field{Key: f.Key, Value: value})
+			}
+			continue
+		}
+		if value, valueChanged := r.redactValue(reflect.ValueOf(f.Value), 0); valueChanged {
+			changed = true
+			f.Value = value
+		}
+		res = append(res, f)
+	}
+	return res, changed
+}
+
+// redactObject redacts a log object, the text forms of the log objects are redacted as text.
+func (r *Redactor) redactObject(obj any) (any, bool) {
+	switch v := obj.(type) {
+	case error:
+		text := v.Error()
+		redacted := r.RedactText(text)
+		return redacted, redacted != text
+	case func() string:
+		text := v()
+		redacted := r.RedactText(text)
+		return redacted, redacted != text
+	case []byte:
+		text := string(v)
+		redacted := r.RedactText(text)
+		return redacted, redacted != text
+	}
+	return r.redactValue(reflect.ValueOf(obj), 0)
+}
+
+// redactValue walks the value and returns its redacted JSON form when a sensitive value is found.
+func (r *Redactor) redactValue(v reflect.Value, depth int) (any, bool) {
+	if !v.IsValid() || depth > maxRedactDepth {
+		return nil, false
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return r.redactValue(v.Elem(), depth+1)
+	case reflect.String:
+		text := v.String()
+		redacted := r.RedactText(text)
+		return redacted, redacted != text
+	case reflect.Struct:
+		if isMarshaler(v) {
+			return nil, false
+		}
+		return r.redactStruct(v, depth)
+	case reflect.Map:
+		if v.IsNil() || isMarshaler(v) {
+			return nil, false
+		}
+		res := make(map[string]any, v.Len())
+		changed := false
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if value, ok := r.redactEntry(key, iter.Value(), false, depth, &changed); ok {
+				res[key] = value
+			}
+		}
+		return res, changed
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) || isMarshaler(v) {
+			return nil, false
+		}
+		res := make([]any, v.Len())
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			value, itemChanged := r.redactValue(v.Index(i), depth+1)
+			if !itemChanged {
+				value = v.Index(i).Interface()
+			}
+			res[i] = value
+			changed = changed || itemChanged
+		}
+		return res, changed
+	}
+	return nil, false
+}
+
+// redactStruct walks the exported fields of the struct with their JSON names.
+func (r *Redactor) redactStruct(v reflect.Value, depth int) (any, bool) {
+	res := map[string]any{}
+	changed := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, omitEmpty, skip := jsonField(sf)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			embedded, embeddedChanged := r.redactValue(fv, depth+1)
+			changed = changed || embeddedChanged
+			if fields, ok := embedded.(map[string]any); ok {
+				for k, val := range fields {
+					if _, exists := res[k]; !exists {
+						res[k] = val
+					}
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if value, ok := r.redactEntry(name, fv, sf.Tag.Get("log") == redactTag, depth, &changed); ok {
+			res[name] = value
+		}
+	}
+	return res, changed
+}
+
+// redactEntry redacts the value of a field, returns false when the field is dropped.
+func (r *Redactor) redactEntry(name string, v reflect.Value, tagged bool, depth int, changed *bool) (any, bool) {
+	if tagged || r.sensitiveName(name) {
+		*changed = true
+		return r.replace(v.Interface())
+	}
+	value, valueChanged := r.redactValue(v, depth+1)
+	if valueChanged {
+		*changed = true
+		return value, true
+	}
+	return v.Interface(), true
+}
+
+// jsonField returns the JSON name of the struct field and whether it is omitted when empty or skipped.
+func jsonField(sf reflect.StructField) (string, bool, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, strings.Contains(opts, "omitempty"), false
+}
+
+// isMarshaler reports whether the value controls its JSON form, such values are not walked.
+func isMarshaler(v reflect.Value) bool {
+	t := v.Type()
+	marshaler := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler := reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	if t.Implements(marshaler) || t.Implements(textMarshaler) {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return pt.Implements(marshaler) || pt.Implements(textMarshaler)
+}
+
+// RedactMux is a Mux that redacts the messages with a [Redactor] before printing them to the wrapped Mux.
+type RedactMux struct {
+	Mux
+	redactor *Redactor
+}
+
+// NewRedactMux creates a new RedactMux that wraps mux.
+func NewRedactMux(mux Mux, redactor *Redactor) *RedactMux {
+	return &RedactMux{Mux: mux, redactor: redactor}
+}
+
+// Print redacts the message and prints it to the wrapped Mux.
+func (r *RedactMux) Print(ctx context.Context, msg *m.LogMessage) {
+	r.Mux.Print(ctx, r.redactor.Redact(msg))
+}
+
+// AddLogWriter adds the log writer to the wrapped Mux.
+func (r *RedactMux) AddLogWriter(ctx context.Context, w logwriter.LogWriter) {
+	r.Mux.AddLogWriter(ctx, w)
+}
+
+// Flush flushes the wrapped Mux when it is a [FlushableMux].
+func (r *RedactMux) Flush(ctx context.Context) error {
+	if mux, ok := r.Mux.(FlushableMux); ok {
+		return mux.Flush(ctx)
+	}
+	return nil
+}
+
+// Close closes the wrapped Mux when it is a [FlushableMux].
+func (r *RedactMux) Close(ctx context.Context) error {
+	if mux, ok := r.Mux.(FlushableMux); ok {
+		return mux.Close(ctx)
+	}
+	return nil
+}
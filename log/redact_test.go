@@ -0,0 +1,93 @@
+package log_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/env"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"github.com/sabariramc/goserverbase/v6/utils"
+	"gotest.tools/assert"
+)
+
+type Address struct {
+	City    string
+	Contact string `json:"contact"`
+}
+
+type Customer struct {
+	Address
+	ID        string    `json:"id"`
+	Name      string    `json:"name" log:"redact"`
+	Password  string    `json:"password,omitempty"`
+	Note      string    `json:"note"`
+	Internal  string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func TestRedactor(t *testing.T) {
+	w := &CaptureLogWriter{}
+	logger := log.New(log.WithLogLevelName("INFO"), log.WithMux(log.NewDefaultLogMux(w)), log.WithRedactor(log.NewRedactor()))
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	customer := &Customer{
+		Address:   Address{City: "Chennai", Contact: "reach me at jane@example.com"},
+		ID:        "c-1",
+		Name:      "Jane",
+		Password:  "hunter2",
+		Note:      "card 4111 1111 1111 1111, order 1234567812345678",
+		Internal:  "secret",
+		CreatedAt: created,
+	}
+	plain := map[string]any{"id": "c-2", "count": 3}
+	logger.Info(ctx, "customer +919876543210 created", customer, plain, errors.New("ssn 123-45-6789 rejected"), log.String("Authorization", "Bearer abc"), log.Any("headers", map[string]string{"x-api-key": "k", "accept": "json"}), log.Int("attempt", 1))
+	assert.Equal(t, len(w.msg), 1)
+	msg := w.msg[0]
+	assert.Equal(t, msg.Message, "customer **** created")
+	assert.DeepEqual(t, msg.LogObject[0], map[string]any{
+		"City":      "Chennai",
+		"contact":   "reach me at ****",
+		"id":        "c-1",
+		"name":      "****",
+		"password":  "****",
+		"note":      "card ****, order 1234567812345678",
+		"createdAt": created,
+	})
+	assert.DeepEqual(t, msg.LogObject[1], plain)
+	assert.Equal(t, msg.LogObject[2], "ssn **** rejected")
+	assert.DeepEqual(t, msg.Fields, []log.Field{
+		log.String("Authorization", "****"),
+		log.Any("headers", map[string]any{"x-api-key": "****", "accept": "json"}),
+		log.Int("attempt", 1),
+	})
+	assert.Equal(t, customer.Name, "Jane")
+}
+
+func TestRedactorActions(t *testing.T) {
+	msg := &message.LogMessage{
+		Message:   "mail jane@example.com",
+		LogObject: []interface{}{map[string]string{"password": "hunter2", "user": "jane"}},
+	}
+	hashed := log.NewRedactor(log.WithRedactAction(log.RedactActionHash)).Redact(msg)
+	assert.Equal(t, hashed.Message, "mail "+utils.GetHash("jane@example.com"))
+	assert.DeepEqual(t, hashed.LogObject[0], map[string]any{"password": utils.GetHash("hunter2"), "user": "jane"})
+	dropped := log.NewRedactor(log.WithRedactAction(log.RedactActionDrop)).Redact(msg)
+	assert.Equal(t, dropped.Message, "mail ")
+	assert.DeepEqual(t, dropped.LogObject[0], map[string]any{"user": "jane"})
+	assert.Equal(t, msg.Message, "mail jane@example.com")
+
+	untouched := &message.LogMessage{Message: "nothing to hide", LogObject: []interface{}{42, "text"}}
+	assert.Assert(t, log.NewRedactor().Redact(untouched) == untouched)
+	assert.Assert(t, log.LuhnValid("4111-1111-1111-1111"))
+	assert.Assert(t, !log.LuhnValid("4111-1111-1111-1112"))
+}
+
+func TestRedactorOptIn(t *testing.T) {
+	t.Setenv(env.LogRedactEnabled, "")
+	assert.Assert(t, log.GetDefaultConfig().Redactor == nil)
+	t.Setenv(env.LogRedactEnabled, "true")
+	assert.Assert(t, log.GetDefaultConfig().Redactor != nil)
+}
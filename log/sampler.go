@@ -0,0 +1,180 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	m "github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// SamplingRule limits the messages logged with the same module, level and message in an interval.
+type SamplingRule struct {
+	First      int // First is the number of messages logged in an interval.
+	Thereafter int // Thereafter logs every Mth message after the first, 0 drops them.
+}
+
+// samplingRules are the rules of a module pattern keyed by level name, * applies to the levels without a rule.
+type samplingRules map[string]SamplingRule
+
+// Sampler suppresses repeated messages to protect the log writers against log storms.
+//
+// Messages are counted per module, level and message in windows of the interval, once the rule of the module and level
+// is exhausted the messages are suppressed and the first message of the next window is preceded by a summary of the suppressed messages.
+// The rules are keyed by module pattern like the rules of [LevelResolver], levels without a rule are not sampled.
+// The sampler is shared by a [Logger] and the loggers derived from it.
+type Sampler struct {
+	interval  time.Duration
+	rules     map[string]samplingRules
+	patterns  []string // patterns are the module patterns, longest first.
+	counters  sync.Map // counters maps the samplingKey to its *sampleCounter.
+	lastSweep atomic.Int64
+}
+
+// samplingKey identifies the messages counted together.
+type samplingKey struct {
+	module  string
+	level   m.LogLevelCode
+	message string
+}
+
+// sampleCounter counts the messages of a samplingKey in the current window.
+type sampleCounter struct {
+	lock        sync.Mutex
+	windowStart time.Time
+	count       int
+	suppressed  int
+}
+
+// NewSampler creates a new Sampler with the rules keyed by module pattern and level name, the interval defaults to a second.
+func NewSampler(interval time.Duration, rules map[string]map[string]SamplingRule) *Sampler {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	s := &Sampler{interval: interval, rules: make(map[string]samplingRules, len(rules))}
+	for pattern, levelRules := range rules {
+		s.rules[pattern] = levelRules
+		s.patterns = append(s.patterns, pattern)
+	}
+	sortPatterns(s.patterns)
+	return s
+}
+
+// ParseSamplingRules parses rules of the form pattern@LEVEL=first/thereafter separated by commas,
+// e.g. *@DEBUG=10/100,KafkaReader@*=100/0, * as level applies to the levels without a rule of the pattern.
+func ParseSamplingRules(spec string) (map[string]map[string]SamplingRule, error) {
+	rules := map[string]map[string]SamplingRule{}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		target, limit, ok := strings.Cut(rule, "=")
+		pattern, level, ok2 := strings.Cut(target, "@")
+		first, thereafter, ok3 := strings.Cut(limit, "/")
+		if !ok || !ok2 || !ok3 || pattern == "" {
+			return nil, fmt.Errorf("log.ParseSamplingRules: invalid rule %q", rule)
+		}
+		if _, valid := m.LookupLogLevelWithName(level); !valid && level != "*" {
+			return nil, fmt.Errorf("log.ParseSamplingRules: invalid level in rule %q", rule)
+		}
+		var r SamplingRule
+		var err error
+		if r.First, err = strconv.Atoi(first); err != nil || r.First < 0 {
+			return nil, fmt.Errorf("log.ParseSamplingRules: invalid first in rule %q", rule)
+		}
+		if r.Thereafter, err = strconv.Atoi(thereafter); err != nil || r.Thereafter < 0 {
+			return nil, fmt.Errorf("log.ParseSamplingRules: invalid thereafter in rule %q", rule)
+		}
+		if rules[pattern] == nil {
+			rules[pattern] = map[string]SamplingRule{}
+		}
+		rules[pattern][level] = r
+	}
+	return rules, nil
+}
+
+// rule returns the rule of the module and level.
+func (s *Sampler) rule(module string, level m.LogLevel) (SamplingRule, bool) {
+	for _, pattern := range s.patterns {
+		if !matchPattern(pattern, module) {
+			continue
+		}
+		rules := s.rules[pattern]
+		if r, ok := rules[level.LogLevelName]; ok {
+			return r, true
+		}
+		r, ok := rules["*"]
+		return r, ok
+	}
+	return SamplingRule{}, false
+}
+
+// Sample reports whether the message is logged and the number of messages suppressed in the previous window,
+// the suppressed count is reported once with the first message of a window.
+func (s *Sampler) Sample(module string, level m.LogLevel, message string) (bool, int) {
+	rule, ok := s.rule(module, level)
+	if !ok {
+		return true, 0
+	}
+	now := time.Now()
+	s.sweep(now)
+	key := samplingKey{module: module, level: level.Level, message: message}
+	v, ok := s.counters.Load(key)
+	if !ok {
+		v, _ = s.counters.LoadOrStore(key, &sampleCounter{windowStart: now})
+	}
+	c := v.(*sampleCounter)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	suppressed := 0
+	if now.Sub(c.windowStart) >= s.interval {
+		suppressed = c.suppressed
+		c.windowStart, c.count, c.suppressed = now, 0, 0
+	}
+	c.count++
+	if c.count <= rule.First || rule.Thereafter > 0 && (c.count-rule.First)%rule.Thereafter == 0 {
+		return true, suppressed
+	}
+	c.suppressed++
+	return false, suppressed
+}
+
+// sweep removes the counters of the windows that ended without suppressed messages, at most once an interval.
+func (s *Sampler) sweep(now time.Time) {
+	last := s.lastSweep.Load()
+	if now.UnixNano()-last < int64(s.interval) || !s.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	s.counters.Range(func(key, value any) bool {
+		c := value.(*sampleCounter)
+		c.lock.Lock()
+		if c.suppressed == 0 && now.Sub(c.windowStart) >= s.interval {
+			s.counters.Delete(key)
+		}
+		c.lock.Unlock()
+		return true
+	})
+}
+
+// matchPattern reports whether the module matches the pattern, a pattern ending with * matches the modules with the prefix.
+func matchPattern(pattern, module string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(module, prefix)
+	}
+	return pattern == module
+}
+
+// sortPatterns sorts the patterns longest first so that the most specific pattern matches first.
+func sortPatterns(patterns []string) {
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+}
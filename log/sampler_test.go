@@ -0,0 +1,45 @@
+package log_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/log"
+	"gotest.tools/assert"
+)
+
+func TestSampler(t *testing.T) {
+	w := &CaptureLogWriter{}
+	logger := log.New(log.WithLogLevelName("DEBUG"), log.WithMux(log.NewDefaultLogMux(w)), log.WithSampling("*@DEBUG=2/3,KafkaReader@*=1/0", 50*time.Millisecond))
+	poller := logger.NewResourceLogger("KafkaReader")
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		logger.Debug(ctx, "poll")
+		logger.Info(ctx, "not sampled")
+		poller.Error(ctx, "fetch failed")
+	}
+	time.Sleep(60 * time.Millisecond)
+	logger.Debug(ctx, "poll")
+	poller.Error(ctx, "fetch failed")
+
+	counts := map[string]int{}
+	for _, msg := range w.msg {
+		counts[msg.Message]++
+	}
+	assert.DeepEqual(t, counts, map[string]int{
+		"poll":                                5,
+		"not sampled":                         10,
+		"fetch failed":                        2,
+		"suppressed 6 messages: poll":         1,
+		"suppressed 9 messages: fetch failed": 1,
+	})
+	summary := w.msg[len(w.msg)-2]
+	assert.Equal(t, summary.ModuleName, "KafkaReader")
+	assert.DeepEqual(t, summary.Fields, []log.Field{log.String("sampled.message", "fetch failed"), log.Int("sampled.suppressed", 9)})
+
+	_, err := log.ParseSamplingRules("*@DEBUG=10")
+	assert.ErrorContains(t, err, "invalid rule")
+	_, err = log.ParseSamplingRules("*@VERBOSE=10/1")
+	assert.ErrorContains(t, err, "invalid level")
+}
@@ -0,0 +1,204 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	m "github.com/sabariramc/goserverbase/v6/log/message"
+)
+
+// SlogHandler is a slog.Handler that writes the records into the [Mux] of a [Logger].
+//
+// The attributes of the record and the handler are written as [Field] values, groups are flattened into dot separated keys.
+// Records at EMERGENCY and FATAL level are logged with the level, the handler neither panics nor exits.
+//
+//	slog.SetDefault(slog.New(logger.SlogHandler()))
+type SlogHandler struct {
+	l     Logger // l is the logger the records are written with.
+	group string // group is the key prefix of the open groups.
+}
+
+// NewSlogHandler creates a new SlogHandler with a new Logger created with the options.
+func NewSlogHandler(options ...Option) *SlogHandler {
+	return New(options...).SlogHandler().(*SlogHandler)
+}
+
+// SlogHandler creates a slog.Handler that writes into the mux of the Logger with the fields of the Logger.
+func (l *Logger) SlogHandler() slog.Handler {
+	return &SlogHandler{l: *l}
+}
+
+// SlogLogger is implemented by the loggers that write the records of log/slog into their mux with their fields, such as [Logger].
+type SlogLogger interface {
+	SlogHandler() slog.Handler
+}
+
+// Handler returns a slog.Handler that writes into the logger, for libraries that log with log/slog. It is the handler of
+// [SlogLogger.SlogHandler] when the logger implements it; the records are written with the level methods of other loggers,
+// with the attributes as [Field] values.
+func Handler(l Log) slog.Handler {
+	if sl, ok := l.(SlogLogger); ok {
+		return sl.SlogHandler()
+	}
+	return &logHandler{l: l}
+}
+
+// logHandler is the slog.Handler of a [Log] that does not implement [SlogLogger].
+type logHandler struct {
+	l      Log
+	fields []Field
+	group  string
+}
+
+// Enabled reports whether the level is within the log level of the logger.
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return m.GetLogLevelWithSlogLevel(level).Level <= h.l.GetLogLevel().Level
+}
+
+// Handle writes the record with the level method of the logger, records above ERROR are written as errors.
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fields := h.fields[:len(h.fields):len(h.fields)]
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+	logObject := make([]interface{}, len(fields))
+	for i, f := range fields {
+		logObject[i] = f
+	}
+	switch level := m.GetLogLevelWithSlogLevel(r.Level).Level; {
+	case level <= m.ERROR:
+		h.l.Error(ctx, r.Message, logObject...)
+	case level == m.WARNING:
+		h.l.Warning(ctx, r.Message, logObject...)
+	case level == m.NOTICE:
+		h.l.Notice(ctx, r.Message, logObject...)
+	case level == m.INFO:
+		h.l.Info(ctx, r.Message, logObject...)
+	case level == m.DEBUG:
+		h.l.Debug(ctx, r.Message, logObject...)
+	default:
+		h.l.Trace(ctx, r.Message, logObject...)
+	}
+	return nil
+}
+
+// WithAttrs returns a new handler that adds the attributes to every record.
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	newHandler := *h
+	fields := h.fields[:len(h.fields):len(h.fields)]
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	newHandler.fields = fields
+	return &newHandler
+}
+
+// WithGroup returns a new handler that prefixes the keys of the attributes that follow with the name.
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	newHandler := *h
+	newHandler.group = h.group + name + "."
+	return &newHandler
+}
+
+// Enabled reports whether the level is within the log level of the logger.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.l.enabled(ctx, m.GetLogLevelWithSlogLevel(level).Level)
+}
+
+// Handle writes the record into the mux, the correlation params of ctx are carried along with the message.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := m.GetLogLevelWithSlogLevel(r.Level)
+	if !h.l.enabled(ctx, level.Level) {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fields := make([]Field, len(h.l.fields), len(h.l.fields)+r.NumAttrs())
+	copy(fields, h.l.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+	msg := &m.LogMessage{
+		LogLevel:    level,
+		Message:     r.Message,
+		Fields:      fields,
+		Timestamp:   r.Time,
+		ModuleName:  h.l.moduleName,
+		ServiceName: h.l.serviceName,
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	if h.l.fileTrace && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		msg.File = fmt.Sprintf("%v:%v", frame.File, frame.Line)
+	}
+	h.l.write(ctx, msg)
+	return nil
+}
+
+// WithAttrs returns a new SlogHandler that adds the attributes to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	newHandler := *h
+	fields := h.l.fields[:len(h.l.fields):len(h.l.fields)]
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	newHandler.l.fields = fields
+	return &newHandler
+}
+
+// WithGroup returns a new SlogHandler that prefixes the keys of the attributes that follow with the name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	newHandler := *h
+	newHandler.group = h.group + name + "."
+	return &newHandler
+}
+
+// appendAttr appends the attribute to the fields, the attributes of a group are appended with the group name as key prefix.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	case slog.KindInt64:
+		return append(fields, Int64(prefix+a.Key, a.Value.Int64()))
+	case slog.KindDuration:
+		return append(fields, Duration(prefix+a.Key, a.Value.Duration()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, String(prefix+a.Key, err.Error()))
+		}
+	}
+	return append(fields, Any(prefix+a.Key, a.Value.Any()))
+}
@@ -0,0 +1,73 @@
+package log_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sabariramc/goserverbase/v6/correlation"
+	"github.com/sabariramc/goserverbase/v6/log"
+	"github.com/sabariramc/goserverbase/v6/log/message"
+	"gotest.tools/assert"
+)
+
+type CaptureLogWriter struct {
+	msg []message.LogMessage
+	cr  []string
+}
+
+func (c *CaptureLogWriter) WriteMessage(ctx context.Context, l *message.LogMessage) error {
+	c.msg = append(c.msg, *l)
+	c.cr = append(c.cr, correlation.ExtractCorrelationParam(ctx).CorrelationID)
+	return nil
+}
+
+func TestSlogHandler(t *testing.T) {
+	w := &CaptureLogWriter{}
+	logger := log.New(log.WithLogLevelName("DEBUG"), log.WithMux(log.NewDefaultLogMux(w)), log.WithModuleName("slog"))
+	sl := slog.New(logger.With(log.String("tenant", "acme")).SlogHandler())
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: "slog-test"})
+	sl.With("topic", "orders").WithGroup("req").InfoContext(ctx, "received", "id", 7, slog.Group("http", "status", 200), "latency", 2*time.Millisecond, "error", errors.New("boom"))
+	sl.Log(ctx, slog.LevelInfo+2, "notice")
+	sl.Log(ctx, slog.LevelDebug-4, "trace")
+	sl.Warn("no context")
+	assert.Equal(t, len(w.msg), 3)
+	assert.Equal(t, w.msg[0].LogLevelName, "INFO")
+	assert.Equal(t, w.msg[0].Message, "received")
+	assert.Equal(t, w.msg[0].ModuleName, "slog")
+	assert.Equal(t, w.cr[0], "slog-test")
+	assert.DeepEqual(t, w.msg[0].Fields, []log.Field{
+		log.String("tenant", "acme"),
+		log.String("topic", "orders"),
+		log.Int64("req.id", 7),
+		log.Int64("req.http.status", 200),
+		log.Duration("req.latency", 2*time.Millisecond),
+		log.String("req.error", "boom"),
+	})
+	assert.Equal(t, w.msg[1].LogLevelName, "NOTICE")
+	assert.Equal(t, w.msg[2].LogLevelName, "WARNING")
+	assert.Equal(t, w.cr[2], "")
+	assert.Assert(t, !sl.Enabled(ctx, slog.LevelDebug-4))
+}
+
+type wrappedLog struct {
+	log.Log
+}
+
+func TestHandlerWithLog(t *testing.T) {
+	w := &CaptureLogWriter{}
+	logger := log.New(log.WithLogLevelName("INFO"), log.WithMux(log.NewDefaultLogMux(w)), log.WithModuleName("slog"))
+	sl := slog.New(log.Handler(wrappedLog{Log: logger}))
+	ctx := correlation.GetContextWithCorrelationParam(context.Background(), &correlation.CorrelationParam{CorrelationID: "handler-test"})
+	sl.With("topic", "orders").ErrorContext(ctx, "failed", "id", 7)
+	sl.Log(ctx, slog.LevelInfo+2, "notice")
+	sl.DebugContext(ctx, "debug")
+	assert.Equal(t, len(w.msg), 2)
+	assert.Equal(t, w.msg[0].LogLevelName, "ERROR")
+	assert.Equal(t, w.msg[0].Message, "failed")
+	assert.Equal(t, w.cr[0], "handler-test")
+	assert.Equal(t, w.msg[1].LogLevelName, "NOTICE")
+	assert.Assert(t, !sl.Enabled(ctx, slog.LevelDebug))
+}